@@ -1,5 +1,7 @@
 package capnp
 
+import "bytes"
+
 // Canonicalize encodes a struct into its canonical form: a single-
 // segment blob without a segment table.  The result will be identical
 // for equivalent structs, even as the schema evolves.  The blob is
@@ -92,7 +94,7 @@ func canonicalList(dst *Segment, l List) (List, error) {
 	if !l.IsValid() {
 		return List{}, nil
 	}
-	if l.size.PointerCount == 0 {
+	if l.size.PointerCount == 0 && l.flags&isCompositeList == 0 {
 		// Data only, just copy over.
 		sz := l.allocSize()
 		_, newAddr, err := alloc(dst, sz)
@@ -109,6 +111,10 @@ func canonicalList(dst *Segment, l List) (List, error) {
 		}
 		end, _ := l.off.addSize(sz) // list was already validated
 		copy(dst.data[newAddr:], l.seg.data[l.off:end])
+		if n := l.length % 8; l.flags&isBitList != 0 && n != 0 {
+			// Bits past the end of the list are padding and must be zero.
+			dst.data[int(newAddr)+int(sz)-1] &= 1<<uint(n) - 1
+		}
 		return cl, nil
 	}
 	if l.flags&isCompositeList == 0 {
@@ -154,3 +160,29 @@ func canonicalList(dst *Segment, l List) (List, error) {
 	}
 	return cl, nil
 }
+
+// IsCanonical reports whether m is in canonical form: a single segment
+// holding exactly what Canonicalize would produce for m's root struct.
+// A message whose root is not a struct is never canonical.  Messages
+// containing capabilities have no canonical form and return an error.
+func (m *Message) IsCanonical() (bool, error) {
+	if m.NumSegments() != 1 {
+		return false, nil
+	}
+	seg, err := m.Segment(0)
+	if err != nil {
+		return false, annotate(err).errorf("is canonical")
+	}
+	root, err := m.Root()
+	if err != nil {
+		return false, annotate(err).errorf("is canonical")
+	}
+	if root.IsValid() && root.flags.ptrType() != structPtrType {
+		return false, nil
+	}
+	b, err := Canonicalize(root.Struct())
+	if err != nil {
+		return false, annotate(err).errorf("is canonical")
+	}
+	return bytes.Equal(b, seg.Data()), nil
+}
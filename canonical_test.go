@@ -189,4 +189,129 @@ func TestCanonicalize(t *testing.T) {
 			t.Errorf("Canonicalize(zero-length struct list) =\n%s\n; want\n%s", hex.Dump(b), hex.Dump(want))
 		}
 	}
+	{
+		// data-only struct list
+		_, seg, _ := NewMessage(SingleSegment(nil))
+		s, _ := NewStruct(seg, ObjectSize{PointerCount: 1})
+		l, _ := NewCompositeList(seg, ObjectSize{DataSize: 16}, 2)
+		s.SetPtr(0, l.ToPtr())
+		l.Struct(0).SetUint64(0, 1)
+		b, err := Canonicalize(s)
+		if err != nil {
+			t.Fatal("Canonicalize(data-only struct list):", err)
+		}
+		want := ([]byte{
+			0, 0, 0, 0, 0, 0, 1, 0,
+			0x01, 0, 0, 0, 0x17, 0, 0, 0,
+			0x08, 0, 0, 0, 1, 0, 0, 0,
+			1, 0, 0, 0, 0, 0, 0, 0,
+			0, 0, 0, 0, 0, 0, 0, 0,
+		})
+		if !bytes.Equal(b, want) {
+			t.Errorf("Canonicalize(data-only struct list) =\n%s\n; want\n%s", hex.Dump(b), hex.Dump(want))
+		}
+	}
+	{
+		// bit list with garbage padding
+		_, seg, _ := NewMessage(SingleSegment(nil))
+		s, _ := NewStruct(seg, ObjectSize{PointerCount: 1})
+		l, _ := NewBitList(seg, 3)
+		s.SetPtr(0, l.ToPtr())
+		l.Set(0, true)
+		l.Set(2, true)
+		seg.Data()[l.off] |= 0xf8
+		b, err := Canonicalize(s)
+		if err != nil {
+			t.Fatal("Canonicalize(bit list):", err)
+		}
+		want := ([]byte{
+			0, 0, 0, 0, 0, 0, 1, 0,
+			0x01, 0, 0, 0, 0x19, 0, 0, 0,
+			0x05, 0, 0, 0, 0, 0, 0, 0,
+		})
+		if !bytes.Equal(b, want) {
+			t.Errorf("Canonicalize(bit list) =\n%s\n; want\n%s", hex.Dump(b), hex.Dump(want))
+		}
+	}
+}
+
+func TestIsCanonical(t *testing.T) {
+	// newMsg returns a message whose root is (0xbeef, ("xyzzy")) with
+	// the given root struct size.
+	newMsg := func(arena Arena, sz ObjectSize) (*Message, error) {
+		msg, seg, err := NewMessage(arena)
+		if err != nil {
+			return nil, err
+		}
+		root, err := NewRootStruct(seg, sz)
+		if err != nil {
+			return nil, err
+		}
+		root.SetUint16(0, 0xbeef)
+		if err := root.SetText(0, "xyzzy"); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+	tests := []struct {
+		name      string
+		arena     Arena
+		size      ObjectSize
+		canonical bool
+	}{
+		{"Tight", SingleSegment(nil), ObjectSize{DataSize: 8, PointerCount: 1}, true},
+		{"TrailingData", SingleSegment(nil), ObjectSize{DataSize: 16, PointerCount: 1}, false},
+		{"TrailingPointer", SingleSegment(nil), ObjectSize{DataSize: 8, PointerCount: 2}, false},
+		{"MultiSegment", MultiSegmentWithGrowth(nil, FixedSegmentGrowth(8)), ObjectSize{DataSize: 8, PointerCount: 1}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg, err := newMsg(test.arena, test.size)
+			if err != nil {
+				t.Fatal("build message:", err)
+			}
+			ok, err := msg.IsCanonical()
+			if err != nil {
+				t.Fatal("IsCanonical:", err)
+			}
+			if ok != test.canonical {
+				t.Errorf("IsCanonical() = %t; want %t", ok, test.canonical)
+			}
+			if test.canonical {
+				return
+			}
+			// Canonicalizing a message makes it canonical.
+			root, err := msg.Root()
+			if err != nil {
+				t.Fatal("Root:", err)
+			}
+			b, err := Canonicalize(root.Struct())
+			if err != nil {
+				t.Fatal("Canonicalize:", err)
+			}
+			cmsg := &Message{Arena: SingleSegment(b)}
+			if ok, err := cmsg.IsCanonical(); err != nil {
+				t.Error("IsCanonical after Canonicalize:", err)
+			} else if !ok {
+				t.Error("IsCanonical after Canonicalize = false; want true")
+			}
+		})
+	}
+
+	t.Run("Interface", func(t *testing.T) {
+		msg, seg, err := NewMessage(SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := NewRootStruct(seg, ObjectSize{PointerCount: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := root.SetPtr(0, NewInterface(seg, 0).ToPtr()); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := msg.IsCanonical(); err == nil {
+			t.Error("IsCanonical on message with an interface did not return an error")
+		}
+	})
 }
@@ -17,11 +17,21 @@ type Interface struct {
 // NewInterface creates a new interface pointer.
 //
 // No allocation is performed in the given segment: it is used purely
-// to associate the interface pointer with a message.
+// to associate the interface pointer with a message.  cap is not checked
+// against the message's capability table; see Message.ValidateForSend.
 func NewInterface(s *Segment, cap CapabilityID) Interface {
 	return Interface{s, cap}
 }
 
+// AddInterface appends client to the capability table of s's message
+// and returns an interface pointer that refers to it.  It "steals"
+// client's reference: the Message will release the client when calling
+// Reset.  Callers that want to keep using client (e.g. when forwarding
+// a received capability) should pass client.AddRef().
+func (s *Segment) AddInterface(client *Client) Interface {
+	return NewInterface(s, s.msg.AddCap(client))
+}
+
 // ToPtr converts the interface to a generic pointer.
 func (p Interface) ToPtr() Ptr {
 	return Ptr{
@@ -116,6 +126,7 @@ type clientHook struct {
 	refs         int         // how many open Clients reference this clientHook
 	calls        int         // number of outstanding ClientHook accesses
 	resolvedHook *clientHook // valid only if resolved is closed
+	releaseOnGC  bool        // set by ReleaseWhenUnreachable
 }
 
 // NewClient creates the first reference to a capability.
@@ -336,16 +347,54 @@ func (c *Client) AddRef() *Client {
 		return nil
 	}
 	c.h.refs++
+	gc := c.h.releaseOnGC
 	c.h.mu.Unlock()
 	d := &Client{h: c.h}
 	if clientLeakFunc != nil {
 		d.creatorFunc = 3
 		_, d.creatorFile, d.creatorLine, _ = runtime.Caller(1)
 		runtime.SetFinalizer(d, finalizeClient)
+	} else if gc {
+		runtime.SetFinalizer(d, finalizeClient)
 	}
 	return d
 }
 
+// ReleaseWhenUnreachable arranges for c to be released if it is garbage
+// collected without having been released.  The same applies to any
+// Client that is later created from c's capability with AddRef or
+// WeakClient.AddRef.  This is intended for capabilities whose last
+// reference may be dropped by application code that does not call
+// Release; the release happens at some unspecified time after the
+// reference becomes unreachable, if at all.
+func (c *Client) ReleaseWhenUnreachable() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if c.released {
+		c.mu.Unlock()
+		panic("ReleaseWhenUnreachable on released client")
+	}
+	if c.h == nil {
+		c.mu.Unlock()
+		return
+	}
+	c.h.mu.Lock()
+	c.h = resolveHook(c.h)
+	if c.h == nil {
+		c.mu.Unlock()
+		return
+	}
+	c.h.releaseOnGC = true
+	c.h.mu.Unlock()
+	c.mu.Unlock()
+	if clientLeakFunc == nil {
+		runtime.SetFinalizer(c, nil)
+		runtime.SetFinalizer(c, finalizeClient)
+	}
+}
+
 // WeakRef creates a new WeakClient that refers to the same capability
 // as c.  If c is nil or has resolved to null, then WeakRef returns nil.
 func (c *Client) WeakRef() *WeakClient {
@@ -489,6 +538,19 @@ func finalizeClient(c *Client) {
 	if c.released {
 		return
 	}
+	if h := c.h; h != nil {
+		h.mu.Lock()
+		gc := h.releaseOnGC
+		h.mu.Unlock()
+		if gc {
+			// Release may block on the hook's shutdown.
+			go c.Release()
+			return
+		}
+	}
+	if clientLeakFunc == nil {
+		return
+	}
 
 	var fname string
 	switch c.creatorFunc {
@@ -508,7 +570,6 @@ func finalizeClient(c *Client) {
 		msg = fmt.Sprintf("leaked client created by %s on %s:%d", fname, c.creatorFile, c.creatorLine)
 	}
 
-	// finalizeClient will only be called if clientLeakFunc != nil.
 	go clientLeakFunc(msg)
 }
 
@@ -591,12 +652,15 @@ func (wc *WeakClient) AddRef() (c *Client, ok bool) {
 		return nil, false
 	}
 	wc.h.refs++
+	gc := wc.h.releaseOnGC
 	wc.h.mu.Unlock()
 	c = &Client{h: wc.h}
 	if clientLeakFunc != nil {
 		c.creatorFunc = 3
 		_, c.creatorFile, c.creatorLine, _ = runtime.Caller(1)
 		runtime.SetFinalizer(c, finalizeClient)
+	} else if gc {
+		runtime.SetFinalizer(c, finalizeClient)
 	}
 	return c, true
 }
@@ -612,6 +676,12 @@ func (wc *WeakClient) AddRef() (c *Client, ok bool) {
 // must guarantee that if foo() then bar() is called on a client, then
 // the capability acknowledging foo() happens before the capability
 // observing bar().
+//
+// Implementing ClientHook is how to provide a capability that is not
+// backed by a generated server, such as a dynamic dispatcher, a
+// forwarding proxy, or a test double.  NewClient wraps a ClientHook in a
+// Client, which can then be used locally or exported over an RPC
+// connection.
 type ClientHook interface {
 	// Send allocates space for parameters, calls s.PlaceArgs to fill out
 	// the arguments, then starts executing a method, returning an answer
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -333,6 +334,63 @@ func TestToInterface(t *testing.T) {
 	}
 }
 
+func TestAddInterface(t *testing.T) {
+	ctx := context.Background()
+	h := &dummyHook{}
+
+	// Receive a message with a capability in it.
+	inMsg, inSeg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := inMsg.SetRoot(inSeg.AddInterface(NewClient(h)).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Forward it through a new message.
+	outMsg, outSeg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStruct(outSeg, ObjectSize{DataSize: 8}); err != nil {
+		// Place something before the interface to catch index mixups.
+		t.Fatal(err)
+	}
+	outMsg.AddCap(ErrorClient(errors.New("placeholder")))
+	inRoot, err := inMsg.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	iface := outSeg.AddInterface(inRoot.Interface().Client().AddRef())
+	if iface.Capability() != 1 {
+		t.Errorf("AddInterface(...).Capability() = %d; want 1", iface.Capability())
+	}
+	if err := outMsg.SetRoot(iface.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	inMsg.Reset(nil)
+	if h.shutdowns != 0 {
+		t.Fatal("releasing received message shut down forwarded capability")
+	}
+
+	outRoot, err := outMsg.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ans, finish := outRoot.Interface().Client().SendCall(ctx, Send{})
+	if _, err := ans.Struct(); err != nil {
+		t.Error("SendCall on forwarded capability:", err)
+	}
+	finish()
+	if h.calls != 1 {
+		t.Errorf("h.calls = %d; want 1", h.calls)
+	}
+	outMsg.Reset(nil)
+	if h.shutdowns != 1 {
+		t.Errorf("after releasing both messages, h.shutdowns = %d; want 1", h.shutdowns)
+	}
+}
+
 func TestInterface_value(t *testing.T) {
 	_, seg, err := NewMessage(SingleSegment(nil))
 	if err != nil {
@@ -568,6 +626,37 @@ func TestWeakClient(t *testing.T) {
 	}
 }
 
+func TestReleaseWhenUnreachable(t *testing.T) {
+	h := &closeHook{shutdown: make(chan struct{})}
+	c := NewClient(h)
+	c.ReleaseWhenUnreachable()
+	// A reference created after ReleaseWhenUnreachable is also released
+	// when it becomes unreachable.
+	c.AddRef()
+	c.Release()
+	select {
+	case <-h.shutdown:
+		t.Fatal("capability shut down while a reference was still open")
+	default:
+	}
+	runtime.GC()
+	select {
+	case <-h.shutdown:
+	case <-time.After(5 * time.Second):
+		t.Fatal("capability not shut down after its last reference became unreachable")
+	}
+}
+
+// closeHook is a dummyHook that closes a channel on Shutdown.
+type closeHook struct {
+	dummyHook
+	shutdown chan struct{}
+}
+
+func (ch *closeHook) Shutdown() {
+	close(ch.shutdown)
+}
+
 func TestWeakPromisedClient(t *testing.T) {
 	a := new(dummyHook)
 	b := new(dummyHook)
@@ -618,13 +707,16 @@ func deepPointerEqual(a, b Ptr) bool {
 	if !a.IsValid() || !b.IsValid() {
 		return false
 	}
-	msgA, _, _ := NewMessage(SingleSegment(nil))
-	msgA.SetRoot(a)
-	abytes, _ := msgA.Marshal()
-	msgB, _, _ := NewMessage(SingleSegment(nil))
-	msgB.SetRoot(b)
-	bbytes, _ := msgB.Marshal()
-	return bytes.Equal(abytes, bbytes)
+	// Copy each pointer into a fresh message, so that the comparison
+	// does not depend on where the objects were allocated.
+	copyPtr := func(p Ptr) []byte {
+		_, seg, _ := NewMessage(SingleSegment(nil))
+		root, _ := NewRootStruct(seg, ObjectSize{PointerCount: 1})
+		root.SetPtr(0, p)
+		data, _ := seg.Message().Marshal()
+		return data
+	}
+	return bytes.Equal(copyPtr(a), copyPtr(b))
 }
 
 func newEmptyStruct() Struct {
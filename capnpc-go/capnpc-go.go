@@ -932,9 +932,85 @@ func (g *generator) defineBaseStructFuncs(n *node) error {
 	if err != nil {
 		return fmt.Errorf("base struct functions for %s: %v", n, err)
 	}
+	fields, err := g.structEqualFields(n)
+	if err != nil {
+		return fmt.Errorf("equal method for %s: %v", n, err)
+	}
+	err = renderStructEqual(g.r, structEqualParams{
+		G:      g,
+		Node:   n,
+		Fields: fields,
+	})
+	if err != nil {
+		return fmt.Errorf("equal method for %s: %v", n, err)
+	}
 	return nil
 }
 
+// structEqualFields lists the comparisons needed to compare two values
+// of n, descending into groups.
+func (g *generator) structEqualFields(n *node) (structEqualFields, error) {
+	ef := structEqualFields{Capnp: g.Capnp()}
+	var union structEqualUnion
+	for _, f := range n.codeOrderFields() {
+		var fields structEqualFields
+		switch f.Which() {
+		case schema.Field_Which_slot:
+			fields.Capnp = ef.Capnp
+			t, err := f.Slot().Type()
+			if err != nil {
+				return structEqualFields{}, fmt.Errorf("field %s: %v", f.Name, err)
+			}
+			off := f.Slot().Offset()
+			switch t.Which() {
+			case schema.Type_Which_void:
+			case schema.Type_Which_bool:
+				fields.Checks = append(fields.Checks, structEqualCheck{Bits: 1, Offset: off})
+			case schema.Type_Which_uint8, schema.Type_Which_uint16, schema.Type_Which_uint32, schema.Type_Which_uint64,
+				schema.Type_Which_int8, schema.Type_Which_int16, schema.Type_Which_int32, schema.Type_Which_int64:
+				bits := intbits(t.Which())
+				fields.Checks = append(fields.Checks, structEqualCheck{Bits: bits, Offset: off * uint32(bits/8)})
+			case schema.Type_Which_enum:
+				fields.Checks = append(fields.Checks, structEqualCheck{Bits: 16, Offset: off * 2})
+			case schema.Type_Which_float32:
+				fields.Checks = append(fields.Checks, structEqualCheck{Bits: 32, Offset: off * 4})
+			case schema.Type_Which_float64:
+				fields.Checks = append(fields.Checks, structEqualCheck{Bits: 64, Offset: off * 8})
+			default:
+				fields.Checks = append(fields.Checks, structEqualCheck{Offset: off})
+			}
+		case schema.Field_Which_group:
+			grp, err := g.nodes.mustFind(f.Group().TypeId())
+			if err != nil {
+				return structEqualFields{}, err
+			}
+			fields, err = g.structEqualFields(grp)
+			if err != nil {
+				return structEqualFields{}, err
+			}
+		}
+		if !f.HasDiscriminant() {
+			ef.Checks = append(ef.Checks, fields.Checks...)
+			ef.Unions = append(ef.Unions, fields.Unions...)
+		} else if !fields.isEmpty() {
+			union.Cases = append(union.Cases, structEqualCase{
+				Name:   n.Name + "_Which_" + f.Name,
+				Fields: fields,
+			})
+		}
+	}
+	if n.StructNode().DiscriminantCount() > 0 {
+		off, err := n.DiscriminantOffset()
+		if err != nil {
+			return structEqualFields{}, err
+		}
+		union.Which = n.Name + "_Which"
+		union.Offset = off
+		ef.Unions = append(ef.Unions, union)
+	}
+	return ef, nil
+}
+
 func (g *generator) defineStructList(n *node) error {
 	err := renderStructList(g.r, structListParams{
 		G:            g,
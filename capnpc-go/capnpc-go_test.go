@@ -3,8 +3,10 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io/ioutil"
 	"path/filepath"
 	"sort"
@@ -388,6 +390,147 @@ func TestDefineFile(t *testing.T) {
 	}
 }
 
+func TestMustAccessors(t *testing.T) {
+	req := mustReadGeneratorRequest(t, "aircraft.capnp.out")
+	nodes, err := buildNodeMap(req)
+	if err != nil {
+		t.Fatal("buildNodeMap:", err)
+	}
+	g := newGenerator(0x832bcc6686a26d56, nodes, genoptions{})
+	if err := g.defineFile(); err != nil {
+		t.Fatal("defineFile:", err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "aircraft.capnp.go", g.generate(), 0)
+	if err != nil {
+		t.Fatal("generated code failed to parse:", err)
+	}
+	results := make(map[string]string)
+	panics := make(map[string]bool)
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || types.ExprString(fn.Recv.List[0].Type) != "Z" || !strings.HasPrefix(fn.Name.Name, "Must") {
+			continue
+		}
+		if fn.Type.Params.NumFields() != 0 || fn.Type.Results.NumFields() != 1 {
+			t.Errorf("Z.%s has signature %s; want no params and one result", fn.Name.Name, types.ExprString(fn.Type))
+			continue
+		}
+		results[fn.Name.Name] = types.ExprString(fn.Type.Results.List[0].Type)
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok && types.ExprString(call.Fun) == "panic" {
+				panics[fn.Name.Name] = true
+			}
+			return true
+		})
+	}
+	tests := []struct {
+		name string
+		typ  string
+	}{
+		{"MustText", "string"},
+		{"MustBlob", "[]byte"},
+		{"MustZvec", "Z_List"},
+		{"MustPlanebase", "PlaneBase"},
+	}
+	for _, test := range tests {
+		typ, ok := results[test.name]
+		if !ok {
+			t.Errorf("Z.%s not generated", test.name)
+			continue
+		}
+		if typ != test.typ {
+			t.Errorf("Z.%s returns %s; want %s", test.name, typ, test.typ)
+		}
+		if !panics[test.name] {
+			t.Errorf("Z.%s does not panic on error", test.name)
+		}
+	}
+	if _, ok := results["MustF64"]; ok {
+		t.Error("Z.MustF64 generated for infallible data field")
+	}
+}
+
+func TestStructEqual(t *testing.T) {
+	req := mustReadGeneratorRequest(t, "aircraft.capnp.out")
+	nodes, err := buildNodeMap(req)
+	if err != nil {
+		t.Fatal("buildNodeMap:", err)
+	}
+	g := newGenerator(0x832bcc6686a26d56, nodes, genoptions{})
+	if err := g.defineFile(); err != nil {
+		t.Fatal("defineFile:", err)
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), "aircraft.capnp.go", g.generate(), 0)
+	if err != nil {
+		t.Fatal("generated code failed to parse:", err)
+	}
+	var fn *ast.FuncDecl
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.FuncDecl); ok && d.Recv != nil && types.ExprString(d.Recv.List[0].Type) == "Z" && d.Name.Name == "Equal" {
+			fn = d
+			break
+		}
+	}
+	if fn == nil {
+		t.Fatal("Z.Equal not generated")
+	}
+	if sig, want := types.ExprString(fn.Type), "func(o Z) (bool, error)"; sig != want {
+		t.Errorf("Z.Equal has signature %s; want %s", sig, want)
+	}
+
+	// Each union member with data gets its own case, comparing only
+	// that member's slot.
+	cases := make(map[string]string)
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		sw, ok := n.(*ast.SwitchStmt)
+		if !ok {
+			return true
+		}
+		if tag := types.ExprString(sw.Tag); tag != "Z_Which(s.Struct.Uint16(0))" {
+			t.Errorf("Z.Equal switches on %s; want Z_Which(s.Struct.Uint16(0))", tag)
+		}
+		for _, stmt := range sw.Body.List {
+			cc := stmt.(*ast.CaseClause)
+			var calls []string
+			ast.Inspect(cc, func(n ast.Node) bool {
+				if call, ok := n.(*ast.CallExpr); ok && len(calls) == 0 {
+					calls = append(calls, types.ExprString(call))
+				}
+				return true
+			})
+			for _, e := range cc.List {
+				if len(calls) > 0 {
+					cases[types.ExprString(e)] = calls[0]
+				}
+			}
+		}
+		return false
+	})
+	tests := []struct {
+		member string
+		call   string
+	}{
+		{"Z_Which_f64", "s.Struct.Uint64(8)"},
+		{"Z_Which_u8", "s.Struct.Uint8(8)"},
+		{"Z_Which_bool", "s.Struct.Bit(64)"},
+		{"Z_Which_text", "s.Struct.Ptr(0)"},
+		{"Z_Which_planebase", "s.Struct.Ptr(0)"},
+	}
+	for _, test := range tests {
+		call, ok := cases[test.member]
+		if !ok {
+			t.Errorf("Z.Equal has no case for %s", test.member)
+			continue
+		}
+		if call != test.call {
+			t.Errorf("Z.Equal case %s compares %s; want %s", test.member, call, test.call)
+		}
+	}
+	if _, ok := cases["Z_Which_void"]; ok {
+		t.Error("Z.Equal has a case for void member")
+	}
+}
+
 func TestSchemaVarLiteral(t *testing.T) {
 	tests := []string{
 		"",
@@ -20,7 +20,20 @@ func (sd *staticData) init(fileID uint64) {
 }
 
 func (sd *staticData) copyData(obj capnp.Ptr) (staticDataRef, error) {
-	m, _, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	m, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return staticDataRef{}, err
+	}
+	// SetRoot only accepts pointers into m, so copy obj into m through
+	// a temporary struct first.
+	tmp, err := capnp.NewStruct(seg, capnp.ObjectSize{PointerCount: 1})
+	if err != nil {
+		return staticDataRef{}, err
+	}
+	if err := tmp.SetPtr(0, obj); err != nil {
+		return staticDataRef{}, err
+	}
+	obj, err = tmp.Ptr(0)
 	if err != nil {
 		return staticDataRef{}, err
 	}
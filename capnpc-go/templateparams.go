@@ -40,6 +40,43 @@ type baseStructFuncsParams struct {
 	StringMethod bool
 }
 
+type structEqualParams struct {
+	G      *generator
+	Node   *node
+	Fields structEqualFields
+}
+
+// structEqualFields is the set of comparisons that an Equal method makes
+// for a struct or group.
+type structEqualFields struct {
+	Capnp  string
+	Checks []structEqualCheck
+	Unions []structEqualUnion
+}
+
+// structEqualCheck compares a single non-void slot.  Bits is 1 for a
+// bool, the width of the value for other data fields, and 0 for a
+// pointer field.
+type structEqualCheck struct {
+	Bits   uint
+	Offset uint32
+}
+
+type structEqualUnion struct {
+	Which  string
+	Offset uint32
+	Cases  []structEqualCase
+}
+
+type structEqualCase struct {
+	Name   string
+	Fields structEqualFields
+}
+
+func (f structEqualFields) isEmpty() bool {
+	return len(f.Checks) == 0 && len(f.Unions) == 0
+}
+
 type structFuncsParams struct {
 	G    *generator
 	Node *node
@@ -71,6 +71,11 @@ There are a few reasons that a read or write of a pointer can fail, but
 the most common are bad pointers or allocation failures.  For accessors,
 an invalid object will be returned in case of an error.
 
+Cap'n Proto data is little-endian on the wire.  Data accessors and
+setters always decode and encode little-endian values, regardless of
+the host's byte order, so messages can be read and written without any
+byte swapping on big-endian hosts.
+
 Since Go doesn't have generics, wrapper types provide type safety on
 lists.  This package provides lists of basic types, and capnpc-go
 generates list wrappers for named types.  However, if you need to use
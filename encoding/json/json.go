@@ -0,0 +1,186 @@
+// Package json supports marshaling Cap'n Proto structs as JSON based on
+// a schema, following the conventions of the reference implementation's
+// JSON codec.
+//
+// Structs become objects with fields in code order.  Fields outside of
+// a union are always written, except for null pointers; of a union, only
+// the active member is written.  Void becomes null, Int64 and UInt64
+// become strings so that JavaScript readers don't lose precision,
+// non-finite floats become "NaN", "Infinity", and "-Infinity", enums
+// become the names of their enumerants, and Data becomes base64.
+// Capabilities and AnyPointers other than null are not supported.
+//
+// The annotations in capnp/compat/json.capnp change the encoding:
+//
+//   - $Json.name renames a field or enumerant.
+//   - $Json.flatten moves the fields of a group or union into the
+//     enclosing object, with an optional prefix on their names.
+//   - $Json.discriminator writes the name of a union's active member to
+//     a separate field, and optionally writes the member's value under a
+//     fixed name.
+//   - $Json.hex writes a Data field as hexadecimal.  $Json.base64 is the
+//     default and needs no special handling.
+package json
+
+import (
+	"fmt"
+
+	"capnproto.org/go/capnp/v3/internal/schema"
+)
+
+// IDs of the annotations declared in capnp/compat/json.capnp.
+const (
+	nameAnnotation          = 0xfa5b1fd61c2e7c3d
+	flattenAnnotation       = 0x82d3e852af0336bf
+	discriminatorAnnotation = 0xcfa794e8d19a0162
+	hexAnnotation           = 0xf061e22f0ae5c7b5
+)
+
+// findAnnotation returns the value of the annotation with the given ID,
+// or an invalid Value if it is absent.
+func findAnnotation(list schema.Annotation_List, id uint64) (schema.Value, error) {
+	for i := 0; i < list.Len(); i++ {
+		a := list.At(i)
+		if a.Id() == id {
+			return a.Value()
+		}
+	}
+	return schema.Value{}, nil
+}
+
+// jsonName returns the JSON name of a field, which is its $Json.name if
+// present and its schema name otherwise.
+func jsonName(f schema.Field) (string, error) {
+	annots, err := f.Annotations()
+	if err != nil {
+		return "", err
+	}
+	v, err := findAnnotation(annots, nameAnnotation)
+	if err != nil {
+		return "", err
+	}
+	if v.IsValid() {
+		return v.Text()
+	}
+	return f.Name()
+}
+
+// enumerantName is like jsonName for an enumerant.
+func enumerantName(e schema.Enumerant) (string, error) {
+	annots, err := e.Annotations()
+	if err != nil {
+		return "", err
+	}
+	v, err := findAnnotation(annots, nameAnnotation)
+	if err != nil {
+		return "", err
+	}
+	if v.IsValid() {
+		return v.Text()
+	}
+	return e.Name()
+}
+
+// flattenPrefix reports whether a group field is annotated with
+// $Json.flatten and returns the prefix for the group's fields.
+func flattenPrefix(f schema.Field) (prefix string, ok bool, err error) {
+	annots, err := f.Annotations()
+	if err != nil {
+		return "", false, err
+	}
+	v, err := findAnnotation(annots, flattenAnnotation)
+	if err != nil || !v.IsValid() {
+		return "", false, err
+	}
+	opts, err := v.StructValue()
+	if err != nil {
+		return "", false, err
+	}
+	p, err := opts.Struct().Ptr(0)
+	if err != nil {
+		return "", false, err
+	}
+	return p.Text(), true, nil
+}
+
+// unionDiscriminator describes how a union's active member is written.
+// The zero value writes the member under its own name.
+type unionDiscriminator struct {
+	// name is the field that holds the active member's name, or empty
+	// if the union has no discriminator.
+	name string
+	// valueName is the field that holds the active member's value, or
+	// empty to use the member's name.
+	valueName string
+}
+
+// discriminatorOf reads $Json.discriminator from annots.  unionName is
+// the default name of the discriminator field; it is empty for a
+// struct's unnamed union, for which the annotation must give a name.
+func discriminatorOf(annots schema.Annotation_List, unionName string) (unionDiscriminator, error) {
+	v, err := findAnnotation(annots, discriminatorAnnotation)
+	if err != nil || !v.IsValid() {
+		return unionDiscriminator{}, err
+	}
+	opts, err := v.StructValue()
+	if err != nil {
+		return unionDiscriminator{}, err
+	}
+	name, err := opts.Struct().Ptr(0)
+	if err != nil {
+		return unionDiscriminator{}, err
+	}
+	valueName, err := opts.Struct().Ptr(1)
+	if err != nil {
+		return unionDiscriminator{}, err
+	}
+	d := unionDiscriminator{name: name.Text(), valueName: valueName.Text()}
+	if d.name == "" {
+		d.name = unionName
+	}
+	if d.name == "" {
+		return unionDiscriminator{}, fmt.Errorf("discriminator on unnamed union has no name")
+	}
+	return d, nil
+}
+
+// dataEncoding is the encoding of a Data value.
+type dataEncoding int
+
+const (
+	base64Data dataEncoding = iota
+	hexData
+)
+
+// dataEncodingOf returns the encoding chosen by a field's annotations.
+func dataEncodingOf(f schema.Field) (dataEncoding, error) {
+	annots, err := f.Annotations()
+	if err != nil {
+		return 0, err
+	}
+	v, err := findAnnotation(annots, hexAnnotation)
+	if err != nil {
+		return 0, err
+	}
+	if v.IsValid() {
+		return hexData, nil
+	}
+	return base64Data, nil
+}
+
+func codeOrderFields(s schema.Node_structNode) ([]schema.Field, error) {
+	list, err := s.Fields()
+	if err != nil {
+		return nil, err
+	}
+	n := list.Len()
+	fields := make([]schema.Field, n)
+	for i := 0; i < n; i++ {
+		f := list.At(i)
+		if int(f.CodeOrder()) >= n {
+			return nil, fmt.Errorf("field %d has code order %d out of range", i, f.CodeOrder())
+		}
+		fields[f.CodeOrder()] = f
+	}
+	return fields, nil
+}
@@ -0,0 +1,494 @@
+package json
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"unicode/utf8"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/nodemap"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+// Marshal returns the JSON encoding of a struct of the given type, using
+// the schemas in the default registry.
+func Marshal(typeID uint64, s capnp.Struct) ([]byte, error) {
+	return MarshalRegistry(&schemas.DefaultRegistry, typeID, s)
+}
+
+// MarshalRegistry is like Marshal, but consults reg for schemas.
+func MarshalRegistry(reg *schemas.Registry, typeID uint64, s capnp.Struct) ([]byte, error) {
+	enc := new(encoder)
+	enc.nodes.UseRegistry(reg)
+	if err := enc.marshalStruct(typeID, s); err != nil {
+		return nil, fmt.Errorf("marshal json: %v", err)
+	}
+	return enc.buf.Bytes(), nil
+}
+
+// encoder writes JSON according to a schema.
+type encoder struct {
+	buf   bytes.Buffer
+	tmp   []byte
+	nodes nodemap.Map
+}
+
+func (enc *encoder) findStruct(typeID uint64) (schema.Node, error) {
+	n, err := enc.nodes.Find(typeID)
+	if err != nil {
+		return schema.Node{}, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_structNode {
+		return schema.Node{}, fmt.Errorf("cannot find struct type %#x", typeID)
+	}
+	return n, nil
+}
+
+func (enc *encoder) marshalStruct(typeID uint64, s capnp.Struct) error {
+	n, err := enc.findStruct(typeID)
+	if err != nil {
+		return err
+	}
+	annots, err := n.Annotations()
+	if err != nil {
+		return err
+	}
+	disc, err := discriminatorOf(annots, "")
+	if err != nil {
+		return err
+	}
+	enc.buf.WriteByte('{')
+	first := true
+	if err := enc.marshalFields(n, s, "", disc, &first); err != nil {
+		return err
+	}
+	enc.buf.WriteByte('}')
+	return nil
+}
+
+// marshalFields writes the fields of struct or group n as members of
+// the current object, prefixing their names with prefix.  disc applies
+// to n's unnamed union.  first is true if no members have been written
+// to the object yet.
+func (enc *encoder) marshalFields(n schema.Node, s capnp.Struct, prefix string, disc unionDiscriminator, first *bool) error {
+	sn := n.StructNode()
+	var active uint16
+	if sn.DiscriminantCount() > 0 {
+		active = s.Uint16(capnp.DataOffset(sn.DiscriminantOffset() * 2))
+	}
+	fields, err := codeOrderFields(sn)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		dv := f.DiscriminantValue()
+		inUnion := dv != schema.Field_noDiscriminant
+		if inUnion && dv != active {
+			continue
+		}
+		name, err := jsonName(f)
+		if err != nil {
+			return err
+		}
+		key := prefix + name
+		if inUnion && disc.name != "" {
+			enc.marshalKey(prefix+disc.name, first)
+			enc.marshalString(name)
+			if disc.valueName != "" {
+				key = prefix + disc.valueName
+			}
+		}
+		switch f.Which() {
+		case schema.Field_Which_slot:
+			typ, err := f.Slot().Type()
+			if err != nil {
+				return err
+			}
+			if inUnion && disc.name != "" && typ.Which() == schema.Type_Which_void {
+				// The discriminator says everything there is to say.
+				continue
+			}
+			if !inUnion && isPointerType(typ) && !s.HasPtr(uint16(f.Slot().Offset())) {
+				continue
+			}
+			enc.marshalKey(key, first)
+			if err := enc.marshalFieldValue(s, f, typ); err != nil {
+				return fmt.Errorf("field %s: %v", name, err)
+			}
+		case schema.Field_Which_group:
+			g, err := enc.findStruct(f.Group().TypeId())
+			if err != nil {
+				return err
+			}
+			annots, err := f.Annotations()
+			if err != nil {
+				return err
+			}
+			gdisc, err := discriminatorOf(annots, name)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", name, err)
+			}
+			flatPrefix, flat, err := flattenPrefix(f)
+			if err != nil {
+				return err
+			}
+			if flat {
+				if err := enc.marshalFields(g, s, prefix+flatPrefix, gdisc, first); err != nil {
+					return fmt.Errorf("field %s: %v", name, err)
+				}
+				continue
+			}
+			enc.marshalKey(key, first)
+			enc.buf.WriteByte('{')
+			gfirst := true
+			if err := enc.marshalFields(g, s, "", gdisc, &gfirst); err != nil {
+				return fmt.Errorf("field %s: %v", name, err)
+			}
+			enc.buf.WriteByte('}')
+		}
+	}
+	return nil
+}
+
+func isPointerType(typ schema.Type) bool {
+	switch typ.Which() {
+	case schema.Type_Which_text, schema.Type_Which_data, schema.Type_Which_list,
+		schema.Type_Which_structType, schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		return true
+	default:
+		return false
+	}
+}
+
+func (enc *encoder) marshalKey(key string, first *bool) {
+	if !*first {
+		enc.buf.WriteByte(',')
+	}
+	*first = false
+	enc.marshalString(key)
+	enc.buf.WriteByte(':')
+}
+
+func (enc *encoder) marshalFieldValue(s capnp.Struct, f schema.Field, typ schema.Type) error {
+	dv, err := f.Slot().DefaultValue()
+	if err != nil {
+		return err
+	}
+	if dv.IsValid() && int(typ.Which()) != int(dv.Which()) {
+		return fmt.Errorf("default value is a %v, want %v", dv.Which(), typ.Which())
+	}
+	off := f.Slot().Offset()
+	switch typ.Which() {
+	case schema.Type_Which_void:
+		enc.buf.WriteString("null")
+	case schema.Type_Which_bool:
+		v := s.Bit(capnp.BitOffset(off))
+		enc.marshalBool(v != dv.Bool())
+	case schema.Type_Which_int8:
+		v := s.Uint8(capnp.DataOffset(off))
+		enc.marshalInt(int64(int8(v ^ uint8(dv.Int8()))))
+	case schema.Type_Which_int16:
+		v := s.Uint16(capnp.DataOffset(off * 2))
+		enc.marshalInt(int64(int16(v ^ uint16(dv.Int16()))))
+	case schema.Type_Which_int32:
+		v := s.Uint32(capnp.DataOffset(off * 4))
+		enc.marshalInt(int64(int32(v ^ uint32(dv.Int32()))))
+	case schema.Type_Which_int64:
+		v := s.Uint64(capnp.DataOffset(off * 8))
+		enc.marshalInt64(int64(v ^ uint64(dv.Int64())))
+	case schema.Type_Which_uint8:
+		v := s.Uint8(capnp.DataOffset(off))
+		enc.marshalUint(uint64(v ^ dv.Uint8()))
+	case schema.Type_Which_uint16:
+		v := s.Uint16(capnp.DataOffset(off * 2))
+		enc.marshalUint(uint64(v ^ dv.Uint16()))
+	case schema.Type_Which_uint32:
+		v := s.Uint32(capnp.DataOffset(off * 4))
+		enc.marshalUint(uint64(v ^ dv.Uint32()))
+	case schema.Type_Which_uint64:
+		v := s.Uint64(capnp.DataOffset(off * 8))
+		enc.marshalUint64(v ^ dv.Uint64())
+	case schema.Type_Which_float32:
+		v := s.Uint32(capnp.DataOffset(off * 4))
+		d := math.Float32bits(dv.Float32())
+		enc.marshalFloat(float64(math.Float32frombits(v^d)), 32)
+	case schema.Type_Which_float64:
+		v := s.Uint64(capnp.DataOffset(off * 8))
+		d := math.Float64bits(dv.Float64())
+		enc.marshalFloat(math.Float64frombits(v^d), 64)
+	case schema.Type_Which_text:
+		p, err := s.Ptr(uint16(off))
+		if err != nil {
+			return err
+		}
+		if !p.IsValid() {
+			enc.buf.WriteString("null")
+			return nil
+		}
+		enc.marshalString(p.Text())
+	case schema.Type_Which_data:
+		p, err := s.Ptr(uint16(off))
+		if err != nil {
+			return err
+		}
+		if !p.IsValid() {
+			enc.buf.WriteString("null")
+			return nil
+		}
+		de, err := dataEncodingOf(f)
+		if err != nil {
+			return err
+		}
+		enc.marshalData(p.Data(), de)
+	case schema.Type_Which_structType:
+		p, err := s.Ptr(uint16(off))
+		if err != nil {
+			return err
+		}
+		if !p.IsValid() {
+			enc.buf.WriteString("null")
+			return nil
+		}
+		return enc.marshalStruct(typ.StructType().TypeId(), p.Struct())
+	case schema.Type_Which_list:
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return err
+		}
+		p, err := s.Ptr(uint16(off))
+		if err != nil {
+			return err
+		}
+		if !p.IsValid() {
+			enc.buf.WriteString("null")
+			return nil
+		}
+		de, err := dataEncodingOf(f)
+		if err != nil {
+			return err
+		}
+		return enc.marshalList(elem, p.List(), de)
+	case schema.Type_Which_enum:
+		v := s.Uint16(capnp.DataOffset(off * 2))
+		return enc.marshalEnum(typ.Enum().TypeId(), v^dv.Enum())
+	case schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		if s.HasPtr(uint16(off)) {
+			return fmt.Errorf("cannot marshal %v", typ.Which())
+		}
+		enc.buf.WriteString("null")
+	default:
+		return fmt.Errorf("unknown field type %v", typ.Which())
+	}
+	return nil
+}
+
+func (enc *encoder) marshalList(elem schema.Type, l capnp.List, de dataEncoding) error {
+	enc.buf.WriteByte('[')
+	for i := 0; i < l.Len(); i++ {
+		if i > 0 {
+			enc.buf.WriteByte(',')
+		}
+		if err := enc.marshalElem(elem, l, i, de); err != nil {
+			return fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	enc.buf.WriteByte(']')
+	return nil
+}
+
+func (enc *encoder) marshalElem(elem schema.Type, l capnp.List, i int, de dataEncoding) error {
+	switch elem.Which() {
+	case schema.Type_Which_void:
+		enc.buf.WriteString("null")
+	case schema.Type_Which_bool:
+		enc.marshalBool(capnp.BitList{List: l}.At(i))
+	case schema.Type_Which_int8:
+		enc.marshalInt(int64(capnp.Int8List{List: l}.At(i)))
+	case schema.Type_Which_int16:
+		enc.marshalInt(int64(capnp.Int16List{List: l}.At(i)))
+	case schema.Type_Which_int32:
+		enc.marshalInt(int64(capnp.Int32List{List: l}.At(i)))
+	case schema.Type_Which_int64:
+		enc.marshalInt64(capnp.Int64List{List: l}.At(i))
+	case schema.Type_Which_uint8:
+		enc.marshalUint(uint64(capnp.UInt8List{List: l}.At(i)))
+	case schema.Type_Which_uint16:
+		enc.marshalUint(uint64(capnp.UInt16List{List: l}.At(i)))
+	case schema.Type_Which_uint32:
+		enc.marshalUint(uint64(capnp.UInt32List{List: l}.At(i)))
+	case schema.Type_Which_uint64:
+		enc.marshalUint64(capnp.UInt64List{List: l}.At(i))
+	case schema.Type_Which_float32:
+		enc.marshalFloat(float64(capnp.Float32List{List: l}.At(i)), 32)
+	case schema.Type_Which_float64:
+		enc.marshalFloat(capnp.Float64List{List: l}.At(i), 64)
+	case schema.Type_Which_text:
+		t, err := capnp.TextList{List: l}.At(i)
+		if err != nil {
+			return err
+		}
+		enc.marshalString(t)
+	case schema.Type_Which_data:
+		d, err := capnp.DataList{List: l}.At(i)
+		if err != nil {
+			return err
+		}
+		enc.marshalData(d, de)
+	case schema.Type_Which_structType:
+		return enc.marshalStruct(elem.StructType().TypeId(), l.Struct(i))
+	case schema.Type_Which_list:
+		ee, err := elem.List().ElementType()
+		if err != nil {
+			return err
+		}
+		p, err := capnp.PointerList{List: l}.At(i)
+		if err != nil {
+			return err
+		}
+		if !p.IsValid() {
+			enc.buf.WriteString("null")
+			return nil
+		}
+		return enc.marshalList(ee, p.List(), de)
+	case schema.Type_Which_enum:
+		return enc.marshalEnum(elem.Enum().TypeId(), capnp.UInt16List{List: l}.At(i))
+	case schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		p, err := capnp.PointerList{List: l}.At(i)
+		if err != nil {
+			return err
+		}
+		if p.IsValid() {
+			return fmt.Errorf("cannot marshal %v", elem.Which())
+		}
+		enc.buf.WriteString("null")
+	default:
+		return fmt.Errorf("unknown list type %v", elem.Which())
+	}
+	return nil
+}
+
+func (enc *encoder) marshalEnum(typeID uint64, val uint16) error {
+	n, err := enc.nodes.Find(typeID)
+	if err != nil {
+		return err
+	}
+	if n.Which() != schema.Node_Which_enum {
+		return fmt.Errorf("marshaling enum of type @%#x: type is not an enum", typeID)
+	}
+	enums, err := n.Enum().Enumerants()
+	if err != nil {
+		return err
+	}
+	if int(val) >= enums.Len() {
+		enc.marshalUint(uint64(val))
+		return nil
+	}
+	name, err := enumerantName(enums.At(int(val)))
+	if err != nil {
+		return err
+	}
+	enc.marshalString(name)
+	return nil
+}
+
+func (enc *encoder) marshalBool(v bool) {
+	if v {
+		enc.buf.WriteString("true")
+	} else {
+		enc.buf.WriteString("false")
+	}
+}
+
+func (enc *encoder) marshalInt(i int64) {
+	enc.tmp = strconv.AppendInt(enc.tmp[:0], i, 10)
+	enc.buf.Write(enc.tmp)
+}
+
+func (enc *encoder) marshalUint(i uint64) {
+	enc.tmp = strconv.AppendUint(enc.tmp[:0], i, 10)
+	enc.buf.Write(enc.tmp)
+}
+
+// marshalInt64 writes a 64-bit integer as a string, since JSON readers
+// commonly parse numbers as float64.
+func (enc *encoder) marshalInt64(i int64) {
+	enc.buf.WriteByte('"')
+	enc.marshalInt(i)
+	enc.buf.WriteByte('"')
+}
+
+// marshalUint64 is like marshalInt64 for unsigned integers.
+func (enc *encoder) marshalUint64(i uint64) {
+	enc.buf.WriteByte('"')
+	enc.marshalUint(i)
+	enc.buf.WriteByte('"')
+}
+
+func (enc *encoder) marshalFloat(f float64, bits int) {
+	switch {
+	case math.IsNaN(f):
+		enc.buf.WriteString(`"NaN"`)
+	case math.IsInf(f, 1):
+		enc.buf.WriteString(`"Infinity"`)
+	case math.IsInf(f, -1):
+		enc.buf.WriteString(`"-Infinity"`)
+	default:
+		enc.tmp = strconv.AppendFloat(enc.tmp[:0], f, 'g', -1, bits)
+		enc.buf.Write(enc.tmp)
+	}
+}
+
+func (enc *encoder) marshalData(b []byte, de dataEncoding) {
+	enc.buf.WriteByte('"')
+	switch de {
+	case hexData:
+		enc.buf.WriteString(hex.EncodeToString(b))
+	default:
+		enc.buf.WriteString(base64.StdEncoding.EncodeToString(b))
+	}
+	enc.buf.WriteByte('"')
+}
+
+// marshalString writes s as a JSON string.  Invalid UTF-8 is replaced
+// with U+FFFD.
+func (enc *encoder) marshalString(s string) {
+	const hexDigits = "0123456789abcdef"
+	enc.buf.WriteByte('"')
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				enc.buf.WriteByte('\\')
+				enc.buf.WriteByte(c)
+			case c == '\n':
+				enc.buf.WriteString(`\n`)
+			case c == '\r':
+				enc.buf.WriteString(`\r`)
+			case c == '\t':
+				enc.buf.WriteString(`\t`)
+			case c < 0x20:
+				enc.buf.WriteString(`\u00`)
+				enc.buf.WriteByte(hexDigits[c>>4])
+				enc.buf.WriteByte(hexDigits[c&0xf])
+			default:
+				enc.buf.WriteByte(c)
+			}
+			i++
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			enc.buf.WriteRune(utf8.RuneError)
+		} else {
+			enc.buf.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	enc.buf.WriteByte('"')
+}
@@ -0,0 +1,411 @@
+package json
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/encoding/text"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+// Type IDs from encoding/text/testdata/txt.capnp.
+const (
+	keyValueID = 0x8df8bc5abdc060a6
+	valueID    = 0xd3602730c572a43b
+)
+
+// txtRegistry returns a registry with the schemas in txt.capnp.
+func txtRegistry(t *testing.T) *schemas.Registry {
+	data, err := ioutil.ReadFile(filepath.Join("..", "text", "testdata", "txt.capnp.out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	err = reg.Register(&schemas.Schema{
+		Bytes: data,
+		Nodes: []uint64{keyValueID, valueID},
+	})
+	if err != nil {
+		t.Fatalf("Adding to registry: %v", err)
+	}
+	return reg
+}
+
+// newValue returns a new txt.capnp Value set from its text format.
+func newValue(reg *schemas.Registry, txt string) (capnp.Struct, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	val, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if err := text.UnmarshalIntoRegistry(reg, valueID, val, []byte(txt)); err != nil {
+		return capnp.Struct{}, err
+	}
+	return val, nil
+}
+
+func TestMarshal(t *testing.T) {
+	tests := []struct {
+		text string
+		json string
+	}{
+		{`(void = void)`, `{"void":null}`},
+		{`(bool = true)`, `{"bool":true}`},
+		{`(int32 = -123)`, `{"int32":-123}`},
+		{`(int64 = -5)`, `{"int64":"-5"}`},
+		{`(uint64 = 18446744073709551615)`, `{"uint64":"18446744073709551615"}`},
+		{`(float64 = 3.14)`, `{"float64":3.14}`},
+		{`(float64 = NaN)`, `{"float64":"NaN"}`},
+		{`(float32 = -Inf)`, `{"float32":"-Infinity"}`},
+		{`(text = "a\n\"b\"\x01")`, `{"text":"a\n\"b\"\u0001"}`},
+		{`(text = "\xff")`, `{"text":"` + "�" + `"}`},
+		{`(data = "Hi")`, `{"data":"SGk="}`},
+		{`(cheese = gouda)`, `{"cheese":"gouda"}`},
+		{`(voidList = [void, void])`, `{"voidList":[null,null]}`},
+		{`(int64List = [1, -2])`, `{"int64List":["1","-2"]}`},
+		{`(float32List = [0.5, Inf])`, `{"float32List":[0.5,"Infinity"]}`},
+		{`(textList = ["foo", "bar"])`, `{"textList":["foo","bar"]}`},
+		{`(dataList = ["Hi", ""])`, `{"dataList":["SGk=",""]}`},
+		{`(cheeseList = [gouda, cheddar])`, `{"cheeseList":["gouda","cheddar"]}`},
+		{`(matrix = [[1, 2], [], [3]])`, `{"matrix":[[1,2],[],[3]]}`},
+		{
+			`(map = [(key = "foo", value = (void = void)), (value = (int8 = 1))])`,
+			`{"map":[{"key":"foo","value":{"void":null}},{"value":{"int8":1}}]}`,
+		},
+	}
+	reg := txtRegistry(t)
+	for _, test := range tests {
+		val, err := newValue(reg, test.text)
+		if err != nil {
+			t.Errorf("%s: %v", test.text, err)
+			continue
+		}
+		got, err := MarshalRegistry(reg, valueID, val)
+		if err != nil {
+			t.Errorf("MarshalRegistry(%s): %v", test.text, err)
+			continue
+		}
+		if string(got) != test.json {
+			t.Errorf("MarshalRegistry(%s) = %s; want %s", test.text, got, test.json)
+		}
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	reg := txtRegistry(t)
+	val, err := newValue(reg, `(int8 = 1)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := MarshalRegistry(reg, 0x1234, val); err == nil {
+		t.Error("MarshalRegistry with unknown type ID did not return an error")
+	}
+}
+
+// Type IDs of the annotated schema built by annotatedRegistry.
+const (
+	personID  = 0xa1b2c3d4e5f60001
+	addressID = 0xa1b2c3d4e5f60002
+	contactID = 0xa1b2c3d4e5f60003
+	colorID   = 0xa1b2c3d4e5f60004
+)
+
+// personSize is the size of a Person struct.
+var personSize = capnp.ObjectSize{DataSize: 16, PointerCount: 4}
+
+// annotatedRegistry returns a registry with the schema:
+//
+//	struct Person {
+//	  name @0 :Text $Json.name("fullName");
+//	  id @1 :UInt64;
+//	  photo @2 :Data $Json.hex;
+//	  color @3 :Color;
+//	  address :group $Json.flatten(prefix = "addr_") {
+//	    street @4 :Text;
+//	    zip @5 :Int32;
+//	  }
+//	  contact :union $Json.discriminator(name = "kind", valueName = "value") {
+//	    email @6 :Text;
+//	    none @7 :Void;
+//	  }
+//	}
+//
+//	enum Color {
+//	  red @0;
+//	  green @1 $Json.name("GREEN");
+//	}
+func annotatedRegistry(t *testing.T) *schemas.Registry {
+	msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := schema.NewRootCodeGeneratorRequest(seg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := req.NewNodes(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	textType := func(typ schema.Type) { typ.SetText() }
+
+	setStructNode(t, nodes.At(0), personID, false, 0, 0, []testField{
+		{name: "name", offset: 0, typ: textType, annots: []testAnnotation{nameAnnot("fullName")}},
+		{name: "id", offset: 0, typ: func(typ schema.Type) { typ.SetUint64() }},
+		{name: "photo", offset: 1, typ: func(typ schema.Type) { typ.SetData() }, annots: []testAnnotation{{id: hexAnnotation}}},
+		{name: "color", offset: 4, typ: func(typ schema.Type) {
+			typ.SetEnum()
+			typ.Enum().SetTypeId(colorID)
+		}},
+		{name: "address", group: addressID, annots: []testAnnotation{{
+			id:     flattenAnnotation,
+			fields: []string{"addr_"},
+		}}},
+		{name: "contact", group: contactID, annots: []testAnnotation{{
+			id:     discriminatorAnnotation,
+			fields: []string{"kind", "value"},
+		}}},
+	})
+	setStructNode(t, nodes.At(1), addressID, true, 0, 0, []testField{
+		{name: "street", offset: 2, typ: textType},
+		{name: "zip", offset: 3, typ: func(typ schema.Type) { typ.SetInt32() }},
+	})
+	setStructNode(t, nodes.At(2), contactID, true, 2, 5, []testField{
+		{name: "email", disc: 1, offset: 3, typ: textType},
+		{name: "none", disc: 2, typ: func(typ schema.Type) { typ.SetVoid() }},
+	})
+
+	color := nodes.At(3)
+	color.SetId(colorID)
+	color.SetEnum()
+	enums, err := color.Enum().NewEnumerants(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"red", "green"} {
+		e := enums.At(i)
+		if err := e.SetName(name); err != nil {
+			t.Fatal(err)
+		}
+		e.SetCodeOrder(uint16(i))
+	}
+	annots, err := enums.At(1).NewAnnotations(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setAnnotation(annots.At(0), nameAnnot("GREEN")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := msg.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	err = reg.Register(&schemas.Schema{
+		Bytes: data,
+		Nodes: []uint64{personID, addressID, contactID, colorID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+// testField describes a field for setStructNode.
+type testField struct {
+	name string
+	// disc is one more than the field's discriminant value, or zero if
+	// the field is not in a union.
+	disc   uint16
+	offset uint32
+	typ    func(schema.Type) // nil for a group
+	group  uint64
+	annots []testAnnotation
+}
+
+// testAnnotation describes an annotation.  Its value is text if that
+// is not empty, a struct whose Text fields are fields if that is not
+// empty, and Void otherwise.
+type testAnnotation struct {
+	id     uint64
+	text   string
+	fields []string
+}
+
+func nameAnnot(name string) testAnnotation {
+	return testAnnotation{id: nameAnnotation, text: name}
+}
+
+func setStructNode(t *testing.T, n schema.Node, id uint64, isGroup bool, discCount uint16, discOffset uint32, fields []testField) {
+	n.SetId(id)
+	n.SetStructNode()
+	n.StructNode().SetDataWordCount(uint16(personSize.DataSize / 8))
+	n.StructNode().SetPointerCount(personSize.PointerCount)
+	n.StructNode().SetIsGroup(isGroup)
+	n.StructNode().SetDiscriminantCount(discCount)
+	n.StructNode().SetDiscriminantOffset(discOffset)
+	list, err := n.StructNode().NewFields(int32(len(fields)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tf := range fields {
+		f := list.At(i)
+		if err := f.SetName(tf.name); err != nil {
+			t.Fatal(err)
+		}
+		f.SetCodeOrder(uint16(i))
+		if tf.disc == 0 {
+			f.SetDiscriminantValue(schema.Field_noDiscriminant)
+		} else {
+			f.SetDiscriminantValue(tf.disc - 1)
+		}
+		if tf.typ == nil {
+			f.SetGroup()
+			f.Group().SetTypeId(tf.group)
+		} else {
+			f.SetSlot()
+			f.Slot().SetOffset(tf.offset)
+			typ, err := f.Slot().NewType()
+			if err != nil {
+				t.Fatal(err)
+			}
+			tf.typ(typ)
+			// The compiler always writes a default value of the slot's type.
+			dv, err := f.Slot().NewDefaultValue()
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch typ.Which() {
+			case schema.Type_Which_void:
+				dv.SetVoid()
+			case schema.Type_Which_int32:
+				dv.SetInt32(0)
+			case schema.Type_Which_uint64:
+				dv.SetUint64(0)
+			case schema.Type_Which_text:
+				err = dv.SetText("")
+			case schema.Type_Which_data:
+				err = dv.SetData(nil)
+			case schema.Type_Which_enum:
+				dv.SetEnum(0)
+			default:
+				t.Fatalf("no default value for %v", typ.Which())
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		annots, err := f.NewAnnotations(int32(len(tf.annots)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, ta := range tf.annots {
+			if err := setAnnotation(annots.At(j), ta); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func setAnnotation(a schema.Annotation, ta testAnnotation) error {
+	a.SetId(ta.id)
+	v, err := a.NewValue()
+	if err != nil {
+		return err
+	}
+	switch {
+	case ta.text != "":
+		return v.SetText(ta.text)
+	case len(ta.fields) > 0:
+		s, err := capnp.NewStruct(a.Segment(), capnp.ObjectSize{PointerCount: uint16(len(ta.fields))})
+		if err != nil {
+			return err
+		}
+		for i, f := range ta.fields {
+			if err := s.SetNewText(uint16(i), f); err != nil {
+				return err
+			}
+		}
+		return v.SetStructValue(s.ToPtr())
+	default:
+		v.SetVoid()
+		return nil
+	}
+}
+
+// newPerson returns a Person with every field set and the given
+// contact member.
+func newPerson(email string) (capnp.Struct, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	p, err := capnp.NewRootStruct(seg, personSize)
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if err := p.SetNewText(0, "Alice"); err != nil {
+		return capnp.Struct{}, err
+	}
+	p.SetUint64(0, 12345678901234567890)
+	photo, err := capnp.NewData(seg, []byte{0xca, 0xfe})
+	if err != nil {
+		return capnp.Struct{}, err
+	}
+	if err := p.SetPtr(1, photo.ToPtr()); err != nil {
+		return capnp.Struct{}, err
+	}
+	p.SetUint16(8, 1) // color = green
+	if err := p.SetNewText(2, "Main"); err != nil {
+		return capnp.Struct{}, err
+	}
+	p.SetUint32(12, 12345)
+	if email == "" {
+		p.SetUint16(10, 1) // contact = none
+		return p, nil
+	}
+	if err := p.SetNewText(3, email); err != nil {
+		return capnp.Struct{}, err
+	}
+	return p, nil
+}
+
+func TestMarshalAnnotations(t *testing.T) {
+	reg := annotatedRegistry(t)
+	tests := []struct {
+		email string
+		json  string
+	}{
+		{
+			"a@example.com",
+			`{"fullName":"Alice","id":"12345678901234567890","photo":"cafe","color":"GREEN",` +
+				`"addr_street":"Main","addr_zip":12345,"contact":{"kind":"email","value":"a@example.com"}}`,
+		},
+		{
+			"",
+			`{"fullName":"Alice","id":"12345678901234567890","photo":"cafe","color":"GREEN",` +
+				`"addr_street":"Main","addr_zip":12345,"contact":{"kind":"none"}}`,
+		},
+	}
+	for _, test := range tests {
+		p, err := newPerson(test.email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := MarshalRegistry(reg, personID, p)
+		if err != nil {
+			t.Errorf("MarshalRegistry(email = %q): %v", test.email, err)
+			continue
+		}
+		if string(got) != test.json {
+			t.Errorf("MarshalRegistry(email = %q) =\n%s\nwant\n%s", test.email, got, test.json)
+		}
+	}
+}
@@ -0,0 +1,759 @@
+package json
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/nodemap"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+// Unmarshal parses the JSON encoding of a struct of the given type, as
+// written by Marshal, and sets the corresponding fields of s, using the
+// schemas in the default registry.  s may be part of a larger message:
+// fields that are not mentioned in data are left unchanged, and new
+// text, data, list and struct values are allocated in s's message.
+// Members that name no field are ignored.
+//
+// Integers may be given as numbers or strings, floats may also be
+// "NaN", "Infinity" or "-Infinity", enums may be given by name or
+// number, and null clears a pointer field.
+func Unmarshal(typeID uint64, s capnp.Struct, data []byte) error {
+	return UnmarshalRegistry(&schemas.DefaultRegistry, typeID, s, data)
+}
+
+// UnmarshalRegistry is like Unmarshal, but consults reg for schemas.
+func UnmarshalRegistry(reg *schemas.Registry, typeID uint64, s capnp.Struct, data []byte) error {
+	dec := gojson.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("unmarshal json: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unmarshal json: unexpected data after value")
+	}
+	u := new(decoder)
+	u.nodes.UseRegistry(reg)
+	if err := u.setStruct(typeID, s, v); err != nil {
+		return fmt.Errorf("unmarshal json: %v", err)
+	}
+	return nil
+}
+
+// decoder sets fields from decoded JSON values according to a schema.
+type decoder struct {
+	nodes nodemap.Map
+}
+
+func (u *decoder) findStruct(typeID uint64) (schema.Node, error) {
+	n, err := u.nodes.Find(typeID)
+	if err != nil {
+		return schema.Node{}, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_structNode {
+		return schema.Node{}, fmt.Errorf("cannot find struct type %#x", typeID)
+	}
+	return n, nil
+}
+
+func (u *decoder) structSize(typeID uint64) (capnp.ObjectSize, error) {
+	n, err := u.findStruct(typeID)
+	if err != nil {
+		return capnp.ObjectSize{}, err
+	}
+	return capnp.ObjectSize{
+		DataSize:     capnp.Size(n.StructNode().DataWordCount()) * 8,
+		PointerCount: n.StructNode().PointerCount(),
+	}, nil
+}
+
+func (u *decoder) setStruct(typeID uint64, s capnp.Struct, v interface{}) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("got %s; want object", kindOf(v))
+	}
+	n, err := u.findStruct(typeID)
+	if err != nil {
+		return err
+	}
+	annots, err := n.Annotations()
+	if err != nil {
+		return err
+	}
+	disc, err := discriminatorOf(annots, "")
+	if err != nil {
+		return err
+	}
+	return u.setFields(n, s, obj, "", disc)
+}
+
+// setFields sets the fields of struct or group n from the members of
+// obj whose names start with prefix.  disc applies to n's unnamed union.
+func (u *decoder) setFields(n schema.Node, s capnp.Struct, obj map[string]interface{}, prefix string, disc unionDiscriminator) error {
+	sn := n.StructNode()
+	var member string
+	if disc.name != "" {
+		if v, ok := obj[prefix+disc.name]; ok {
+			m, ok := v.(string)
+			if !ok {
+				return fmt.Errorf("discriminator %s: got %s; want string", prefix+disc.name, kindOf(v))
+			}
+			member = m
+		}
+	}
+	fields, err := codeOrderFields(sn)
+	if err != nil {
+		return err
+	}
+	for _, f := range fields {
+		name, err := jsonName(f)
+		if err != nil {
+			return err
+		}
+		key := prefix + name
+		dv := f.DiscriminantValue()
+		inUnion := dv != schema.Field_noDiscriminant
+		selected := false
+		if inUnion && disc.name != "" {
+			if name != member {
+				continue
+			}
+			selected = true
+			if disc.valueName != "" {
+				key = prefix + disc.valueName
+			}
+		}
+		setDiscriminant := func() error {
+			if !inUnion {
+				return nil
+			}
+			off := sn.DiscriminantOffset()
+			if err := checkData(s, off, 16); err != nil {
+				return fmt.Errorf("field %s: %v", name, err)
+			}
+			s.SetUint16(capnp.DataOffset(off*2), dv)
+			return nil
+		}
+		switch f.Which() {
+		case schema.Field_Which_slot:
+			v, ok := obj[key]
+			if !ok {
+				if selected {
+					// A discriminator alone selects a member, such as a Void.
+					if err := setDiscriminant(); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := setDiscriminant(); err != nil {
+				return err
+			}
+			if err := u.setField(s, f, v); err != nil {
+				return fmt.Errorf("field %s: %v", name, err)
+			}
+		case schema.Field_Which_group:
+			g, err := u.findStruct(f.Group().TypeId())
+			if err != nil {
+				return err
+			}
+			annots, err := f.Annotations()
+			if err != nil {
+				return err
+			}
+			gdisc, err := discriminatorOf(annots, name)
+			if err != nil {
+				return fmt.Errorf("field %s: %v", name, err)
+			}
+			flatPrefix, flat, err := flattenPrefix(f)
+			if err != nil {
+				return err
+			}
+			if flat {
+				if selected {
+					if err := setDiscriminant(); err != nil {
+						return err
+					}
+				}
+				if err := u.setFields(g, s, obj, prefix+flatPrefix, gdisc); err != nil {
+					return fmt.Errorf("field %s: %v", name, err)
+				}
+				continue
+			}
+			v, ok := obj[key]
+			if !ok {
+				continue
+			}
+			sub, ok := v.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("field %s: got %s; want object", name, kindOf(v))
+			}
+			if err := setDiscriminant(); err != nil {
+				return err
+			}
+			if err := u.setFields(g, s, sub, "", gdisc); err != nil {
+				return fmt.Errorf("field %s: %v", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkData returns an error if a value of the given width at the
+// offset, in units of the width, is outside of s's data section.
+func checkData(s capnp.Struct, off uint32, bits uint64) error {
+	if (uint64(off)+1)*bits > uint64(s.Size().DataSize)*8 {
+		return fmt.Errorf("offset %d is outside of the struct's data section", off)
+	}
+	return nil
+}
+
+func checkPtr(s capnp.Struct, off uint32) error {
+	if off >= uint32(s.Size().PointerCount) {
+		return fmt.Errorf("pointer %d is outside of the struct's pointer section", off)
+	}
+	return nil
+}
+
+func (u *decoder) setField(s capnp.Struct, f schema.Field, v interface{}) error {
+	typ, err := f.Slot().Type()
+	if err != nil {
+		return err
+	}
+	dv, err := f.Slot().DefaultValue()
+	if err != nil {
+		return err
+	}
+	if dv.IsValid() && int(typ.Which()) != int(dv.Which()) {
+		return fmt.Errorf("default value is a %v, want %v", dv.Which(), typ.Which())
+	}
+	off := f.Slot().Offset()
+	if isPointerType(typ) {
+		if err := checkPtr(s, off); err != nil {
+			return err
+		}
+		if v == nil {
+			return s.SetPtr(uint16(off), capnp.Ptr{})
+		}
+	}
+	switch typ.Which() {
+	case schema.Type_Which_void:
+		if v != nil {
+			return fmt.Errorf("got %s; want null", kindOf(v))
+		}
+	case schema.Type_Which_bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("got %s; want boolean", kindOf(v))
+		}
+		if err := checkData(s, off, 1); err != nil {
+			return err
+		}
+		s.SetBit(capnp.BitOffset(off), b != dv.Bool())
+	case schema.Type_Which_int8:
+		i, err := parseInt(v, 8)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 8); err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^uint8(dv.Int8()))
+	case schema.Type_Which_int16:
+		i, err := parseInt(v, 16)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 16); err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^uint16(dv.Int16()))
+	case schema.Type_Which_int32:
+		i, err := parseInt(v, 32)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 32); err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^uint32(dv.Int32()))
+	case schema.Type_Which_int64:
+		i, err := parseInt(v, 64)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 64); err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), uint64(i)^uint64(dv.Int64()))
+	case schema.Type_Which_uint8:
+		i, err := parseUint(v, 8)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 8); err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^dv.Uint8())
+	case schema.Type_Which_uint16:
+		i, err := parseUint(v, 16)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 16); err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^dv.Uint16())
+	case schema.Type_Which_uint32:
+		i, err := parseUint(v, 32)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 32); err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^dv.Uint32())
+	case schema.Type_Which_uint64:
+		i, err := parseUint(v, 64)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 64); err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), i^dv.Uint64())
+	case schema.Type_Which_float32:
+		x, err := parseFloat(v, 32)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 32); err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), math.Float32bits(float32(x))^math.Float32bits(dv.Float32()))
+	case schema.Type_Which_float64:
+		x, err := parseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 64); err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), math.Float64bits(x)^math.Float64bits(dv.Float64()))
+	case schema.Type_Which_text:
+		t, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("got %s; want string", kindOf(v))
+		}
+		return s.SetNewText(uint16(off), t)
+	case schema.Type_Which_data:
+		de, err := dataEncodingOf(f)
+		if err != nil {
+			return err
+		}
+		b, err := parseData(v, de)
+		if err != nil {
+			return err
+		}
+		d, err := capnp.NewData(s.Segment(), b)
+		if err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), d.ToPtr())
+	case schema.Type_Which_structType:
+		tid := typ.StructType().TypeId()
+		sz, err := u.structSize(tid)
+		if err != nil {
+			return err
+		}
+		ss, err := capnp.NewStruct(s.Segment(), sz)
+		if err != nil {
+			return err
+		}
+		if err := u.setStruct(tid, ss, v); err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), ss.ToPtr())
+	case schema.Type_Which_list:
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return err
+		}
+		de, err := dataEncodingOf(f)
+		if err != nil {
+			return err
+		}
+		l, err := u.newList(s.Segment(), elem, v, de)
+		if err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), l.ToPtr())
+	case schema.Type_Which_enum:
+		e, err := u.enumValue(typ.Enum().TypeId(), v)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 16); err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), e^dv.Enum())
+	case schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		return fmt.Errorf("cannot unmarshal %v other than null", typ.Which())
+	default:
+		return fmt.Errorf("unknown field type %v", typ.Which())
+	}
+	return nil
+}
+
+func (u *decoder) newList(seg *capnp.Segment, elem schema.Type, v interface{}, de dataEncoding) (capnp.List, error) {
+	elems, ok := v.([]interface{})
+	if !ok {
+		return capnp.List{}, fmt.Errorf("got %s; want array", kindOf(v))
+	}
+	n := int32(len(elems))
+	var l capnp.List
+	switch elem.Which() {
+	case schema.Type_Which_void:
+		l = capnp.NewVoidList(seg, n).List
+	case schema.Type_Which_bool:
+		bl, err := capnp.NewBitList(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = bl.List
+	case schema.Type_Which_int8:
+		il, err := capnp.NewInt8List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_int16:
+		il, err := capnp.NewInt16List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_int32:
+		il, err := capnp.NewInt32List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_int64:
+		il, err := capnp.NewInt64List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_uint8:
+		il, err := capnp.NewUInt8List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_uint16, schema.Type_Which_enum:
+		il, err := capnp.NewUInt16List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_uint32:
+		il, err := capnp.NewUInt32List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_uint64:
+		il, err := capnp.NewUInt64List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = il.List
+	case schema.Type_Which_float32:
+		fl, err := capnp.NewFloat32List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = fl.List
+	case schema.Type_Which_float64:
+		fl, err := capnp.NewFloat64List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = fl.List
+	case schema.Type_Which_text, schema.Type_Which_data, schema.Type_Which_list:
+		pl, err := capnp.NewPointerList(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l = pl.List
+	case schema.Type_Which_structType:
+		sz, err := u.structSize(elem.StructType().TypeId())
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l, err = capnp.NewCompositeList(seg, sz, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+	case schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		return capnp.List{}, fmt.Errorf("cannot unmarshal a list of %v", elem.Which())
+	default:
+		return capnp.List{}, fmt.Errorf("unknown list type %v", elem.Which())
+	}
+	for i, e := range elems {
+		if err := u.setElem(l, i, elem, e, de); err != nil {
+			return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	return l, nil
+}
+
+func (u *decoder) setElem(l capnp.List, i int, elem schema.Type, v interface{}, de dataEncoding) error {
+	switch elem.Which() {
+	case schema.Type_Which_void:
+		if v != nil {
+			return fmt.Errorf("got %s; want null", kindOf(v))
+		}
+	case schema.Type_Which_bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("got %s; want boolean", kindOf(v))
+		}
+		capnp.BitList{List: l}.Set(i, b)
+	case schema.Type_Which_int8:
+		x, err := parseInt(v, 8)
+		if err != nil {
+			return err
+		}
+		capnp.Int8List{List: l}.Set(i, int8(x))
+	case schema.Type_Which_int16:
+		x, err := parseInt(v, 16)
+		if err != nil {
+			return err
+		}
+		capnp.Int16List{List: l}.Set(i, int16(x))
+	case schema.Type_Which_int32:
+		x, err := parseInt(v, 32)
+		if err != nil {
+			return err
+		}
+		capnp.Int32List{List: l}.Set(i, int32(x))
+	case schema.Type_Which_int64:
+		x, err := parseInt(v, 64)
+		if err != nil {
+			return err
+		}
+		capnp.Int64List{List: l}.Set(i, x)
+	case schema.Type_Which_uint8:
+		x, err := parseUint(v, 8)
+		if err != nil {
+			return err
+		}
+		capnp.UInt8List{List: l}.Set(i, uint8(x))
+	case schema.Type_Which_uint16:
+		x, err := parseUint(v, 16)
+		if err != nil {
+			return err
+		}
+		capnp.UInt16List{List: l}.Set(i, uint16(x))
+	case schema.Type_Which_uint32:
+		x, err := parseUint(v, 32)
+		if err != nil {
+			return err
+		}
+		capnp.UInt32List{List: l}.Set(i, uint32(x))
+	case schema.Type_Which_uint64:
+		x, err := parseUint(v, 64)
+		if err != nil {
+			return err
+		}
+		capnp.UInt64List{List: l}.Set(i, x)
+	case schema.Type_Which_float32:
+		x, err := parseFloat(v, 32)
+		if err != nil {
+			return err
+		}
+		capnp.Float32List{List: l}.Set(i, float32(x))
+	case schema.Type_Which_float64:
+		x, err := parseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		capnp.Float64List{List: l}.Set(i, x)
+	case schema.Type_Which_text:
+		if v == nil {
+			return nil
+		}
+		t, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("got %s; want string", kindOf(v))
+		}
+		return capnp.TextList{List: l}.Set(i, t)
+	case schema.Type_Which_data:
+		if v == nil {
+			return nil
+		}
+		b, err := parseData(v, de)
+		if err != nil {
+			return err
+		}
+		return capnp.DataList{List: l}.Set(i, b)
+	case schema.Type_Which_structType:
+		return u.setStruct(elem.StructType().TypeId(), l.Struct(i), v)
+	case schema.Type_Which_list:
+		if v == nil {
+			return nil
+		}
+		ee, err := elem.List().ElementType()
+		if err != nil {
+			return err
+		}
+		inner, err := u.newList(l.Segment(), ee, v, de)
+		if err != nil {
+			return err
+		}
+		return capnp.PointerList{List: l}.SetList(i, inner)
+	case schema.Type_Which_enum:
+		x, err := u.enumValue(elem.Enum().TypeId(), v)
+		if err != nil {
+			return err
+		}
+		capnp.UInt16List{List: l}.Set(i, x)
+	}
+	return nil
+}
+
+func (u *decoder) enumValue(typeID uint64, v interface{}) (uint16, error) {
+	n, err := u.nodes.Find(typeID)
+	if err != nil {
+		return 0, err
+	}
+	if n.Which() != schema.Node_Which_enum {
+		return 0, fmt.Errorf("unmarshaling enum of type @%#x: type is not an enum", typeID)
+	}
+	switch v := v.(type) {
+	case string:
+		enums, err := n.Enum().Enumerants()
+		if err != nil {
+			return 0, err
+		}
+		for i := 0; i < enums.Len(); i++ {
+			name, err := enumerantName(enums.At(i))
+			if err != nil {
+				return 0, err
+			}
+			if name == v {
+				return uint16(i), nil
+			}
+		}
+		return 0, fmt.Errorf("unknown enumerant %q", v)
+	case gojson.Number:
+		// Marshal writes unknown enumerants as numbers.
+		x, err := strconv.ParseUint(string(v), 10, 16)
+		if err != nil {
+			return 0, err
+		}
+		return uint16(x), nil
+	default:
+		return 0, fmt.Errorf("got %s; want enumerant", kindOf(v))
+	}
+}
+
+// numberText returns the text of a number, which may be a JSON number
+// or a string.
+func numberText(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case gojson.Number:
+		return string(v), nil
+	case string:
+		return v, nil
+	default:
+		return "", fmt.Errorf("got %s; want number", kindOf(v))
+	}
+}
+
+func parseInt(v interface{}, bits int) (int64, error) {
+	s, err := numberText(v)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, bits)
+}
+
+func parseUint(v interface{}, bits int) (uint64, error) {
+	s, err := numberText(v)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, bits)
+}
+
+func parseFloat(v interface{}, bits int) (float64, error) {
+	s, err := numberText(v)
+	if err != nil {
+		return 0, err
+	}
+	switch s {
+	case "NaN":
+		return math.NaN(), nil
+	case "Infinity":
+		return math.Inf(1), nil
+	case "-Infinity":
+		return math.Inf(-1), nil
+	}
+	return strconv.ParseFloat(s, bits)
+}
+
+// parseData decodes a Data value, which is a string in the field's
+// encoding or an array of byte values.
+func parseData(v interface{}, de dataEncoding) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		if de == hexData {
+			return hex.DecodeString(v)
+		}
+		return base64.StdEncoding.DecodeString(v)
+	case []interface{}:
+		b := make([]byte, len(v))
+		for i, e := range v {
+			x, err := parseUint(e, 8)
+			if err != nil {
+				return nil, fmt.Errorf("byte %d: %v", i, err)
+			}
+			b[i] = byte(x)
+		}
+		return b, nil
+	default:
+		return nil, fmt.Errorf("got %s; want string", kindOf(v))
+	}
+}
+
+// kindOf names the JSON type of a decoded value for error messages.
+func kindOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case gojson.Number:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
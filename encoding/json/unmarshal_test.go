@@ -0,0 +1,133 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/encoding/text"
+)
+
+func TestUnmarshal(t *testing.T) {
+	tests := []struct {
+		json string
+		text string // value as marshaled by the text package
+	}{
+		{`{"void":null}`, `(void = void)`},
+		{`{"bool":true}`, `(bool = true)`},
+		{`{"int32":-123}`, `(int32 = -123)`},
+		{`{"int32":"-123"}`, `(int32 = -123)`},
+		{`{"int64":"-5"}`, `(int64 = -5)`},
+		{`{"uint64":"18446744073709551615"}`, `(uint64 = 18446744073709551615)`},
+		{`{"float64":3.14}`, `(float64 = 3.14)`},
+		{`{"float64":"NaN"}`, `(float64 = NaN)`},
+		{`{"float32":"-Infinity"}`, `(float32 = -Inf)`},
+		{`{"text":"a\n\"b\"\u0001"}`, `(text = "a\n"b"\x01")`},
+		{`{"data":"SGk="}`, `(data = "Hi")`},
+		{`{"data":[72, 105]}`, `(data = "Hi")`},
+		{`{"cheese":"gouda"}`, `(cheese = gouda)`},
+		{`{"cheese":1}`, `(cheese = gouda)`},
+		{`{"voidList":[null,null]}`, `(voidList = [void, void])`},
+		{`{"int64List":["1",-2]}`, `(int64List = [1, -2])`},
+		{`{"textList":["foo","bar"]}`, `(textList = ["foo", "bar"])`},
+		{`{"dataList":["SGk=",""]}`, `(dataList = ["Hi", ""])`},
+		{`{"cheeseList":["gouda","cheddar"]}`, `(cheeseList = [gouda, cheddar])`},
+		{`{"matrix":[[1,2],[],[3]]}`, `(matrix = [[1, 2], [], [3]])`},
+		{
+			`{"map":[{"key":"foo","value":{"void":null}},{"value":{"int8":1}}]}`,
+			`(map = [(key = "foo", value = (void = void)), (key = "", value = (int8 = 1))])`,
+		},
+		{`{"unknown":[1, 2], "int8":1}`, `(int8 = 1)`},
+		{` { "int8" : 1 } `, `(int8 = 1)`},
+	}
+	reg := txtRegistry(t)
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := UnmarshalRegistry(reg, valueID, val, []byte(test.json)); err != nil {
+			t.Errorf("UnmarshalRegistry(%s): %v", test.json, err)
+			continue
+		}
+		enc := new(strings.Builder)
+		tenc := text.NewEncoder(enc)
+		tenc.UseRegistry(reg)
+		if err := tenc.Encode(valueID, val); err != nil {
+			t.Errorf("UnmarshalRegistry(%s) then Encode: %v", test.json, err)
+			continue
+		}
+		if got := enc.String(); got != test.text {
+			t.Errorf("UnmarshalRegistry(%s) then Encode = %s; want %s", test.json, got, test.text)
+		}
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		err  string
+	}{
+		{`{"int8":300}`, "field int8"},
+		{`{"int32":true}`, "want number"},
+		{`{"cheese":"brie"}`, `unknown enumerant "brie"`},
+		{`{"text":1}`, "want string"},
+		{`{"data":"!"}`, "field data"},
+		{`{"void":1}`, "want null"},
+		{`{"map":{}}`, "want array"},
+		{`{"map":[1]}`, "field map: element 0: got number; want object"},
+		{`[1]`, "want object"},
+		{`{"int8":1`, "unexpected EOF"},
+		{`{"int8":1} {}`, "after value"},
+	}
+	reg := txtRegistry(t)
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = UnmarshalRegistry(reg, valueID, val, []byte(test.json))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("UnmarshalRegistry(%s) = %v; want error containing %q", test.json, err, test.err)
+		}
+	}
+}
+
+func TestUnmarshalAnnotations(t *testing.T) {
+	reg := annotatedRegistry(t)
+	for _, email := range []string{"a@example.com", ""} {
+		want, err := newPerson(email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := MarshalRegistry(reg, personID, want)
+		if err != nil {
+			t.Fatalf("MarshalRegistry(email = %q): %v", email, err)
+		}
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := capnp.NewRootStruct(seg, personSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := UnmarshalRegistry(reg, personID, got, data); err != nil {
+			t.Errorf("UnmarshalRegistry(%s): %v", data, err)
+			continue
+		}
+		if eq, err := capnp.Equal(got.ToPtr(), want.ToPtr()); err != nil {
+			t.Errorf("UnmarshalRegistry(%s): compare: %v", data, err)
+		} else if !eq {
+			t.Errorf("UnmarshalRegistry(%s) does not round-trip", data)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package text
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+// unsetMarker is the value reported in a FieldDiff for a union member
+// that is not set.
+const unsetMarker = "<unset>"
+
+// A FieldDiff is a field whose value differs between two structs.
+type FieldDiff struct {
+	// Path is the field's location relative to the compared structs,
+	// like "value.map[1].key".
+	Path string
+
+	// Old and New are the text representations of the field's value in
+	// the first and second struct.  A union member that is not set is
+	// reported as "<unset>".
+	Old, New string
+}
+
+func (d FieldDiff) String() string {
+	return d.Path + ": " + d.Old + " -> " + d.New
+}
+
+// Diff compares two structs of the given type and returns the fields
+// that differ, using the schemas in the default registry.  Structs and
+// lists of structs with the same length are compared field by field;
+// other values are compared by their text representation.
+func Diff(typeID uint64, a, b capnp.Struct) ([]FieldDiff, error) {
+	return DiffRegistry(&schemas.DefaultRegistry, typeID, a, b)
+}
+
+// DiffRegistry is like Diff, but consults reg for schemas.
+func DiffRegistry(reg *schemas.Registry, typeID uint64, a, b capnp.Struct) ([]FieldDiff, error) {
+	d := new(differ)
+	d.enc = NewEncoder(&d.buf)
+	d.enc.UseRegistry(reg)
+	if err := d.diffStruct("", typeID, a, b); err != nil {
+		return nil, err
+	}
+	return d.diffs, nil
+}
+
+type differ struct {
+	buf   bytes.Buffer
+	enc   *Encoder
+	diffs []FieldDiff
+}
+
+func (d *differ) diffStruct(path string, typeID uint64, a, b capnp.Struct) error {
+	n, err := d.enc.nodes.Find(typeID)
+	if err != nil {
+		return err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_structNode {
+		return fmt.Errorf("cannot find struct type %#x", typeID)
+	}
+	var da, db uint16
+	if n.StructNode().DiscriminantCount() > 0 {
+		off := capnp.DataOffset(n.StructNode().DiscriminantOffset() * 2)
+		da, db = a.Uint16(off), b.Uint16(off)
+	}
+	for _, f := range codeOrderFields(n.StructNode()) {
+		if !(f.Which() == schema.Field_Which_slot || f.Which() == schema.Field_Which_group) {
+			continue
+		}
+		name, err := f.Name()
+		if err != nil {
+			return err
+		}
+		fpath := name
+		if path != "" {
+			fpath = path + "." + name
+		}
+		dv := f.DiscriminantValue()
+		inA := dv == schema.Field_noDiscriminant || dv == da
+		inB := dv == schema.Field_noDiscriminant || dv == db
+		switch {
+		case !inA && !inB:
+			continue
+		case inA && inB:
+			if err := d.diffField(fpath, f, a, b); err != nil {
+				return err
+			}
+		default:
+			old, new := unsetMarker, unsetMarker
+			if inA {
+				old, err = d.render(a, f)
+			} else {
+				new, err = d.render(b, f)
+			}
+			if err != nil {
+				return err
+			}
+			d.diffs = append(d.diffs, FieldDiff{Path: fpath, Old: old, New: new})
+		}
+	}
+	return nil
+}
+
+func (d *differ) diffField(path string, f schema.Field, a, b capnp.Struct) error {
+	if f.Which() == schema.Field_Which_group {
+		return d.diffStruct(path, f.Group().TypeId(), a, b)
+	}
+	typ, err := f.Slot().Type()
+	if err != nil {
+		return err
+	}
+	switch typ.Which() {
+	case schema.Type_Which_structType:
+		pa, pb, err := d.fieldPtrs(f, a, b)
+		if err != nil {
+			return err
+		}
+		return d.diffStruct(path, typ.StructType().TypeId(), pa.Struct(), pb.Struct())
+	case schema.Type_Which_list:
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return err
+		}
+		if elem.Which() != schema.Type_Which_structType {
+			break
+		}
+		pa, pb, err := d.fieldPtrs(f, a, b)
+		if err != nil {
+			return err
+		}
+		la, lb := pa.List(), pb.List()
+		if la.Len() != lb.Len() {
+			break
+		}
+		for i := 0; i < la.Len(); i++ {
+			epath := path + "[" + strconv.Itoa(i) + "]"
+			if err := d.diffStruct(epath, elem.StructType().TypeId(), la.Struct(i), lb.Struct(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	old, err := d.render(a, f)
+	if err != nil {
+		return err
+	}
+	new, err := d.render(b, f)
+	if err != nil {
+		return err
+	}
+	if old != new {
+		d.diffs = append(d.diffs, FieldDiff{Path: path, Old: old, New: new})
+	}
+	return nil
+}
+
+// fieldPtrs returns the pointer field f of a and b, substituting the
+// field's default value for null pointers.
+func (d *differ) fieldPtrs(f schema.Field, a, b capnp.Struct) (pa, pb capnp.Ptr, err error) {
+	dv, err := f.Slot().DefaultValue()
+	if err != nil {
+		return capnp.Ptr{}, capnp.Ptr{}, err
+	}
+	var def capnp.Ptr
+	if dv.IsValid() {
+		switch dv.Which() {
+		case schema.Value_Which_structValue:
+			def, _ = dv.StructValue()
+		case schema.Value_Which_list:
+			def, _ = dv.List()
+		}
+	}
+	off := uint16(f.Slot().Offset())
+	if pa, err = a.Ptr(off); err != nil {
+		return capnp.Ptr{}, capnp.Ptr{}, err
+	}
+	if pb, err = b.Ptr(off); err != nil {
+		return capnp.Ptr{}, capnp.Ptr{}, err
+	}
+	if !pa.IsValid() {
+		pa = def
+	}
+	if !pb.IsValid() {
+		pb = def
+	}
+	return pa, pb, nil
+}
+
+// render returns the text representation of field f in s.
+func (d *differ) render(s capnp.Struct, f schema.Field) (string, error) {
+	d.buf.Reset()
+	if f.Which() == schema.Field_Which_group {
+		if err := d.enc.marshalStruct(f.Group().TypeId(), s); err != nil {
+			return "", err
+		}
+	} else if err := d.enc.marshalFieldValue(s, f); err != nil {
+		return "", err
+	}
+	return d.buf.String(), nil
+}
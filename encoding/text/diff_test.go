@@ -0,0 +1,152 @@
+package text
+
+import (
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+func TestDiff(t *testing.T) {
+	const (
+		keyValueID = 0x8df8bc5abdc060a6
+		valueID    = 0xd3602730c572a43b
+	)
+	data, err := readTestFile("txt.capnp.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	err = reg.Register(&schemas.Schema{
+		Bytes: data,
+		Nodes: []uint64{keyValueID, valueID},
+	})
+	if err != nil {
+		t.Fatalf("Adding to registry: %v", err)
+	}
+	consts, err := readConstStructs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kv := consts[0xc0b634e19e5a9a4e]
+	floatKv := consts[0x967c8fe21790b0fb]
+	mapVal := consts[0xb167974479102805]
+	emptyMap := consts[0x81fdbfdc91779421]
+
+	// Copy mapVal and change the key of its second entry.
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	changedMap, err := capnp.NewRootStruct(seg, mapVal.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := changedMap.CopyFrom(mapVal); err != nil {
+		t.Fatal(err)
+	}
+	m, err := changedMap.Ptr(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.List().Struct(1).SetText(0, "baz"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		typeID uint64
+		a, b   capnp.Struct
+		want   []FieldDiff
+	}{
+		{
+			name:   "same",
+			typeID: keyValueID,
+			a:      kv,
+			b:      kv,
+		},
+		{
+			name:   "different union members",
+			typeID: keyValueID,
+			a:      kv,
+			b:      floatKv,
+			want: []FieldDiff{
+				{Path: "key", Old: `"42"`, New: `"float"`},
+				{Path: "value.int32", Old: "-123", New: "<unset>"},
+				{Path: "value.float64", Old: "<unset>", New: "3.14"},
+			},
+		},
+		{
+			name:   "list lengths",
+			typeID: valueID,
+			a:      mapVal,
+			b:      emptyMap,
+			want: []FieldDiff{
+				{Path: "map", Old: `[(key = "foo", value = (void = void)), (key = "bar", value = (void = void))]`, New: "[]"},
+			},
+		},
+		{
+			name:   "nested field",
+			typeID: valueID,
+			a:      mapVal,
+			b:      changedMap,
+			want: []FieldDiff{
+				{Path: "map[1].key", Old: `"bar"`, New: `"baz"`},
+			},
+		},
+	}
+	for _, test := range tests {
+		diffs, err := DiffRegistry(reg, test.typeID, test.a, test.b)
+		if err != nil {
+			t.Errorf("%s: DiffRegistry: %v", test.name, err)
+			continue
+		}
+		if len(diffs) != len(test.want) {
+			t.Errorf("%s: DiffRegistry = %v; want %v", test.name, diffs, test.want)
+			continue
+		}
+		for i := range diffs {
+			if diffs[i] != test.want[i] {
+				t.Errorf("%s: DiffRegistry[%d] = %v; want %v", test.name, i, diffs[i], test.want[i])
+			}
+		}
+	}
+}
+
+// readConstStructs returns the struct values of the constants in a
+// serialized CodeGeneratorRequest, keyed by node ID.
+func readConstStructs(data []byte) (map[uint64]capnp.Struct, error) {
+	msg, err := capnp.Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	req, err := schema.ReadRootCodeGeneratorRequest(msg)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := req.Nodes()
+	if err != nil {
+		return nil, err
+	}
+	consts := make(map[uint64]capnp.Struct)
+	for i := 0; i < nodes.Len(); i++ {
+		n := nodes.At(i)
+		if n.Which() != schema.Node_Which_const {
+			continue
+		}
+		v, err := n.Const().Value()
+		if err != nil {
+			return nil, err
+		}
+		if v.Which() != schema.Value_Which_structValue {
+			continue
+		}
+		p, err := v.StructValue()
+		if err != nil {
+			return nil, err
+		}
+		consts[n.Id()] = p.Struct()
+	}
+	return consts, nil
+}
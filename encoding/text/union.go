@@ -0,0 +1,67 @@
+package text
+
+import (
+	"fmt"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/nodemap"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+// A UnionMember is a field of a struct's unnamed union.
+type UnionMember struct {
+	// Name is the field's name.
+	Name string
+
+	// Discriminant is the value of the union's discriminant when the
+	// member is set.
+	Discriminant uint16
+
+	discOffset capnp.DataOffset
+}
+
+// IsActive reports whether m is the union member set in s.  s must be
+// a struct of the type that m was obtained from.
+func (m UnionMember) IsActive(s capnp.Struct) bool {
+	return s.Uint16(m.discOffset) == m.Discriminant
+}
+
+// UnionMembers returns the members of the unnamed union of the struct
+// type with the given ID in the order they are declared, using the
+// schemas in the default registry.  It returns no members if the struct
+// does not have an unnamed union.
+func UnionMembers(typeID uint64) ([]UnionMember, error) {
+	return UnionMembersRegistry(&schemas.DefaultRegistry, typeID)
+}
+
+// UnionMembersRegistry is like UnionMembers, but consults reg for
+// schemas.
+func UnionMembersRegistry(reg *schemas.Registry, typeID uint64) ([]UnionMember, error) {
+	var nodes nodemap.Map
+	nodes.UseRegistry(reg)
+	n, err := nodes.Find(typeID)
+	if err != nil {
+		return nil, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_structNode {
+		return nil, fmt.Errorf("cannot find struct type %#x", typeID)
+	}
+	off := capnp.DataOffset(n.StructNode().DiscriminantOffset() * 2)
+	var members []UnionMember
+	for _, f := range codeOrderFields(n.StructNode()) {
+		if f.DiscriminantValue() == schema.Field_noDiscriminant {
+			continue
+		}
+		name, err := f.Name()
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, UnionMember{
+			Name:         name,
+			Discriminant: f.DiscriminantValue(),
+			discOffset:   off,
+		})
+	}
+	return members, nil
+}
@@ -0,0 +1,102 @@
+package text
+
+import (
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+func TestUnionMembers(t *testing.T) {
+	// struct Shape {
+	//   id @0 :UInt32;
+	//   union {
+	//     circle @1 :Float64;
+	//     square @2 :Float64;
+	//     label @3 :Text;
+	//   }
+	// }
+	const shapeID = 0xe1a5d1f0c2b3a4d5
+	msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, err := schema.NewRootCodeGeneratorRequest(seg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes, err := req.NewNodes(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := nodes.At(0)
+	n.SetId(shapeID)
+	n.SetStructNode()
+	n.StructNode().SetDataWordCount(2)
+	n.StructNode().SetPointerCount(1)
+	n.StructNode().SetDiscriminantCount(3)
+	n.StructNode().SetDiscriminantOffset(2)
+	fields, err := n.StructNode().NewFields(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, name := range []string{"id", "circle", "square", "label"} {
+		f := fields.At(i)
+		if err := f.SetName(name); err != nil {
+			t.Fatal(err)
+		}
+		f.SetCodeOrder(uint16(i))
+		if i == 0 {
+			f.SetDiscriminantValue(schema.Field_noDiscriminant)
+		} else {
+			f.SetDiscriminantValue(uint16(i - 1))
+		}
+		f.SetSlot()
+	}
+	data, err := msg.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	if err := reg.Register(&schemas.Schema{Bytes: data, Nodes: []uint64{shapeID}}); err != nil {
+		t.Fatal(err)
+	}
+
+	members, err := UnionMembersRegistry(reg, shapeID)
+	if err != nil {
+		t.Fatal("UnionMembersRegistry:", err)
+	}
+	want := []struct {
+		name string
+		disc uint16
+	}{{"circle", 0}, {"square", 1}, {"label", 2}}
+	if len(members) != len(want) {
+		t.Fatalf("UnionMembersRegistry(...) = %+v; want %d members", members, len(want))
+	}
+	for i := range want {
+		if members[i].Name != want[i].name || members[i].Discriminant != want[i].disc {
+			t.Errorf("members[%d] = {%q, %d}; want {%q, %d}", i, members[i].Name, members[i].Discriminant, want[i].name, want[i].disc)
+		}
+	}
+
+	// Set the label member, whose discriminant is at byte offset 4.
+	_, seg, err = capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shape.SetUint16(4, 2)
+	for _, m := range members {
+		if got, want := m.IsActive(shape), m.Name == "label"; got != want {
+			t.Errorf("%s.IsActive(shape) = %t; want %t", m.Name, got, want)
+		}
+	}
+
+	if _, err := UnionMembersRegistry(reg, 0x1234); err == nil {
+		t.Error("UnionMembersRegistry(reg, unknown ID) = _, <nil>; want error")
+	}
+}
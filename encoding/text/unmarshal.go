@@ -0,0 +1,916 @@
+package text
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/nodemap"
+	"capnproto.org/go/capnp/v3/internal/schema"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+// UnmarshalInto parses the text representation of a struct of the given
+// type, as written by Marshal, and sets the corresponding fields of s,
+// using the schemas in the default registry.  s may be part of a larger
+// message: fields that are not mentioned in data are left unchanged, and
+// new text, data, list and struct values are allocated in s's message.
+// Setting a union member makes it the union's active member.
+//
+// Capabilities and AnyPointer fields cannot be unmarshaled, except that
+// a capability may be set to null.
+//
+// data may span multiple lines and contain comments, which run from '#'
+// to the end of the line.  Syntax errors give the line and column where
+// parsing failed; other errors name the path of fields to the bad value.
+func UnmarshalInto(typeID uint64, s capnp.Struct, data []byte) error {
+	return UnmarshalIntoRegistry(&schemas.DefaultRegistry, typeID, s, data)
+}
+
+// UnmarshalIntoRegistry is like UnmarshalInto, but consults reg for
+// schemas.
+func UnmarshalIntoRegistry(reg *schemas.Registry, typeID uint64, s capnp.Struct, data []byte) error {
+	p := &parser{data: data}
+	v, err := p.parse()
+	if err != nil {
+		return fmt.Errorf("unmarshal text: %v", err)
+	}
+	u := new(unmarshaler)
+	u.nodes.UseRegistry(reg)
+	if err := u.setStruct(typeID, s, v); err != nil {
+		return fmt.Errorf("unmarshal text: %v", err)
+	}
+	return nil
+}
+
+// A textValue is a parsed value in the text format.  Its meaning
+// depends on the schema type that it is assigned to.
+type textValue struct {
+	kind   valueKind
+	atom   string      // identifier or number for atomValue
+	bytes  []byte      // decoded contents for stringValue
+	fields []textField // for structValue
+	elems  []textValue // for listValue
+}
+
+type valueKind int
+
+const (
+	atomValue valueKind = iota
+	stringValue
+	structValue
+	listValue
+)
+
+func (k valueKind) String() string {
+	switch k {
+	case atomValue:
+		return "identifier or number"
+	case stringValue:
+		return "string"
+	case structValue:
+		return "struct"
+	case listValue:
+		return "list"
+	default:
+		return "valueKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+type textField struct {
+	name  string
+	value textValue
+}
+
+// parser turns text into a tree of textValues.
+type parser struct {
+	data []byte
+	pos  int
+}
+
+func (p *parser) parse() (textValue, error) {
+	v, err := p.value()
+	if err != nil {
+		return textValue{}, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.data) {
+		return textValue{}, p.errorf("unexpected %q after value", p.data[p.pos])
+	}
+	return v, nil
+}
+
+// errorf returns an error at the current position, given as a 1-based
+// line and column.  Columns count bytes.
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line, col := 1, 1
+	for _, c := range p.data[:p.pos] {
+		if c == '\n' {
+			line++
+			col = 1
+		} else {
+			col++
+		}
+	}
+	return fmt.Errorf("line %d, column %d: %s", line, col, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments.
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		case '#':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// consume skips whitespace and then reports whether the next byte is c,
+// advancing past it if so.
+func (p *parser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.data) && p.data[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) value() (textValue, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return textValue{}, p.errorf("unexpected end of text")
+	}
+	switch c := p.data[p.pos]; {
+	case c == '(':
+		return p.structValue()
+	case c == '[':
+		return p.listValue()
+	case c == '"':
+		b, err := p.str()
+		if err != nil {
+			return textValue{}, err
+		}
+		return textValue{kind: stringValue, bytes: b}, nil
+	case isAtomByte(c):
+		a := p.atom()
+		if a == "0x" && p.pos < len(p.data) && p.data[p.pos] == '"' {
+			return p.hexData()
+		}
+		return textValue{kind: atomValue, atom: a}, nil
+	default:
+		return textValue{}, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *parser) structValue() (textValue, error) {
+	p.pos++ // '('
+	v := textValue{kind: structValue}
+	if p.consume(')') {
+		return v, nil
+	}
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) || !isAtomByte(p.data[p.pos]) {
+			return textValue{}, p.errorf("expected field name")
+		}
+		name := p.atom()
+		if !p.consume('=') {
+			return textValue{}, p.errorf("expected '=' after field name %s", name)
+		}
+		fv, err := p.value()
+		if err != nil {
+			return textValue{}, err
+		}
+		v.fields = append(v.fields, textField{name: name, value: fv})
+		if p.consume(')') {
+			return v, nil
+		}
+		if !p.consume(',') {
+			return textValue{}, p.errorf("expected ',' or ')' in struct")
+		}
+	}
+}
+
+func (p *parser) listValue() (textValue, error) {
+	p.pos++ // '['
+	v := textValue{kind: listValue}
+	if p.consume(']') {
+		return v, nil
+	}
+	for {
+		e, err := p.value()
+		if err != nil {
+			return textValue{}, err
+		}
+		v.elems = append(v.elems, e)
+		if p.consume(']') {
+			return v, nil
+		}
+		if !p.consume(',') {
+			return textValue{}, p.errorf("expected ',' or ']' in list")
+		}
+	}
+}
+
+func isAtomByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '+'
+}
+
+// atom reads an identifier or number.
+func (p *parser) atom() string {
+	start := p.pos
+	for p.pos < len(p.data) && isAtomByte(p.data[p.pos]) {
+		p.pos++
+	}
+	return string(p.data[start:p.pos])
+}
+
+// str reads a string literal with the escapes written by Marshal.
+func (p *parser) str() ([]byte, error) {
+	p.pos++ // '"'
+	var b []byte
+	for {
+		if p.pos >= len(p.data) {
+			return nil, p.errorf("unterminated string")
+		}
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b, nil
+		case '\\':
+			if p.pos >= len(p.data) {
+				return nil, p.errorf("unterminated string")
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'a':
+				b = append(b, '\a')
+			case 'b':
+				b = append(b, '\b')
+			case 'f':
+				b = append(b, '\f')
+			case 'n':
+				b = append(b, '\n')
+			case 'r':
+				b = append(b, '\r')
+			case 't':
+				b = append(b, '\t')
+			case 'v':
+				b = append(b, '\v')
+			case '\'', '"', '\\':
+				b = append(b, e)
+			case 'x':
+				if p.pos+2 > len(p.data) {
+					return nil, p.errorf("unterminated string")
+				}
+				x, err := strconv.ParseUint(string(p.data[p.pos:p.pos+2]), 16, 8)
+				if err != nil {
+					return nil, p.errorf("invalid escape \\x%s", p.data[p.pos:p.pos+2])
+				}
+				p.pos += 2
+				b = append(b, byte(x))
+			default:
+				return nil, p.errorf("invalid escape \\%c", e)
+			}
+		default:
+			b = append(b, c)
+		}
+	}
+}
+
+// hexData reads the string part of a 0x"..." data literal.
+func (p *parser) hexData() (textValue, error) {
+	s, err := p.str()
+	if err != nil {
+		return textValue{}, err
+	}
+	digits := make([]byte, 0, len(s))
+	for _, c := range s {
+		if c != ' ' {
+			digits = append(digits, c)
+		}
+	}
+	b := make([]byte, hex.DecodedLen(len(digits)))
+	if _, err := hex.Decode(b, digits); err != nil {
+		return textValue{}, p.errorf("data literal: %v", err)
+	}
+	return textValue{kind: stringValue, bytes: b}, nil
+}
+
+// unmarshaler sets fields from textValues according to a schema.
+type unmarshaler struct {
+	nodes nodemap.Map
+}
+
+func (u *unmarshaler) findStruct(typeID uint64) (schema.Node, error) {
+	n, err := u.nodes.Find(typeID)
+	if err != nil {
+		return schema.Node{}, err
+	}
+	if !n.IsValid() || n.Which() != schema.Node_Which_structNode {
+		return schema.Node{}, fmt.Errorf("cannot find struct type %#x", typeID)
+	}
+	return n, nil
+}
+
+func (u *unmarshaler) structSize(typeID uint64) (capnp.ObjectSize, error) {
+	n, err := u.findStruct(typeID)
+	if err != nil {
+		return capnp.ObjectSize{}, err
+	}
+	return capnp.ObjectSize{
+		DataSize:     capnp.Size(n.StructNode().DataWordCount()) * 8,
+		PointerCount: n.StructNode().PointerCount(),
+	}, nil
+}
+
+func (u *unmarshaler) setStruct(typeID uint64, s capnp.Struct, v textValue) error {
+	if v.kind != structValue {
+		return fmt.Errorf("got %v; want struct", v.kind)
+	}
+	n, err := u.findStruct(typeID)
+	if err != nil {
+		return err
+	}
+	fields, err := n.StructNode().Fields()
+	if err != nil {
+		return err
+	}
+	for _, tf := range v.fields {
+		f, err := findField(fields, tf.name)
+		if err != nil {
+			return err
+		}
+		if dv := f.DiscriminantValue(); dv != schema.Field_noDiscriminant {
+			off := n.StructNode().DiscriminantOffset() * 2
+			if err := checkData(s, off, 16); err != nil {
+				return fmt.Errorf("field %s: %v", tf.name, err)
+			}
+			s.SetUint16(capnp.DataOffset(off), dv)
+		}
+		switch f.Which() {
+		case schema.Field_Which_slot:
+			err = u.setField(s, f, tf.value)
+		case schema.Field_Which_group:
+			err = u.setStruct(f.Group().TypeId(), s, tf.value)
+		}
+		if err != nil {
+			return fmt.Errorf("field %s: %v", tf.name, err)
+		}
+	}
+	return nil
+}
+
+func findField(fields schema.Field_List, name string) (schema.Field, error) {
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.At(i)
+		fn, err := f.Name()
+		if err != nil {
+			return schema.Field{}, err
+		}
+		if fn == name {
+			return f, nil
+		}
+	}
+	return schema.Field{}, fmt.Errorf("unknown field %s", name)
+}
+
+// checkData returns an error if a value of the given width at the
+// offset, in units of the width, is outside of s's data section.
+func checkData(s capnp.Struct, off uint32, bits uint64) error {
+	if (uint64(off)+1)*bits > uint64(s.Size().DataSize)*8 {
+		return fmt.Errorf("offset %d is outside of the struct's data section", off)
+	}
+	return nil
+}
+
+func checkPtr(s capnp.Struct, off uint32) error {
+	if off >= uint32(s.Size().PointerCount) {
+		return fmt.Errorf("pointer %d is outside of the struct's pointer section", off)
+	}
+	return nil
+}
+
+func (u *unmarshaler) setField(s capnp.Struct, f schema.Field, v textValue) error {
+	typ, err := f.Slot().Type()
+	if err != nil {
+		return err
+	}
+	dv, err := f.Slot().DefaultValue()
+	if err != nil {
+		return err
+	}
+	if dv.IsValid() && int(typ.Which()) != int(dv.Which()) {
+		return fmt.Errorf("default value is a %v, want %v", dv.Which(), typ.Which())
+	}
+	off := f.Slot().Offset()
+	switch typ.Which() {
+	case schema.Type_Which_void:
+		return expectAtom(v, voidMarker)
+	case schema.Type_Which_bool:
+		b, err := parseBool(v)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 1); err != nil {
+			return err
+		}
+		s.SetBit(capnp.BitOffset(off), b != dv.Bool())
+	case schema.Type_Which_int8:
+		i, err := parseInt(v, 8)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 8); err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^uint8(dv.Int8()))
+	case schema.Type_Which_int16:
+		i, err := parseInt(v, 16)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 16); err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^uint16(dv.Int16()))
+	case schema.Type_Which_int32:
+		i, err := parseInt(v, 32)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 32); err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^uint32(dv.Int32()))
+	case schema.Type_Which_int64:
+		i, err := parseInt(v, 64)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 64); err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), uint64(i)^uint64(dv.Int64()))
+	case schema.Type_Which_uint8:
+		i, err := parseUint(v, 8)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 8); err != nil {
+			return err
+		}
+		s.SetUint8(capnp.DataOffset(off), uint8(i)^dv.Uint8())
+	case schema.Type_Which_uint16:
+		i, err := parseUint(v, 16)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 16); err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), uint16(i)^dv.Uint16())
+	case schema.Type_Which_uint32:
+		i, err := parseUint(v, 32)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 32); err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), uint32(i)^dv.Uint32())
+	case schema.Type_Which_uint64:
+		i, err := parseUint(v, 64)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 64); err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), i^dv.Uint64())
+	case schema.Type_Which_float32:
+		x, err := parseFloat(v, 32)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 32); err != nil {
+			return err
+		}
+		s.SetUint32(capnp.DataOffset(off*4), math.Float32bits(float32(x))^math.Float32bits(dv.Float32()))
+	case schema.Type_Which_float64:
+		x, err := parseFloat(v, 64)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 64); err != nil {
+			return err
+		}
+		s.SetUint64(capnp.DataOffset(off*8), math.Float64bits(x)^math.Float64bits(dv.Float64()))
+	case schema.Type_Which_text:
+		b, err := stringOf(v)
+		if err != nil {
+			return err
+		}
+		if err := checkPtr(s, off); err != nil {
+			return err
+		}
+		return s.SetNewText(uint16(off), string(b))
+	case schema.Type_Which_data:
+		b, err := stringOf(v)
+		if err != nil {
+			return err
+		}
+		if err := checkPtr(s, off); err != nil {
+			return err
+		}
+		d, err := capnp.NewData(s.Segment(), b)
+		if err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), d.ToPtr())
+	case schema.Type_Which_structType:
+		if err := checkPtr(s, off); err != nil {
+			return err
+		}
+		tid := typ.StructType().TypeId()
+		sz, err := u.structSize(tid)
+		if err != nil {
+			return err
+		}
+		ss, err := capnp.NewStruct(s.Segment(), sz)
+		if err != nil {
+			return err
+		}
+		if err := u.setStruct(tid, ss, v); err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), ss.ToPtr())
+	case schema.Type_Which_list:
+		if err := checkPtr(s, off); err != nil {
+			return err
+		}
+		elem, err := typ.List().ElementType()
+		if err != nil {
+			return err
+		}
+		l, err := u.newList(s.Segment(), elem, v)
+		if err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), l.ToPtr())
+	case schema.Type_Which_enum:
+		e, err := u.enumValue(typ.Enum().TypeId(), v)
+		if err != nil {
+			return err
+		}
+		if err := checkData(s, off, 16); err != nil {
+			return err
+		}
+		s.SetUint16(capnp.DataOffset(off*2), e^dv.Enum())
+	case schema.Type_Which_interface:
+		if err := expectAtom(v, interfaceNullMarker); err != nil {
+			return fmt.Errorf("cannot unmarshal a capability other than null")
+		}
+		if err := checkPtr(s, off); err != nil {
+			return err
+		}
+		return s.SetPtr(uint16(off), capnp.Ptr{})
+	case schema.Type_Which_anyPointer:
+		return fmt.Errorf("cannot unmarshal an AnyPointer")
+	default:
+		return fmt.Errorf("unknown field type %v", typ.Which())
+	}
+	return nil
+}
+
+func (u *unmarshaler) newList(seg *capnp.Segment, elem schema.Type, v textValue) (capnp.List, error) {
+	if v.kind != listValue {
+		return capnp.List{}, fmt.Errorf("got %v; want list", v.kind)
+	}
+	n := int32(len(v.elems))
+	switch elem.Which() {
+	case schema.Type_Which_void:
+		for i, e := range v.elems {
+			if err := expectAtom(e, voidMarker); err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return capnp.NewVoidList(seg, n).List, nil
+	case schema.Type_Which_bool:
+		l, err := capnp.NewBitList(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			b, err := parseBool(e)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, b)
+		}
+		return l.List, nil
+	case schema.Type_Which_int8:
+		l, err := capnp.NewInt8List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 8)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, int8(x))
+		}
+		return l.List, nil
+	case schema.Type_Which_int16:
+		l, err := capnp.NewInt16List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 16)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, int16(x))
+		}
+		return l.List, nil
+	case schema.Type_Which_int32:
+		l, err := capnp.NewInt32List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 32)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, int32(x))
+		}
+		return l.List, nil
+	case schema.Type_Which_int64:
+		l, err := capnp.NewInt64List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseInt(e, 64)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, x)
+		}
+		return l.List, nil
+	case schema.Type_Which_uint8:
+		l, err := capnp.NewUInt8List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 8)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, uint8(x))
+		}
+		return l.List, nil
+	case schema.Type_Which_uint16:
+		l, err := capnp.NewUInt16List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 16)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, uint16(x))
+		}
+		return l.List, nil
+	case schema.Type_Which_uint32:
+		l, err := capnp.NewUInt32List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 32)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, uint32(x))
+		}
+		return l.List, nil
+	case schema.Type_Which_uint64:
+		l, err := capnp.NewUInt64List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseUint(e, 64)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, x)
+		}
+		return l.List, nil
+	case schema.Type_Which_float32:
+		l, err := capnp.NewFloat32List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseFloat(e, 32)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, float32(x))
+		}
+		return l.List, nil
+	case schema.Type_Which_float64:
+		l, err := capnp.NewFloat64List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := parseFloat(e, 64)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, x)
+		}
+		return l.List, nil
+	case schema.Type_Which_text:
+		l, err := capnp.NewTextList(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			b, err := stringOf(e)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			if err := l.Set(i, string(b)); err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return l.List, nil
+	case schema.Type_Which_data:
+		l, err := capnp.NewDataList(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			b, err := stringOf(e)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			if err := l.Set(i, b); err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return l.List, nil
+	case schema.Type_Which_structType:
+		tid := elem.StructType().TypeId()
+		sz, err := u.structSize(tid)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l, err := capnp.NewCompositeList(seg, sz, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			if err := u.setStruct(tid, l.Struct(i), e); err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return l, nil
+	case schema.Type_Which_list:
+		ee, err := elem.List().ElementType()
+		if err != nil {
+			return capnp.List{}, err
+		}
+		l, err := capnp.NewPointerList(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			inner, err := u.newList(seg, ee, e)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			if err := l.SetList(i, inner); err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+		}
+		return l.List, nil
+	case schema.Type_Which_enum:
+		l, err := capnp.NewUInt16List(seg, n)
+		if err != nil {
+			return capnp.List{}, err
+		}
+		for i, e := range v.elems {
+			x, err := u.enumValue(elem.Enum().TypeId(), e)
+			if err != nil {
+				return capnp.List{}, fmt.Errorf("element %d: %v", i, err)
+			}
+			l.Set(i, x)
+		}
+		return l.List, nil
+	case schema.Type_Which_interface, schema.Type_Which_anyPointer:
+		return capnp.List{}, fmt.Errorf("cannot unmarshal a list of %v", elem.Which())
+	default:
+		return capnp.List{}, fmt.Errorf("unknown list type %v", elem.Which())
+	}
+}
+
+func (u *unmarshaler) enumValue(typeID uint64, v textValue) (uint16, error) {
+	if v.kind != atomValue {
+		return 0, fmt.Errorf("got %v; want enumerant", v.kind)
+	}
+	n, err := u.nodes.Find(typeID)
+	if err != nil {
+		return 0, err
+	}
+	if n.Which() != schema.Node_Which_enum {
+		return 0, fmt.Errorf("unmarshaling enum of type @%#x: type is not an enum", typeID)
+	}
+	enums, err := n.Enum().Enumerants()
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < enums.Len(); i++ {
+		name, err := enums.At(i).Name()
+		if err != nil {
+			return 0, err
+		}
+		if name == v.atom {
+			return uint16(i), nil
+		}
+	}
+	// Marshal writes unknown enumerants as numbers.
+	x, err := strconv.ParseUint(v.atom, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("unknown enumerant %s", v.atom)
+	}
+	return uint16(x), nil
+}
+
+func expectAtom(v textValue, want string) error {
+	if v.kind != atomValue || v.atom != want {
+		return fmt.Errorf("want %s", want)
+	}
+	return nil
+}
+
+func parseBool(v textValue) (bool, error) {
+	if v.kind == atomValue {
+		switch v.atom {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("want true or false")
+}
+
+func parseInt(v textValue, bits int) (int64, error) {
+	if v.kind != atomValue {
+		return 0, fmt.Errorf("got %v; want integer", v.kind)
+	}
+	return strconv.ParseInt(v.atom, 0, bits)
+}
+
+func parseUint(v textValue, bits int) (uint64, error) {
+	if v.kind != atomValue {
+		return 0, fmt.Errorf("got %v; want integer", v.kind)
+	}
+	return strconv.ParseUint(v.atom, 0, bits)
+}
+
+func parseFloat(v textValue, bits int) (float64, error) {
+	if v.kind != atomValue {
+		return 0, fmt.Errorf("got %v; want number", v.kind)
+	}
+	return strconv.ParseFloat(v.atom, bits)
+}
+
+func stringOf(v textValue) ([]byte, error) {
+	if v.kind != stringValue {
+		return nil, fmt.Errorf("got %v; want string", v.kind)
+	}
+	return v.bytes, nil
+}
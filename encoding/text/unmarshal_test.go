@@ -0,0 +1,150 @@
+package text
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/schemas"
+)
+
+func TestUnmarshalInto(t *testing.T) {
+	const (
+		keyValueID = 0x8df8bc5abdc060a6
+		valueID    = 0xd3602730c572a43b
+	)
+	tests := []struct {
+		text string
+		want string // value as marshaled, or empty if same as text
+	}{
+		{text: `(int32 = -123)`},
+		{text: `(bool = true)`},
+		{text: `(uint64 = 0xffffffffffffffff)`, want: `(uint64 = 18446744073709551615)`},
+		{text: `(float64 = 3.14)`},
+		{text: `(text = "a\n\"b\"\x01")`, want: `(text = "a\n"b"\x01")`},
+		{text: `(data = 0x"4869 dead")`, want: `(data = "Hi\xde\xad")`},
+		{text: `(cheese = gouda)`},
+		{text: `(void = void)`},
+		{text: `(voidList = [void, void])`},
+		{text: `(boolList = [false, true])`},
+		{text: `(int8List = [1, -2])`},
+		{text: `(textList = ["foo", "bar"])`},
+		{text: `(cheeseList = [gouda, cheddar])`},
+		{text: `(matrix = [[1, 2, 3], [], [4]])`},
+		{text: `(map = [(key = "foo", value = (void = void)), (key = "bar", value = (map = []))])`},
+		{
+			text: "( # comment\n  int16 = 7\n)",
+			want: `(int16 = 7)`,
+		},
+		{
+			text: "(int8List = [\n  1, # one\n  -2\n])",
+			want: `(int8List = [1, -2])`,
+		},
+	}
+
+	data, err := readTestFile("txt.capnp.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	err = reg.Register(&schemas.Schema{
+		Bytes: data,
+		Nodes: []uint64{keyValueID, valueID},
+	})
+	if err != nil {
+		t.Fatalf("Adding to registry: %v", err)
+	}
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		kv, err := capnp.NewRootStruct(seg, capnp.ObjectSize{PointerCount: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := kv.SetNewText(0, "key"); err != nil {
+			t.Fatal(err)
+		}
+		val, err := capnp.NewStruct(seg, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := val.SetNewText(0, "old"); err != nil {
+			t.Fatal(err)
+		}
+		val.SetUint16(0, 12) // text
+		if err := kv.SetPtr(1, val.ToPtr()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := UnmarshalIntoRegistry(reg, valueID, val, []byte(test.text)); err != nil {
+			t.Errorf("UnmarshalIntoRegistry(%q): %v", test.text, err)
+			continue
+		}
+		want := test.want
+		if want == "" {
+			want = test.text
+		}
+		want = `(key = "key", value = ` + want + `)`
+		var buf bytes.Buffer
+		enc := NewEncoder(&buf)
+		enc.UseRegistry(reg)
+		if err := enc.Encode(keyValueID, kv); err != nil {
+			t.Errorf("UnmarshalIntoRegistry(%q) then Encode: %v", test.text, err)
+			continue
+		}
+		if got := buf.String(); got != want {
+			t.Errorf("UnmarshalIntoRegistry(%q) then Encode = %s; want %s", test.text, got, want)
+		}
+	}
+}
+
+func TestUnmarshalIntoErrors(t *testing.T) {
+	const valueID = 0xd3602730c572a43b
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{`(nope = 1)`, "unknown field nope"},
+		{`(int8 = 300)`, "field int8"},
+		{`(int32 = "1")`, "want integer"},
+		{`(cheese = brie)`, "unknown enumerant brie"},
+		{`(text = "abc)`, "unterminated string"},
+		{`(int32 = 1`, "expected ',' or ')'"},
+		{`(int32 = 1) x`, "after value"},
+		{`[1]`, "want struct"},
+		{`(int32 = 1`, "line 1, column 11: "},
+		{"(\n  int32 = 1,\n  nope 2)", "line 3, column 8: expected '='"},
+		{"(map = [\n  (key = \"a\"),\n  (key = \"b\" value = (void = void))\n])", "line 3, column 14: expected ',' or ')'"},
+		{`(map = [(key = 1)])`, "field map: element 0: field key"},
+	}
+
+	data, err := readTestFile("txt.capnp.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := new(schemas.Registry)
+	err = reg.Register(&schemas.Schema{
+		Bytes: data,
+		Nodes: []uint64{valueID},
+	})
+	if err != nil {
+		t.Fatalf("Adding to registry: %v", err)
+	}
+	for _, test := range tests {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		val, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 16, PointerCount: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = UnmarshalIntoRegistry(reg, valueID, val, []byte(test.text))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("UnmarshalIntoRegistry(%q) = %v; want error containing %q", test.text, err, test.err)
+		}
+	}
+}
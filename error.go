@@ -28,6 +28,19 @@ func IsDisconnected(e error) bool {
 	return errors.TypeOf(e) == errors.Disconnected
 }
 
+// ErrDepthLimit is returned by Marshal and Message.WriteTo when a
+// message is nested more deeply than its MarshalDepthLimit.
+var ErrDepthLimit = errors.New(errors.Failed, "capnp", "marshal: depth limit exceeded")
+
+// ErrMessageTooLarge is returned by Decoder.Decode when a message is
+// larger than the decoder's MaxMessageSize.  It is detected from the
+// stream header, before the message's segments are read.
+var ErrMessageTooLarge = errors.New(errors.Failed, "capnp", "decode: message too large")
+
+// errDepthLimitReached is returned when reading a pointer beyond the
+// depth limit.
+var errDepthLimitReached = newError("read pointer: depth limit reached")
+
 func newError(msg string) error {
 	return errors.New(errors.Failed, "capnp", msg)
 }
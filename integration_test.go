@@ -5,6 +5,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"io"
+	"math"
 	"math/rand"
 	"reflect"
 	"testing"
@@ -2225,6 +2226,16 @@ func BenchmarkGrowth_MultiSegment(b *testing.B) {
 	benchmarkGrowth(b, func() capnp.Arena { return capnp.MultiSegment(nil) })
 }
 
+func BenchmarkGrowth_MultiSegmentFixed(b *testing.B) {
+	grow := capnp.FixedSegmentGrowth(64 * 1024)
+	benchmarkGrowth(b, func() capnp.Arena { return capnp.MultiSegmentWithGrowth(nil, grow) })
+}
+
+func BenchmarkGrowth_MultiSegmentDoubling(b *testing.B) {
+	grow := capnp.DoublingSegmentGrowth(4*1024, 256*1024)
+	benchmarkGrowth(b, func() capnp.Arena { return capnp.MultiSegmentWithGrowth(nil, grow) })
+}
+
 func benchmarkSmallMessage(b *testing.B, newArena func() capnp.Arena) {
 	const fieldValue = "1234567" // carefully chosen to be word-padded
 	b.SetBytes(8 * 9)
@@ -2271,3 +2282,161 @@ func BenchmarkSmallMessage_SingleSegment(b *testing.B) {
 func BenchmarkSmallMessage_MultiSegment(b *testing.B) {
 	benchmarkSmallMessage(b, func() capnp.Arena { return capnp.MultiSegment(nil) })
 }
+
+func BenchmarkSmallMessage_SizeHint(b *testing.B) {
+	b.Run("NoHint", func(b *testing.B) {
+		benchmarkKnownSize(b, func() (*capnp.Segment, error) {
+			_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+			return seg, err
+		})
+	})
+	b.Run("Hint", func(b *testing.B) {
+		benchmarkKnownSize(b, func() (*capnp.Segment, error) {
+			_, seg, err := capnp.NewMessageWithSizeHint(capnp.SingleSegment(nil), knownSizeWords)
+			return seg, err
+		})
+	})
+}
+
+func TestMustAccessors(t *testing.T) {
+	t.Parallel()
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	z, err := air.NewRootZdata(seg)
+	if err != nil {
+		t.Fatal("NewRootZdata:", err)
+	}
+	if err := z.SetData([]byte("hi")); err != nil {
+		t.Fatal("z.SetData:", err)
+	}
+	if data := z.MustData(); !bytes.Equal(data, []byte("hi")) {
+		t.Errorf("z.MustData() = %q; want \"hi\"", data)
+	}
+
+	// The data pointer points past the end of the segment.
+	msg, err := capnp.Unmarshal([]byte{
+		0, 0, 0, 0, 2, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 1, 0,
+		0x91, 0x01, 0, 0, 0x2a, 0, 0, 0,
+	})
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	z, err = air.ReadRootZdata(msg)
+	if err != nil {
+		t.Fatal("ReadRootZdata:", err)
+	}
+	if _, err := z.Data(); err == nil {
+		t.Fatal("corrupt z.Data() did not return an error")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("corrupt z.MustData() did not panic")
+		}
+	}()
+	z.MustData()
+}
+
+func TestGeneratedEqual(t *testing.T) {
+	t.Parallel()
+	newZ := func(set func(z air.Z) error) air.Z {
+		_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal("NewMessage:", err)
+		}
+		z, err := air.NewRootZ(seg)
+		if err != nil {
+			t.Fatal("NewRootZ:", err)
+		}
+		if err := set(z); err != nil {
+			t.Fatal(err)
+		}
+		return z
+	}
+	f64 := newZ(func(z air.Z) error {
+		z.SetF64(1.5)
+		return nil
+	})
+	u64 := newZ(func(z air.Z) error {
+		// Same data section bits as f64, different union member.
+		z.SetU64(math.Float64bits(1.5))
+		return nil
+	})
+	stale := newZ(func(z air.Z) error {
+		// Leaves text in the pointer section while f64 is active.
+		if err := z.SetText("stale"); err != nil {
+			return err
+		}
+		z.SetF64(1.5)
+		return nil
+	})
+	text := newZ(func(z air.Z) error { return z.SetText("hi") })
+	otherText := newZ(func(z air.Z) error { return z.SetText("bye") })
+	grp := newZ(func(z air.Z) error {
+		z.SetGrp()
+		z.Grp().SetFirst(1)
+		z.Grp().SetSecond(2)
+		return nil
+	})
+	otherGrp := newZ(func(z air.Z) error {
+		z.SetGrp()
+		z.Grp().SetFirst(1)
+		z.Grp().SetSecond(3)
+		return nil
+	})
+
+	tests := []struct {
+		name string
+		a, b air.Z
+		want bool
+	}{
+		{"same", f64, f64, true},
+		{"different members", f64, u64, false},
+		{"inactive member ignored", f64, stale, true},
+		{"text", text, text, true},
+		{"different text", text, otherText, false},
+		{"different group", grp, otherGrp, false},
+		{"text and group", text, grp, false},
+	}
+	for _, test := range tests {
+		eq, err := test.a.Equal(test.b)
+		if err != nil {
+			t.Errorf("%s: Equal: %v", test.name, err)
+			continue
+		}
+		if eq != test.want {
+			t.Errorf("%s: Equal = %t; want %t", test.name, eq, test.want)
+		}
+	}
+}
+
+// knownSizeWords is the size of the message built by benchmarkKnownSize:
+// the root pointer, the root struct, and the list's tag and elements.
+const knownSizeWords = 1 + 2 + 1 + 256*2
+
+func benchmarkKnownSize(b *testing.B, newSegment func() (*capnp.Segment, error)) {
+	b.SetBytes(8 * knownSizeWords)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		seg, err := newSegment()
+		if err != nil {
+			b.Fatal(err)
+		}
+		root, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 8, PointerCount: 1})
+		if err != nil {
+			b.Fatal(err)
+		}
+		l, err := capnp.NewCompositeList(seg, capnp.ObjectSize{DataSize: 16}, 256)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := root.SetPtr(0, l.ToPtr()); err != nil {
+			b.Fatal(err)
+		}
+		if n := seg.Message().NumSegments(); n != 1 {
+			b.Fatalf("message has %d segments; want 1", n)
+		}
+	}
+}
@@ -48,6 +48,22 @@ func (s Zdate) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Zdate) Equal(o Zdate) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if s.Struct.Uint8(2) != o.Struct.Uint8(2) {
+		return false, nil
+	}
+	if s.Struct.Uint8(3) != o.Struct.Uint8(3) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Zdate) Year() int16 {
 	return int16(s.Struct.Uint16(0))
 }
@@ -123,11 +139,36 @@ func (s Zdata) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Zdata) Equal(o Zdata) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Zdata) Data() ([]byte, error) {
 	p, err := s.Struct.Ptr(0)
 	return []byte(p.Data()), err
 }
 
+// MustData is like Data, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Zdata) MustData() []byte {
+	v, err := s.Data()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Zdata) HasData() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -267,11 +308,55 @@ func (s PlaneBase) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s PlaneBase) Equal(o PlaneBase) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if s.Struct.Bit(64) != o.Struct.Bit(64) {
+		return false, nil
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	if s.Struct.Uint64(24) != o.Struct.Uint64(24) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s PlaneBase) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s PlaneBase) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s PlaneBase) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -290,6 +375,17 @@ func (s PlaneBase) Homes() (Airport_List, error) {
 	return Airport_List{List: p.List()}, err
 }
 
+// MustHomes is like Homes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s PlaneBase) MustHomes() Airport_List {
+	v, err := s.Homes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s PlaneBase) HasHomes() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -392,11 +488,36 @@ func (s B737) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s B737) Equal(o B737) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s B737) Base() (PlaneBase, error) {
 	p, err := s.Struct.Ptr(0)
 	return PlaneBase{Struct: p.Struct()}, err
 }
 
+// MustBase is like Base, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s B737) MustBase() PlaneBase {
+	v, err := s.Base()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s B737) HasBase() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -471,11 +592,36 @@ func (s A320) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s A320) Equal(o A320) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s A320) Base() (PlaneBase, error) {
 	p, err := s.Struct.Ptr(0)
 	return PlaneBase{Struct: p.Struct()}, err
 }
 
+// MustBase is like Base, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s A320) MustBase() PlaneBase {
+	v, err := s.Base()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s A320) HasBase() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -550,11 +696,36 @@ func (s F16) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s F16) Equal(o F16) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s F16) Base() (PlaneBase, error) {
 	p, err := s.Struct.Ptr(0)
 	return PlaneBase{Struct: p.Struct()}, err
 }
 
+// MustBase is like Base, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s F16) MustBase() PlaneBase {
+	v, err := s.Base()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s F16) HasBase() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -629,11 +800,59 @@ func (s Regression) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Regression) Equal(o Regression) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+		return false, nil
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Regression) Base() (PlaneBase, error) {
 	p, err := s.Struct.Ptr(0)
 	return PlaneBase{Struct: p.Struct()}, err
 }
 
+// MustBase is like Base, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Regression) MustBase() PlaneBase {
+	v, err := s.Base()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Regression) HasBase() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -666,6 +885,17 @@ func (s Regression) Beta() (capnp.Float64List, error) {
 	return capnp.Float64List{List: p.List()}, err
 }
 
+// MustBeta is like Beta, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Regression) MustBeta() capnp.Float64List {
+	v, err := s.Beta()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Regression) HasBeta() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -690,6 +920,17 @@ func (s Regression) Planes() (Aircraft_List, error) {
 	return Aircraft_List{List: p.List()}, err
 }
 
+// MustPlanes is like Planes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Regression) MustPlanes() Aircraft_List {
+	v, err := s.Planes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Regression) HasPlanes() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -804,6 +1045,42 @@ func (s Aircraft) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Aircraft) Equal(o Aircraft) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Aircraft_Which(s.Struct.Uint16(0)) {
+	case Aircraft_Which_b737:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Aircraft_Which_a320:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Aircraft_Which_f16:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Aircraft) Which() Aircraft_Which {
 	return Aircraft_Which(s.Struct.Uint16(0))
 }
@@ -820,6 +1097,17 @@ func (s Aircraft) B737() (B737, error) {
 	return B737{Struct: p.Struct()}, err
 }
 
+// MustB737 is like B737, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Aircraft) MustB737() B737 {
+	v, err := s.B737()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Aircraft) HasB737() bool {
 	if s.Struct.Uint16(0) != 1 {
 		return false
@@ -852,6 +1140,17 @@ func (s Aircraft) A320() (A320, error) {
 	return A320{Struct: p.Struct()}, err
 }
 
+// MustA320 is like A320, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Aircraft) MustA320() A320 {
+	v, err := s.A320()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Aircraft) HasA320() bool {
 	if s.Struct.Uint16(0) != 2 {
 		return false
@@ -884,6 +1183,17 @@ func (s Aircraft) F16() (F16, error) {
 	return F16{Struct: p.Struct()}, err
 }
 
+// MustF16 is like F16, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Aircraft) MustF16() F16 {
+	v, err := s.F16()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Aircraft) HasF16() bool {
 	if s.Struct.Uint16(0) != 3 {
 		return false
@@ -1131,6 +1441,353 @@ func (s Z) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Z) Equal(o Z) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Z_Which(s.Struct.Uint16(0)) {
+	case Z_Which_zz:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_f64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Z_Which_f32:
+		if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+			return false, nil
+		}
+	case Z_Which_i64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Z_Which_i32:
+		if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+			return false, nil
+		}
+	case Z_Which_i16:
+		if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+			return false, nil
+		}
+	case Z_Which_i8:
+		if s.Struct.Uint8(8) != o.Struct.Uint8(8) {
+			return false, nil
+		}
+	case Z_Which_u64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Z_Which_u32:
+		if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+			return false, nil
+		}
+	case Z_Which_u16:
+		if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+			return false, nil
+		}
+	case Z_Which_u8:
+		if s.Struct.Uint8(8) != o.Struct.Uint8(8) {
+			return false, nil
+		}
+	case Z_Which_bool:
+		if s.Struct.Bit(64) != o.Struct.Bit(64) {
+			return false, nil
+		}
+	case Z_Which_text:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_blob:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_f64vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_f32vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_i64vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_i32vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_i16vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_i8vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_u64vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_u32vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_u16vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_u8vec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_boolvec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_datavec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_textvec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_zvec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_zvecvec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_zdate:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_zdata:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_aircraftvec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_aircraft:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_regression:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_planebase:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_airport:
+		if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+			return false, nil
+		}
+	case Z_Which_b737:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_a320:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_f16:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_zdatevec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_zdatavec:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_grp:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+		if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+			return false, nil
+		}
+	case Z_Which_echo:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_echoes:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_anyPtr:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_anyStruct:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_anyList:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Z_Which_anyCapability:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Z) Which() Z_Which {
 	return Z_Which(s.Struct.Uint16(0))
 }
@@ -1147,6 +1804,17 @@ func (s Z) Zz() (Z, error) {
 	return Z{Struct: p.Struct()}, err
 }
 
+// MustZz is like Zz, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustZz() Z {
+	v, err := s.Zz()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasZz() bool {
 	if s.Struct.Uint16(0) != 1 {
 		return false
@@ -1311,6 +1979,17 @@ func (s Z) Text() (string, error) {
 	return p.Text(), err
 }
 
+// MustText is like Text, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustText() string {
+	v, err := s.Text()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasText() bool {
 	if s.Struct.Uint16(0) != 13 {
 		return false
@@ -1336,6 +2015,17 @@ func (s Z) Blob() ([]byte, error) {
 	return []byte(p.Data()), err
 }
 
+// MustBlob is like Blob, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustBlob() []byte {
+	v, err := s.Blob()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasBlob() bool {
 	if s.Struct.Uint16(0) != 14 {
 		return false
@@ -1356,6 +2046,17 @@ func (s Z) F64vec() (capnp.Float64List, error) {
 	return capnp.Float64List{List: p.List()}, err
 }
 
+// MustF64vec is like F64vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustF64vec() capnp.Float64List {
+	v, err := s.F64vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasF64vec() bool {
 	if s.Struct.Uint16(0) != 15 {
 		return false
@@ -1388,6 +2089,17 @@ func (s Z) F32vec() (capnp.Float32List, error) {
 	return capnp.Float32List{List: p.List()}, err
 }
 
+// MustF32vec is like F32vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustF32vec() capnp.Float32List {
+	v, err := s.F32vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasF32vec() bool {
 	if s.Struct.Uint16(0) != 16 {
 		return false
@@ -1420,6 +2132,17 @@ func (s Z) I64vec() (capnp.Int64List, error) {
 	return capnp.Int64List{List: p.List()}, err
 }
 
+// MustI64vec is like I64vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustI64vec() capnp.Int64List {
+	v, err := s.I64vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasI64vec() bool {
 	if s.Struct.Uint16(0) != 17 {
 		return false
@@ -1452,6 +2175,17 @@ func (s Z) I32vec() (capnp.Int32List, error) {
 	return capnp.Int32List{List: p.List()}, err
 }
 
+// MustI32vec is like I32vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustI32vec() capnp.Int32List {
+	v, err := s.I32vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasI32vec() bool {
 	if s.Struct.Uint16(0) != 18 {
 		return false
@@ -1484,6 +2218,17 @@ func (s Z) I16vec() (capnp.Int16List, error) {
 	return capnp.Int16List{List: p.List()}, err
 }
 
+// MustI16vec is like I16vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustI16vec() capnp.Int16List {
+	v, err := s.I16vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasI16vec() bool {
 	if s.Struct.Uint16(0) != 19 {
 		return false
@@ -1516,6 +2261,17 @@ func (s Z) I8vec() (capnp.Int8List, error) {
 	return capnp.Int8List{List: p.List()}, err
 }
 
+// MustI8vec is like I8vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustI8vec() capnp.Int8List {
+	v, err := s.I8vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasI8vec() bool {
 	if s.Struct.Uint16(0) != 20 {
 		return false
@@ -1548,6 +2304,17 @@ func (s Z) U64vec() (capnp.UInt64List, error) {
 	return capnp.UInt64List{List: p.List()}, err
 }
 
+// MustU64vec is like U64vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustU64vec() capnp.UInt64List {
+	v, err := s.U64vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasU64vec() bool {
 	if s.Struct.Uint16(0) != 21 {
 		return false
@@ -1580,6 +2347,17 @@ func (s Z) U32vec() (capnp.UInt32List, error) {
 	return capnp.UInt32List{List: p.List()}, err
 }
 
+// MustU32vec is like U32vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustU32vec() capnp.UInt32List {
+	v, err := s.U32vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasU32vec() bool {
 	if s.Struct.Uint16(0) != 22 {
 		return false
@@ -1612,6 +2390,17 @@ func (s Z) U16vec() (capnp.UInt16List, error) {
 	return capnp.UInt16List{List: p.List()}, err
 }
 
+// MustU16vec is like U16vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustU16vec() capnp.UInt16List {
+	v, err := s.U16vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasU16vec() bool {
 	if s.Struct.Uint16(0) != 23 {
 		return false
@@ -1644,6 +2433,17 @@ func (s Z) U8vec() (capnp.UInt8List, error) {
 	return capnp.UInt8List{List: p.List()}, err
 }
 
+// MustU8vec is like U8vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustU8vec() capnp.UInt8List {
+	v, err := s.U8vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasU8vec() bool {
 	if s.Struct.Uint16(0) != 24 {
 		return false
@@ -1676,6 +2476,17 @@ func (s Z) Boolvec() (capnp.BitList, error) {
 	return capnp.BitList{List: p.List()}, err
 }
 
+// MustBoolvec is like Boolvec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustBoolvec() capnp.BitList {
+	v, err := s.Boolvec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasBoolvec() bool {
 	if s.Struct.Uint16(0) != 39 {
 		return false
@@ -1708,6 +2519,17 @@ func (s Z) Datavec() (capnp.DataList, error) {
 	return capnp.DataList{List: p.List()}, err
 }
 
+// MustDatavec is like Datavec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustDatavec() capnp.DataList {
+	v, err := s.Datavec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasDatavec() bool {
 	if s.Struct.Uint16(0) != 40 {
 		return false
@@ -1740,6 +2562,17 @@ func (s Z) Textvec() (capnp.TextList, error) {
 	return capnp.TextList{List: p.List()}, err
 }
 
+// MustTextvec is like Textvec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustTextvec() capnp.TextList {
+	v, err := s.Textvec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasTextvec() bool {
 	if s.Struct.Uint16(0) != 41 {
 		return false
@@ -1772,6 +2605,17 @@ func (s Z) Zvec() (Z_List, error) {
 	return Z_List{List: p.List()}, err
 }
 
+// MustZvec is like Zvec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustZvec() Z_List {
+	v, err := s.Zvec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasZvec() bool {
 	if s.Struct.Uint16(0) != 25 {
 		return false
@@ -1804,6 +2648,17 @@ func (s Z) Zvecvec() (capnp.PointerList, error) {
 	return capnp.PointerList{List: p.List()}, err
 }
 
+// MustZvecvec is like Zvecvec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustZvecvec() capnp.PointerList {
+	v, err := s.Zvecvec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasZvecvec() bool {
 	if s.Struct.Uint16(0) != 26 {
 		return false
@@ -1836,6 +2691,17 @@ func (s Z) Zdate() (Zdate, error) {
 	return Zdate{Struct: p.Struct()}, err
 }
 
+// MustZdate is like Zdate, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustZdate() Zdate {
+	v, err := s.Zdate()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasZdate() bool {
 	if s.Struct.Uint16(0) != 27 {
 		return false
@@ -1868,6 +2734,17 @@ func (s Z) Zdata() (Zdata, error) {
 	return Zdata{Struct: p.Struct()}, err
 }
 
+// MustZdata is like Zdata, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustZdata() Zdata {
+	v, err := s.Zdata()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasZdata() bool {
 	if s.Struct.Uint16(0) != 28 {
 		return false
@@ -1900,6 +2777,17 @@ func (s Z) Aircraftvec() (Aircraft_List, error) {
 	return Aircraft_List{List: p.List()}, err
 }
 
+// MustAircraftvec is like Aircraftvec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustAircraftvec() Aircraft_List {
+	v, err := s.Aircraftvec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasAircraftvec() bool {
 	if s.Struct.Uint16(0) != 29 {
 		return false
@@ -1932,6 +2820,17 @@ func (s Z) Aircraft() (Aircraft, error) {
 	return Aircraft{Struct: p.Struct()}, err
 }
 
+// MustAircraft is like Aircraft, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustAircraft() Aircraft {
+	v, err := s.Aircraft()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasAircraft() bool {
 	if s.Struct.Uint16(0) != 30 {
 		return false
@@ -1964,6 +2863,17 @@ func (s Z) Regression() (Regression, error) {
 	return Regression{Struct: p.Struct()}, err
 }
 
+// MustRegression is like Regression, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustRegression() Regression {
+	v, err := s.Regression()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasRegression() bool {
 	if s.Struct.Uint16(0) != 31 {
 		return false
@@ -1996,6 +2906,17 @@ func (s Z) Planebase() (PlaneBase, error) {
 	return PlaneBase{Struct: p.Struct()}, err
 }
 
+// MustPlanebase is like Planebase, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustPlanebase() PlaneBase {
+	v, err := s.Planebase()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasPlanebase() bool {
 	if s.Struct.Uint16(0) != 32 {
 		return false
@@ -2040,6 +2961,17 @@ func (s Z) B737() (B737, error) {
 	return B737{Struct: p.Struct()}, err
 }
 
+// MustB737 is like B737, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustB737() B737 {
+	v, err := s.B737()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasB737() bool {
 	if s.Struct.Uint16(0) != 34 {
 		return false
@@ -2072,6 +3004,17 @@ func (s Z) A320() (A320, error) {
 	return A320{Struct: p.Struct()}, err
 }
 
+// MustA320 is like A320, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustA320() A320 {
+	v, err := s.A320()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasA320() bool {
 	if s.Struct.Uint16(0) != 35 {
 		return false
@@ -2104,6 +3047,17 @@ func (s Z) F16() (F16, error) {
 	return F16{Struct: p.Struct()}, err
 }
 
+// MustF16 is like F16, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustF16() F16 {
+	v, err := s.F16()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasF16() bool {
 	if s.Struct.Uint16(0) != 36 {
 		return false
@@ -2136,6 +3090,17 @@ func (s Z) Zdatevec() (Zdate_List, error) {
 	return Zdate_List{List: p.List()}, err
 }
 
+// MustZdatevec is like Zdatevec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustZdatevec() Zdate_List {
+	v, err := s.Zdatevec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasZdatevec() bool {
 	if s.Struct.Uint16(0) != 37 {
 		return false
@@ -2168,6 +3133,17 @@ func (s Z) Zdatavec() (Zdata_List, error) {
 	return Zdata_List{List: p.List()}, err
 }
 
+// MustZdatavec is like Zdatavec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustZdatavec() Zdata_List {
+	v, err := s.Zdatavec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasZdatavec() bool {
 	if s.Struct.Uint16(0) != 38 {
 		return false
@@ -2247,6 +3223,17 @@ func (s Z) Echoes() (capnp.PointerList, error) {
 	return capnp.PointerList{List: p.List()}, err
 }
 
+// MustEchoes is like Echoes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustEchoes() capnp.PointerList {
+	v, err := s.Echoes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasEchoes() bool {
 	if s.Struct.Uint16(0) != 44 {
 		return false
@@ -2278,6 +3265,17 @@ func (s Z) AnyPtr() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustAnyPtr is like AnyPtr, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustAnyPtr() capnp.Ptr {
+	v, err := s.AnyPtr()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasAnyPtr() bool {
 	if s.Struct.Uint16(0) != 45 {
 		return false
@@ -2297,6 +3295,17 @@ func (s Z) AnyStruct() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustAnyStruct is like AnyStruct, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustAnyStruct() capnp.Ptr {
+	v, err := s.AnyStruct()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasAnyStruct() bool {
 	if s.Struct.Uint16(0) != 46 {
 		return false
@@ -2316,6 +3325,17 @@ func (s Z) AnyList() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustAnyList is like AnyList, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustAnyList() capnp.Ptr {
+	v, err := s.AnyList()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasAnyList() bool {
 	if s.Struct.Uint16(0) != 47 {
 		return false
@@ -2335,6 +3355,17 @@ func (s Z) AnyCapability() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustAnyCapability is like AnyCapability, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Z) MustAnyCapability() capnp.Ptr {
+	v, err := s.AnyCapability()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Z) HasAnyCapability() bool {
 	if s.Struct.Uint16(0) != 48 {
 		return false
@@ -2464,6 +3495,37 @@ func (s Counter) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Counter) Equal(o Counter) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Counter) Size() int64 {
 	return int64(s.Struct.Uint64(0))
 }
@@ -2477,6 +3539,17 @@ func (s Counter) Words() (string, error) {
 	return p.Text(), err
 }
 
+// MustWords is like Words, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Counter) MustWords() string {
+	v, err := s.Words()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Counter) HasWords() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2495,6 +3568,17 @@ func (s Counter) Wordlist() (capnp.TextList, error) {
 	return capnp.TextList{List: p.List()}, err
 }
 
+// MustWordlist is like Wordlist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Counter) MustWordlist() capnp.TextList {
+	v, err := s.Wordlist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Counter) HasWordlist() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -2519,6 +3603,17 @@ func (s Counter) Bitlist() (capnp.BitList, error) {
 	return capnp.BitList{List: p.List()}, err
 }
 
+// MustBitlist is like Bitlist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Counter) MustBitlist() capnp.BitList {
+	v, err := s.Bitlist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Counter) HasBitlist() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -2589,11 +3684,36 @@ func (s Bag) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Bag) Equal(o Bag) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Bag) Counter() (Counter, error) {
 	p, err := s.Struct.Ptr(0)
 	return Counter{Struct: p.Struct()}, err
 }
 
+// MustCounter is like Counter, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Bag) MustCounter() Counter {
+	v, err := s.Counter()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Bag) HasCounter() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2668,11 +3788,36 @@ func (s Zserver) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Zserver) Equal(o Zserver) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Zserver) Waitingjobs() (Zjob_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return Zjob_List{List: p.List()}, err
 }
 
+// MustWaitingjobs is like Waitingjobs, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Zserver) MustWaitingjobs() Zjob_List {
+	v, err := s.Waitingjobs()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Zserver) HasWaitingjobs() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2743,11 +3888,43 @@ func (s Zjob) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Zjob) Equal(o Zjob) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Zjob) Cmd() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustCmd is like Cmd, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Zjob) MustCmd() string {
+	v, err := s.Cmd()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Zjob) HasCmd() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2766,6 +3943,17 @@ func (s Zjob) Args() (capnp.TextList, error) {
 	return capnp.TextList{List: p.List()}, err
 }
 
+// MustArgs is like Args, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Zjob) MustArgs() capnp.TextList {
+	v, err := s.Args()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Zjob) HasArgs() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -2836,6 +4024,13 @@ func (s VerEmpty) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VerEmpty) Equal(o VerEmpty) (bool, error) {
+	return true, nil
+}
+
 // VerEmpty_List is a list of VerEmpty.
 type VerEmpty_List struct{ capnp.List }
 
@@ -2887,6 +4082,16 @@ func (s VerOneData) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VerOneData) Equal(o VerOneData) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s VerOneData) Val() int16 {
 	return int16(s.Struct.Uint16(0))
 }
@@ -2946,6 +4151,19 @@ func (s VerTwoData) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VerTwoData) Equal(o VerTwoData) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s VerTwoData) Val() int16 {
 	return int16(s.Struct.Uint16(0))
 }
@@ -3013,11 +4231,36 @@ func (s VerOnePtr) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VerOnePtr) Equal(o VerOnePtr) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s VerOnePtr) Ptr() (VerOneData, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerOneData{Struct: p.Struct()}, err
 }
 
+// MustPtr is like Ptr, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerOnePtr) MustPtr() VerOneData {
+	v, err := s.Ptr()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerOnePtr) HasPtr() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3092,11 +4335,43 @@ func (s VerTwoPtr) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VerTwoPtr) Equal(o VerTwoPtr) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s VerTwoPtr) Ptr1() (VerOneData, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerOneData{Struct: p.Struct()}, err
 }
 
+// MustPtr1 is like Ptr1, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerTwoPtr) MustPtr1() VerOneData {
+	v, err := s.Ptr1()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerTwoPtr) HasPtr1() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3121,6 +4396,17 @@ func (s VerTwoPtr) Ptr2() (VerOneData, error) {
 	return VerOneData{Struct: p.Struct()}, err
 }
 
+// MustPtr2 is like Ptr2, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerTwoPtr) MustPtr2() VerOneData {
+	v, err := s.Ptr2()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerTwoPtr) HasPtr2() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -3199,6 +4485,33 @@ func (s VerTwoDataTwoPtr) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VerTwoDataTwoPtr) Equal(o VerTwoDataTwoPtr) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s VerTwoDataTwoPtr) Val() int16 {
 	return int16(s.Struct.Uint16(0))
 }
@@ -3220,6 +4533,17 @@ func (s VerTwoDataTwoPtr) Ptr1() (VerOneData, error) {
 	return VerOneData{Struct: p.Struct()}, err
 }
 
+// MustPtr1 is like Ptr1, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerTwoDataTwoPtr) MustPtr1() VerOneData {
+	v, err := s.Ptr1()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerTwoDataTwoPtr) HasPtr1() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3244,6 +4568,17 @@ func (s VerTwoDataTwoPtr) Ptr2() (VerOneData, error) {
 	return VerOneData{Struct: p.Struct()}, err
 }
 
+// MustPtr2 is like Ptr2, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerTwoDataTwoPtr) MustPtr2() VerOneData {
+	v, err := s.Ptr2()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerTwoDataTwoPtr) HasPtr2() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -3324,11 +4659,36 @@ func (s HoldsVerEmptyList) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsVerEmptyList) Equal(o HoldsVerEmptyList) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsVerEmptyList) Mylist() (VerEmpty_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerEmpty_List{List: p.List()}, err
 }
 
+// MustMylist is like Mylist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsVerEmptyList) MustMylist() VerEmpty_List {
+	v, err := s.Mylist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsVerEmptyList) HasMylist() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3403,11 +4763,36 @@ func (s HoldsVerOneDataList) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsVerOneDataList) Equal(o HoldsVerOneDataList) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsVerOneDataList) Mylist() (VerOneData_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerOneData_List{List: p.List()}, err
 }
 
+// MustMylist is like Mylist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsVerOneDataList) MustMylist() VerOneData_List {
+	v, err := s.Mylist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsVerOneDataList) HasMylist() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3482,11 +4867,36 @@ func (s HoldsVerTwoDataList) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsVerTwoDataList) Equal(o HoldsVerTwoDataList) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsVerTwoDataList) Mylist() (VerTwoData_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerTwoData_List{List: p.List()}, err
 }
 
+// MustMylist is like Mylist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsVerTwoDataList) MustMylist() VerTwoData_List {
+	v, err := s.Mylist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsVerTwoDataList) HasMylist() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3561,11 +4971,36 @@ func (s HoldsVerOnePtrList) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsVerOnePtrList) Equal(o HoldsVerOnePtrList) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsVerOnePtrList) Mylist() (VerOnePtr_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerOnePtr_List{List: p.List()}, err
 }
 
+// MustMylist is like Mylist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsVerOnePtrList) MustMylist() VerOnePtr_List {
+	v, err := s.Mylist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsVerOnePtrList) HasMylist() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3640,11 +5075,36 @@ func (s HoldsVerTwoPtrList) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsVerTwoPtrList) Equal(o HoldsVerTwoPtrList) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsVerTwoPtrList) Mylist() (VerTwoPtr_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerTwoPtr_List{List: p.List()}, err
 }
 
+// MustMylist is like Mylist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsVerTwoPtrList) MustMylist() VerTwoPtr_List {
+	v, err := s.Mylist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsVerTwoPtrList) HasMylist() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3719,11 +5179,36 @@ func (s HoldsVerTwoTwoList) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsVerTwoTwoList) Equal(o HoldsVerTwoTwoList) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsVerTwoTwoList) Mylist() (VerTwoDataTwoPtr_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerTwoDataTwoPtr_List{List: p.List()}, err
 }
 
+// MustMylist is like Mylist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsVerTwoTwoList) MustMylist() VerTwoDataTwoPtr_List {
+	v, err := s.Mylist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsVerTwoTwoList) HasMylist() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3798,11 +5283,36 @@ func (s HoldsVerTwoTwoPlus) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsVerTwoTwoPlus) Equal(o HoldsVerTwoTwoPlus) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsVerTwoTwoPlus) Mylist() (VerTwoTwoPlus_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerTwoTwoPlus_List{List: p.List()}, err
 }
 
+// MustMylist is like Mylist, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsVerTwoTwoPlus) MustMylist() VerTwoTwoPlus_List {
+	v, err := s.Mylist()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsVerTwoTwoPlus) HasMylist() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3877,6 +5387,43 @@ func (s VerTwoTwoPlus) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VerTwoTwoPlus) Equal(o VerTwoTwoPlus) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s VerTwoTwoPlus) Val() int16 {
 	return int16(s.Struct.Uint16(0))
 }
@@ -3898,6 +5445,17 @@ func (s VerTwoTwoPlus) Ptr1() (VerTwoDataTwoPtr, error) {
 	return VerTwoDataTwoPtr{Struct: p.Struct()}, err
 }
 
+// MustPtr1 is like Ptr1, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerTwoTwoPlus) MustPtr1() VerTwoDataTwoPtr {
+	v, err := s.Ptr1()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerTwoTwoPlus) HasPtr1() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3922,6 +5480,17 @@ func (s VerTwoTwoPlus) Ptr2() (VerTwoDataTwoPtr, error) {
 	return VerTwoDataTwoPtr{Struct: p.Struct()}, err
 }
 
+// MustPtr2 is like Ptr2, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerTwoTwoPlus) MustPtr2() VerTwoDataTwoPtr {
+	v, err := s.Ptr2()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerTwoTwoPlus) HasPtr2() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -3954,6 +5523,17 @@ func (s VerTwoTwoPlus) Lst3() (capnp.Int64List, error) {
 	return capnp.Int64List{List: p.List()}, err
 }
 
+// MustLst3 is like Lst3, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s VerTwoTwoPlus) MustLst3() capnp.Int64List {
+	v, err := s.Lst3()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s VerTwoTwoPlus) HasLst3() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -4032,11 +5612,50 @@ func (s HoldsText) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s HoldsText) Equal(o HoldsText) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s HoldsText) Txt() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustTxt is like Txt, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsText) MustTxt() string {
+	v, err := s.Txt()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsText) HasTxt() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4055,6 +5674,17 @@ func (s HoldsText) Lst() (capnp.TextList, error) {
 	return capnp.TextList{List: p.List()}, err
 }
 
+// MustLst is like Lst, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsText) MustLst() capnp.TextList {
+	v, err := s.Lst()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsText) HasLst() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -4079,6 +5709,17 @@ func (s HoldsText) Lstlst() (capnp.PointerList, error) {
 	return capnp.PointerList{List: p.List()}, err
 }
 
+// MustLstlst is like Lstlst, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s HoldsText) MustLstlst() capnp.PointerList {
+	v, err := s.Lstlst()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s HoldsText) HasLstlst() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -4149,11 +5790,36 @@ func (s WrapEmpty) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s WrapEmpty) Equal(o WrapEmpty) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s WrapEmpty) MightNotBeReallyEmpty() (VerEmpty, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerEmpty{Struct: p.Struct()}, err
 }
 
+// MustMightNotBeReallyEmpty is like MightNotBeReallyEmpty, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s WrapEmpty) MustMightNotBeReallyEmpty() VerEmpty {
+	v, err := s.MightNotBeReallyEmpty()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s WrapEmpty) HasMightNotBeReallyEmpty() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4228,11 +5894,36 @@ func (s Wrap2x2) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Wrap2x2) Equal(o Wrap2x2) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Wrap2x2) MightNotBeReallyEmpty() (VerTwoDataTwoPtr, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerTwoDataTwoPtr{Struct: p.Struct()}, err
 }
 
+// MustMightNotBeReallyEmpty is like MightNotBeReallyEmpty, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Wrap2x2) MustMightNotBeReallyEmpty() VerTwoDataTwoPtr {
+	v, err := s.MightNotBeReallyEmpty()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Wrap2x2) HasMightNotBeReallyEmpty() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4307,11 +5998,36 @@ func (s Wrap2x2plus) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Wrap2x2plus) Equal(o Wrap2x2plus) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Wrap2x2plus) MightNotBeReallyEmpty() (VerTwoTwoPlus, error) {
 	p, err := s.Struct.Ptr(0)
 	return VerTwoTwoPlus{Struct: p.Struct()}, err
 }
 
+// MustMightNotBeReallyEmpty is like MightNotBeReallyEmpty, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Wrap2x2plus) MustMightNotBeReallyEmpty() VerTwoTwoPlus {
+	v, err := s.MightNotBeReallyEmpty()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Wrap2x2plus) HasMightNotBeReallyEmpty() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4404,6 +6120,16 @@ func (s VoidUnion) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VoidUnion) Equal(o VoidUnion) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s VoidUnion) Which() VoidUnion_Which {
 	return VoidUnion_Which(s.Struct.Uint16(0))
 }
@@ -4468,11 +6194,36 @@ func (s Nester1Capn) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Nester1Capn) Equal(o Nester1Capn) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Nester1Capn) Strs() (capnp.TextList, error) {
 	p, err := s.Struct.Ptr(0)
 	return capnp.TextList{List: p.List()}, err
 }
 
+// MustStrs is like Strs, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Nester1Capn) MustStrs() capnp.TextList {
+	v, err := s.Strs()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Nester1Capn) HasStrs() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4543,11 +6294,36 @@ func (s RWTestCapn) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s RWTestCapn) Equal(o RWTestCapn) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s RWTestCapn) NestMatrix() (capnp.PointerList, error) {
 	p, err := s.Struct.Ptr(0)
 	return capnp.PointerList{List: p.List()}, err
 }
 
+// MustNestMatrix is like NestMatrix, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s RWTestCapn) MustNestMatrix() capnp.PointerList {
+	v, err := s.NestMatrix()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s RWTestCapn) HasNestMatrix() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4618,11 +6394,36 @@ func (s ListStructCapn) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s ListStructCapn) Equal(o ListStructCapn) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s ListStructCapn) Vec() (Nester1Capn_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return Nester1Capn_List{List: p.List()}, err
 }
 
+// MustVec is like Vec, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s ListStructCapn) MustVec() Nester1Capn_List {
+	v, err := s.Vec()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s ListStructCapn) HasVec() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4781,11 +6582,36 @@ func (s Echo_echo_Params) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Echo_echo_Params) Equal(o Echo_echo_Params) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Echo_echo_Params) In() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustIn is like In, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Echo_echo_Params) MustIn() string {
+	v, err := s.In()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Echo_echo_Params) HasIn() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4852,11 +6678,36 @@ func (s Echo_echo_Results) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Echo_echo_Results) Equal(o Echo_echo_Results) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Echo_echo_Results) Out() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustOut is like Out, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Echo_echo_Results) MustOut() string {
+	v, err := s.Out()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Echo_echo_Results) HasOut() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -4925,11 +6776,36 @@ func (s Hoth) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Hoth) Equal(o Hoth) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Hoth) Base() (EchoBase, error) {
 	p, err := s.Struct.Ptr(0)
 	return EchoBase{Struct: p.Struct()}, err
 }
 
+// MustBase is like Base, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Hoth) MustBase() EchoBase {
+	v, err := s.Base()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Hoth) HasBase() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -5004,6 +6880,20 @@ func (s EchoBase) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s EchoBase) Equal(o EchoBase) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s EchoBase) Echo() Echo {
 	p, _ := s.Struct.Ptr(0)
 	return Echo{Client: p.Interface().Client()}
@@ -5077,11 +6967,43 @@ func (s StackingRoot) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s StackingRoot) Equal(o StackingRoot) (bool, error) {
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s StackingRoot) A() (StackingA, error) {
 	p, err := s.Struct.Ptr(1)
 	return StackingA{Struct: p.Struct()}, err
 }
 
+// MustA is like A, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s StackingRoot) MustA() StackingA {
+	v, err := s.A()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s StackingRoot) HasA() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -5110,6 +7032,17 @@ func (s StackingRoot) AWithDefault() (StackingA, error) {
 	return StackingA{Struct: ss}, err
 }
 
+// MustAWithDefault is like AWithDefault, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s StackingRoot) MustAWithDefault() StackingA {
+	v, err := s.AWithDefault()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s StackingRoot) HasAWithDefault() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -5188,6 +7121,23 @@ func (s StackingA) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s StackingA) Equal(o StackingA) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s StackingA) Num() int32 {
 	return int32(s.Struct.Uint32(0))
 }
@@ -5201,6 +7151,17 @@ func (s StackingA) B() (StackingB, error) {
 	return StackingB{Struct: p.Struct()}, err
 }
 
+// MustB is like B, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s StackingA) MustB() StackingB {
+	v, err := s.B()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s StackingA) HasB() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -5275,6 +7236,16 @@ func (s StackingB) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s StackingB) Equal(o StackingB) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s StackingB) Num() int32 {
 	return int32(s.Struct.Uint32(0))
 }
@@ -5422,6 +7393,13 @@ func (s CallSequence_getNumber_Params) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CallSequence_getNumber_Params) Equal(o CallSequence_getNumber_Params) (bool, error) {
+	return true, nil
+}
+
 // CallSequence_getNumber_Params_List is a list of CallSequence_getNumber_Params.
 type CallSequence_getNumber_Params_List struct{ capnp.List }
 
@@ -5477,6 +7455,16 @@ func (s CallSequence_getNumber_Results) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CallSequence_getNumber_Results) Equal(o CallSequence_getNumber_Results) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s CallSequence_getNumber_Results) N() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -5658,6 +7646,13 @@ func (s Pipeliner_newPipeliner_Params) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Pipeliner_newPipeliner_Params) Equal(o Pipeliner_newPipeliner_Params) (bool, error) {
+	return true, nil
+}
+
 // Pipeliner_newPipeliner_Params_List is a list of Pipeliner_newPipeliner_Params.
 type Pipeliner_newPipeliner_Params_List struct{ capnp.List }
 
@@ -5713,10 +7708,42 @@ func (s Pipeliner_newPipeliner_Results) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Pipeliner_newPipeliner_Results) Equal(o Pipeliner_newPipeliner_Results) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Pipeliner_newPipeliner_Results) Extra() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustExtra is like Extra, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Pipeliner_newPipeliner_Results) MustExtra() capnp.Ptr {
+	v, err := s.Extra()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Pipeliner_newPipeliner_Results) HasExtra() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -5806,11 +7833,52 @@ func (s Defaults) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Defaults) Equal(o Defaults) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+		return false, nil
+	}
+	if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Defaults) Text() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.TextDefault("foo"), err
 }
 
+// MustText is like Text, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Defaults) MustText() string {
+	v, err := s.Text()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Defaults) HasText() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -5829,6 +7897,17 @@ func (s Defaults) Data() ([]byte, error) {
 	return []byte(p.DataDefault([]byte{0x62, 0x61, 0x72})), err
 }
 
+// MustData is like Data, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Defaults) MustData() []byte {
+	v, err := s.Data()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Defaults) HasData() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -5915,11 +7994,55 @@ func (s BenchmarkA) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s BenchmarkA) Equal(o BenchmarkA) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+		return false, nil
+	}
+	if s.Struct.Bit(96) != o.Struct.Bit(96) {
+		return false, nil
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s BenchmarkA) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s BenchmarkA) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s BenchmarkA) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -5946,6 +8069,17 @@ func (s BenchmarkA) Phone() (string, error) {
 	return p.Text(), err
 }
 
+// MustPhone is like Phone, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s BenchmarkA) MustPhone() string {
+	v, err := s.Phone()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s BenchmarkA) HasPhone() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -6034,11 +8168,36 @@ func (s AllocBenchmark) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s AllocBenchmark) Equal(o AllocBenchmark) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s AllocBenchmark) Fields() (AllocBenchmark_Field_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return AllocBenchmark_Field_List{List: p.List()}, err
 }
 
+// MustFields is like Fields, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s AllocBenchmark) MustFields() AllocBenchmark_Field_List {
+	v, err := s.Fields()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s AllocBenchmark) HasFields() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -6109,11 +8268,36 @@ func (s AllocBenchmark_Field) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s AllocBenchmark_Field) Equal(o AllocBenchmark_Field) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s AllocBenchmark_Field) StringValue() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustStringValue is like StringValue, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s AllocBenchmark_Field) MustStringValue() string {
+	v, err := s.StringValue()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s AllocBenchmark_Field) HasStringValue() bool {
 	return s.Struct.HasPtr(0)
 }
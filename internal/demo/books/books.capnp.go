@@ -33,11 +33,39 @@ func (s Book) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Book) Equal(o Book) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Book) Title() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustTitle is like Title, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Book) MustTitle() string {
+	v, err := s.Title()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Book) HasTitle() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -8,12 +8,19 @@ type capnpError struct {
 	typ    Type
 	prefix string
 	msg    string
+	cause  error
 }
 
 // New creates a new error that formats as "<prefix>: <msg>".
 // The type can be recovered using the TypeOf() function.
 func New(typ Type, prefix, msg string) error {
-	return &capnpError{typ, prefix, msg}
+	return &capnpError{typ: typ, prefix: prefix, msg: msg}
+}
+
+// Wrap is like New, but the returned error's Unwrap method returns
+// cause.  Only the type, prefix, and message are used for formatting.
+func Wrap(typ Type, prefix, msg string, cause error) error {
+	return &capnpError{typ, prefix, msg, cause}
 }
 
 func (e *capnpError) Error() string {
@@ -27,21 +34,28 @@ func (e *capnpError) GoString() string {
 	return "errors.New(" + e.typ.GoString() + ", " + strconv.Quote(e.prefix) + ", " + strconv.Quote(e.msg) + ")"
 }
 
+// Unwrap returns the error passed to Annotate or Wrap, or nil if the
+// error was created by New.
+func (e *capnpError) Unwrap() error {
+	return e.cause
+}
+
 // Annotate creates a new error that formats as "<prefix>: <msg>: <err>".
 // If err has the same prefix, then the prefix won't be duplicated.
-// The returned error's type will match err's type.
+// The returned error's type will match err's type and its Unwrap
+// method returns err.
 func Annotate(prefix, msg string, err error) error {
 	if err == nil {
 		panic("Annotate on nil error")
 	}
 	ce, ok := err.(*capnpError)
 	if !ok {
-		return &capnpError{Failed, prefix, msg + ": " + err.Error()}
+		return &capnpError{Failed, prefix, msg + ": " + err.Error(), err}
 	}
 	if prefix != ce.prefix {
-		return &capnpError{ce.typ, prefix, msg + ": " + err.Error()}
+		return &capnpError{ce.typ, prefix, msg + ": " + err.Error(), err}
 	}
-	return &capnpError{ce.typ, prefix, msg + ": " + ce.msg}
+	return &capnpError{ce.typ, prefix, msg + ": " + ce.msg, err}
 }
 
 // TypeOf returns err's type if err was created by this package or
@@ -120,5 +120,8 @@ func TestAnnotate(t *testing.T) {
 		if gotType != test.wantType {
 			t.Errorf("TypeOf(Annotate(%q, %q, %#v)) = %#v; %#v", test.prefix, test.msg, test.err, gotType, test.wantType)
 		}
+		if !errors.Is(got, test.err) {
+			t.Errorf("errors.Is(Annotate(%q, %q, %#v), %#v) = false; want true", test.prefix, test.msg, test.err, test.err)
+		}
 	}
 }
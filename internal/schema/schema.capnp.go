@@ -68,6 +68,166 @@ func ReadRootNode(msg *capnp.Message) (Node, error) {
 	return Node{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Node) Equal(o Node) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+		return false, nil
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(5); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(5); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Bit(288) != o.Struct.Bit(288) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(12) != o.Struct.Uint16(12) {
+		return false, nil
+	}
+	switch Node_Which(s.Struct.Uint16(12)) {
+	case Node_Which_structNode:
+		if s.Struct.Uint16(14) != o.Struct.Uint16(14) {
+			return false, nil
+		}
+		if s.Struct.Uint16(24) != o.Struct.Uint16(24) {
+			return false, nil
+		}
+		if s.Struct.Uint16(26) != o.Struct.Uint16(26) {
+			return false, nil
+		}
+		if s.Struct.Bit(224) != o.Struct.Bit(224) {
+			return false, nil
+		}
+		if s.Struct.Uint16(30) != o.Struct.Uint16(30) {
+			return false, nil
+		}
+		if s.Struct.Uint32(32) != o.Struct.Uint32(32) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_enum:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_interface:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if p1, err := s.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_const:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if p1, err := s.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_annotation:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if s.Struct.Bit(112) != o.Struct.Bit(112) {
+			return false, nil
+		}
+		if s.Struct.Bit(113) != o.Struct.Bit(113) {
+			return false, nil
+		}
+		if s.Struct.Bit(114) != o.Struct.Bit(114) {
+			return false, nil
+		}
+		if s.Struct.Bit(115) != o.Struct.Bit(115) {
+			return false, nil
+		}
+		if s.Struct.Bit(116) != o.Struct.Bit(116) {
+			return false, nil
+		}
+		if s.Struct.Bit(117) != o.Struct.Bit(117) {
+			return false, nil
+		}
+		if s.Struct.Bit(118) != o.Struct.Bit(118) {
+			return false, nil
+		}
+		if s.Struct.Bit(119) != o.Struct.Bit(119) {
+			return false, nil
+		}
+		if s.Struct.Bit(120) != o.Struct.Bit(120) {
+			return false, nil
+		}
+		if s.Struct.Bit(121) != o.Struct.Bit(121) {
+			return false, nil
+		}
+		if s.Struct.Bit(122) != o.Struct.Bit(122) {
+			return false, nil
+		}
+		if s.Struct.Bit(123) != o.Struct.Bit(123) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (s Node) Which() Node_Which {
 	return Node_Which(s.Struct.Uint16(12))
 }
@@ -84,6 +244,17 @@ func (s Node) DisplayName() (string, error) {
 	return p.Text(), err
 }
 
+// MustDisplayName is like DisplayName, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustDisplayName() string {
+	v, err := s.DisplayName()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasDisplayName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -118,6 +289,17 @@ func (s Node) Parameters() (Node_Parameter_List, error) {
 	return Node_Parameter_List{List: p.List()}, err
 }
 
+// MustParameters is like Parameters, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustParameters() Node_Parameter_List {
+	v, err := s.Parameters()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasParameters() bool {
 	return s.Struct.HasPtr(5)
 }
@@ -150,6 +332,17 @@ func (s Node) NestedNodes() (Node_NestedNode_List, error) {
 	return Node_NestedNode_List{List: p.List()}, err
 }
 
+// MustNestedNodes is like NestedNodes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustNestedNodes() Node_NestedNode_List {
+	v, err := s.NestedNodes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasNestedNodes() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -174,6 +367,17 @@ func (s Node) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasAnnotations() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -257,6 +461,17 @@ func (s Node_structNode) Fields() (Field_List, error) {
 	return Field_List{List: p.List()}, err
 }
 
+// MustFields is like Fields, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_structNode) MustFields() Field_List {
+	v, err := s.Fields()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_structNode) HasFields() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -287,6 +502,17 @@ func (s Node_enum) Enumerants() (Enumerant_List, error) {
 	return Enumerant_List{List: p.List()}, err
 }
 
+// MustEnumerants is like Enumerants, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_enum) MustEnumerants() Enumerant_List {
+	v, err := s.Enumerants()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_enum) HasEnumerants() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -317,6 +543,17 @@ func (s Node_interface) Methods() (Method_List, error) {
 	return Method_List{List: p.List()}, err
 }
 
+// MustMethods is like Methods, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_interface) MustMethods() Method_List {
+	v, err := s.Methods()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_interface) HasMethods() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -341,6 +578,17 @@ func (s Node_interface) Superclasses() (Superclass_List, error) {
 	return Superclass_List{List: p.List()}, err
 }
 
+// MustSuperclasses is like Superclasses, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_interface) MustSuperclasses() Superclass_List {
+	v, err := s.Superclasses()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_interface) HasSuperclasses() bool {
 	return s.Struct.HasPtr(4)
 }
@@ -371,6 +619,17 @@ func (s Node_const) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_const) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_const) HasType() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -395,6 +654,17 @@ func (s Node_const) Value() (Value, error) {
 	return Value{Struct: p.Struct()}, err
 }
 
+// MustValue is like Value, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_const) MustValue() Value {
+	v, err := s.Value()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_const) HasValue() bool {
 	return s.Struct.HasPtr(4)
 }
@@ -425,6 +695,17 @@ func (s Node_annotation) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_annotation) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_annotation) HasType() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -573,11 +854,36 @@ func ReadRootNode_Parameter(msg *capnp.Message) (Node_Parameter, error) {
 	return Node_Parameter{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Node_Parameter) Equal(o Node_Parameter) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Node_Parameter) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_Parameter) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_Parameter) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -624,11 +930,39 @@ func ReadRootNode_NestedNode(msg *capnp.Message) (Node_NestedNode, error) {
 	return Node_NestedNode{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Node_NestedNode) Equal(o Node_NestedNode) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Node_NestedNode) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_NestedNode) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_NestedNode) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -725,6 +1059,72 @@ func ReadRootField(msg *capnp.Message) (Field, error) {
 	return Field{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Field) Equal(o Field) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+		return false, nil
+	}
+	if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+		return false, nil
+	}
+	switch Field_ordinal_Which(s.Struct.Uint16(10)) {
+	case Field_ordinal_Which_explicit:
+		if s.Struct.Uint16(12) != o.Struct.Uint16(12) {
+			return false, nil
+		}
+	}
+	if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+		return false, nil
+	}
+	switch Field_Which(s.Struct.Uint16(8)) {
+	case Field_Which_slot:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(2); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(2); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if s.Struct.Bit(128) != o.Struct.Bit(128) {
+			return false, nil
+		}
+	case Field_Which_group:
+		if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (s Field) Which() Field_Which {
 	return Field_Which(s.Struct.Uint16(8))
 }
@@ -733,6 +1133,17 @@ func (s Field) Name() (string, error) {
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -759,6 +1170,17 @@ func (s Field) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field) HasAnnotations() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -805,6 +1227,17 @@ func (s Field_slot) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field_slot) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field_slot) HasType() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -829,6 +1262,17 @@ func (s Field_slot) DefaultValue() (Value, error) {
 	return Value{Struct: p.Struct()}, err
 }
 
+// MustDefaultValue is like DefaultValue, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field_slot) MustDefaultValue() Value {
+	v, err := s.DefaultValue()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field_slot) HasDefaultValue() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -925,11 +1369,46 @@ func ReadRootEnumerant(msg *capnp.Message) (Enumerant, error) {
 	return Enumerant{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Enumerant) Equal(o Enumerant) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Enumerant) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Enumerant) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Enumerant) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -956,6 +1435,17 @@ func (s Enumerant) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Enumerant) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Enumerant) HasAnnotations() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -1008,6 +1498,23 @@ func ReadRootSuperclass(msg *capnp.Message) (Superclass, error) {
 	return Superclass{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Superclass) Equal(o Superclass) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Superclass) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -1021,6 +1528,17 @@ func (s Superclass) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Superclass) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Superclass) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1073,11 +1591,73 @@ func ReadRootMethod(msg *capnp.Message) (Method, error) {
 	return Method{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Method) Equal(o Method) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(4); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(4); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(3); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(3); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Method) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1104,6 +1684,17 @@ func (s Method) ImplicitParameters() (Node_Parameter_List, error) {
 	return Node_Parameter_List{List: p.List()}, err
 }
 
+// MustImplicitParameters is like ImplicitParameters, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustImplicitParameters() Node_Parameter_List {
+	v, err := s.ImplicitParameters()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasImplicitParameters() bool {
 	return s.Struct.HasPtr(4)
 }
@@ -1136,6 +1727,17 @@ func (s Method) ParamBrand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustParamBrand is like ParamBrand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustParamBrand() Brand {
+	v, err := s.ParamBrand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasParamBrand() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -1168,6 +1770,17 @@ func (s Method) ResultBrand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustResultBrand is like ResultBrand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustResultBrand() Brand {
+	v, err := s.ResultBrand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasResultBrand() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -1192,6 +1805,17 @@ func (s Method) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasAnnotations() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -1368,6 +1992,80 @@ func ReadRootType(msg *capnp.Message) (Type, error) {
 	return Type{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Type) Equal(o Type) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Type_Which(s.Struct.Uint16(0)) {
+	case Type_Which_list:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_enum:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_structType:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_interface:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_anyPointer:
+		if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+			return false, nil
+		}
+		switch Type_anyPointer_Which(s.Struct.Uint16(8)) {
+		case Type_anyPointer_Which_unconstrained:
+			if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+				return false, nil
+			}
+		case Type_anyPointer_Which_parameter:
+			if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+				return false, nil
+			}
+			if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+				return false, nil
+			}
+		case Type_anyPointer_Which_implicitMethodParameter:
+			if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
 func (s Type) Which() Type_Which {
 	return Type_Which(s.Struct.Uint16(0))
 }
@@ -1452,6 +2150,17 @@ func (s Type_list) ElementType() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustElementType is like ElementType, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_list) MustElementType() Type {
+	v, err := s.ElementType()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_list) HasElementType() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1490,6 +2199,17 @@ func (s Type_enum) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_enum) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_enum) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1528,6 +2248,17 @@ func (s Type_structType) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_structType) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_structType) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1566,6 +2297,17 @@ func (s Type_interface) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_interface) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_interface) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1696,11 +2438,36 @@ func ReadRootBrand(msg *capnp.Message) (Brand, error) {
 	return Brand{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Brand) Equal(o Brand) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Brand) Scopes() (Brand_Scope_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return Brand_Scope_List{List: p.List()}, err
 }
 
+// MustScopes is like Scopes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Brand) MustScopes() Brand_Scope_List {
+	v, err := s.Scopes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Brand) HasScopes() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1771,6 +2538,29 @@ func ReadRootBrand_Scope(msg *capnp.Message) (Brand_Scope, error) {
 	return Brand_Scope{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Brand_Scope) Equal(o Brand_Scope) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+		return false, nil
+	}
+	switch Brand_Scope_Which(s.Struct.Uint16(8)) {
+	case Brand_Scope_Which_bind:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Brand_Scope) Which() Brand_Scope_Which {
 	return Brand_Scope_Which(s.Struct.Uint16(8))
 }
@@ -1790,6 +2580,17 @@ func (s Brand_Scope) Bind() (Brand_Binding_List, error) {
 	return Brand_Binding_List{List: p.List()}, err
 }
 
+// MustBind is like Bind, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Brand_Scope) MustBind() Brand_Binding_List {
+	v, err := s.Bind()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Brand_Scope) HasBind() bool {
 	if s.Struct.Uint16(8) != 0 {
 		return false
@@ -1870,6 +2671,26 @@ func ReadRootBrand_Binding(msg *capnp.Message) (Brand_Binding, error) {
 	return Brand_Binding{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Brand_Binding) Equal(o Brand_Binding) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Brand_Binding_Which(s.Struct.Uint16(0)) {
+	case Brand_Binding_Which_type:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Brand_Binding) Which() Brand_Binding_Which {
 	return Brand_Binding_Which(s.Struct.Uint16(0))
 }
@@ -1886,6 +2707,17 @@ func (s Brand_Binding) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Brand_Binding) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Brand_Binding) HasType() bool {
 	if s.Struct.Uint16(0) != 1 {
 		return false
@@ -2012,6 +2844,106 @@ func ReadRootValue(msg *capnp.Message) (Value, error) {
 	return Value{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Value) Equal(o Value) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Value_Which(s.Struct.Uint16(0)) {
+	case Value_Which_bool:
+		if s.Struct.Bit(16) != o.Struct.Bit(16) {
+			return false, nil
+		}
+	case Value_Which_int8:
+		if s.Struct.Uint8(2) != o.Struct.Uint8(2) {
+			return false, nil
+		}
+	case Value_Which_int16:
+		if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+			return false, nil
+		}
+	case Value_Which_int32:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case Value_Which_int64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Value_Which_uint8:
+		if s.Struct.Uint8(2) != o.Struct.Uint8(2) {
+			return false, nil
+		}
+	case Value_Which_uint16:
+		if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+			return false, nil
+		}
+	case Value_Which_uint32:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case Value_Which_uint64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Value_Which_float32:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case Value_Which_float64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Value_Which_text:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_data:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_list:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_enum:
+		if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+			return false, nil
+		}
+	case Value_Which_structValue:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_anyPointer:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Value) Which() Value_Which {
 	return Value_Which(s.Struct.Uint16(0))
 }
@@ -2160,6 +3092,17 @@ func (s Value) Text() (string, error) {
 	return p.Text(), err
 }
 
+// MustText is like Text, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustText() string {
+	v, err := s.Text()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasText() bool {
 	if s.Struct.Uint16(0) != 12 {
 		return false
@@ -2185,6 +3128,17 @@ func (s Value) Data() ([]byte, error) {
 	return []byte(p.Data()), err
 }
 
+// MustData is like Data, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustData() []byte {
+	v, err := s.Data()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasData() bool {
 	if s.Struct.Uint16(0) != 13 {
 		return false
@@ -2204,6 +3158,17 @@ func (s Value) List() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustList is like List, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustList() capnp.Ptr {
+	v, err := s.List()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasList() bool {
 	if s.Struct.Uint16(0) != 14 {
 		return false
@@ -2235,6 +3200,17 @@ func (s Value) StructValue() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustStructValue is like StructValue, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustStructValue() capnp.Ptr {
+	v, err := s.StructValue()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasStructValue() bool {
 	if s.Struct.Uint16(0) != 16 {
 		return false
@@ -2259,6 +3235,17 @@ func (s Value) AnyPointer() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustAnyPointer is like AnyPointer, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustAnyPointer() capnp.Ptr {
+	v, err := s.AnyPointer()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasAnyPointer() bool {
 	if s.Struct.Uint16(0) != 18 {
 		return false
@@ -2304,6 +3291,30 @@ func ReadRootAnnotation(msg *capnp.Message) (Annotation, error) {
 	return Annotation{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Annotation) Equal(o Annotation) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Annotation) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -2317,6 +3328,17 @@ func (s Annotation) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Annotation) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Annotation) HasBrand() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -2341,6 +3363,17 @@ func (s Annotation) Value() (Value, error) {
 	return Value{Struct: p.Struct()}, err
 }
 
+// MustValue is like Value, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Annotation) MustValue() Value {
+	v, err := s.Value()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Annotation) HasValue() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2478,11 +3511,43 @@ func ReadRootCodeGeneratorRequest(msg *capnp.Message) (CodeGeneratorRequest, err
 	return CodeGeneratorRequest{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CodeGeneratorRequest) Equal(o CodeGeneratorRequest) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s CodeGeneratorRequest) Nodes() (Node_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return Node_List{List: p.List()}, err
 }
 
+// MustNodes is like Nodes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest) MustNodes() Node_List {
+	v, err := s.Nodes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest) HasNodes() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2507,6 +3572,17 @@ func (s CodeGeneratorRequest) RequestedFiles() (CodeGeneratorRequest_RequestedFi
 	return CodeGeneratorRequest_RequestedFile_List{List: p.List()}, err
 }
 
+// MustRequestedFiles is like RequestedFiles, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest) MustRequestedFiles() CodeGeneratorRequest_RequestedFile_List {
+	v, err := s.RequestedFiles()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest) HasRequestedFiles() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -2563,6 +3639,30 @@ func ReadRootCodeGeneratorRequest_RequestedFile(msg *capnp.Message) (CodeGenerat
 	return CodeGeneratorRequest_RequestedFile{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CodeGeneratorRequest_RequestedFile) Equal(o CodeGeneratorRequest_RequestedFile) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s CodeGeneratorRequest_RequestedFile) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -2576,6 +3676,17 @@ func (s CodeGeneratorRequest_RequestedFile) Filename() (string, error) {
 	return p.Text(), err
 }
 
+// MustFilename is like Filename, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest_RequestedFile) MustFilename() string {
+	v, err := s.Filename()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest_RequestedFile) HasFilename() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2594,6 +3705,17 @@ func (s CodeGeneratorRequest_RequestedFile) Imports() (CodeGeneratorRequest_Requ
 	return CodeGeneratorRequest_RequestedFile_Import_List{List: p.List()}, err
 }
 
+// MustImports is like Imports, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest_RequestedFile) MustImports() CodeGeneratorRequest_RequestedFile_Import_List {
+	v, err := s.Imports()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest_RequestedFile) HasImports() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -2650,6 +3772,23 @@ func ReadRootCodeGeneratorRequest_RequestedFile_Import(msg *capnp.Message) (Code
 	return CodeGeneratorRequest_RequestedFile_Import{root.Struct()}, err
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CodeGeneratorRequest_RequestedFile_Import) Equal(o CodeGeneratorRequest_RequestedFile_Import) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s CodeGeneratorRequest_RequestedFile_Import) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -2663,6 +3802,17 @@ func (s CodeGeneratorRequest_RequestedFile_Import) Name() (string, error) {
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest_RequestedFile_Import) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest_RequestedFile_Import) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2,7 +2,9 @@ package capnp
 
 import (
 	"math"
+	"reflect"
 	"strconv"
+	"unsafe"
 
 	"capnproto.org/go/capnp/v3/internal/strquote"
 )
@@ -213,6 +215,25 @@ func (p List) Struct(i int) Struct {
 	}
 }
 
+// PtrAt returns the i'th element of the list as a Ptr.  For a list of
+// pointers (including text and data lists), this is the pointer stored
+// in the element.  For a list of structs, this is the element itself.
+// PtrAt returns an error for lists of primitives.
+func (p List) PtrAt(i int) (Ptr, error) {
+	if p.seg == nil || i < 0 || i >= int(p.length) {
+		// This is programmer error, not input error.
+		panic("list element out of bounds")
+	}
+	switch {
+	case p.flags&isCompositeList != 0:
+		return p.Struct(i).ToPtr(), nil
+	case p.flags&isBitList == 0 && p.size == ObjectSize{PointerCount: 1}:
+		return PointerList{p}.At(i)
+	default:
+		return Ptr{}, errorf("read list element %d: list of primitives has no pointers", i)
+	}
+}
+
 // SetStruct set the i'th element to the value in s.
 func (p List) SetStruct(i int, s Struct) error {
 	if p.flags&isBitList != 0 {
@@ -343,6 +364,25 @@ func (p PointerList) Set(i int, v Ptr) error {
 	return p.seg.writePtr(addr, v, false)
 }
 
+// ListAt returns the i'th pointer in the list as a List, for reading a
+// List(List(T)).  If the element is null or is not a list, then ListAt
+// returns an invalid List.
+func (p PointerList) ListAt(i int) (List, error) {
+	ptr, err := p.At(i)
+	if err != nil {
+		return List{}, err
+	}
+	return ptr.List(), nil
+}
+
+// SetList sets the i'th pointer in the list to inner, for building a
+// List(List(T)).  Inner lists may have different lengths, and an invalid
+// inner list sets the element to null.  If inner is in a different
+// message, it is copied into p's message.
+func (p PointerList) SetList(i int, inner List) error {
+	return p.Set(i, inner.ToPtr())
+}
+
 // TextList is an array of pointers to strings.
 type TextList struct{ List }
 
@@ -574,6 +614,28 @@ func (l UInt8List) Set(i int, v uint8) {
 	l.seg.writeUint8(addr, v)
 }
 
+// Slice returns the list's elements.  When the list is stored as a
+// packed array, the result aliases the message: writing to it writes to
+// the list, and it must not be used once the message is reset or
+// released, or after an allocation has moved the list's segment.
+// Otherwise, Slice returns a copy.
+func (l UInt8List) Slice() ([]uint8, error) {
+	if b := l.aliasBytes(1); b != nil {
+		var v []uint8
+		aliasSlice(unsafe.Pointer(&v), b, 1)
+		return v, nil
+	}
+	v := make([]uint8, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 1})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = l.seg.readUint8(addr)
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l UInt8List) String() string {
 	var buf []byte
@@ -618,6 +680,28 @@ func (l Int8List) Set(i int, v int8) {
 	l.seg.writeUint8(addr, uint8(v))
 }
 
+// Slice returns the list's elements.  When the list is stored as a
+// packed array, the result aliases the message: writing to it writes to
+// the list, and it must not be used once the message is reset or
+// released, or after an allocation has moved the list's segment.
+// Otherwise, Slice returns a copy.
+func (l Int8List) Slice() ([]int8, error) {
+	if b := l.aliasBytes(1); b != nil {
+		var v []int8
+		aliasSlice(unsafe.Pointer(&v), b, 1)
+		return v, nil
+	}
+	v := make([]int8, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 1})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = int8(l.seg.readUint8(addr))
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l Int8List) String() string {
 	var buf []byte
@@ -662,6 +746,28 @@ func (l UInt16List) Set(i int, v uint16) {
 	l.seg.writeUint16(addr, v)
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l UInt16List) Slice() ([]uint16, error) {
+	if b := l.aliasBytes(2); b != nil {
+		var v []uint16
+		aliasSlice(unsafe.Pointer(&v), b, 2)
+		return v, nil
+	}
+	v := make([]uint16, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 2})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = l.seg.readUint16(addr)
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l UInt16List) String() string {
 	var buf []byte
@@ -706,6 +812,28 @@ func (l Int16List) Set(i int, v int16) {
 	l.seg.writeUint16(addr, uint16(v))
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l Int16List) Slice() ([]int16, error) {
+	if b := l.aliasBytes(2); b != nil {
+		var v []int16
+		aliasSlice(unsafe.Pointer(&v), b, 2)
+		return v, nil
+	}
+	v := make([]int16, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 2})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = int16(l.seg.readUint16(addr))
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l Int16List) String() string {
 	var buf []byte
@@ -750,6 +878,28 @@ func (l UInt32List) Set(i int, v uint32) {
 	l.seg.writeUint32(addr, v)
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l UInt32List) Slice() ([]uint32, error) {
+	if b := l.aliasBytes(4); b != nil {
+		var v []uint32
+		aliasSlice(unsafe.Pointer(&v), b, 4)
+		return v, nil
+	}
+	v := make([]uint32, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 4})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = l.seg.readUint32(addr)
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l UInt32List) String() string {
 	var buf []byte
@@ -794,6 +944,28 @@ func (l Int32List) Set(i int, v int32) {
 	l.seg.writeUint32(addr, uint32(v))
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l Int32List) Slice() ([]int32, error) {
+	if b := l.aliasBytes(4); b != nil {
+		var v []int32
+		aliasSlice(unsafe.Pointer(&v), b, 4)
+		return v, nil
+	}
+	v := make([]int32, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 4})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = int32(l.seg.readUint32(addr))
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l Int32List) String() string {
 	var buf []byte
@@ -838,6 +1010,28 @@ func (l UInt64List) Set(i int, v uint64) {
 	l.seg.writeUint64(addr, v)
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l UInt64List) Slice() ([]uint64, error) {
+	if b := l.aliasBytes(8); b != nil {
+		var v []uint64
+		aliasSlice(unsafe.Pointer(&v), b, 8)
+		return v, nil
+	}
+	v := make([]uint64, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 8})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = l.seg.readUint64(addr)
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l UInt64List) String() string {
 	var buf []byte
@@ -882,6 +1076,28 @@ func (l Int64List) Set(i int, v int64) {
 	l.seg.writeUint64(addr, uint64(v))
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l Int64List) Slice() ([]int64, error) {
+	if b := l.aliasBytes(8); b != nil {
+		var v []int64
+		aliasSlice(unsafe.Pointer(&v), b, 8)
+		return v, nil
+	}
+	v := make([]int64, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 8})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = int64(l.seg.readUint64(addr))
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l Int64List) String() string {
 	var buf []byte
@@ -926,6 +1142,28 @@ func (l Float32List) Set(i int, v float32) {
 	l.seg.writeUint32(addr, math.Float32bits(v))
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l Float32List) Slice() ([]float32, error) {
+	if b := l.aliasBytes(4); b != nil {
+		var v []float32
+		aliasSlice(unsafe.Pointer(&v), b, 4)
+		return v, nil
+	}
+	v := make([]float32, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 4})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = math.Float32frombits(l.seg.readUint32(addr))
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l Float32List) String() string {
 	var buf []byte
@@ -970,6 +1208,28 @@ func (l Float64List) Set(i int, v float64) {
 	l.seg.writeUint64(addr, math.Float64bits(v))
 }
 
+// Slice returns the list's elements.  When the host is little-endian
+// and the list is stored as a packed, aligned array, the result aliases
+// the message: writing to it writes to the list, and it must not be
+// used once the message is reset or released, or after an allocation
+// has moved the list's segment.  Otherwise, Slice returns a copy.
+func (l Float64List) Slice() ([]float64, error) {
+	if b := l.aliasBytes(8); b != nil {
+		var v []float64
+		aliasSlice(unsafe.Pointer(&v), b, 8)
+		return v, nil
+	}
+	v := make([]float64, l.Len())
+	for i := range v {
+		addr, err := l.primitiveElem(i, ObjectSize{DataSize: 8})
+		if err != nil {
+			return nil, err
+		}
+		v[i] = math.Float64frombits(l.seg.readUint64(addr))
+	}
+	return v, nil
+}
+
 // String returns the list in Cap'n Proto schema format (e.g. "[1, 2, 3]").
 func (l Float64List) String() string {
 	var buf []byte
@@ -990,3 +1250,37 @@ const (
 	isCompositeList listFlags = 1 << iota
 	isBitList
 )
+
+// hostLittleEndian is true if the host stores integers least
+// significant byte first, like Cap'n Proto does.
+var hostLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// aliasBytes returns the bytes of a non-empty list of primitives that
+// are sz bytes wide, if they can be reinterpreted as a Go slice of the
+// element type in place.  Otherwise, it returns nil.
+func (p List) aliasBytes(sz Size) []byte {
+	if p.seg == nil || p.length == 0 || p.flags != 0 || p.size != (ObjectSize{DataSize: sz}) {
+		return nil
+	}
+	if sz > 1 && !hostLittleEndian {
+		return nil
+	}
+	b := p.seg.slice(p.off, sz.timesUnchecked(p.length))
+	if uintptr(unsafe.Pointer(&b[0]))%uintptr(sz) != 0 {
+		// The segment's buffer is misaligned.
+		return nil
+	}
+	return b
+}
+
+// aliasSlice sets the slice that dst points to to the elements of
+// size sz stored in b.
+func aliasSlice(dst unsafe.Pointer, b []byte, sz Size) {
+	h := (*reflect.SliceHeader)(dst)
+	h.Data = uintptr(unsafe.Pointer(&b[0]))
+	h.Len = len(b) / int(sz)
+	h.Cap = h.Len
+}
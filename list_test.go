@@ -79,6 +79,122 @@ func TestTextListBytesAt(t *testing.T) {
 	}
 }
 
+func TestListPtrAt(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structs, err := NewCompositeList(seg, ObjectSize{DataSize: 8}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structs.Struct(1).SetUint64(0, 42)
+	p, err := structs.PtrAt(1)
+	if err != nil {
+		t.Fatal("structs.PtrAt(1):", err)
+	}
+	if got := p.Struct().Uint64(0); got != 42 {
+		t.Errorf("structs.PtrAt(1).Struct().Uint64(0) = %d; want 42", got)
+	}
+
+	texts, err := NewTextList(seg, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := texts.Set(0, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	p, err = texts.PtrAt(0)
+	if err != nil {
+		t.Fatal("texts.PtrAt(0):", err)
+	}
+	if got := p.Text(); got != "foo" {
+		t.Errorf("texts.PtrAt(0).Text() = %q; want \"foo\"", got)
+	}
+	if p, err := texts.PtrAt(1); err != nil || p.IsValid() {
+		t.Errorf("texts.PtrAt(1) = %v, %v; want null pointer, <nil>", p, err)
+	}
+
+	ints, err := NewUInt32List(seg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ints.PtrAt(0); err == nil {
+		t.Error("ints.PtrAt(0) succeeded; want error")
+	}
+	bits, err := NewBitList(seg, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bits.PtrAt(0); err == nil {
+		t.Error("bits.PtrAt(0) succeeded; want error")
+	}
+}
+
+func TestPointerListNestedLists(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := [][]uint32{{1, 2, 3}, {}, nil, {4}}
+	outer, err := NewPointerList(seg, int32(len(rows)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows {
+		if row == nil {
+			continue
+		}
+		inner, err := NewUInt32List(seg, int32(len(row)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, v := range row {
+			inner.Set(j, v)
+		}
+		if err := outer.SetList(i, inner.List); err != nil {
+			t.Fatalf("outer.SetList(%d, ...): %v", i, err)
+		}
+	}
+
+	for i, row := range rows {
+		l, err := outer.ListAt(i)
+		if err != nil {
+			t.Errorf("outer.ListAt(%d): %v", i, err)
+			continue
+		}
+		if row == nil {
+			if l.IsValid() {
+				t.Errorf("outer.ListAt(%d) is valid; want null", i)
+			}
+			continue
+		}
+		if !l.IsValid() {
+			t.Errorf("outer.ListAt(%d) is null; want %v", i, row)
+			continue
+		}
+		inner := UInt32List{l}
+		if inner.Len() != len(row) {
+			t.Errorf("outer.ListAt(%d).Len() = %d; want %d", i, inner.Len(), len(row))
+			continue
+		}
+		for j, want := range row {
+			if got := inner.At(j); got != want {
+				t.Errorf("outer.ListAt(%d).At(%d) = %d; want %d", i, j, got, want)
+			}
+		}
+	}
+
+	// Setting an invalid list clears the element.
+	if err := outer.SetList(0, List{}); err != nil {
+		t.Fatal("outer.SetList(0, List{}):", err)
+	}
+	if l, err := outer.ListAt(0); err != nil || l.IsValid() {
+		t.Errorf("outer.ListAt(0) after clearing = %v, %v; want null, <nil>", l, err)
+	}
+}
+
 func TestListRaw(t *testing.T) {
 	_, seg, err := NewMessage(SingleSegment(nil))
 	if err != nil {
@@ -128,3 +244,144 @@ func TestListRaw(t *testing.T) {
 		}
 	}
 }
+
+func TestListSlice(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Int8", func(t *testing.T) {
+		l, err := NewInt8List(seg, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range []int8{1, -2, 3} {
+			l.Set(i, v)
+		}
+		s, err := l.Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 3 || s[0] != 1 || s[1] != -2 || s[2] != 3 {
+			t.Fatalf("Slice() = %v; want [1 -2 3]", s)
+		}
+		s[1] = 42
+		if got := l.At(1); got != 42 {
+			t.Errorf("after writing to slice, l.At(1) = %d; want 42", got)
+		}
+	})
+	t.Run("Int32", func(t *testing.T) {
+		l, err := NewInt32List(seg, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range []int32{1, -2, 1 << 30} {
+			l.Set(i, v)
+		}
+		s, err := l.Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 3 || s[0] != 1 || s[1] != -2 || s[2] != 1<<30 {
+			t.Fatalf("Slice() = %v; want [1 -2 %d]", s, 1<<30)
+		}
+		s[1] = 42
+		want := int32(42)
+		if !hostLittleEndian {
+			want = -2
+		}
+		if got := l.At(1); got != want {
+			t.Errorf("after writing to slice, l.At(1) = %d; want %d", got, want)
+		}
+	})
+	t.Run("Float64", func(t *testing.T) {
+		l, err := NewFloat64List(seg, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Set(0, 3.5)
+		l.Set(1, -1)
+		s, err := l.Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 2 || s[0] != 3.5 || s[1] != -1 {
+			t.Fatalf("Slice() = %v; want [3.5 -1]", s)
+		}
+	})
+	t.Run("Empty", func(t *testing.T) {
+		l, err := NewUInt64List(seg, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := l.Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 0 {
+			t.Errorf("Slice() = %v; want []", s)
+		}
+	})
+	t.Run("Composite", func(t *testing.T) {
+		// A list of structs whose first field is a UInt16 can be read
+		// as a UInt16 list, but not aliased.
+		cl, err := NewCompositeList(seg, ObjectSize{DataSize: 8}, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cl.Struct(0).SetUint16(0, 7)
+		cl.Struct(1).SetUint16(0, 8)
+		l := UInt16List{cl}
+		s, err := l.Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 2 || s[0] != 7 || s[1] != 8 {
+			t.Fatalf("Slice() = %v; want [7 8]", s)
+		}
+		s[0] = 42
+		if got := l.At(0); got != 7 {
+			t.Errorf("after writing to copied slice, l.At(0) = %d; want 7", got)
+		}
+	})
+	t.Run("Misaligned", func(t *testing.T) {
+		msg, seg, err := NewMessage(SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := NewUInt32List(seg, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Set(0, 0xdeadbeef)
+		l.Set(1, 0xcafe)
+		if err := msg.SetRoot(l.ToPtr()); err != nil {
+			t.Fatal(err)
+		}
+		// Copy the segment to an odd address.
+		buf := make([]byte, len(seg.Data())+1)[1:]
+		copy(buf, seg.Data())
+		rmsg := &Message{Arena: SingleSegment(buf)}
+		p, err := rmsg.Root()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := UInt32List{p.List()}.Slice()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s) != 2 || s[0] != 0xdeadbeef || s[1] != 0xcafe {
+			t.Errorf("Slice() = %#x; want [0xdeadbeef 0xcafe]", s)
+		}
+	})
+	t.Run("BitList", func(t *testing.T) {
+		bl, err := NewBitList(seg, 8)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (UInt8List{bl.List}).Slice(); err == nil {
+			t.Error("Slice() on a bit list did not return an error")
+		}
+	})
+}
@@ -58,6 +58,23 @@ type Message struct {
 	// If not set, this defaults to 64.
 	DepthLimit uint
 
+	// MarshalDepthLimit, if non-zero, makes Marshal and WriteTo walk the
+	// message and return ErrDepthLimit if it is nested more deeply than
+	// the limit.  This catches structures that a reader using the same
+	// DepthLimit would reject.  The walk does not count toward the read
+	// limit.
+	MarshalDepthLimit uint
+
+	// pooledBuf is the buffer that a Decoder with PoolBuffers set read
+	// the message into.  Release returns it to readBufferPool.
+	pooledBuf *[]byte
+
+	// copies, if not nil, maps the objects copied into the message from
+	// another message to their copies, so that an object reached through
+	// more than one pointer is copied once.  GC sets it on the message
+	// that it copies into.
+	copies map[copyKey]Ptr
+
 	// mu protects the following fields:
 	mu       sync.Mutex
 	segs     map[SegmentID]*Segment
@@ -67,10 +84,32 @@ type Message struct {
 // NewMessage creates a message with a new root and returns the first
 // segment.  It is an error to call NewMessage on an arena with data in it.
 func NewMessage(arena Arena) (msg *Message, first *Segment, err error) {
+	return newMessage(arena, wordSize)
+}
+
+// NewMessageWithSizeHint is like NewMessage, but asks the arena to make
+// room for at least words words in the first segment up front.  When
+// the final size of a message is known in advance, this avoids growing
+// the segment while building it.  The hint includes the root pointer.
+// If the arena already has an empty first segment that is too small,
+// SingleSegment, MultiSegment and pooled arenas replace it with a
+// larger one; other arenas keep their first segment and the hint has
+// no effect.
+func NewMessageWithSizeHint(arena Arena, words int) (msg *Message, first *Segment, err error) {
+	if words < 1 {
+		words = 1
+	}
+	if int64(words) > int64(maxSegmentSize/wordSize) {
+		return nil, nil, newError("new message: size hint too large")
+	}
+	return newMessage(arena, wordSize.timesUnchecked(int32(words)))
+}
+
+func newMessage(arena Arena, hint Size) (msg *Message, first *Segment, err error) {
 	msg = &Message{Arena: arena}
 	switch arena.NumSegments() {
 	case 0:
-		first, err = msg.allocSegment(wordSize)
+		first, err = msg.allocSegment(hint)
 		if err != nil {
 			return nil, nil, annotate(err).errorf("new message")
 		}
@@ -82,6 +121,23 @@ func NewMessage(arena Arena) (msg *Message, first *Segment, err error) {
 		if len(first.data) > 0 {
 			return nil, nil, newError("new message: arena not empty")
 		}
+		if !hasCapacity(first.data, hint) {
+			switch a := arena.(type) {
+			case *singleSegmentArena, *PooledArena:
+				// Grows the first segment in place.
+				if _, err := msg.allocSegment(hint); err != nil {
+					return nil, nil, annotate(err).errorf("new message")
+				}
+			case *multiSegmentArena:
+				// Allocating would add a second segment, so replace the
+				// first segment's buffer instead.
+				(*a)[0] = make([]byte, 0, int(hint))
+				first.data = (*a)[0]
+			case *growthArena:
+				a.multiSegmentArena[0] = make([]byte, 0, int(hint))
+				first.data = a.multiSegmentArena[0]
+			}
+		}
 	default:
 		return nil, nil, newError("new message: arena not empty")
 	}
@@ -109,6 +165,7 @@ func (m *Message) Reset(arena Arena) {
 	m.mu.Unlock()
 
 	m.Arena = arena
+	m.pooledBuf = nil
 	for _, c := range m.CapTable {
 		c.Release()
 	}
@@ -117,6 +174,30 @@ func (m *Message) Reset(arena Arena) {
 	m.initReadLimit()
 }
 
+// Release resets the message to an empty one, like Reset(nil), and
+// returns its buffers to their pools: the buffer that a Decoder with
+// PoolBuffers read it into, and its arena if it is a PooledArena.
+//
+// Release ends the lifetime of everything read from the message.  No
+// Ptr, Struct, List, or other value obtained from it may be used
+// afterward, since the memory it points to is handed to another
+// message; copy anything that must outlive the message first.  Clients
+// in the capability table are released, so take another reference to
+// keep one.  When the race detector is on, pooled buffers are
+// overwritten with garbage instead of being reused, so that such
+// mistakes show up as corrupted data.  It is safe to call Release more
+// than once.
+func (m *Message) Release() {
+	arena, buf := m.Arena, m.pooledBuf
+	m.Reset(nil)
+	if pa, ok := arena.(*PooledArena); ok {
+		pa.Release()
+	}
+	if buf != nil {
+		putReadBuffer(buf)
+	}
+}
+
 func (m *Message) initReadLimit() {
 	if m.TraverseLimit == 0 {
 		atomic.StoreUint64(&m.rlimit, defaultTraverseLimit)
@@ -149,6 +230,13 @@ func (m *Message) ResetReadLimit(limit uint64) {
 	atomic.StoreUint64(&m.rlimit, limit)
 }
 
+// readLimit returns the number of bytes that can still be read from
+// this message.
+func (m *Message) readLimit() uint64 {
+	m.rlimitInit.Do(m.initReadLimit)
+	return atomic.LoadUint64(&m.rlimit)
+}
+
 // Unread increases the read limit by sz.
 func (m *Message) Unread(sz Size) {
 	m.rlimitInit.Do(m.initReadLimit)
@@ -168,8 +256,12 @@ func (m *Message) Root() (Ptr, error) {
 	return p, nil
 }
 
-// SetRoot sets the message's root object to p.
+// SetRoot sets the message's root object to p.  It returns an error if
+// p belongs to a different message.
 func (m *Message) SetRoot(p Ptr) error {
+	if p.IsValid() && p.seg.msg != m {
+		return newError("set root: pointer belongs to a different message")
+	}
 	s, err := m.Segment(0)
 	if err != nil {
 		return annotate(err).errorf("set root")
@@ -180,9 +272,17 @@ func (m *Message) SetRoot(p Ptr) error {
 	return nil
 }
 
+// SetRootStruct sets the message's root object to s.  It returns an
+// error if s belongs to a different message.
+func (m *Message) SetRootStruct(s Struct) error {
+	return m.SetRoot(s.ToPtr())
+}
+
 // AddCap appends a capability to the message's capability table and
 // returns its ID.  It "steals" c's reference: the Message will release
-// the client when calling Reset.
+// the client when calling Reset.  IDs are assigned in the order that
+// AddCap is called; see NormalizeCapTable for an order that depends
+// only on the message's contents.
 func (m *Message) AddCap(c *Client) CapabilityID {
 	n := CapabilityID(len(m.CapTable))
 	m.CapTable = append(m.CapTable, c)
@@ -196,7 +296,9 @@ func (m *Message) depthLimit() uint {
 	return defaultDepthLimit
 }
 
-// NumSegments returns the number of segments in the message.
+// NumSegments returns the number of segments in the message.  It is
+// read directly from the arena without loading any segments, so it is
+// cheap to call.
 func (m *Message) NumSegments() int64 {
 	return int64(m.Arena.NumSegments())
 }
@@ -395,6 +497,46 @@ func (ssa *singleSegmentArena) String() string {
 	return fmt.Sprintf("single-segment arena [len=%d cap=%d]", len(*ssa), cap(*ssa))
 }
 
+// A PooledArena is a single-segment arena whose buffer is reused by
+// later messages.  Arenas are taken from a pool shared by the package
+// with NewPooledArena and returned to it with Release, which avoids
+// allocating a new buffer for every short-lived message.
+type PooledArena struct {
+	singleSegmentArena
+}
+
+// maxPooledSize is the largest buffer capacity that Release keeps for
+// reuse.  Larger buffers are left to the garbage collector, so that a
+// few big messages do not pin memory in the pool.
+const maxPooledSize = 64 * 1024
+
+var arenaPool = sync.Pool{
+	New: func() interface{} { return new(PooledArena) },
+}
+
+// NewPooledArena returns an empty PooledArena from the pool.  Its
+// buffer is zeroed, as allocating objects requires.
+func NewPooledArena() *PooledArena {
+	return arenaPool.Get().(*PooledArena)
+}
+
+// Release zeroes pa's buffer and returns pa to the pool.  The message
+// using pa must be Reset to another arena (or nil) first, and neither
+// pa nor any object read from its message may be used afterward.
+func (pa *PooledArena) Release() {
+	b := []byte(pa.singleSegmentArena)
+	if cap(b) > maxPooledSize {
+		pa.singleSegmentArena = nil
+		return
+	}
+	b = b[:cap(b)]
+	for i := range b {
+		b[i] = 0
+	}
+	pa.singleSegmentArena = b[:0]
+	arenaPool.Put(pa)
+}
+
 type roSingleSegment []byte
 
 func (ss roSingleSegment) NumSegments() int64 {
@@ -416,6 +558,27 @@ func (ss roSingleSegment) String() string {
 	return fmt.Sprintf("read-only single-segment arena [len=%d]", len(ss))
 }
 
+type roMultiSegment [][]byte
+
+func (ms roMultiSegment) NumSegments() int64 {
+	return int64(len(ms))
+}
+
+func (ms roMultiSegment) Data(id SegmentID) ([]byte, error) {
+	if int64(id) >= int64(len(ms)) {
+		return nil, errorf("segment %d requested (arena only has %d segments)", id, len(ms))
+	}
+	return ms[id], nil
+}
+
+func (ms roMultiSegment) Allocate(sz Size, segs map[SegmentID]*Segment) (SegmentID, []byte, error) {
+	return 0, nil, newError("arena is read-only")
+}
+
+func (ms roMultiSegment) String() string {
+	return fmt.Sprintf("read-only multi-segment arena [%d segments]", len(ms))
+}
+
 type multiSegmentArena [][]byte
 
 // MultiSegment returns a new arena that allocates new segments when
@@ -457,6 +620,12 @@ func (msa *multiSegmentArena) Data(id SegmentID) ([]byte, error) {
 }
 
 func (msa *multiSegmentArena) Allocate(sz Size, segs map[SegmentID]*Segment) (SegmentID, []byte, error) {
+	return msa.allocate(sz, segs, nil)
+}
+
+// allocate implements Allocate, sizing new segments with grow, or with
+// nextAlloc if grow is nil.
+func (msa *multiSegmentArena) allocate(sz Size, segs map[SegmentID]*Segment, grow SegmentGrowth) (SegmentID, []byte, error) {
 	var total int64
 	for i, data := range *msa {
 		id := SegmentID(i)
@@ -472,7 +641,13 @@ func (msa *multiSegmentArena) Allocate(sz Size, segs map[SegmentID]*Segment) (Se
 			return 0, nil, errorf("alloc %d bytes: message too large", sz)
 		}
 	}
-	n, err := nextAlloc(total, 1<<63-1, sz)
+	var n int
+	var err error
+	if grow == nil {
+		n, err = nextAlloc(total, 1<<63-1, sz)
+	} else {
+		n, err = growAlloc(grow, total, sz)
+	}
 	if err != nil {
 		return 0, nil, err
 	}
@@ -486,6 +661,78 @@ func (msa *multiSegmentArena) String() string {
 	return fmt.Sprintf("multi-segment arena [%d segments]", len(*msa))
 }
 
+// A SegmentGrowth decides the size in bytes of each new segment that a
+// MultiSegmentWithGrowth arena allocates.  total is the combined
+// capacity of the arena's existing segments and req is the size of the
+// object that did not fit in any of them.  The arena rounds the size up
+// to a whole word and to at least req.
+type SegmentGrowth func(total int64, req Size) int
+
+// MultiSegmentWithGrowth is like MultiSegment, but sizes new segments
+// with grow instead of growing the message by about a quarter each
+// time.  Allocating new segments instead of copying into larger ones
+// avoids reallocating data for large messages, at the cost of far
+// pointers between segments.
+func MultiSegmentWithGrowth(b [][]byte, grow SegmentGrowth) Arena {
+	return &growthArena{multiSegmentArena: b, grow: grow}
+}
+
+// FixedSegmentGrowth returns a SegmentGrowth that allocates segments of
+// size bytes.  Objects larger than size get a segment of their own.
+func FixedSegmentGrowth(size int) SegmentGrowth {
+	return func(total int64, req Size) int {
+		return size
+	}
+}
+
+// DoublingSegmentGrowth returns a SegmentGrowth that allocates a first
+// segment of first bytes, then segments as large as all the previous
+// ones combined, so that the arena doubles each time, but never more
+// than max bytes.
+func DoublingSegmentGrowth(first, max int) SegmentGrowth {
+	return func(total int64, req Size) int {
+		switch {
+		case total == 0:
+			return first
+		case total > int64(max):
+			return max
+		default:
+			return int(total)
+		}
+	}
+}
+
+type growthArena struct {
+	multiSegmentArena
+	grow SegmentGrowth
+}
+
+func (ga *growthArena) Allocate(sz Size, segs map[SegmentID]*Segment) (SegmentID, []byte, error) {
+	return ga.multiSegmentArena.allocate(sz, segs, ga.grow)
+}
+
+// growAlloc returns the size of a new segment chosen by grow, given
+// the number of bytes allocated in the entire message and the requested
+// number of bytes.  It will always return a multiple of wordSize that
+// is at least the padded request.
+func growAlloc(grow SegmentGrowth, total int64, req Size) (int, error) {
+	if req > maxAllocSize() {
+		return 0, errorf("alloc %v: too large", req)
+	}
+	padreq := int64(req.padToWord())
+	n := (int64(grow(total, req)) + 7) &^ 7
+	if n < padreq {
+		n = padreq
+	}
+	if n > int64(maxAllocSize()) {
+		n = int64(maxAllocSize())
+	}
+	if total+n < total {
+		return 0, errorf("alloc %v: message size overflow", req)
+	}
+	return int(n), nil
+}
+
 // nextAlloc computes how much more space to allocate given the number
 // of bytes allocated in the entire message and the requested number of
 // bytes.  It will always return a multiple of wordSize.  max must be a
@@ -542,8 +789,11 @@ type Decoder struct {
 	msg   Message
 	arena roSingleSegment
 
-	// Maximum number of bytes that can be read per call to Decode.
-	// If not set, a reasonable default is used.
+	pool bool
+
+	// Maximum number of bytes that can be read per call to Decode,
+	// counting the stream header.  Decode returns ErrMessageTooLarge
+	// for a larger message.  If not set, a reasonable default is used.
 	MaxMessageSize uint64
 }
 
@@ -561,8 +811,10 @@ func NewPackedDecoder(r io.Reader) *Decoder {
 	return NewDecoder(packed.NewReader(bufio.NewReader(r)))
 }
 
-// Decode reads a message from the decoder stream.  The error is io.EOF
-// only if no bytes were read.
+// Decode reads a message from the decoder stream.  It may be called
+// repeatedly to read a stream of concatenated messages.  The error is
+// io.EOF only if no bytes were read; if the stream ends in the middle
+// of a message, then errors.Is(err, io.ErrUnexpectedEOF) reports true.
 func (d *Decoder) Decode() (*Message, error) {
 	maxSize := d.MaxMessageSize
 	if maxSize == 0 {
@@ -576,7 +828,7 @@ func (d *Decoder) Decode() (*Message, error) {
 	if _, err := io.ReadFull(d.r, d.wordbuf[:]); err == io.EOF {
 		return nil, io.EOF
 	} else if err != nil {
-		return nil, errorf("decode: read header: %v", err)
+		return nil, annotate(err).errorf("decode: read header")
 	}
 	maxSeg := SegmentID(binary.LittleEndian.Uint32(d.wordbuf[:]))
 	if maxSeg > maxStreamSegments {
@@ -590,12 +842,12 @@ func (d *Decoder) Decode() (*Message, error) {
 	} else {
 		hdrSize := streamHeaderSize(maxSeg)
 		if hdrSize > maxSize || hdrSize > uint64(maxInt) {
-			return nil, newError("decode: message too large")
+			return nil, ErrMessageTooLarge
 		}
 		d.hdrbuf = resizeSlice(d.hdrbuf, int(hdrSize))
 		copy(d.hdrbuf, d.wordbuf[:])
-		if _, err := io.ReadFull(d.r, d.hdrbuf[len(d.wordbuf):]); err != nil {
-			return nil, errorf("decode: read header: %v", err)
+		if err := d.readRest(d.hdrbuf[len(d.wordbuf):]); err != nil {
+			return nil, annotate(err).errorf("decode: read header")
 		}
 		hdr = streamHeader{d.hdrbuf}
 	}
@@ -606,14 +858,27 @@ func (d *Decoder) Decode() (*Message, error) {
 	// TODO(someday): if total size is greater than can fit in one buffer,
 	// attempt to allocate buffer per segment.
 	if total > maxSize-uint64(len(hdr.b)) || total > uint64(maxInt) {
-		return nil, newError("decode: message too large")
+		return nil, ErrMessageTooLarge
 	}
 
 	// Read segments.
+	if !d.reuse && d.pool {
+		bp := getReadBuffer(int(total))
+		if err := d.readRest(*bp); err != nil {
+			putReadBuffer(bp)
+			return nil, annotate(err).errorf("decode: read segments")
+		}
+		arena, err := demuxArena(hdr, *bp)
+		if err != nil {
+			putReadBuffer(bp)
+			return nil, annotate(err).errorf("decode")
+		}
+		return &Message{Arena: arena, pooledBuf: bp}, nil
+	}
 	if !d.reuse {
 		buf := make([]byte, int(total))
-		if _, err := io.ReadFull(d.r, buf); err != nil {
-			return nil, errorf("decode: read segments: %v", err)
+		if err := d.readRest(buf); err != nil {
+			return nil, annotate(err).errorf("decode: read segments")
 		}
 		arena, err := demuxArena(hdr, buf)
 		if err != nil {
@@ -622,8 +887,8 @@ func (d *Decoder) Decode() (*Message, error) {
 		return &Message{Arena: arena}, nil
 	}
 	d.buf = resizeSlice(d.buf, int(total))
-	if _, err := io.ReadFull(d.r, d.buf); err != nil {
-		return nil, errorf("decode: read segments: %v", err)
+	if err := d.readRest(d.buf); err != nil {
+		return nil, annotate(err).errorf("decode: read segments")
 	}
 	var arena Arena
 	if maxSeg == 0 {
@@ -640,6 +905,67 @@ func (d *Decoder) Decode() (*Message, error) {
 	return &d.msg, nil
 }
 
+// readRest fills b with the remainder of a message whose first word
+// has already been read, so running out of input is always
+// io.ErrUnexpectedEOF.
+func (d *Decoder) readRest(b []byte) error {
+	_, err := io.ReadFull(d.r, b)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readBufferPool holds buffers for a Decoder with PoolBuffers set.
+var readBufferPool sync.Pool
+
+// getReadBuffer returns a buffer of n bytes from readBufferPool.  Its
+// contents are unspecified.
+func getReadBuffer(n int) *[]byte {
+	bp, _ := readBufferPool.Get().(*[]byte)
+	if bp == nil || cap(*bp) < n {
+		b := make([]byte, n)
+		return &b
+	}
+	*bp = (*bp)[:n]
+	return bp
+}
+
+// putReadBuffer returns a buffer to readBufferPool, or poisons it if the
+// race detector is on.  Buffers larger than maxPooledSize are dropped.
+func putReadBuffer(bp *[]byte) {
+	if raceEnabled {
+		b := *bp
+		for i := range b {
+			b[i] = 0xa5
+		}
+		return
+	}
+	if cap(*bp) > maxPooledSize {
+		return
+	}
+	readBufferPool.Put(bp)
+}
+
+// DecodeAll reads messages from r until EOF.  If r ends in the middle
+// of a message, then DecodeAll returns the messages decoded before it
+// along with an error.  An empty stream returns no messages and a nil
+// error.
+func DecodeAll(r io.Reader) ([]*Message, error) {
+	dec := NewDecoder(r)
+	msgs := []*Message{}
+	for {
+		msg, err := dec.Decode()
+		if err == io.EOF {
+			return msgs, nil
+		}
+		if err != nil {
+			return msgs, annotate(err).errorf("decode message %d", len(msgs))
+		}
+		msgs = append(msgs, msg)
+	}
+}
+
 func resizeSlice(b []byte, size int) []byte {
 	if cap(b) < size {
 		return make([]byte, size)
@@ -647,6 +973,15 @@ func resizeSlice(b []byte, size int) []byte {
 	return b[:size]
 }
 
+// PoolBuffers causes the decoder to read messages into buffers taken
+// from a pool shared by the package.  Each message returned by Decode
+// must be released with Message.Release once it is no longer needed,
+// which returns its buffer to the pool.  A message that is not released
+// is simply garbage collected.  ReuseBuffer takes precedence.
+func (d *Decoder) PoolBuffers() {
+	d.pool = true
+}
+
 // ReuseBuffer causes the decoder to reuse its buffer on subsequent decodes.
 // The decoder may return messages that cannot handle allocations.
 func (d *Decoder) ReuseBuffer() {
@@ -782,6 +1117,28 @@ func (e *Encoder) writePacked(bufs [][]byte) error {
 	return nil
 }
 
+// TotalSize returns the number of bytes that Marshal or WriteTo would
+// produce for the message: the stream header followed by every
+// segment.  Capabilities in m.CapTable are not part of the encoding, so
+// they are not counted.
+func (m *Message) TotalSize() (uint64, error) {
+	nsegs := m.NumSegments()
+	if nsegs == 0 {
+		return 0, newError("total size: message has no segments")
+	}
+	sz := streamHeaderSize(SegmentID(nsegs - 1))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := int64(0); i < nsegs; i++ {
+		s, err := m.segment(SegmentID(i))
+		if err != nil {
+			return 0, annotate(err).errorf("total size")
+		}
+		sz += uint64(len(s.data))
+	}
+	return sz, nil
+}
+
 // Marshal concatenates the segments in the message into a single byte
 // slice including framing.
 func (m *Message) Marshal() ([]byte, error) {
@@ -790,6 +1147,41 @@ func (m *Message) Marshal() ([]byte, error) {
 	if nsegs == 0 {
 		return nil, newError("marshal: message has no segments")
 	}
+	if err := m.checkMarshalDepth(); err != nil {
+		return nil, err
+	}
+	if nsegs == 1 {
+		return m.marshalSingleSegment()
+	}
+	return m.marshalSegments(nsegs)
+}
+
+// marshalSingleSegment is the fast path of Marshal for a message with
+// exactly one segment, which has a fixed one-word header.
+func (m *Message) marshalSingleSegment() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, err := m.segment(0)
+	if err != nil {
+		return nil, annotate(err).errorf("marshal")
+	}
+	n := len(s.data)
+	if n%int(wordSize) != 0 {
+		return nil, newError("marshal: segment 0 not word-aligned")
+	}
+	if uint64(n) > uint64(maxSegmentSize) {
+		return nil, newError("marshal: segment 0 too large")
+	}
+	if n > maxInt-int(wordSize) {
+		return nil, newError("marshal: message size overflows int")
+	}
+	buf := make([]byte, int(wordSize), int(wordSize)+n)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(n/int(wordSize)))
+	return append(buf, s.data...), nil
+}
+
+// marshalSegments marshals a message with any number of segments.
+func (m *Message) marshalSegments(nsegs int64) ([]byte, error) {
 	hdrSize := streamHeaderSize(SegmentID(nsegs - 1))
 	if hdrSize > uint64(maxInt) {
 		return nil, newError("marshal: header size overflows int")
@@ -843,6 +1235,342 @@ func (m *Message) Marshal() ([]byte, error) {
 	return buf, nil
 }
 
+// WriteTo writes the message to w in the same format as Marshal.  Unlike
+// Marshal, it writes the header and each segment to w in turn, without
+// first concatenating them into a single buffer.  It implements
+// io.WriterTo.
+func (m *Message) WriteTo(w io.Writer) (int64, error) {
+	nsegs := m.NumSegments()
+	if nsegs == 0 {
+		return 0, newError("write message: message has no segments")
+	}
+	if err := m.checkMarshalDepth(); err != nil {
+		return 0, err
+	}
+	maxSeg := SegmentID(nsegs - 1)
+	hdrSize := streamHeaderSize(maxSeg)
+	if hdrSize > uint64(maxInt) {
+		return 0, newError("write message: header size overflows int")
+	}
+	hdr := make([]byte, 0, int(hdrSize))
+	hdr = appendUint32(hdr, uint32(maxSeg))
+	segs := make([][]byte, 0, int(nsegs))
+	for i := int64(0); i < nsegs; i++ {
+		s, err := m.Segment(SegmentID(i))
+		if err != nil {
+			return 0, annotate(err).errorf("write message")
+		}
+		n := len(s.data)
+		if n%int(wordSize) != 0 {
+			return 0, errorf("write message: segment %d not word-aligned", i)
+		}
+		if n > int(maxSegmentSize) {
+			return 0, errorf("write message: segment %d too large", i)
+		}
+		hdr = appendUint32(hdr, uint32(Size(n)/wordSize))
+		segs = append(segs, s.data)
+	}
+	if len(hdr)%int(wordSize) != 0 {
+		hdr = appendUint32(hdr, 0)
+	}
+
+	total, err := writeFull(w, hdr)
+	if err != nil {
+		return total, errorf("write message: %v", err)
+	}
+	for _, b := range segs {
+		n, err := writeFull(w, b)
+		total += n
+		if err != nil {
+			return total, errorf("write message: %v", err)
+		}
+	}
+	return total, nil
+}
+
+// writeFull writes b to w, returning io.ErrShortWrite if w accepts
+// fewer than len(b) bytes without reporting an error.
+func writeFull(w io.Writer, b []byte) (int64, error) {
+	n, err := w.Write(b)
+	if err == nil && n < len(b) {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
+// checkMarshalDepth returns ErrDepthLimit if the message is nested more
+// deeply than m.MarshalDepthLimit.
+func (m *Message) checkMarshalDepth() error {
+	if m.MarshalDepthLimit == 0 {
+		return nil
+	}
+	s, err := m.Segment(0)
+	if err != nil {
+		return annotate(err).errorf("marshal")
+	}
+	defer m.ResetReadLimit(m.readLimit())
+	root, err := s.readPtr(0, m.MarshalDepthLimit)
+	if err != nil {
+		return annotate(err).errorf("marshal")
+	}
+	return checkDepth(root)
+}
+
+// checkDepth returns ErrDepthLimit if any pointer reachable from p
+// cannot be read within p's depth limit.
+func checkDepth(p Ptr) error {
+	err := Walk(p, func(Ptr) error { return nil })
+	if err == errDepthLimitReached {
+		return ErrDepthLimit
+	}
+	if err != nil {
+		return annotate(err).errorf("marshal")
+	}
+	return nil
+}
+
+// ValidateForSend checks that every object reachable from the message's
+// root can be read and that every interface pointer refers to an entry
+// in m.CapTable, returning an error describing the first problem found.
+// It is intended to catch bugs in code that builds messages before the
+// message is sent to another vat.  The traversal does not count toward
+// the message's read limit.
+func (m *Message) ValidateForSend() error {
+	defer m.ResetReadLimit(m.readLimit())
+	root, err := m.Root()
+	if err != nil {
+		return annotate(err).errorf("validate")
+	}
+	if err := validatePtr(root, len(m.CapTable)); err != nil {
+		return annotate(err).errorf("validate")
+	}
+	return nil
+}
+
+// validatePtr checks the object tree rooted at p for a message with
+// ncaps capabilities.
+func validatePtr(p Ptr, ncaps int) error {
+	return Walk(p, func(q Ptr) error {
+		if q.flags.ptrType() != interfacePtrType {
+			return nil
+		}
+		if id := q.Interface().Capability(); int64(id) >= int64(ncaps) {
+			return errorf("interface pointer references capability %d, but cap table has %d entries", id, ncaps)
+		}
+		return nil
+	})
+}
+
+// CapCount returns the number of non-nil entries in m.CapTable.
+func (m *Message) CapCount() int {
+	n := 0
+	for _, c := range m.CapTable {
+		if c != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// ReachableCapCount returns the number of distinct capabilities that
+// interface pointers in the object tree rooted at root refer to.  The
+// traversal does not count toward the message's read limit.
+func ReachableCapCount(root Ptr) (int, error) {
+	m := root.Message()
+	if m == nil {
+		return 0, nil
+	}
+	defer m.ResetReadLimit(m.readLimit())
+	caps := make(map[CapabilityID]struct{})
+	if err := collectCaps(root, caps); err != nil {
+		return 0, annotate(err).errorf("count capabilities")
+	}
+	return len(caps), nil
+}
+
+// collectCaps adds the capability IDs referenced in the object tree
+// rooted at p to caps.
+func collectCaps(p Ptr, caps map[CapabilityID]struct{}) error {
+	return Walk(p, func(q Ptr) error {
+		if q.flags.ptrType() == interfacePtrType {
+			caps[q.Interface().Capability()] = struct{}{}
+		}
+		return nil
+	})
+}
+
+// NormalizeCapTable renumbers m's capability table in the order that
+// interface pointers are first reached by a depth-first traversal from
+// the root, visiting the pointers of each struct and list in order.
+// Entries that no interface pointer refers to are moved to the end,
+// keeping their relative order.  Afterward, messages with the same
+// contents and capabilities have the same capability table no matter
+// the order the capabilities were added in, which is useful for
+// comparing messages in tests.  The traversal does not count toward the
+// message's read limit.  On error, m is not modified.
+func (m *Message) NormalizeCapTable() error {
+	defer m.ResetReadLimit(m.readLimit())
+	seg, err := m.Segment(0)
+	if err != nil {
+		return annotate(err).errorf("normalize cap table")
+	}
+	root, err := m.Root()
+	if err != nil {
+		return annotate(err).errorf("normalize cap table")
+	}
+	refs, err := collectCapRefs(nil, seg, 0, root)
+	if err != nil {
+		return annotate(err).errorf("normalize cap table")
+	}
+	newIDs := make(map[CapabilityID]CapabilityID)
+	table := make([]*Client, 0, len(m.CapTable))
+	for _, r := range refs {
+		if int64(r.id) >= int64(len(m.CapTable)) {
+			return errorf("normalize cap table: interface pointer references capability %d, but cap table has %d entries", r.id, len(m.CapTable))
+		}
+		if _, ok := newIDs[r.id]; !ok {
+			newIDs[r.id] = CapabilityID(len(table))
+			table = append(table, m.CapTable[r.id])
+		}
+	}
+	for i, c := range m.CapTable {
+		if _, ok := newIDs[CapabilityID(i)]; !ok {
+			table = append(table, c)
+		}
+	}
+	for _, r := range refs {
+		r.seg.writeRawPointer(r.addr, rawInterfacePointer(newIDs[r.id]))
+	}
+	m.CapTable = table
+	return nil
+}
+
+// A capRef is the location of an interface pointer.
+type capRef struct {
+	seg  *Segment
+	addr address
+	id   CapabilityID
+}
+
+// collectCapRefs appends the interface pointers in the object tree
+// rooted at p, which is stored at addr in seg, to refs.
+func collectCapRefs(refs []capRef, seg *Segment, addr address, p Ptr) ([]capRef, error) {
+	if p.flags.ptrType() == interfacePtrType {
+		return append(refs, capRef{seg: seg, addr: addr, id: p.Interface().Capability()}), nil
+	}
+	err := eachChildPtr(p, func(qaddr address, q Ptr) error {
+		var err error
+		refs, err = collectCapRefs(refs, p.seg, qaddr, q)
+		return err
+	})
+	return refs, err
+}
+
+// GC compacts m by copying the objects reachable from its root and
+// dropping the objects that are no longer reachable, like the old value
+// of a pointer field that has been set again.  An object reached through more than one pointer, as with
+// Struct.SharePtr, is copied once and stays shared.
+//
+// A SingleSegment arena or a PooledArena is kept: the objects are
+// compacted back into its buffer.  A MultiSegmentWithGrowth arena is
+// replaced by a new one with the same SegmentGrowth, and any other
+// arena by a new MultiSegment arena.  The capability table is not
+// changed.  Like Reset, this invalidates any existing pointers in the
+// Message: get them again from m.Root.  The traversal does not count
+// toward the message's read limit.  On error, m is not modified.
+func (m *Message) GC() error {
+	defer m.ResetReadLimit(m.readLimit())
+	seg, err := m.Segment(0)
+	if err != nil {
+		return annotate(err).errorf("gc")
+	}
+	root, err := m.Root()
+	if err != nil {
+		return annotate(err).errorf("gc")
+	}
+	refs, err := collectCapRefs(nil, seg, 0, root)
+	if err != nil {
+		return annotate(err).errorf("gc")
+	}
+	var ssa *singleSegmentArena
+	var arena Arena
+	switch a := m.Arena.(type) {
+	case *singleSegmentArena:
+		ssa, arena = a, SingleSegment(nil)
+	case *PooledArena:
+		ssa, arena = &a.singleSegmentArena, SingleSegment(nil)
+	case *growthArena:
+		arena = MultiSegmentWithGrowth(nil, a.grow)
+	default:
+		arena = MultiSegment(nil)
+	}
+	out, outSeg, err := NewMessage(arena)
+	if err != nil {
+		return annotate(err).errorf("gc")
+	}
+	out.copies = make(map[copyKey]Ptr)
+	// Copying into out adds a reference to out's capability table for
+	// each interface pointer.  Point them back into m's table instead,
+	// pairing them up by traversal order.
+	defer func() {
+		for _, c := range out.CapTable {
+			c.Release()
+		}
+	}()
+	if err := outSeg.root().Set(0, root); err != nil {
+		return annotate(err).errorf("gc")
+	}
+	outRoot, err := out.Root()
+	if err != nil {
+		return annotate(err).errorf("gc")
+	}
+	outRefs, err := collectCapRefs(nil, outSeg, 0, outRoot)
+	if err != nil {
+		return annotate(err).errorf("gc")
+	}
+	if len(outRefs) != len(refs) {
+		return errorf("gc: copy has %d interface pointers; want %d", len(outRefs), len(refs))
+	}
+	for i, r := range outRefs {
+		r.seg.writeRawPointer(r.addr, rawInterfacePointer(refs[i].id))
+	}
+
+	// The arena's slices may be shorter than the segments built on
+	// them, so carry over the segments' data.
+	data := make([][]byte, out.NumSegments())
+	for i := range data {
+		s, err := out.Segment(SegmentID(i))
+		if err != nil {
+			return annotate(err).errorf("gc")
+		}
+		data[i] = s.data
+	}
+	m.mu.Lock()
+	if ssa != nil {
+		// Compact into m's own buffer, clearing what the dropped objects
+		// leave past its new end.
+		old := seg.data
+		buf := append(old[:0], data[0]...)
+		if len(old) > len(buf) {
+			stale := old[len(buf):]
+			for i := range stale {
+				stale[i] = 0
+			}
+		}
+		*ssa = buf
+		data[0] = buf
+	} else {
+		m.Arena = out.Arena
+	}
+	m.segs = nil
+	m.firstSeg = Segment{}
+	for i, d := range data {
+		m.setSegment(SegmentID(i), d)
+	}
+	m.mu.Unlock()
+	return nil
+}
+
 // MarshalPacked marshals the message in packed form.
 func (m *Message) MarshalPacked() ([]byte, error) {
 	data, err := m.Marshal()
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 	"testing"
 )
 
@@ -48,6 +49,44 @@ func TestNewMessage(t *testing.T) {
 	}
 }
 
+func TestNewMessageWithSizeHint(t *testing.T) {
+	const words = 100
+	tests := []struct {
+		name  string
+		arena Arena
+	}{
+		{"SingleSegment", SingleSegment(nil)},
+		{"SingleSegmentSmallBuffer", SingleSegment(make([]byte, 0, 16))},
+		{"MultiSegment", MultiSegment(nil)},
+		{"MultiSegmentSmallBuffer", MultiSegment([][]byte{make([]byte, 0, 16)})},
+	}
+	for _, test := range tests {
+		msg, seg, err := NewMessageWithSizeHint(test.arena, words)
+		if err != nil {
+			t.Errorf("%s: NewMessageWithSizeHint: %v", test.name, err)
+			continue
+		}
+		if c := cap(seg.data); c < words*int(wordSize) {
+			t.Errorf("%s: cap(first segment) = %d; want >= %d", test.name, c, words*int(wordSize))
+		}
+		start := &seg.data[:1][0]
+		for i := 0; i < words-1; i++ {
+			if _, err := NewStruct(seg, ObjectSize{DataSize: 8}); err != nil {
+				t.Fatalf("%s: NewStruct #%d: %v", test.name, i, err)
+			}
+		}
+		if n := msg.NumSegments(); n != 1 {
+			t.Errorf("%s: NumSegments() = %d; want 1", test.name, n)
+		}
+		if &seg.data[0] != start {
+			t.Errorf("%s: first segment was reallocated while filling size hint", test.name)
+		}
+	}
+	if _, _, err := NewMessageWithSizeHint(SingleSegment(nil), int(maxSegmentSize/wordSize)+1); err == nil {
+		t.Error("NewMessageWithSizeHint with hint larger than a segment succeeded; want error")
+	}
+}
+
 func TestAlloc(t *testing.T) {
 	type allocTest struct {
 		name string
@@ -308,6 +347,140 @@ func TestMultiSegment(t *testing.T) {
 	}
 }
 
+func TestMessageNumSegments(t *testing.T) {
+	single, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStruct(seg, ObjectSize{DataSize: 4096}); err != nil {
+		t.Fatal(err)
+	}
+	if n := single.NumSegments(); n != 1 {
+		t.Errorf("single-segment message NumSegments() = %d; want 1", n)
+	}
+
+	multi, seg, err := NewMessage(MultiSegment([][]byte{make([]byte, 0, 16)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := multi.NumSegments(); n != 1 {
+		t.Errorf("new multi-segment message NumSegments() = %d; want 1", n)
+	}
+	if _, err := NewStruct(seg, ObjectSize{DataSize: 64}); err != nil {
+		t.Fatal(err)
+	}
+	if n := multi.NumSegments(); n != 2 {
+		t.Errorf("grown multi-segment message NumSegments() = %d; want 2", n)
+	}
+
+	read := &Message{Arena: MultiSegment([][]byte{incrementingData(8), incrementingData(16), incrementingData(24)})}
+	if n := read.NumSegments(); n != 3 {
+		t.Errorf("read message NumSegments() = %d; want 3", n)
+	}
+}
+
+func TestMessageWriteTo(t *testing.T) {
+	msg := &Message{Arena: MultiSegment([][]byte{
+		incrementingData(8),
+		incrementingData(24),
+		incrementingData(16),
+	})}
+	want, err := msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+	var buf bytes.Buffer
+	n, err := msg.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("WriteTo:", err)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("WriteTo returned %d; want %d", n, len(want))
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteTo wrote:\n%x\nwant (Marshal):\n%x", buf.Bytes(), want)
+	}
+
+	w := &shortWriter{max: len(want) - 4}
+	n, err = msg.WriteTo(w)
+	if err == nil {
+		t.Error("WriteTo(short writer) succeeded; want error")
+	}
+	if n != int64(w.n) {
+		t.Errorf("WriteTo(short writer) returned %d; wrote %d", n, w.n)
+	}
+}
+
+func TestMarshalDepthLimit(t *testing.T) {
+	// Build a chain of 10 structs, each pointing to the next.  The
+	// second link is a one-element struct list to cover list elements,
+	// which adds a level for the list itself.
+	const depth = 10 + 1
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewRootStruct(seg, ObjectSize{PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < 10; i++ {
+		var next Struct
+		if i == 1 {
+			l, err := NewCompositeList(seg, ObjectSize{PointerCount: 1}, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetPtr(0, l.ToPtr()); err != nil {
+				t.Fatal(err)
+			}
+			next = l.Struct(0)
+		} else {
+			next, err = NewStruct(seg, ObjectSize{PointerCount: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := s.SetPtr(0, next.ToPtr()); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s = next
+	}
+
+	tests := []struct {
+		limit uint
+		err   error
+	}{
+		{0, nil},
+		{depth, nil},
+		{depth - 1, ErrDepthLimit},
+		{2, ErrDepthLimit},
+	}
+	for _, test := range tests {
+		msg.MarshalDepthLimit = test.limit
+		if _, err := msg.Marshal(); err != test.err {
+			t.Errorf("with MarshalDepthLimit = %d, Marshal() error = %v; want %v", test.limit, err, test.err)
+		}
+		if _, err := msg.WriteTo(io.Discard); err != test.err {
+			t.Errorf("with MarshalDepthLimit = %d, WriteTo() error = %v; want %v", test.limit, err, test.err)
+		}
+	}
+}
+
+// shortWriter accepts up to max bytes, then silently drops the rest.
+type shortWriter struct {
+	n, max int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	if w.n+n > w.max {
+		n = w.max - w.n
+	}
+	w.n += n
+	return n, nil
+}
+
 func TestMultiSegmentAllocate(t *testing.T) {
 	tests := []arenaAllocTest{
 		{
@@ -376,6 +549,113 @@ func TestMultiSegmentAllocate(t *testing.T) {
 	}
 }
 
+func TestMultiSegmentWithGrowth(t *testing.T) {
+	tests := []struct {
+		name string
+		grow SegmentGrowth
+		objs []Size // sizes of the structs allocated after the root
+		caps []int  // capacity of each segment afterward
+	}{
+		{
+			name: "fixed",
+			grow: FixedSegmentGrowth(64),
+			objs: []Size{48, 32, 32, 24, 100},
+			caps: []int{64, 64, 64, 104},
+		},
+		{
+			name: "fixed rounds up to word",
+			grow: FixedSegmentGrowth(60),
+			objs: []Size{48, 16},
+			caps: []int{64, 64},
+		},
+		{
+			name: "doubling",
+			grow: DoublingSegmentGrowth(32, 128),
+			objs: []Size{24, 32, 64, 128, 128},
+			caps: []int{32, 32, 64, 128, 128},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			arena := MultiSegmentWithGrowth(nil, test.grow)
+			_, seg, err := NewMessage(arena)
+			if err != nil {
+				t.Fatal("NewMessage:", err)
+			}
+			for _, sz := range test.objs {
+				if _, err := NewStruct(seg, ObjectSize{DataSize: sz}); err != nil {
+					t.Fatalf("NewStruct(%d bytes): %v", sz, err)
+				}
+			}
+			if n := arena.NumSegments(); n != int64(len(test.caps)) {
+				t.Fatalf("NumSegments() = %d; want %d", n, len(test.caps))
+			}
+			for i, want := range test.caps {
+				data, err := arena.Data(SegmentID(i))
+				if err != nil {
+					t.Fatalf("Data(%d): %v", i, err)
+				}
+				if cap(data) != want {
+					t.Errorf("segment %d capacity = %d; want %d", i, cap(data), want)
+				}
+			}
+		})
+	}
+}
+
+func TestPooledArena(t *testing.T) {
+	arena := NewPooledArena()
+	msg, seg, err := NewMessage(arena)
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	st, err := NewRootStruct(seg, ObjectSize{DataSize: 16})
+	if err != nil {
+		t.Fatal("NewRootStruct:", err)
+	}
+	st.SetUint64(0, 0xdeadbeef)
+	st.SetUint64(8, 0xcafef00d)
+	msg.Reset(nil)
+	buf := []byte(arena.singleSegmentArena)
+	arena.Release()
+	if len(arena.singleSegmentArena) != 0 {
+		t.Errorf("after Release, len(buffer) = %d; want 0", len(arena.singleSegmentArena))
+	}
+	for i, b := range buf[:cap(buf)] {
+		if b != 0 {
+			t.Fatalf("after Release, buffer[%d] = %#x; want 0", i, b)
+		}
+	}
+
+	// An arena from the pool, reused or not, starts out empty.
+	arena = NewPooledArena()
+	msg, seg, err = NewMessage(arena)
+	if err != nil {
+		t.Fatal("NewMessage:", err)
+	}
+	st, err = NewRootStruct(seg, ObjectSize{DataSize: 16})
+	if err != nil {
+		t.Fatal("NewRootStruct:", err)
+	}
+	if st.Uint64(0) != 0 || st.Uint64(8) != 0 {
+		t.Errorf("new struct = {%#x, %#x}; want zeroed", st.Uint64(0), st.Uint64(8))
+	}
+	msg.Reset(nil)
+	arena.Release()
+
+	// Large buffers are not kept.
+	arena = NewPooledArena()
+	msg, _, err = NewMessageWithSizeHint(arena, maxPooledSize/int(wordSize)+1)
+	if err != nil {
+		t.Fatal("NewMessageWithSizeHint:", err)
+	}
+	msg.Reset(nil)
+	arena.Release()
+	if arena.singleSegmentArena != nil {
+		t.Errorf("after Release of a %d-byte buffer, buffer was kept", maxPooledSize+8)
+	}
+}
+
 type serializeTest struct {
 	name        string
 	segs        [][]byte
@@ -532,6 +812,134 @@ func TestMarshal(t *testing.T) {
 	}
 }
 
+func TestTotalSize(t *testing.T) {
+	for i, test := range serializeTests {
+		if test.decodeFails {
+			continue
+		}
+		msg := &Message{Arena: test.arena()}
+		sz, err := msg.TotalSize()
+		if test.encodeFails {
+			if err == nil {
+				t.Errorf("serializeTests[%d] - %s: TotalSize() = %d, <nil>; want error", i, test.name, sz)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("serializeTests[%d] - %s: TotalSize error: %v", i, test.name, err)
+			continue
+		}
+		if sz != uint64(len(test.out)) {
+			t.Errorf("serializeTests[%d] - %s: TotalSize() = %d; want %d", i, test.name, sz, len(test.out))
+		}
+	}
+
+	// Three segments need no header padding, unlike two.
+	msg := &Message{Arena: MultiSegment([][]byte{
+		incrementingData(8),
+		incrementingData(24),
+		incrementingData(16),
+	})}
+	sz, err := msg.TotalSize()
+	if err != nil {
+		t.Fatal("TotalSize:", err)
+	}
+	out, err := msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+	if sz != uint64(len(out)) || sz != 16+48 {
+		t.Errorf("TotalSize() = %d; want %d (len(Marshal()))", sz, len(out))
+	}
+}
+
+func TestMarshalSingleSegment(t *testing.T) {
+	for i, test := range serializeTests {
+		if test.decodeFails || test.encodeFails || len(test.segs) != 1 {
+			continue
+		}
+		msg := &Message{Arena: test.arena()}
+		fast, err := msg.Marshal()
+		if err != nil {
+			t.Errorf("serializeTests[%d] - %s: Marshal error: %v", i, test.name, err)
+			continue
+		}
+		slow, err := msg.marshalSegments(1)
+		if err != nil {
+			t.Errorf("serializeTests[%d] - %s: marshalSegments error: %v", i, test.name, err)
+			continue
+		}
+		if !bytes.Equal(fast, slow) {
+			t.Errorf("serializeTests[%d] - %s: Marshal = % 02x; marshalSegments = % 02x", i, test.name, fast, slow)
+		}
+	}
+
+	msg := newSmallRPCMessage(t)
+	fast, err := msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal:", err)
+	}
+	slow, err := msg.marshalSegments(1)
+	if err != nil {
+		t.Fatal("marshalSegments:", err)
+	}
+	if !bytes.Equal(fast, slow) {
+		t.Errorf("Marshal = % 02x; marshalSegments = % 02x", fast, slow)
+	}
+}
+
+// newSmallRPCMessage builds a single-segment message about the size of
+// a typical RPC call: a struct with a few scalars, a text field, and a
+// nested parameters struct.
+func newSmallRPCMessage(tb testing.TB) *Message {
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		tb.Fatal("NewMessage:", err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{DataSize: 24, PointerCount: 2})
+	if err != nil {
+		tb.Fatal("NewRootStruct:", err)
+	}
+	root.SetUint32(0, 42)
+	root.SetUint64(8, 0xa7317bd7216570aa)
+	root.SetUint16(16, 9)
+	if err := root.SetText(0, "method"); err != nil {
+		tb.Fatal("SetText:", err)
+	}
+	params, err := NewStruct(seg, ObjectSize{DataSize: 8, PointerCount: 1})
+	if err != nil {
+		tb.Fatal("NewStruct:", err)
+	}
+	params.SetUint64(0, 0xdeadbeef)
+	if err := root.SetPtr(1, params.ToPtr()); err != nil {
+		tb.Fatal("SetPtr:", err)
+	}
+	return msg
+}
+
+func BenchmarkMarshalSingleSegment(b *testing.B) {
+	msg := newSmallRPCMessage(b)
+	b.Run("Generic", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := msg.checkMarshalDepth(); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := msg.marshalSegments(1); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("FastPath", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := msg.Marshal(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func TestUnmarshal(t *testing.T) {
 	for i, test := range serializeTests {
 		if test.encodeFails {
@@ -630,6 +1038,174 @@ func TestDecoder(t *testing.T) {
 	}
 }
 
+func TestDecoder_PoolBuffers(t *testing.T) {
+	var stream []byte
+	var tests []serializeTest
+	for _, test := range serializeTests {
+		if test.encodeFails || test.decodeFails {
+			continue
+		}
+		stream = append(stream, test.out...)
+		tests = append(tests, test)
+	}
+	dec := NewDecoder(bytes.NewReader(stream))
+	dec.PoolBuffers()
+	for _, test := range tests {
+		msg, err := dec.Decode()
+		if err != nil {
+			t.Fatalf("%s: Decode error: %v", test.name, err)
+		}
+		if msg.NumSegments() != int64(len(test.segs)) {
+			t.Fatalf("%s: Decode NumSegments() = %d; want %d", test.name, msg.NumSegments(), len(test.segs))
+		}
+		for j := range test.segs {
+			seg, err := msg.Segment(SegmentID(j))
+			if err != nil {
+				t.Fatalf("%s: Decode Segment(%d) error: %v", test.name, j, err)
+			}
+			if !bytes.Equal(seg.Data(), test.segs[j]) {
+				t.Errorf("%s: Decode Segment(%d) = % 02x; want % 02x", test.name, j, seg.Data(), test.segs[j])
+			}
+		}
+		if msg.pooledBuf == nil {
+			t.Fatalf("%s: decoded message has no pooled buffer", test.name)
+		}
+		buf := *msg.pooledBuf
+		msg.Release()
+		if msg.Arena != nil || msg.pooledBuf != nil {
+			t.Errorf("%s: after Release, message still has its arena or buffer", test.name)
+		}
+		if raceEnabled {
+			for k, b := range buf {
+				if b != 0xa5 {
+					t.Errorf("%s: after Release in race build, buffer[%d] = %#x; want poisoned", test.name, k, b)
+					break
+				}
+			}
+		}
+		msg.Release() // second call is a no-op
+	}
+	if _, err := dec.Decode(); err != io.EOF {
+		t.Errorf("Decode at end of stream error = %v; want EOF", err)
+	}
+}
+
+func TestDecodeAll(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	for i := 0; i < 3; i++ {
+		msg, seg, err := NewMessage(SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := NewRootStruct(seg, ObjectSize{DataSize: 8})
+		if err != nil {
+			t.Fatal(err)
+		}
+		root.SetUint64(0, uint64(i))
+		if err := enc.Encode(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+
+	t.Run("Empty", func(t *testing.T) {
+		msgs, err := DecodeAll(bytes.NewReader(nil))
+		if err != nil {
+			t.Errorf("DecodeAll(empty) error: %v", err)
+		}
+		if msgs == nil || len(msgs) != 0 {
+			t.Errorf("DecodeAll(empty) = %v; want empty slice", msgs)
+		}
+	})
+	t.Run("CleanEOF", func(t *testing.T) {
+		msgs, err := DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			t.Errorf("DecodeAll error: %v", err)
+		}
+		if len(msgs) != 3 {
+			t.Fatalf("len(DecodeAll(...)) = %d; want 3", len(msgs))
+		}
+		for i, msg := range msgs {
+			p, err := msg.Root()
+			if err != nil {
+				t.Errorf("msgs[%d].Root(): %v", i, err)
+				continue
+			}
+			if n := p.Struct().Uint64(0); n != uint64(i) {
+				t.Errorf("msgs[%d] root value = %d; want %d", i, n, i)
+			}
+		}
+	})
+	t.Run("TruncatedTail", func(t *testing.T) {
+		msgs, err := DecodeAll(bytes.NewReader(data[:len(data)-4]))
+		if err == nil {
+			t.Error("DecodeAll(truncated) error = <nil>; want error")
+		}
+		if len(msgs) != 2 {
+			t.Errorf("len(DecodeAll(truncated)) = %d; want 2", len(msgs))
+		}
+	})
+}
+
+func TestDecoder_Truncated(t *testing.T) {
+	t.Parallel()
+	first, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRootStruct(seg, ObjectSize{DataSize: 8}); err != nil {
+		t.Fatal(err)
+	}
+	last, seg, err := NewMessage(MultiSegment([][]byte{make([]byte, 0, 8)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewRootStruct(seg, ObjectSize{DataSize: 16}); err != nil {
+		t.Fatal(err)
+	}
+	if last.NumSegments() < 2 {
+		t.Fatalf("last.NumSegments() = %d; want multiple segments", last.NumSegments())
+	}
+	firstData, err := first.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastData, err := last.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := append(append([]byte(nil), firstData...), lastData...)
+
+	modes := []struct {
+		name  string
+		setup func(*Decoder)
+	}{
+		{"Default", func(*Decoder) {}},
+		{"ReuseBuffer", (*Decoder).ReuseBuffer},
+		{"PoolBuffers", (*Decoder).PoolBuffers},
+	}
+	for _, mode := range modes {
+		mode := mode
+		t.Run(mode.name, func(t *testing.T) {
+			for n := len(firstData) + 1; n < len(stream); n++ {
+				dec := NewDecoder(bytes.NewReader(stream[:n]))
+				mode.setup(dec)
+				if _, err := dec.Decode(); err != nil {
+					t.Fatalf("stream[:%d]: first Decode: %v", n, err)
+				}
+				_, err := dec.Decode()
+				if err == nil || err == io.EOF {
+					t.Errorf("stream[:%d]: second Decode error = %v; want truncation error", n, err)
+				} else if !errors.Is(err, io.ErrUnexpectedEOF) {
+					t.Errorf("stream[:%d]: second Decode error = %v; want wrapped io.ErrUnexpectedEOF", n, err)
+				}
+			}
+		})
+	}
+}
+
 func TestDecoder_MaxMessageSize(t *testing.T) {
 	t.Parallel()
 	zeroWord := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
@@ -825,6 +1401,486 @@ func TestAddCap(t *testing.T) {
 	}
 }
 
+func TestSetRoot(t *testing.T) {
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewStruct(seg, ObjectSize{DataSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetUint64(0, 42)
+	if err := msg.SetRootStruct(s); err != nil {
+		t.Fatal("SetRootStruct(same message):", err)
+	}
+	root, err := msg.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SamePtr(root, s.ToPtr()) {
+		t.Error("Root() does not refer to the struct passed to SetRootStruct")
+	}
+
+	_, otherSeg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewStruct(otherSeg, ObjectSize{DataSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.SetRoot(other.ToPtr()); err == nil {
+		t.Error("SetRoot(pointer from other message) = <nil>; want error")
+	}
+	if err := msg.SetRootStruct(other); err == nil {
+		t.Error("SetRootStruct(struct from other message) = <nil>; want error")
+	}
+	root, err = msg.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SamePtr(root, s.ToPtr()) {
+		t.Error("failed SetRoot changed the root")
+	}
+	if err := msg.SetRoot(Ptr{}); err != nil {
+		t.Error("SetRoot(Ptr{}):", err)
+	}
+}
+
+func TestValidateForSend(t *testing.T) {
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewPointerList(seg, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SetPtr(0, l.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Set(1, NewInterface(seg, msg.AddCap(nil)).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.ValidateForSend(); err != nil {
+		t.Errorf("ValidateForSend() with valid capability = %v; want <nil>", err)
+	}
+
+	// Reference a capability past the end of the cap table.
+	if err := l.Set(0, NewInterface(seg, 1).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.ValidateForSend(); err == nil {
+		t.Error("ValidateForSend() with missing capability = <nil>; want error")
+	} else if !strings.Contains(err.Error(), "capability 1") {
+		t.Errorf("ValidateForSend() with missing capability = %v; want error to mention capability 1", err)
+	}
+
+	// Point the root struct past the end of the segment.
+	msg, err = Unmarshal([]byte{
+		0, 0, 0, 0, 2, 0, 0, 0,
+		0x20, 0, 0, 0, 1, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	})
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	if err := msg.ValidateForSend(); err == nil {
+		t.Error("ValidateForSend() with out-of-bounds pointer = <nil>; want error")
+	}
+}
+
+func TestCapCount(t *testing.T) {
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// root: (cap, child, list)
+	// child: (cap)
+	// list: [cap, cap]
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id0 := msg.AddCap(NewClient(new(dummyHook)))
+	id1 := msg.AddCap(NewClient(new(dummyHook)))
+	msg.AddCap(nil)
+	msg.AddCap(NewClient(new(dummyHook))) // unreferenced
+	if err := root.SetPtr(0, NewInterface(seg, id0).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	child, err := NewStruct(seg, ObjectSize{PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := child.SetPtr(0, NewInterface(seg, id1).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SetPtr(1, child.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	list, err := NewPointerList(seg, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Set(0, NewInterface(seg, id0).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Set(1, NewInterface(seg, id1).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SetPtr(2, list.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := msg.CapCount(); got != 3 {
+		t.Errorf("msg.CapCount() = %d; want 3", got)
+	}
+	msg.ResetReadLimit(64)
+	if got, err := ReachableCapCount(root.ToPtr()); got != 2 || err != nil {
+		t.Errorf("ReachableCapCount(root) = %d, %v; want 2, <nil>", got, err)
+	}
+	if got, err := ReachableCapCount(child.ToPtr()); got != 1 || err != nil {
+		t.Errorf("ReachableCapCount(child) = %d, %v; want 1, <nil>", got, err)
+	}
+	if got := msg.readLimit(); got != 64 {
+		t.Errorf("read limit after ReachableCapCount = %d; want 64", got)
+	}
+	if got, err := ReachableCapCount(Ptr{}); got != 0 || err != nil {
+		t.Errorf("ReachableCapCount(Ptr{}) = %d, %v; want 0, <nil>", got, err)
+	}
+	for _, c := range msg.CapTable {
+		c.Release()
+	}
+}
+
+func TestNormalizeCapTable(t *testing.T) {
+	hooks := []*dummyHook{new(dummyHook), new(dummyHook), new(dummyHook)}
+	clients := make([]*Client, len(hooks))
+	for i, h := range hooks {
+		clients[i] = NewClient(h)
+		defer clients[i].Release()
+	}
+	// build makes a message whose root refers to clients[2] and then
+	// clients[0] twice, adding clients to the cap table in addOrder.
+	build := func(addOrder []int) (*Message, error) {
+		msg, seg, err := NewMessage(SingleSegment(nil))
+		if err != nil {
+			return nil, err
+		}
+		ids := make(map[int]CapabilityID)
+		for _, i := range addOrder {
+			ids[i] = msg.AddCap(clients[i].AddRef())
+		}
+		root, err := NewRootStruct(seg, ObjectSize{PointerCount: 2})
+		if err != nil {
+			return nil, err
+		}
+		if err := root.SetPtr(0, NewInterface(seg, ids[2]).ToPtr()); err != nil {
+			return nil, err
+		}
+		list, err := NewPointerList(seg, 2)
+		if err != nil {
+			return nil, err
+		}
+		if err := list.Set(0, NewInterface(seg, ids[0]).ToPtr()); err != nil {
+			return nil, err
+		}
+		if err := list.Set(1, NewInterface(seg, ids[0]).ToPtr()); err != nil {
+			return nil, err
+		}
+		if err := root.SetPtr(1, list.ToPtr()); err != nil {
+			return nil, err
+		}
+		return msg, nil
+	}
+
+	var want []byte
+	for _, addOrder := range [][]int{{0, 1, 2}, {1, 2, 0}, {2, 0, 1}} {
+		msg, err := build(addOrder)
+		if err != nil {
+			t.Fatalf("build(%v): %v", addOrder, err)
+		}
+		if err := msg.NormalizeCapTable(); err != nil {
+			t.Fatalf("build(%v).NormalizeCapTable(): %v", addOrder, err)
+		}
+		// Referenced in order 2, 0; 1 is unreferenced.
+		if len(msg.CapTable) != 3 || !msg.CapTable[0].IsSame(clients[2]) || !msg.CapTable[1].IsSame(clients[0]) || !msg.CapTable[2].IsSame(clients[1]) {
+			t.Errorf("build(%v) cap table after NormalizeCapTable = %v; want [%v %v %v]", addOrder, msg.CapTable, clients[2], clients[0], clients[1])
+		}
+		data, err := msg.Marshal()
+		if err != nil {
+			t.Fatalf("build(%v).Marshal(): %v", addOrder, err)
+		}
+		if want == nil {
+			want = data
+		} else if !bytes.Equal(data, want) {
+			t.Errorf("build(%v) after NormalizeCapTable marshals to %x; want %x", addOrder, data, want)
+		}
+		msg.Reset(nil)
+	}
+}
+
+func TestNormalizeCapTableInvalid(t *testing.T) {
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.AddCap(nil)
+	id := msg.AddCap(nil)
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pointer 0 would be renumbered to 0 if pointer 1 were valid.
+	if err := root.SetPtr(0, NewInterface(seg, id).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SetPtr(1, NewInterface(seg, id+1).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.NormalizeCapTable(); err == nil {
+		t.Error("NormalizeCapTable() with out-of-range capability = <nil>; want error")
+	}
+	if p, err := root.Ptr(0); err != nil || p.Interface().Capability() != id {
+		t.Errorf("root pointer 0 after failed NormalizeCapTable = %v, %v; want capability %d", p, err, id)
+	}
+}
+
+func TestGC(t *testing.T) {
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{DataSize: 8, PointerCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.SetUint64(0, 42)
+	// Overwrite the text field many times, leaving garbage behind.
+	for i := 0; i < 100; i++ {
+		if err := root.SetText(0, strings.Repeat("x", 1000+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	msg.AddCap(nil)
+	id := msg.AddCap(NewClient(new(dummyHook)))
+	if err := root.SetPtr(1, NewInterface(seg, id).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	before, err := msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal before GC:", err)
+	}
+
+	if err := msg.GC(); err != nil {
+		t.Fatal("GC:", err)
+	}
+	after, err := msg.Marshal()
+	if err != nil {
+		t.Fatal("Marshal after GC:", err)
+	}
+	if len(after) >= len(before)/10 {
+		t.Errorf("message size after GC = %d bytes; want much less than %d bytes", len(after), len(before))
+	}
+	p, err := msg.Root()
+	if err != nil {
+		t.Fatal("Root after GC:", err)
+	}
+	root = p.Struct()
+	if got := root.Uint64(0); got != 42 {
+		t.Errorf("root data after GC = %d; want 42", got)
+	}
+	if p, err := root.Ptr(0); err != nil {
+		t.Error("root text after GC:", err)
+	} else if want := strings.Repeat("x", 1099); p.Text() != want {
+		t.Errorf("root text after GC has length %d; want %d", len(p.Text()), len(want))
+	}
+	if p, err := root.Ptr(1); err != nil {
+		t.Error("root interface after GC:", err)
+	} else if got := p.Interface().Capability(); got != id {
+		t.Errorf("root interface after GC = capability %d; want %d", got, id)
+	}
+	if len(msg.CapTable) != 2 || !msg.CapTable[id].IsValid() {
+		t.Errorf("cap table after GC = %v; want 2 entries with capability %d valid", msg.CapTable, id)
+	}
+
+	// The message can still be built on.
+	if err := root.SetText(0, "short"); err != nil {
+		t.Fatal("SetText after GC:", err)
+	}
+	if p, err := root.Ptr(0); err != nil || p.Text() != "short" {
+		t.Errorf("root text after GC and SetText = %q, %v; want \"short\"", p.Text(), err)
+	}
+	msg.Reset(nil)
+}
+
+func TestGCKeepsCapabilityIDs(t *testing.T) {
+	msg, seg, err := NewMessage(MultiSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewPointerList(seg, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.SetRoot(l.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	msg.AddCap(nil)
+	msg.AddCap(nil)
+	want := []CapabilityID{1, 0, 1}
+	for i, id := range want {
+		if err := l.Set(i, NewInterface(seg, id).ToPtr()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := msg.GC(); err != nil {
+		t.Fatal("GC:", err)
+	}
+	p, err := msg.Root()
+	if err != nil {
+		t.Fatal("Root after GC:", err)
+	}
+	pl := PointerList{p.List()}
+	for i, id := range want {
+		if p, err := pl.At(i); err != nil {
+			t.Errorf("list[%d] after GC: %v", i, err)
+		} else if got := p.Interface().Capability(); got != id {
+			t.Errorf("list[%d] after GC = capability %d; want %d", i, got, id)
+		}
+	}
+	if len(msg.CapTable) != 2 {
+		t.Errorf("len(msg.CapTable) after GC = %d; want 2", len(msg.CapTable))
+	}
+}
+
+func TestGCKeepsSharedObjects(t *testing.T) {
+	pa := NewPooledArena()
+	msg, seg, err := NewMessage(pa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Repeat("x", 4096)
+	if err := root.SetText(0, want); err != nil {
+		t.Fatal(err)
+	}
+	p, err := root.Ptr(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SharePtr(1, p); err != nil {
+		t.Fatal("SharePtr:", err)
+	}
+	before := len(seg.Data())
+
+	if err := msg.GC(); err != nil {
+		t.Fatal("GC:", err)
+	}
+	if msg.Arena != pa {
+		t.Errorf("arena after GC = %v; want the original PooledArena", msg.Arena)
+	}
+	seg, err = msg.Segment(0)
+	if err != nil {
+		t.Fatal("Segment(0) after GC:", err)
+	}
+	if after := len(seg.Data()); after > before {
+		t.Errorf("segment size after GC = %d bytes; want at most %d bytes", after, before)
+	}
+	rp, err := msg.Root()
+	if err != nil {
+		t.Fatal("Root after GC:", err)
+	}
+	root = rp.Struct()
+	p0, err := root.Ptr(0)
+	if err != nil {
+		t.Fatal("root pointer 0 after GC:", err)
+	}
+	p1, err := root.Ptr(1)
+	if err != nil {
+		t.Fatal("root pointer 1 after GC:", err)
+	}
+	if !SamePtr(p0, p1) {
+		t.Error("root pointers after GC refer to different objects; want shared")
+	}
+	if p0.Text() != want {
+		t.Errorf("root text after GC has length %d; want %d", len(p0.Text()), len(want))
+	}
+	msg.Reset(nil)
+	pa.Release()
+}
+
+func TestGCKeepsSegmentGrowth(t *testing.T) {
+	msg, seg, err := NewMessage(MultiSegmentWithGrowth(nil, FixedSegmentGrowth(1024)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SetText(0, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if err := msg.GC(); err != nil {
+		t.Fatal("GC:", err)
+	}
+	if _, ok := msg.Arena.(*growthArena); !ok {
+		t.Errorf("arena after GC = %v; want a MultiSegmentWithGrowth arena", msg.Arena)
+	}
+	seg, err = msg.Segment(0)
+	if err != nil {
+		t.Fatal("Segment(0) after GC:", err)
+	}
+	if got := cap(seg.data); got != 1024 {
+		t.Errorf("capacity of segment 0 after GC = %d; want 1024", got)
+	}
+}
+
+func TestFailedTraversalKeepsReadLimit(t *testing.T) {
+	msg, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := NewStruct(seg, ObjectSize{DataSize: 64})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SetPtr(0, child.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The limit is enough to read the root, but not the child.
+	const limit = 32
+	msg.ResetReadLimit(limit)
+	if err := msg.ValidateForSend(); err == nil {
+		t.Error("ValidateForSend() = <nil>; want read limit error")
+	}
+	if got := msg.readLimit(); got != limit {
+		t.Errorf("read limit after failed ValidateForSend = %d; want %d", got, limit)
+	}
+	msg.MarshalDepthLimit = 8
+	if _, err := msg.Marshal(); err == nil {
+		t.Error("Marshal() = <nil>; want read limit error")
+	}
+	if got := msg.readLimit(); got != limit {
+		t.Errorf("read limit after failed Marshal = %d; want %d", got, limit)
+	}
+}
+
 func TestFirstSegmentMessage_SingleSegment(t *testing.T) {
 	msg, seg, err := NewMessage(SingleSegment(nil))
 	if err != nil {
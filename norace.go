@@ -0,0 +1,5 @@
+// +build !race
+
+package capnp
+
+const raceEnabled = false
@@ -21,6 +21,42 @@ func Extract(val interface{}, typeID uint64, s capnp.Struct) error {
 	return nil
 }
 
+// ExtractList copies l, a list of the struct type typeID, into dst, a
+// pointer to a slice of Go structs or pointers to Go structs.  The slice
+// is replaced with a new one of the same length as l.
+func ExtractList(dst interface{}, typeID uint64, l capnp.List) error {
+	err := extractStructList(reflect.ValueOf(dst), typeID, l)
+	if err != nil {
+		return fmt.Errorf("pogs: extract list @%#x: %v", typeID, err)
+	}
+	return nil
+}
+
+func extractStructList(val reflect.Value, typeID uint64, l capnp.List) error {
+	if !val.IsValid() {
+		return errors.New("can't extract struct list into nil")
+	}
+	if val.Kind() != reflect.Ptr || val.Type().Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("can't extract struct list into %v", val.Type())
+	}
+	if val.IsNil() {
+		return errors.New("can't extract struct list into nil")
+	}
+	val = val.Elem()
+	if !isStructOrStructPtr(val.Type().Elem()) {
+		return fmt.Errorf("can't extract struct list into a Go %v", val.Type())
+	}
+	n := l.Len()
+	val.Set(reflect.MakeSlice(val.Type(), n, n))
+	e := new(extracter)
+	for i := 0; i < n; i++ {
+		if err := e.extractStruct(val.Index(i), typeID, l.Struct(i)); err != nil {
+			return fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
 type extracter struct {
 	nodes nodemap.Map
 }
@@ -21,6 +21,36 @@ func Insert(typeID uint64, s capnp.Struct, val interface{}) error {
 	return nil
 }
 
+// InsertList copies src, a slice of Go structs or pointers to Go
+// structs, into l, a list of the struct type typeID.  l must have the
+// same length as src.
+func InsertList(typeID uint64, l capnp.List, src interface{}) error {
+	err := insertStructList(typeID, l, reflect.ValueOf(src))
+	if err != nil {
+		return fmt.Errorf("pogs: insert list @%#x: %v", typeID, err)
+	}
+	return nil
+}
+
+func insertStructList(typeID uint64, l capnp.List, val reflect.Value) error {
+	if val.Kind() != reflect.Slice {
+		return fmt.Errorf("can't insert %v into a struct list", val.Kind())
+	}
+	if !isStructOrStructPtr(val.Type().Elem()) {
+		return fmt.Errorf("can't insert Go %v into a struct list", val.Type())
+	}
+	if n := val.Len(); n != l.Len() {
+		return fmt.Errorf("can't insert %d elements into a list of length %d", n, l.Len())
+	}
+	ins := new(inserter)
+	for i := 0; i < l.Len(); i++ {
+		if err := ins.insertStruct(typeID, l.Struct(i), val.Index(i)); err != nil {
+			return fmt.Errorf("element %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
 type inserter struct {
 	nodes nodemap.Map
 }
@@ -760,6 +760,90 @@ type TagZ struct {
 	U8      bool    `capnp:"bool"`
 }
 
+func TestStructListRoundTrip(t *testing.T) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	in := []PlaneBase{
+		{Name: "foo", Homes: []air.Airport{air.Airport_jfk}, Rating: 5, CanFly: true},
+		{Name: "bar", Capacity: 100, MaxSpeed: 9.5},
+		{},
+	}
+	l, err := air.NewPlaneBase_List(seg, int32(len(in)))
+	if err != nil {
+		t.Fatalf("NewPlaneBase_List: %v", err)
+	}
+	if err := InsertList(air.PlaneBase_TypeID, l.List, in); err != nil {
+		t.Fatalf("InsertList(%s) error: %v", zpretty.Sprint(in), err)
+	}
+	if name, err := l.At(1).Name(); err != nil || name != "bar" {
+		t.Errorf("l.At(1).Name() = %q, %v; want \"bar\", <nil>", name, err)
+	}
+
+	var out []PlaneBase
+	if err := ExtractList(&out, air.PlaneBase_TypeID, l.List); err != nil {
+		t.Fatalf("ExtractList error: %v", err)
+	}
+	if len(out) != len(in) {
+		t.Fatalf("ExtractList produced %d elements; want %d", len(out), len(in))
+	}
+	for i := range in {
+		if !out[i].equal(&in[i]) {
+			t.Errorf("ExtractList element %d = %s; want %s", i, zpretty.Sprint(out[i]), zpretty.Sprint(in[i]))
+		}
+	}
+
+	// Slices of pointers work too, and any existing elements are replaced.
+	ptrs := []*PlaneBase{{Name: "stale"}}
+	if err := ExtractList(&ptrs, air.PlaneBase_TypeID, l.List); err != nil {
+		t.Fatalf("ExtractList into []*PlaneBase error: %v", err)
+	}
+	if len(ptrs) != len(in) {
+		t.Fatalf("ExtractList into []*PlaneBase produced %d elements; want %d", len(ptrs), len(in))
+	}
+	for i := range in {
+		if !ptrs[i].equal(&in[i]) {
+			t.Errorf("ExtractList into []*PlaneBase element %d = %s; want %s", i, zpretty.Sprint(ptrs[i]), zpretty.Sprint(in[i]))
+		}
+	}
+}
+
+func TestStructListErrors(t *testing.T) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatalf("NewMessage: %v", err)
+	}
+	l, err := air.NewPlaneBase_List(seg, 2)
+	if err != nil {
+		t.Fatalf("NewPlaneBase_List: %v", err)
+	}
+	if err := InsertList(air.PlaneBase_TypeID, l.List, []PlaneBase{{Name: "foo"}}); err == nil {
+		t.Error("InsertList with length mismatch = <nil>; want error")
+	}
+	if err := InsertList(air.PlaneBase_TypeID, l.List, []int{1, 2}); err == nil {
+		t.Error("InsertList of []int = <nil>; want error")
+	}
+	if err := InsertList(air.PlaneBase_TypeID, l.List, PlaneBase{}); err == nil {
+		t.Error("InsertList of a struct = <nil>; want error")
+	}
+	if err := InsertList(air.PlaneBase_TypeID, l.List, []Z{{}, {}}); err == nil {
+		t.Error("InsertList of []Z into PlaneBase list = <nil>; want error")
+	}
+
+	var ints []int
+	if err := ExtractList(&ints, air.PlaneBase_TypeID, l.List); err == nil {
+		t.Error("ExtractList into []int = <nil>; want error")
+	}
+	var out []PlaneBase
+	if err := ExtractList(out, air.PlaneBase_TypeID, l.List); err == nil {
+		t.Error("ExtractList into non-pointer = <nil>; want error")
+	}
+	if err := ExtractList(nil, air.PlaneBase_TypeID, l.List); err == nil {
+		t.Error("ExtractList into nil = <nil>; want error")
+	}
+}
+
 func TestExtract_Tags(t *testing.T) {
 	tests := []struct {
 		name string
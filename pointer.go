@@ -195,6 +195,93 @@ func (p Ptr) Default(def []byte) (Ptr, error) {
 	return p, nil
 }
 
+// Equal reports whether p and other are structurally equal, as defined
+// by the Equal function.  Far pointers are followed, so the result does
+// not depend on how either message is split into segments.  Default
+// values are not applied: a null pointer only equals another null
+// pointer, so call Default first to compare against a field's default.
+func (p Ptr) Equal(other Ptr) (bool, error) {
+	return Equal(p, other)
+}
+
+// Walk calls visit for root and each non-null pointer reachable from
+// it, in depth-first order: after visiting a pointer to a struct or
+// list, Walk visits the pointers it contains, in order.  An object
+// reached through more than one pointer is visited once for each.  If
+// visit returns an error, Walk stops and returns it unchanged; it also
+// stops at the first pointer that cannot be read.  Reading counts toward
+// the message's read limit and each level of nesting toward the depth
+// limit, so walking a cyclic message ends with an error.
+func Walk(root Ptr, visit func(p Ptr) error) error {
+	if !root.IsValid() {
+		return nil
+	}
+	if err := visit(root); err != nil {
+		return err
+	}
+	return eachChildPtr(root, func(_ address, q Ptr) error {
+		return Walk(q, visit)
+	})
+}
+
+// eachChildPtr calls f for each pointer contained in the struct or list
+// that p refers to, in order, along with the address of the pointer in
+// p's segment.  It stops at the first error.
+func eachChildPtr(p Ptr, f func(addr address, q Ptr) error) error {
+	switch p.flags.ptrType() {
+	case structPtrType:
+		s := p.Struct()
+		for i := uint16(0); i < s.size.PointerCount; i++ {
+			q, err := s.Ptr(i)
+			if err != nil {
+				return err
+			}
+			if err := f(s.pointerAddress(i), q); err != nil {
+				return err
+			}
+		}
+	case listPtrType:
+		l := p.List()
+		if l.size.PointerCount == 0 {
+			break
+		}
+		if l.flags&isCompositeList == 0 {
+			pl := PointerList{l}
+			for i := 0; i < pl.Len(); i++ {
+				addr, err := pl.primitiveElem(i, ObjectSize{PointerCount: 1})
+				if err != nil {
+					return err
+				}
+				q, err := pl.At(i)
+				if err != nil {
+					return err
+				}
+				if err := f(addr, q); err != nil {
+					return err
+				}
+			}
+			break
+		}
+		for i := 0; i < l.Len(); i++ {
+			s := l.Struct(i)
+			if l.depthLimit == 0 {
+				// Don't let the element's depth limit wrap around.
+				s.depthLimit = 0
+			}
+			for j := uint16(0); j < s.size.PointerCount; j++ {
+				q, err := s.Ptr(j)
+				if err != nil {
+					return err
+				}
+				if err := f(s.pointerAddress(j), q); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // SamePtr reports whether p and q refer to the same object.
 func SamePtr(p, q Ptr) bool {
 	return p.seg == q.seg && p.off == q.off
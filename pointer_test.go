@@ -2,6 +2,8 @@ package capnp
 
 import (
 	"errors"
+	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -163,3 +165,165 @@ func TestEqual(t *testing.T) {
 		})
 	}
 }
+
+func TestPtrEqual(t *testing.T) {
+	// build writes root(data, child(data)) into a new message.
+	build := func(arena Arena) (Ptr, error) {
+		_, seg, err := NewMessage(arena)
+		if err != nil {
+			return Ptr{}, err
+		}
+		root, err := NewRootStruct(seg, ObjectSize{DataSize: 8, PointerCount: 1})
+		if err != nil {
+			return Ptr{}, err
+		}
+		root.SetUint64(0, 0xdeadbeef)
+		child, err := NewStruct(seg, ObjectSize{DataSize: 8})
+		if err != nil {
+			return Ptr{}, err
+		}
+		child.SetUint64(0, 0x0cafefe0)
+		if err := root.SetPtr(0, child.ToPtr()); err != nil {
+			return Ptr{}, err
+		}
+		return root.ToPtr(), nil
+	}
+	single, err := build(SingleSegment(nil))
+	if err != nil {
+		t.Fatal("build single segment:", err)
+	}
+	multi, err := build(MultiSegmentWithGrowth(nil, FixedSegmentGrowth(8)))
+	if err != nil {
+		t.Fatal("build multi segment:", err)
+	}
+	if n := multi.Message().NumSegments(); n < 3 {
+		t.Fatalf("multi segment message has %d segments; want at least 3", n)
+	}
+	if ok, err := single.Equal(multi); err != nil {
+		t.Error("single.Equal(multi):", err)
+	} else if !ok {
+		t.Error("single.Equal(multi) = false; want true")
+	}
+	if ok, err := multi.Equal(single); err != nil {
+		t.Error("multi.Equal(single):", err)
+	} else if !ok {
+		t.Error("multi.Equal(single) = false; want true")
+	}
+
+	def, err := Ptr{}.Default(mustMarshal(t, single.Message()))
+	if err != nil {
+		t.Fatal("Default:", err)
+	}
+	if ok, err := def.Equal(multi); err != nil {
+		t.Error("def.Equal(multi):", err)
+	} else if !ok {
+		t.Error("def.Equal(multi) = false; want true")
+	}
+	if ok, err := (Ptr{}).Equal(multi); err != nil {
+		t.Error("Ptr{}.Equal(multi):", err)
+	} else if ok {
+		t.Error("Ptr{}.Equal(multi) = true; want false")
+	}
+}
+
+func TestWalk(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// root: (child, list, child)
+	// child: ("a")
+	// list: [(iface 0), (iface 1)]
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	child, err := NewStruct(seg, ObjectSize{PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := child.SetText(0, "a"); err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewCompositeList(seg, ObjectSize{PointerCount: 1}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < l.Len(); i++ {
+		if err := l.Struct(i).SetPtr(0, NewInterface(seg, CapabilityID(i)).ToPtr()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, p := range []Ptr{child.ToPtr(), l.ToPtr(), child.ToPtr()} {
+		if err := root.SetPtr(uint16(i), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var visited []string
+	err = Walk(root.ToPtr(), func(p Ptr) error {
+		switch {
+		case p.flags.ptrType() == interfacePtrType:
+			visited = append(visited, "iface "+strconv.Itoa(int(p.Interface().Capability())))
+		case p.flags.ptrType() == structPtrType:
+			visited = append(visited, "struct")
+		case p.List().size.PointerCount == 0:
+			visited = append(visited, "text "+p.Text())
+		default:
+			visited = append(visited, "list")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal("Walk:", err)
+	}
+	want := []string{"struct", "struct", "text a", "list", "iface 0", "iface 1", "struct", "text a"}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("Walk visited %q; want %q", visited, want)
+	}
+
+	// An error from visit stops the walk.
+	errStop := errors.New("stop")
+	n := 0
+	err = Walk(root.ToPtr(), func(Ptr) error {
+		n++
+		if n == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || n != 3 {
+		t.Errorf("Walk stopped after %d visits with %v; want 3 visits and %v", n, err, errStop)
+	}
+
+	if err := Walk(Ptr{}, func(Ptr) error { return errStop }); err != nil {
+		t.Errorf("Walk(null pointer) = %v; want <nil>", err)
+	}
+}
+
+func TestWalkCycle(t *testing.T) {
+	// The root struct's only pointer refers back to the struct.
+	msg, err := Unmarshal([]byte{
+		0, 0, 0, 0, 2, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 1, 0,
+		0xfc, 0xff, 0xff, 0xff, 0, 0, 1, 0,
+	})
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	root, err := msg.Root()
+	if err != nil {
+		t.Fatal("Root:", err)
+	}
+	n := 0
+	err = Walk(root, func(Ptr) error {
+		n++
+		return nil
+	})
+	if err == nil {
+		t.Error("Walk on cyclic message = <nil>; want error")
+	}
+	if limit := int(msg.depthLimit()); n > limit+1 {
+		t.Errorf("Walk on cyclic message visited %d pointers; want at most %d", n, limit+1)
+	}
+}
@@ -0,0 +1,8 @@
+// +build race
+
+package capnp
+
+// raceEnabled is true when the race detector is on.  Message.Release
+// then poisons pooled buffers instead of reusing them, so that reads
+// through pointers kept past Release fail loudly.
+const raceEnabled = true
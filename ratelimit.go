@@ -0,0 +1,64 @@
+package capnp
+
+import (
+	"context"
+
+	"capnproto.org/go/capnp/v3/internal/errors"
+)
+
+// A RateLimiter paces the calls made through a RateLimitedClient.
+// *rate.Limiter from golang.org/x/time/rate implements RateLimiter.
+type RateLimiter interface {
+	// Allow reports whether a call may proceed now.
+	Allow() bool
+
+	// Wait blocks until a call may proceed.  It returns an error if ctx
+	// is done first or if the call could not proceed before ctx's
+	// deadline.
+	Wait(ctx context.Context) error
+}
+
+// RateLimitedClient returns a client that forwards calls to c, limited
+// by limiter.  Calls made with SendCall, as application code does, wait
+// on the limiter until they may proceed or their Context is done.
+// Calls delivered with RecvCall, as the RPC system does for calls from
+// a remote vat, do not wait: they are rejected right away when the
+// limiter is exhausted, so that they don't hold up the connection.
+// Either way, a call that is not forwarded fails with an overloaded
+// exception.
+//
+// RateLimitedClient steals the reference to c: releasing the returned
+// client releases c.
+func RateLimitedClient(c *Client, limiter RateLimiter) *Client {
+	return NewClient(&rateLimitedClient{c: c, limiter: limiter})
+}
+
+type rateLimitedClient struct {
+	c       *Client
+	limiter RateLimiter
+}
+
+func (rl *rateLimitedClient) Send(ctx context.Context, s Send) (*Answer, ReleaseFunc) {
+	if err := rl.limiter.Wait(ctx); err != nil {
+		return ErrorAnswer(s.Method, errors.Wrap(errors.Overloaded, "capnp", "rate limit: "+err.Error(), err)), func() {}
+	}
+	return rl.c.SendCall(ctx, s)
+}
+
+func (rl *rateLimitedClient) Recv(ctx context.Context, r Recv) PipelineCaller {
+	if !rl.limiter.Allow() {
+		r.Reject(errors.New(errors.Overloaded, "capnp", "rate limit exceeded"))
+		return nil
+	}
+	return rl.c.RecvCall(ctx, r)
+}
+
+// Brand returns the zero Brand, so that callers that look for c's brand
+// can't bypass the limiter by calling c directly.
+func (rl *rateLimitedClient) Brand() Brand {
+	return Brand{}
+}
+
+func (rl *rateLimitedClient) Shutdown() {
+	rl.c.Release()
+}
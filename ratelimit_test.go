@@ -0,0 +1,112 @@
+package capnp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	interr "capnproto.org/go/capnp/v3/internal/errors"
+)
+
+func TestRateLimitedClient(t *testing.T) {
+	method := Method{InterfaceID: 0x8e5322c1e9282534, MethodID: 1}
+
+	t.Run("SendAllowed", func(t *testing.T) {
+		h := new(dummyHook)
+		c := RateLimitedClient(NewClient(h), &fakeLimiter{allow: true})
+		ans, finish := c.SendCall(context.Background(), Send{Method: method})
+		if _, err := ans.Struct(); err != nil {
+			t.Error("call:", err)
+		}
+		finish()
+		if h.calls != 1 {
+			t.Errorf("underlying client got %d calls; want 1", h.calls)
+		}
+		c.Release()
+		if h.shutdowns != 1 {
+			t.Errorf("underlying client shut down %d times after Release; want 1", h.shutdowns)
+		}
+	})
+	t.Run("SendCanceled", func(t *testing.T) {
+		h := new(dummyHook)
+		l := &fakeLimiter{waiting: make(chan struct{})}
+		c := RateLimitedClient(NewClient(h), l)
+		defer c.Release()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			<-l.waiting
+			cancel()
+		}()
+		ans, finish := c.SendCall(ctx, Send{Method: method})
+		defer finish()
+		_, err := ans.Struct()
+		if err == nil {
+			t.Fatal("call succeeded; want overloaded error")
+		}
+		if typ := interr.TypeOf(err); typ != interr.Overloaded {
+			t.Errorf("call error type = %v; want overloaded", typ)
+		}
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("call error = %v; want wrapped context.Canceled", err)
+		}
+		if h.calls != 0 {
+			t.Errorf("underlying client got %d calls; want 0", h.calls)
+		}
+	})
+	t.Run("Recv", func(t *testing.T) {
+		for _, allow := range []bool{true, false} {
+			h := new(dummyHook)
+			c := RateLimitedClient(NewClient(h), &fakeLimiter{allow: allow})
+			ret := new(dummyReturner)
+			released := false
+			pcall := c.RecvCall(context.Background(), Recv{
+				Method:      method,
+				ReleaseArgs: func() { released = true },
+				Returner:    ret,
+			})
+			c.Release()
+			if pcall != nil {
+				t.Errorf("allow = %t: RecvCall returned a PipelineCaller; want nil", allow)
+			}
+			if !ret.returned || !released {
+				t.Errorf("allow = %t: call did not return and release its arguments", allow)
+			}
+			if allow {
+				if ret.err != nil || h.calls != 1 {
+					t.Errorf("allow = %t: call error = %v and %d underlying calls; want success forwarded once", allow, ret.err, h.calls)
+				}
+				continue
+			}
+			if typ := interr.TypeOf(ret.err); ret.err == nil || typ != interr.Overloaded {
+				t.Errorf("allow = %t: call error = %v; want overloaded", allow, ret.err)
+			}
+			if h.calls != 0 {
+				t.Errorf("allow = %t: underlying client got %d calls; want 0", allow, h.calls)
+			}
+		}
+	})
+}
+
+// fakeLimiter is a RateLimiter that either always allows calls or never
+// does.  When it doesn't, Wait closes waiting (if not nil) and blocks
+// until its Context is done.
+type fakeLimiter struct {
+	allow   bool
+	waiting chan struct{}
+}
+
+func (l *fakeLimiter) Allow() bool {
+	return l.allow
+}
+
+func (l *fakeLimiter) Wait(ctx context.Context) error {
+	if l.allow {
+		return nil
+	}
+	if l.waiting != nil {
+		close(l.waiting)
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
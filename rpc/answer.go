@@ -5,7 +5,6 @@ import (
 	"sync"
 
 	"capnproto.org/go/capnp/v3"
-	"capnproto.org/go/capnp/v3/internal/errors"
 	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
 )
 
@@ -69,6 +68,11 @@ type answer struct {
 	// the Return message.  Can only be read after resultsReady is set in
 	// flags.
 	err error
+
+	// returned is closed when Return finishes.  It is only set for
+	// connections using Options.SynchronousDispatch, before the call is
+	// delivered.
+	returned chan struct{}
 }
 
 type answerFlags uint8
@@ -158,10 +162,23 @@ func (ans *answer) setBootstrap(c *capnp.Client) error {
 //
 // The caller must NOT be holding onto ans.c.mu or the sender lock.
 func (ans *answer) Return(e error) {
+	if ans.returned != nil {
+		defer close(ans.returned)
+	}
+	if e == nil && ans.results.IsValid() {
+		// Catch dangling interface pointers before the results are sent.
+		if err := ans.results.Message().ValidateForSend(); err != nil {
+			e = annotate(err).errorf("return")
+		}
+	}
 	var cstates []capnp.ClientState
 	if ans.results.IsValid() {
 		ans.resultCapTable, cstates = extractCapTable(ans.results.Message())
 	}
+	var intros []introduction
+	if e == nil {
+		intros = ans.c.introduce(ans.resultCapTable, cstates)
+	}
 	ans.c.mu.Lock()
 	ans.c.lockSender()
 	if e != nil {
@@ -173,7 +190,7 @@ func (ans *answer) Return(e error) {
 		ans.c.tasks.Done() // added by handleCall
 		return
 	}
-	rl, err := ans.sendReturn(cstates)
+	rl, err := ans.sendReturn(cstates, intros)
 	ans.c.unlockSender()
 	if err != nil {
 		select {
@@ -196,7 +213,8 @@ func (ans *answer) Return(e error) {
 }
 
 // sendReturn sends the return message with results allocated by a
-// previous call to AllocResults.  If the answer already received a
+// previous call to AllocResults.  intros are the handoffs of the
+// results' capabilities started by introduce, if any.  If the answer already received a
 // Finish with releaseResultCaps set to true, then sendReturn returns
 // the number of references to be subtracted from each export.
 //
@@ -204,11 +222,11 @@ func (ans *answer) Return(e error) {
 // The result's capability table must have been extracted into
 // ans.resultsCapTable before calling sendReturn. Only one of
 // sendReturn or sendException should be called.
-func (ans *answer) sendReturn(cstates []capnp.ClientState) (releaseList, error) {
+func (ans *answer) sendReturn(cstates []capnp.ClientState, intros []introduction) (releaseList, error) {
 	ans.pcall = nil
 	ans.flags |= resultsReady
 	var err error
-	ans.exportRefs, err = ans.c.fillPayloadCapTable(ans.results, ans.resultCapTable, cstates)
+	ans.exportRefs, err = ans.c.fillPayloadCapTable(ans.results, ans.resultCapTable, cstates, intros)
 	if err != nil {
 		ans.c.report(annotate(err).errorf("send return"))
 		// Continue.  Don't fail to send return if cap table isn't fully filled.
@@ -260,8 +278,9 @@ func (ans *answer) sendException(e error) releaseList {
 		if exc, err := ans.ret.NewException(); err != nil {
 			ans.c.reportf("send exception: %v", err)
 		} else {
-			exc.SetType(rpccp.Exception_Type(errors.TypeOf(e)))
-			if err := exc.SetReason(e.Error()); err != nil {
+			reason, typ := ans.c.exception(e)
+			exc.SetType(typ)
+			if err := exc.SetReason(reason); err != nil {
 				ans.c.reportf("send exception: %v", err)
 			} else if err := ans.sendMsg(); err != nil {
 				ans.c.reportf("send return: %v", err)
@@ -295,6 +314,7 @@ func (ans *answer) sendException(e error) releaseList {
 // shutdown has its own strategy for cleaning up an answer.
 func (ans *answer) destroy() (releaseList, error) {
 	delete(ans.c.answers, ans.id)
+	ans.c.checkDrained()
 	rl := releaseList(ans.resultCapTable)
 	if ans.flags&releaseResultCapsFlag == 0 || len(ans.exportRefs) == 0 {
 		return rl, nil
@@ -2,10 +2,13 @@ package rpc_test
 
 import (
 	"context"
+	"io"
 	"testing"
 
+	"capnproto.org/go/capnp/v3"
 	"capnproto.org/go/capnp/v3/rpc"
 	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
 )
 
 func BenchmarkPingPong(b *testing.B) {
@@ -64,3 +67,76 @@ func (pingPongServer) EchoNum(ctx context.Context, call testcp.PingPong_echoNum)
 	out.SetN(call.Args().N())
 	return nil
 }
+
+// BenchmarkOutboundMessage builds, writes, and releases the kinds of
+// small messages a Conn sends most often, with a fresh arena for each
+// message and with a pooled one.
+func BenchmarkOutboundMessage(b *testing.B) {
+	finish := func(msg rpccp.Message) error {
+		fin, err := msg.NewFinish()
+		if err != nil {
+			return err
+		}
+		fin.SetQuestionId(42)
+		fin.SetReleaseResultCaps(true)
+		return nil
+	}
+	ret := func(msg rpccp.Message) error {
+		r, err := msg.NewReturn()
+		if err != nil {
+			return err
+		}
+		r.SetAnswerId(42)
+		payload, err := r.NewResults()
+		if err != nil {
+			return err
+		}
+		results, err := capnp.NewStruct(payload.Segment(), capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			return err
+		}
+		results.SetUint64(0, 42)
+		return payload.SetContent(results.ToPtr())
+	}
+	newArenas := []struct {
+		name     string
+		newArena func() (capnp.Arena, func())
+	}{
+		{"MultiSegment", func() (capnp.Arena, func()) {
+			return capnp.MultiSegment(nil), func() {}
+		}},
+		{"Pooled", func() (capnp.Arena, func()) {
+			arena := capnp.NewPooledArena()
+			return arena, arena.Release
+		}},
+	}
+	for _, m := range []struct {
+		name  string
+		build func(rpccp.Message) error
+	}{{"Finish", finish}, {"Return", ret}} {
+		for _, a := range newArenas {
+			b.Run(m.name+"/"+a.name, func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					arena, release := a.newArena()
+					msg, seg, err := capnp.NewMessage(arena)
+					if err != nil {
+						b.Fatal(err)
+					}
+					rmsg, err := rpccp.NewRootMessage(seg)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if err := m.build(rmsg); err != nil {
+						b.Fatal(err)
+					}
+					if _, err := msg.WriteTo(io.Discard); err != nil {
+						b.Fatal(err)
+					}
+					msg.Reset(nil)
+					release()
+				}
+			})
+		}
+	}
+}
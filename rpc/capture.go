@@ -0,0 +1,133 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"sync"
+
+	capnp "capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// A CaptureDirection is the direction of a captured message relative to
+// the Conn that captured it.
+type CaptureDirection uint8
+
+// Capture directions.
+const (
+	// CaptureSent marks a message that the Conn sent.
+	CaptureSent CaptureDirection = 1
+
+	// CaptureReceived marks a message that the Conn received.
+	CaptureReceived CaptureDirection = 2
+)
+
+// String returns "sent" or "received".
+func (dir CaptureDirection) String() string {
+	switch dir {
+	case CaptureSent:
+		return "sent"
+	case CaptureReceived:
+		return "received"
+	default:
+		return "CaptureDirection(" + strconv.Itoa(int(dir)) + ")"
+	}
+}
+
+// captureTransport is a transport that writes a copy of every message it
+// sends or receives to w.  See Options.CaptureTo.
+type captureTransport struct {
+	Transport
+	reporter ErrorReporter
+
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (ct *captureTransport) NewMessage(ctx context.Context) (rpccp.Message, func() error, capnp.ReleaseFunc, error) {
+	msg, send, release, err := ct.Transport.NewMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, nil, err
+	}
+	capturedSend := func() error {
+		if err := send(); err != nil {
+			return err
+		}
+		ct.capture(CaptureSent, msg.Message())
+		return nil
+	}
+	return msg, capturedSend, release, nil
+}
+
+func (ct *captureTransport) RecvMessage(ctx context.Context) (rpccp.Message, capnp.ReleaseFunc, error) {
+	msg, release, err := ct.Transport.RecvMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, err
+	}
+	ct.capture(CaptureReceived, msg.Message())
+	return msg, release, nil
+}
+
+// capture writes a record for msg.  Errors are reported rather than
+// returned, so that a failing capture does not affect the connection.
+func (ct *captureTransport) capture(dir CaptureDirection, msg *capnp.Message) {
+	data, err := msg.Marshal()
+	if err != nil {
+		ct.report(errorf("capture %v message: %v", dir, err))
+		return
+	}
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	if _, err := ct.w.Write(append([]byte{byte(dir)}, data...)); err != nil {
+		ct.report(errorf("capture %v message: %v", dir, err))
+	}
+}
+
+func (ct *captureTransport) report(err error) {
+	if ct.reporter != nil {
+		ct.reporter.ReportError(err)
+	}
+}
+
+// A CaptureReader reads the messages written by a Conn with
+// Options.CaptureTo set.  Each record in a capture is a single byte
+// holding the CaptureDirection, followed by the message in the standard
+// Cap'n Proto stream framing.
+type CaptureReader struct {
+	r   io.Reader
+	dec *capnp.Decoder
+	dir [1]byte
+}
+
+// NewCaptureReader returns a reader for the capture stored in r.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{r: r, dec: capnp.NewDecoder(r)}
+}
+
+// Next reads the next captured message.  It returns io.EOF once the end
+// of the capture is reached.
+func (cr *CaptureReader) Next() (CaptureDirection, rpccp.Message, error) {
+	if _, err := io.ReadFull(cr.r, cr.dir[:]); err != nil {
+		if err == io.EOF {
+			return 0, rpccp.Message{}, io.EOF
+		}
+		return 0, rpccp.Message{}, errorf("read capture: %v", err)
+	}
+	dir := CaptureDirection(cr.dir[0])
+	if dir != CaptureSent && dir != CaptureReceived {
+		return 0, rpccp.Message{}, errorf("read capture: unknown direction %d", cr.dir[0])
+	}
+	msg, err := cr.dec.Decode()
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, rpccp.Message{}, errorf("read capture: %v", err)
+	}
+	rmsg, err := rpccp.ReadRootMessage(msg)
+	if err != nil {
+		return 0, rpccp.Message{}, errorf("read capture: %v", err)
+	}
+	return dir, rmsg, nil
+}
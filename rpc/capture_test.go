@@ -0,0 +1,108 @@
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/server"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestCaptureTo(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			return err
+		}
+		resp.SetUint64(0, 42)
+		return nil
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	capture := new(bytes.Buffer)
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		CaptureTo:     capture,
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	client := conn2.Bootstrap(ctx)
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if s, err := ans.Struct(); err != nil {
+		t.Error("call on bootstrap:", err)
+	} else if got := s.Uint64(0); got != 42 {
+		t.Errorf("call on bootstrap returned %d; want 42", got)
+	}
+	release()
+	client.Release()
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+
+	// Finish, Release and Abort messages depend on timing, so only the
+	// requests and their returns are checked.
+	type record struct {
+		dir   rpc.CaptureDirection
+		which rpccp.Message_Which
+	}
+	want := []record{
+		{rpc.CaptureSent, rpccp.Message_Which_bootstrap},
+		{rpc.CaptureReceived, rpccp.Message_Which_return},
+		{rpc.CaptureSent, rpccp.Message_Which_call},
+		{rpc.CaptureReceived, rpccp.Message_Which_return},
+	}
+	var got []record
+	r := rpc.NewCaptureReader(capture)
+	for {
+		dir, msg, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal("r.Next():", err)
+		}
+		switch w := msg.Which(); w {
+		case rpccp.Message_Which_finish, rpccp.Message_Which_release, rpccp.Message_Which_abort:
+		default:
+			got = append(got, record{dir, w})
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("captured %v; want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("captured[%d] = %v; want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCaptureReaderTruncated(t *testing.T) {
+	r := rpc.NewCaptureReader(bytes.NewReader([]byte{byte(rpc.CaptureSent), 0, 0}))
+	if _, _, err := r.Next(); err == nil || err == io.EOF {
+		t.Errorf("r.Next() on truncated capture = %v; want non-EOF error", err)
+	}
+	r = rpc.NewCaptureReader(bytes.NewReader([]byte{7}))
+	if _, _, err := r.Next(); err == nil || err == io.EOF {
+		t.Errorf("r.Next() with unknown direction = %v; want non-EOF error", err)
+	}
+}
@@ -0,0 +1,183 @@
+package rpc
+
+import (
+	"context"
+	"encoding/binary"
+	"hash"
+	"hash/crc32"
+	"strconv"
+
+	capnp "capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/errors"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// A ChecksumAlgo is a checksum algorithm used by
+// NewChecksummedTransport.
+type ChecksumAlgo int
+
+// Checksum algorithms.
+const (
+	// ChecksumCRC32 is CRC-32 with the IEEE polynomial.
+	ChecksumCRC32 ChecksumAlgo = iota
+
+	// ChecksumCRC32C is CRC-32 with the Castagnoli polynomial.
+	ChecksumCRC32C
+)
+
+func (algo ChecksumAlgo) new() hash.Hash32 {
+	switch algo {
+	case ChecksumCRC32:
+		return crc32.NewIEEE()
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	default:
+		panic("rpc: unknown checksum algorithm " + strconv.Itoa(int(algo)))
+	}
+}
+
+// NewChecksummedTransport returns a transport that sends and receives
+// messages using t, but adds a checksum of each message's segments to
+// its root struct, past the fields defined by the RPC schema.  A received
+// message whose checksum does not match causes RecvMessage to fail,
+// which aborts the connection.  Both vats must use the same algorithm,
+// and t must deliver messages with the same segments that were sent,
+// as the stream transports do.  Received messages are not modified.
+//
+// The root struct is enlarged when t creates the message, so t must be
+// a transport created by this package; NewChecksummedTransport panics
+// otherwise.  A wrapper around t that rebuilds or replaces the messages
+// t creates would drop the checksum, so t can't be such a wrapper.
+//
+// This is intended for transports that may silently corrupt data, like
+// serial links.
+func NewChecksummedTransport(t Transport, algo ChecksumAlgo) Transport {
+	algo.new() // panic early on unknown algorithm
+	st, ok := t.(shapedTransport)
+	if !ok || !st.shapeMessages(messageShape{rootSize: checksumMessageSize}) {
+		panic("rpc: NewChecksummedTransport: transport can't make room for a checksum")
+	}
+	return &checksumTransport{t: st, algo: algo}
+}
+
+// checksumMessageSize is the size of rpccp.Message plus one word for
+// the checksum.
+var checksumMessageSize = capnp.ObjectSize{DataSize: 16, PointerCount: 1}
+
+// checksumOffset is the offset of the checksum in the root struct.
+const checksumOffset capnp.DataOffset = 8
+
+// checksumStart is the offset of the checksum in the first segment.
+// The root struct comes right after the root pointer's word.
+const checksumStart = 8 + int(checksumOffset)
+
+type checksumTransport struct {
+	t    shapedTransport
+	algo ChecksumAlgo
+}
+
+func (ct *checksumTransport) NewMessage(ctx context.Context) (rpccp.Message, func() error, capnp.ReleaseFunc, error) {
+	msg, send, release, err := ct.t.NewMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, nil, err
+	}
+	if _, ok := checksumField(msg.Segment()); !ok {
+		release()
+		return rpccp.Message{}, nil, nil, errors.New(errors.Failed, "rpc checksum", "new message: no room for checksum")
+	}
+	checksummedSend := func() error {
+		sum, err := ct.checksum(msg.Message())
+		if err != nil {
+			return err
+		}
+		msg.SetUint64(checksumOffset, uint64(sum))
+		return send()
+	}
+	return msg, checksummedSend, release, nil
+}
+
+func (ct *checksumTransport) shapeMessages(shape messageShape) bool {
+	return ct.t.shapeMessages(shape)
+}
+
+func (ct *checksumTransport) poolInbound() {
+	if pt, ok := ct.t.(poolingTransport); ok {
+		pt.poolInbound()
+	}
+}
+
+func (ct *checksumTransport) limitInbound(max uint64) {
+	if lt, ok := ct.t.(limitedTransport); ok {
+		lt.limitInbound(max)
+	}
+}
+
+func (ct *checksumTransport) RecvMessage(ctx context.Context) (rpccp.Message, capnp.ReleaseFunc, error) {
+	msg, release, err := ct.t.RecvMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, err
+	}
+	seg, err := msg.Message().Segment(0)
+	if err != nil {
+		release()
+		return rpccp.Message{}, nil, errors.New(errors.Failed, "rpc checksum", "read segment: "+err.Error())
+	}
+	field, ok := checksumField(seg)
+	if !ok {
+		release()
+		return rpccp.Message{}, nil, errors.New(errors.Failed, "rpc checksum", "message has no checksum")
+	}
+	got, err := ct.checksum(msg.Message())
+	if err != nil {
+		release()
+		return rpccp.Message{}, nil, err
+	}
+	if uint64(got) != binary.LittleEndian.Uint64(field) {
+		release()
+		return rpccp.Message{}, nil, errors.New(errors.Failed, "rpc checksum", "checksum mismatch: message corrupted")
+	}
+	return msg, release, nil
+}
+
+func (ct *checksumTransport) Close() error {
+	return ct.t.Close()
+}
+
+// checksumField returns the bytes of the checksum in seg, the first
+// segment of a message.  It reports false if the root pointer doesn't
+// point to a struct right after it with room for the checksum.
+func checksumField(seg *capnp.Segment) ([]byte, bool) {
+	data := seg.Data()
+	if len(data) < checksumStart+8 {
+		return nil, false
+	}
+	// A struct pointer with an offset of zero has its low 32 bits clear.
+	// The data section size in words is in bits 32 through 47.
+	root := binary.LittleEndian.Uint64(data)
+	if uint32(root) != 0 || uint16(root>>32) < uint16(checksumMessageSize.DataSize/8) {
+		return nil, false
+	}
+	return data[checksumStart : checksumStart+8], true
+}
+
+// checksum computes the checksum of every segment in msg, with the
+// checksum itself read as zero.
+func (ct *checksumTransport) checksum(msg *capnp.Message) (uint32, error) {
+	h := ct.algo.new()
+	n := msg.NumSegments()
+	for i := int64(0); i < n; i++ {
+		seg, err := msg.Segment(capnp.SegmentID(i))
+		if err != nil {
+			return 0, errors.New(errors.Failed, "rpc checksum", "read segment: "+err.Error())
+		}
+		data := seg.Data()
+		if i == 0 {
+			var zero [8]byte
+			h.Write(data[:checksumStart])
+			h.Write(zero[:])
+			data = data[checksumStart+8:]
+		}
+		h.Write(data)
+	}
+	return h.Sum32(), nil
+}
@@ -0,0 +1,130 @@
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"capnproto.org/go/capnp/v3/rpc"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestChecksummedTransport(t *testing.T) {
+	testTransport(t, func() (t1, t2 rpc.Transport, err error) {
+		c1, c2, err := tcpPair()
+		if err != nil {
+			return nil, nil, err
+		}
+		return rpc.NewChecksummedTransport(rpc.NewStreamTransport(c1), rpc.ChecksumCRC32),
+			rpc.NewChecksummedTransport(rpc.NewStreamTransport(c2), rpc.ChecksumCRC32), nil
+	})
+}
+
+func TestChecksumMismatch(t *testing.T) {
+	c1, c2 := net.Pipe()
+	errs := make(chan error, 10)
+	conn1 := rpc.NewConn(rpc.NewChecksummedTransport(rpc.NewStreamTransport(c1), rpc.ChecksumCRC32), &rpc.Options{
+		ErrorReporter: chanErrorReporter(errs),
+	})
+	defer conn1.Close()
+	// Flip a byte in the root struct of the first message sent by conn2.
+	conn2 := rpc.NewConn(rpc.NewChecksummedTransport(rpc.NewStreamTransport(&corruptingConn{Conn: c2, off: 32}), rpc.ChecksumCRC32), &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer conn2.Close()
+
+	boot := conn2.Bootstrap(context.Background())
+	defer boot.Release()
+	<-conn1.Done()
+	err := <-errs
+	var te *rpc.TransportError
+	if !errors.As(err, &te) {
+		t.Errorf("reported error = %v; want *rpc.TransportError", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("reported error = %v; want checksum mismatch", err)
+	}
+}
+
+// TestChecksummedMessages checks that the checksum only adds one word
+// to a message and that received messages are not modified.
+func TestChecksummedMessages(t *testing.T) {
+	ctx := context.Background()
+	boot := &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: 7},
+	}
+	// firstSegment sends boot on send and returns the first segment of
+	// the message received on recv.
+	firstSegment := func(send, recv rpc.Transport) []byte {
+		if err := sendMessage(ctx, send, boot); err != nil {
+			t.Fatal(err)
+		}
+		msg, release, err := recv.RecvMessage(ctx)
+		if err != nil {
+			t.Fatal("RecvMessage:", err)
+		}
+		defer release()
+		seg, err := msg.Message().Segment(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append([]byte(nil), seg.Data()...)
+	}
+
+	p1, p2 := rpc.NewPipe()
+	defer p2.Close()
+	defer p1.Close()
+	plain := firstSegment(p1, p2)
+	checksummed := firstSegment(rpc.NewChecksummedTransport(p1, rpc.ChecksumCRC32), p2)
+	if len(checksummed) != len(plain)+8 {
+		t.Errorf("checksummed message's first segment is %d bytes; want %d (plain message plus a word)", len(checksummed), len(plain)+8)
+	}
+
+	p3, p4 := rpc.NewPipe()
+	ct3 := rpc.NewChecksummedTransport(p3, rpc.ChecksumCRC32)
+	defer ct3.Close()
+	ct4 := rpc.NewChecksummedTransport(p4, rpc.ChecksumCRC32)
+	defer ct4.Close()
+	if got := firstSegment(ct3, ct4); !bytes.Equal(got, checksummed) {
+		t.Errorf("received message = %x; want %x as sent", got, checksummed)
+	}
+}
+
+func TestChecksummedTransportUnsupported(t *testing.T) {
+	p1, p2 := rpc.NewPipe()
+	defer p1.Close()
+	defer p2.Close()
+	defer func() {
+		if recover() == nil {
+			t.Error("NewChecksummedTransport did not panic on a transport outside the rpc package")
+		}
+	}()
+	rpc.NewChecksummedTransport(struct{ rpc.Transport }{p1}, rpc.ChecksumCRC32)
+}
+
+// corruptingConn flips the bits of the byte written at offset off.
+type corruptingConn struct {
+	net.Conn
+
+	mu  sync.Mutex
+	n   int
+	off int
+}
+
+func (c *corruptingConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.off >= c.n && c.off < c.n+len(p) {
+		q := make([]byte, len(p))
+		copy(q, p)
+		q[c.off-c.n] ^= 0xff
+		p = q
+	}
+	c.n += len(p)
+	c.mu.Unlock()
+	return c.Conn.Write(p)
+}
@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"context"
+
+	"capnproto.org/go/capnp/v3"
+)
+
+// Dial creates a connection on t and returns the remote vat's bootstrap
+// capability once it has resolved, along with the connection.  It is a
+// shorthand for NewConn followed by Conn.Bootstrap for a client that
+// only needs the remote vat's main interface.  If the remote vat
+// answers the bootstrap request with an exception, the connection shuts
+// down, or ctx is done before the capability has resolved, then the
+// connection is closed and the error is returned.  Otherwise, the caller
+// is responsible for releasing the client and closing the connection.
+func Dial(ctx context.Context, t Transport, opts *Options) (*capnp.Client, *Conn, error) {
+	conn := NewConn(t, opts)
+	client, hook := conn.startBootstrap(ctx, capnp.Ptr{})
+	err := waitBootstrap(ctx, hook)
+	if err == nil {
+		err = client.Resolve(ctx)
+	}
+	if err != nil {
+		client.Release()
+		conn.Close()
+		return nil, nil, annotate(err).errorf("dial")
+	}
+	return client, conn, nil
+}
+
+// waitBootstrap waits for the return of the bootstrap request made for
+// the client with the given hook, which is nil if the connection had
+// already shut down.
+func waitBootstrap(ctx context.Context, hook *bootstrapClient) error {
+	if hook == nil {
+		return disconnected("connection closed")
+	}
+	select {
+	case <-hook.ready:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if hook.err != nil {
+		return hook.err
+	}
+	select {
+	case <-hook.ans.Done():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	_, err := hook.ans.Struct()
+	return err
+}
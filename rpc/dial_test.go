@@ -0,0 +1,90 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestDial(t *testing.T) {
+	ctx := context.Background()
+	t.Run("Bootstrap", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		srvConn := rpc.NewConn(p2, &rpc.Options{
+			BootstrapClient: testcp.PingPong_ServerToClient(pingPongServer{}, nil).Client,
+			ErrorReporter:   testErrorReporter{tb: t},
+		})
+		client, conn, err := rpc.Dial(ctx, p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		if err != nil {
+			t.Fatal("rpc.Dial:", err)
+		}
+		defer closeConns(t, conn, srvConn)
+		pp := testcp.PingPong{Client: client}
+		defer pp.Client.Release()
+		ans, release := pp.EchoNum(ctx, func(args testcp.PingPong_echoNum_Params) error {
+			args.SetN(42)
+			return nil
+		})
+		defer release()
+		result, err := ans.Struct()
+		if err != nil {
+			t.Fatal("echoNum:", err)
+		}
+		if result.N() != 42 {
+			t.Errorf("echoNum(42) = %d", result.N())
+		}
+	})
+	t.Run("Exception", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		srvConn := rpc.NewConn(p2, &rpc.Options{
+			NoBootstrapHandler: func(context.Context) (*capnp.Client, error) {
+				return nil, capnp.Unimplemented("no bootstrap here")
+			},
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		client, conn, err := rpc.Dial(ctx, p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		if err == nil {
+			client.Release()
+			closeConns(t, conn, srvConn)
+			t.Fatal("rpc.Dial succeeded; want error")
+		}
+		if !capnp.IsUnimplemented(err) {
+			t.Errorf("rpc.Dial error = %v; want unimplemented", err)
+		}
+		<-srvConn.Done()
+		if err := srvConn.Close(); err != nil {
+			t.Error("srvConn.Close:", err)
+		}
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		defer p2.Close()
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+		_, _, err := rpc.Dial(ctx, p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("rpc.Dial error = %v; want %v", err, context.DeadlineExceeded)
+		}
+		// The bootstrap request was sent before Dial gave up.
+		rmsg, release, err := recvMessage(context.Background(), p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Errorf("Received %v message; want bootstrap", rmsg.Which)
+		}
+	})
+}
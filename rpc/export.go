@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"context"
+	"time"
 
 	"capnproto.org/go/capnp/v3"
 	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
@@ -10,10 +11,18 @@ import (
 // An exportID is an index into the exports table.
 type exportID uint32
 
-// expent is an entry in a Conn's export table.
+// expent is an entry in a Conn's export table.  client is the Conn's
+// strong reference to the capability, so an export keeps the capability
+// alive exactly as long as the remote vat holds wire references to it.
+// A vat that must not pin an exported capability itself can keep a
+// capnp.WeakClient to it and let the export hold the only reference.
 type expent struct {
 	client   *capnp.Client
 	wireRefs uint32
+
+	// provides finish the Provide questions of handoffs that use this
+	// export as their vine.  They are called when the export is removed.
+	provides []context.CancelFunc
 }
 
 // findExport returns the export entry with the given ID or nil if
@@ -28,7 +37,9 @@ func (c *Conn) findExport(id exportID) *expent {
 // releaseExport decreases the number of wire references to an export
 // by a given number.  If the export's reference count reaches zero,
 // then releaseExport will pop export from the table and return the
-// export's client.  The caller must be holding onto c.mu, and the
+// export's client.  Releasing more references than the export has is
+// an error, as is releasing zero references if Options.StrictReleases
+// is set.  The caller must be holding onto c.mu, and the
 // caller is responsible for releasing the client once the caller is no
 // longer holding onto c.mu.
 func (c *Conn) releaseExport(id exportID, count uint32) (*capnp.Client, error) {
@@ -37,10 +48,18 @@ func (c *Conn) releaseExport(id exportID, count uint32) (*capnp.Client, error) {
 		return nil, errorf("unknown export ID %d", id)
 	}
 	switch {
+	case count == 0:
+		if c.strictReleases {
+			return nil, errorf("export ID %d released zero references", id)
+		}
+		return nil, nil
 	case count == ent.wireRefs:
 		client := ent.client
+		for _, cancel := range ent.provides {
+			cancel()
+		}
 		c.exports[id] = nil
-		c.exportID.remove(uint32(id))
+		c.freeExportID(id)
 		return client, nil
 	case count > ent.wireRefs:
 		return nil, errorf("export ID %d released too many references", id)
@@ -50,6 +69,21 @@ func (c *Conn) releaseExport(id exportID, count uint32) (*capnp.Client, error) {
 	}
 }
 
+// freeExportID frees the ID of a removed export for reuse, shrinking
+// the exports table if it held the largest ID in use.  The caller must
+// be holding onto c.mu.
+func (c *Conn) freeExportID(id exportID) {
+	c.exportID.remove(uint32(id))
+	n := c.exportID.len()
+	if n >= len(c.exports) {
+		return
+	}
+	c.exports = c.exports[:n]
+	if n <= cap(c.exports)/4 {
+		c.exports = append([]*expent(nil), c.exports...)
+	}
+}
+
 func (c *Conn) releaseExports(refs map[exportID]uint32) (releaseList, error) {
 	n := len(refs)
 	var rl releaseList
@@ -121,10 +155,11 @@ func (c *Conn) sendCap(d rpccp.CapDescriptor, client *capnp.Client, state capnp.
 
 // fillPayloadCapTable adds descriptors of payload's message's
 // capabilities into payload's capability table and returns the
-// reference counts added to the exports table.
+// reference counts added to the exports table.  intros is the result
+// of calling introduce with clients and states.
 //
 // The caller must be holding onto c.mu.
-func (c *Conn) fillPayloadCapTable(payload rpccp.Payload, clients []*capnp.Client, states []capnp.ClientState) (map[exportID]uint32, error) {
+func (c *Conn) fillPayloadCapTable(payload rpccp.Payload, clients []*capnp.Client, states []capnp.ClientState, intros []introduction) (map[exportID]uint32, error) {
 	if len(clients) != len(states) {
 		panic("states slice must be same size as cap table")
 	}
@@ -133,11 +168,23 @@ func (c *Conn) fillPayloadCapTable(payload rpccp.Payload, clients []*capnp.Clien
 	}
 	list, err := payload.NewCapTable(int32(len(clients)))
 	if err != nil {
+		for _, intro := range intros {
+			if intro.cancel != nil {
+				intro.cancel()
+			}
+		}
 		return nil, errorf("payload capability table: %v", err)
 	}
 	var refs map[exportID]uint32
 	for i, client := range clients {
 		id, isExport := c.sendCap(list.At(i), client, states[i])
+		if intros != nil && intros[i].cancel != nil {
+			if isExport {
+				c.sendThirdPartyCap(list.At(i), id, intros[i])
+			} else {
+				intros[i].cancel()
+			}
+		}
 		if !isExport {
 			continue
 		}
@@ -173,6 +220,10 @@ type embargo struct {
 	c      *capnp.Client
 	p      *capnp.ClientPromise
 	lifted chan struct{}
+
+	// timer is non-nil if the Conn has an embargo timeout.  It fires
+	// expireEmbargo.
+	timer *time.Timer
 }
 
 // embargo creates a new embargoed client, stealing the reference.
@@ -191,6 +242,11 @@ func (c *Conn) embargo(client *capnp.Client) (embargoID, *capnp.Client) {
 	}
 	var c2 *capnp.Client
 	c2, c.embargoes[id].p = capnp.NewPromisedClient(c.embargoes[id])
+	if c.embargoTimeout > 0 {
+		e.timer = time.AfterFunc(c.embargoTimeout, func() {
+			c.expireEmbargo(id, e)
+		})
+	}
 	return id, c2
 }
 
@@ -203,8 +259,72 @@ func (c *Conn) findEmbargo(id embargoID) *embargo {
 	return c.embargoes[id] // might be nil
 }
 
+// clearEmbargo removes the embargo entry with the given ID and frees
+// the ID for reuse, shrinking the table if it held the largest ID in
+// use, so that embargoes stays as small as the number of outstanding
+// embargoes.
+//
+// The caller must be holding onto c.mu.
+func (c *Conn) clearEmbargo(id embargoID) {
+	c.embargoes[id] = nil
+	c.embargoID.remove(uint32(id))
+	n := c.embargoID.len()
+	if n >= len(c.embargoes) {
+		return
+	}
+	c.embargoes = c.embargoes[:n]
+	if n <= cap(c.embargoes)/4 {
+		c.embargoes = append([]*embargo(nil), c.embargoes...)
+	}
+}
+
+// dropEmbargo frees the ID of an embargo whose disembargo could not be
+// sent.  The embargo may have already been removed by shutdown.
+func (c *Conn) dropEmbargo(id embargoID) {
+	c.mu.Lock()
+	if c.findEmbargo(id) != nil {
+		c.clearEmbargo(id)
+	}
+	c.mu.Unlock()
+}
+
+// expireEmbargo lifts an embargo whose disembargo was not looped back
+// within Options.EmbargoTimeout.  If Options.AbortOnEmbargoTimeout is
+// set, it also shuts down the connection.  e is checked against the
+// table because id may have been freed and reused since the timer was
+// started.
+func (c *Conn) expireEmbargo(id embargoID, e *embargo) {
+	c.mu.Lock()
+	if c.findEmbargo(id) != e {
+		c.mu.Unlock()
+		return
+	}
+	c.clearEmbargo(id)
+	err := errorf("embargo %d: no disembargo from remote vat within %v", id, c.embargoTimeout)
+	if !c.abortOnEmbargoTimeout {
+		c.mu.Unlock()
+		c.report(err)
+		e.lift()
+		return
+	}
+	select {
+	case <-c.bgctx.Done():
+		c.mu.Unlock()
+	default:
+		c.report(err)
+		// shutdown unlocks c.mu.
+		if err := c.shutdown(err); err != nil {
+			c.report(err)
+		}
+	}
+	e.lift()
+}
+
 // lift disembargoes the client.  It must be called only once.
 func (e *embargo) lift() {
+	if e.timer != nil {
+		e.timer.Stop()
+	}
 	close(e.lifted)
 	e.p.Fulfill(e.c)
 }
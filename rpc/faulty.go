@@ -0,0 +1,266 @@
+package rpc
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	capnp "capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/errors"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// reorderFlushDelay is how long a transport created with FaultReorder
+// holds messages after the last send before sending them anyway.
+const reorderFlushDelay = 10 * time.Millisecond
+
+// A FaultOption configures a transport created by FaultyTransport.
+type FaultOption func(*faultConfig)
+
+type faultConfig struct {
+	seed          int64
+	latency       time.Duration
+	reorderWindow int
+	dropRate      float64
+	corruptRate   float64
+}
+
+// FaultSeed seeds the random choices of a faulty transport.  Two
+// transports with the same seed and options make the same choices for
+// the same sequence of sends.  The default seed is 1.
+func FaultSeed(seed int64) FaultOption {
+	return func(cfg *faultConfig) { cfg.seed = seed }
+}
+
+// FaultLatency delays every send by d.  The delay is cut short if the
+// message's Context is done.
+func FaultLatency(d time.Duration) FaultOption {
+	return func(cfg *faultConfig) { cfg.latency = d }
+}
+
+// FaultReorder shuffles messages within a window of n: sent messages
+// are held until n of them are waiting, then one is picked at random
+// and sent.  Messages still held when there have been no sends for a
+// short while are sent in random order, so that a peer waiting on a
+// held message is not stuck forever.  Held messages are copied, and
+// errors from sending them are ignored, as if they were lost in
+// transit.  A window of 1 or less does not reorder.
+func FaultReorder(n int) FaultOption {
+	return func(cfg *faultConfig) { cfg.reorderWindow = n }
+}
+
+// FaultDrop silently discards the given fraction of sent messages.
+func FaultDrop(fraction float64) FaultOption {
+	return func(cfg *faultConfig) { cfg.dropRate = fraction }
+}
+
+// FaultCorrupt flips one random bit in the given fraction of sent
+// messages.  The bit may be anywhere in the message's segments, so the
+// remote vat may see a malformed message or one that is well-formed but
+// wrong.
+func FaultCorrupt(fraction float64) FaultOption {
+	return func(cfg *faultConfig) { cfg.corruptRate = fraction }
+}
+
+// FaultyTransport returns a transport that sends messages through t
+// with the faults given in opts, for testing how a Conn copes with an
+// unreliable network or an adversarial peer.  Faults are only injected
+// into sent messages; wrapping one end of a pipe affects one direction.
+// Received messages are passed through unchanged.
+func FaultyTransport(t Transport, opts ...FaultOption) Transport {
+	cfg := faultConfig{seed: 1}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &faultyTransport{
+		Transport: t,
+		cfg:       cfg,
+		rng:       rand.New(rand.NewSource(cfg.seed)),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+type faultyTransport struct {
+	Transport
+	cfg faultConfig
+
+	// ctx is used to send held messages.  It is canceled by Close.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu is held while sending on the underlying transport, so that held
+	// messages are not sent concurrently with other messages.
+	mu     sync.Mutex
+	rng    *rand.Rand
+	held   []heldMessage
+	timer  *time.Timer
+	closed bool
+}
+
+// heldMessage is a copy of a message held by FaultReorder, created on
+// the underlying transport.
+type heldMessage struct {
+	send    func() error
+	release capnp.ReleaseFunc
+}
+
+func (ft *faultyTransport) NewMessage(ctx context.Context) (rpccp.Message, func() error, capnp.ReleaseFunc, error) {
+	msg, send, release, err := ft.Transport.NewMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, nil, err
+	}
+	faultySend := func() error {
+		if ft.cfg.latency > 0 {
+			t := time.NewTimer(ft.cfg.latency)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return errors.New(errors.Failed, "rpc faulty transport", "send: "+ctx.Err().Error())
+			}
+		}
+		ft.mu.Lock()
+		defer ft.mu.Unlock()
+		if ft.closed {
+			return errors.New(errors.Disconnected, "rpc faulty transport", "send on closed transport")
+		}
+		if ft.cfg.dropRate > 0 && ft.rng.Float64() < ft.cfg.dropRate {
+			return nil
+		}
+		if ft.cfg.reorderWindow <= 1 {
+			ft.corrupt(msg.Message())
+			return send()
+		}
+		if err := ft.hold(msg); err != nil {
+			return errors.New(errors.Failed, "rpc faulty transport", "send: "+err.Error())
+		}
+		for len(ft.held) >= ft.cfg.reorderWindow {
+			ft.sendHeld()
+		}
+		ft.scheduleFlush()
+		return nil
+	}
+	return msg, faultySend, release, nil
+}
+
+// shapeMessages shapes the messages of the underlying transport, which
+// include the copies held by FaultReorder.
+func (ft *faultyTransport) shapeMessages(shape messageShape) bool {
+	st, ok := ft.Transport.(shapedTransport)
+	return ok && st.shapeMessages(shape)
+}
+
+// poolInbound pools the received buffers of the underlying transport.
+func (ft *faultyTransport) poolInbound() {
+	if pt, ok := ft.Transport.(poolingTransport); ok {
+		pt.poolInbound()
+	}
+}
+
+// limitInbound limits the received messages of the underlying transport.
+func (ft *faultyTransport) limitInbound(max uint64) {
+	if lt, ok := ft.Transport.(limitedTransport); ok {
+		lt.limitInbound(max)
+	}
+}
+
+// corrupt flips a bit in msg with probability cfg.corruptRate.  The
+// caller must be holding ft.mu.
+func (ft *faultyTransport) corrupt(msg *capnp.Message) {
+	if ft.cfg.corruptRate <= 0 || ft.rng.Float64() >= ft.cfg.corruptRate {
+		return
+	}
+	var segs [][]byte
+	total := 0
+	for i := int64(0); i < msg.NumSegments(); i++ {
+		seg, err := msg.Segment(capnp.SegmentID(i))
+		if err != nil {
+			return
+		}
+		segs = append(segs, seg.Data())
+		total += len(seg.Data())
+	}
+	if total == 0 {
+		return
+	}
+	bit := ft.rng.Intn(total * 8)
+	for _, b := range segs {
+		if bit < len(b)*8 {
+			b[bit/8] ^= 1 << uint(bit%8)
+			return
+		}
+		bit -= len(b) * 8
+	}
+}
+
+// hold copies msg to a new message on the underlying transport and
+// adds it to ft.held.  The caller must be holding ft.mu.
+func (ft *faultyTransport) hold(msg rpccp.Message) error {
+	cp, send, release, err := ft.Transport.NewMessage(ft.ctx)
+	if err != nil {
+		return err
+	}
+	if err := cp.Struct.CopyFrom(msg.Struct); err != nil {
+		release()
+		return err
+	}
+	ft.corrupt(cp.Message())
+	ft.held = append(ft.held, heldMessage{send, release})
+	return nil
+}
+
+// sendHeld sends and releases a random held message.  The caller must
+// be holding ft.mu.
+func (ft *faultyTransport) sendHeld() {
+	i := ft.rng.Intn(len(ft.held))
+	m := ft.held[i]
+	last := len(ft.held) - 1
+	ft.held[i] = ft.held[last]
+	ft.held[last] = heldMessage{}
+	ft.held = ft.held[:last]
+	m.send()
+	m.release()
+}
+
+// scheduleFlush arranges for the held messages to be sent after
+// reorderFlushDelay, unless another send comes first.  The caller must
+// be holding ft.mu.
+func (ft *faultyTransport) scheduleFlush() {
+	if ft.timer != nil {
+		ft.timer.Stop()
+	}
+	if len(ft.held) == 0 {
+		return
+	}
+	ft.timer = time.AfterFunc(reorderFlushDelay, func() {
+		ft.mu.Lock()
+		defer ft.mu.Unlock()
+		if ft.closed {
+			return
+		}
+		for len(ft.held) > 0 {
+			ft.sendHeld()
+		}
+	})
+}
+
+// Close releases any held messages without sending them and closes the
+// underlying transport.
+func (ft *faultyTransport) Close() error {
+	ft.cancel()
+	ft.mu.Lock()
+	ft.closed = true
+	if ft.timer != nil {
+		ft.timer.Stop()
+	}
+	held := ft.held
+	ft.held = nil
+	ft.mu.Unlock()
+	for _, m := range held {
+		m.release()
+	}
+	return ft.Transport.Close()
+}
@@ -0,0 +1,180 @@
+package rpc_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3/rpc"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestFaultyTransport(t *testing.T) {
+	const n = 20
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// deliver sends n bootstrap messages through a faulty transport,
+	// closes it, and returns the question IDs received on the other end.
+	deliver := func(t *testing.T, opts ...rpc.FaultOption) []uint32 {
+		p1, p2 := rpc.NewPipe()
+		defer p2.Close()
+		ft := rpc.FaultyTransport(p1, opts...)
+		for i := uint32(0); i < n; i++ {
+			err := sendMessage(ctx, ft, &rpcMessage{
+				Which:     rpccp.Message_Which_bootstrap,
+				Bootstrap: &rpcBootstrap{QuestionID: i},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		var got []uint32
+		if err := ft.Close(); err != nil {
+			t.Fatal("ft.Close():", err)
+		}
+		for {
+			msg, release, err := recvMessage(ctx, p2)
+			if err == io.EOF {
+				return got
+			}
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			if msg.Which != rpccp.Message_Which_bootstrap {
+				t.Fatalf("received %v message; want bootstrap", msg.Which)
+			}
+			got = append(got, msg.Bootstrap.QuestionID)
+			release()
+		}
+	}
+
+	t.Run("NoFaults", func(t *testing.T) {
+		got := deliver(t)
+		if len(got) != n {
+			t.Fatalf("received %d messages; want %d", len(got), n)
+		}
+		for i, id := range got {
+			if id != uint32(i) {
+				t.Fatalf("received %v; want 0 to %d in order", got, n-1)
+			}
+		}
+	})
+	t.Run("Drop", func(t *testing.T) {
+		got := deliver(t, rpc.FaultSeed(42), rpc.FaultDrop(0.5))
+		if len(got) == 0 || len(got) == n {
+			t.Errorf("received %d of %d messages; want some dropped", len(got), n)
+		}
+		again := deliver(t, rpc.FaultSeed(42), rpc.FaultDrop(0.5))
+		if !equalIDs(got, again) {
+			t.Errorf("with the same seed, received %v then %v", got, again)
+		}
+	})
+	t.Run("Reorder", func(t *testing.T) {
+		// Close releases held messages without sending them, so all of
+		// them are received before closing.
+		got := deliverReordered(ctx, t, n, rpc.FaultSeed(7), rpc.FaultReorder(4))
+		sorted := append([]uint32(nil), got...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		for i, id := range sorted {
+			if id != uint32(i) {
+				t.Fatalf("received %v; want a permutation of 0 to %d", got, n-1)
+			}
+		}
+		if equalIDs(got, sorted) {
+			t.Errorf("received %v in order; want reordered", got)
+		}
+		again := deliverReordered(ctx, t, n, rpc.FaultSeed(7), rpc.FaultReorder(4))
+		if !equalIDs(got, again) {
+			t.Errorf("with the same seed, received %v then %v", got, again)
+		}
+	})
+	t.Run("Latency", func(t *testing.T) {
+		const latency = 5 * time.Millisecond
+		start := time.Now()
+		deliver(t, rpc.FaultLatency(latency))
+		if d := time.Since(start); d < n*latency {
+			t.Errorf("sending %d messages took %v; want at least %v", n, d, n*latency)
+		}
+	})
+	t.Run("Corrupt", func(t *testing.T) {
+		p1, p2 := rpc.NewPipe()
+		defer p2.Close()
+		ft := rpc.FaultyTransport(p1, rpc.FaultCorrupt(1))
+		defer ft.Close()
+		msg, send, release, err := ft.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("ft.NewMessage:", err)
+		}
+		boot, err := msg.NewBootstrap()
+		if err != nil {
+			t.Fatal(err)
+		}
+		boot.SetQuestionId(1)
+		want, err := msg.Message().Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := send(); err != nil {
+			t.Fatal("send():", err)
+		}
+		release()
+		rmsg, rrelease, err := p2.RecvMessage(ctx)
+		if err != nil {
+			// A corrupted header or root pointer is also a valid outcome.
+			t.Log("p2.RecvMessage:", err)
+			return
+		}
+		defer rrelease()
+		got, err := rmsg.Message().Marshal()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Equal(got, want) {
+			t.Error("message received unchanged; want a flipped bit")
+		}
+	})
+}
+
+// deliverReordered sends n bootstrap messages through a faulty
+// transport and receives all of them before closing it.
+func deliverReordered(ctx context.Context, t *testing.T, n int, opts ...rpc.FaultOption) []uint32 {
+	p1, p2 := rpc.NewPipe()
+	defer p2.Close()
+	ft := rpc.FaultyTransport(p1, opts...)
+	defer ft.Close()
+	for i := 0; i < n; i++ {
+		err := sendMessage(ctx, ft, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: uint32(i)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	got := make([]uint32, 0, n)
+	for len(got) < n {
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		got = append(got, msg.Bootstrap.QuestionID)
+		release()
+	}
+	return got
+}
+
+func equalIDs(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+
+	"capnproto.org/go/capnp/v3/internal/errors"
+)
+
+// NewHTTPHandler returns an http.Handler that serves Cap'n Proto RPC
+// over the bidirectional body of an HTTP/2 request.  The request body
+// carries messages from the client and the response body carries
+// messages to the client, both framed as in NewStreamTransport.
+//
+// serve is called once per request with a transport for the stream,
+// typically to create a Conn and wait for it to finish.  serve must not
+// return until it has closed the transport.  Any writes after serve
+// returns will fail.
+//
+// Requests made with HTTP/1.x are rejected, since HTTP/1.x does not
+// support full-duplex request and response bodies.
+func NewHTTPHandler(serve func(context.Context, Transport)) http.Handler {
+	return httpHandler(serve)
+}
+
+type httpHandler func(context.Context, Transport)
+
+func (serve httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor < 2 {
+		http.Error(w, "capnp rpc requires HTTP/2", http.StatusHTTPVersionNotSupported)
+		return
+	}
+	f, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "capnp rpc: response writer does not support flushing", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", httpContentType)
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+
+	s := &httpServerStream{body: r.Body, w: w, f: f}
+	serve(r.Context(), NewStreamTransport(s))
+	s.finish()
+}
+
+// httpServerStream adapts a server-side HTTP/2 request and response to
+// an io.ReadWriteCloser.
+type httpServerStream struct {
+	body io.ReadCloser
+
+	mu   sync.Mutex
+	w    io.Writer
+	f    http.Flusher
+	done bool // set when the handler has returned
+}
+
+func (s *httpServerStream) Read(p []byte) (int, error) {
+	return s.body.Read(p)
+}
+
+func (s *httpServerStream) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return 0, io.ErrClosedPipe
+	}
+	n, err := s.w.Write(p)
+	s.f.Flush()
+	return n, err
+}
+
+func (s *httpServerStream) Close() error {
+	return s.body.Close()
+}
+
+// finish prevents any further writes to the response.
+func (s *httpServerStream) finish() {
+	s.mu.Lock()
+	s.done = true
+	s.mu.Unlock()
+}
+
+// NewHTTPTransport opens an RPC stream to a handler created by
+// NewHTTPHandler at the given URL.  The client must support HTTP/2
+// (e.g. an *http.Transport with ForceAttemptHTTP2 set); passing nil
+// uses http.DefaultClient.
+//
+// The stream lives as long as ctx: canceling ctx will break the
+// transport.  Closing the transport will end the request.
+func NewHTTPTransport(ctx context.Context, client *http.Client, url string) (Transport, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	pr, pw := io.Pipe()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, pr)
+	if err != nil {
+		pw.Close()
+		return nil, errors.New(errors.Failed, "rpc http transport", "new request: "+err.Error())
+	}
+	req.Header.Set("Content-Type", httpContentType)
+	resp, err := client.Do(req)
+	if err != nil {
+		pw.Close()
+		return nil, errors.New(errors.Failed, "rpc http transport", "request: "+err.Error())
+	}
+	if resp.StatusCode != http.StatusOK {
+		pw.Close()
+		resp.Body.Close()
+		return nil, errors.New(errors.Failed, "rpc http transport", "request: "+resp.Status)
+	}
+	if resp.ProtoMajor < 2 {
+		pw.Close()
+		resp.Body.Close()
+		return nil, errors.New(errors.Failed, "rpc http transport", "request: server responded with "+resp.Proto+"; want HTTP/2")
+	}
+	return NewStreamTransport(&httpClientStream{body: resp.Body, pw: pw}), nil
+}
+
+// httpClientStream adapts a client-side HTTP/2 request and response to
+// an io.ReadWriteCloser.
+type httpClientStream struct {
+	body io.ReadCloser
+	pw   *io.PipeWriter
+}
+
+func (s *httpClientStream) Read(p []byte) (int, error) {
+	return s.body.Read(p)
+}
+
+func (s *httpClientStream) Write(p []byte) (int, error) {
+	return s.pw.Write(p)
+}
+
+func (s *httpClientStream) Close() error {
+	werr := s.pw.Close()
+	rerr := s.body.Close()
+	if werr != nil {
+		return werr
+	}
+	return rerr
+}
+
+const httpContentType = "application/x-capnp-rpc"
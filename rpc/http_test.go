@@ -0,0 +1,76 @@
+package rpc_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+)
+
+func TestHTTPTransport(t *testing.T) {
+	srvDone := make(chan struct{})
+	h := rpc.NewHTTPHandler(func(ctx context.Context, tr rpc.Transport) {
+		defer close(srvDone)
+		conn := rpc.NewConn(tr, &rpc.Options{
+			ErrorReporter:   testErrorReporter{tb: t},
+			BootstrapClient: testcp.PingPong_ServerToClient(pingPongServer{}, nil).Client,
+		})
+		select {
+		case <-conn.Done():
+		case <-ctx.Done():
+		}
+		conn.Close()
+	})
+	srv := httptest.NewUnstartedServer(h)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tr, err := rpc.NewHTTPTransport(ctx, srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatal("NewHTTPTransport:", err)
+	}
+	conn := rpc.NewConn(tr, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	client := testcp.PingPong{Client: conn.Bootstrap(ctx)}
+	ans, release := client.EchoNum(ctx, func(args testcp.PingPong_echoNum_Params) error {
+		args.SetN(42)
+		return nil
+	})
+	result, err := ans.Struct()
+	if err != nil {
+		t.Error("EchoNum:", err)
+	} else if n := result.N(); n != 42 {
+		t.Errorf("EchoNum(42) = %d; want 42", n)
+	}
+	release()
+	client.Release()
+	if err := conn.Close(); err != nil {
+		t.Error("conn.Close:", err)
+	}
+	<-srvDone
+}
+
+func TestHTTPHandlerRejectsHTTP1(t *testing.T) {
+	h := rpc.NewHTTPHandler(func(ctx context.Context, tr rpc.Transport) {
+		t.Error("serve called for HTTP/1.1 request")
+		tr.Close()
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "application/octet-stream", nil)
+	if err != nil {
+		t.Fatal("http.Post:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusHTTPVersionNotSupported {
+		t.Errorf("status = %d; want %d", resp.StatusCode, http.StatusHTTPVersionNotSupported)
+	}
+}
@@ -25,8 +25,22 @@ func (gen *idgen) next() uint32 {
 	return i
 }
 
+// remove frees i for reuse.  Once the largest IDs in use are freed,
+// the generator forgets them, so that len shrinks back down after a
+// burst of IDs.
 func (gen *idgen) remove(i uint32) {
 	gen.free.add(uint(i))
+	for gen.i > 0 && gen.free.has(uint(gen.i-1)) {
+		gen.i--
+		gen.free.remove(uint(gen.i))
+	}
+	gen.free.trim()
+}
+
+// len returns one more than the largest ID in use, which bounds the
+// length of a table indexed by the IDs.
+func (gen *idgen) len() int {
+	return int(gen.i)
 }
 
 // A uintSet is a set of unsigned integers represented by a bit set.
@@ -59,6 +73,19 @@ func (s uintSet) remove(i uint) {
 	}
 }
 
+// trim drops trailing empty words from s.
+func (s *uintSet) trim() {
+	n := len(*s)
+	for n > 0 && (*s)[n-1] == 0 {
+		n--
+	}
+	if n == 0 {
+		*s = nil
+	} else {
+		*s = (*s)[:n]
+	}
+}
+
 func (s uintSet) min() (_ uint, ok bool) {
 	for i, x := range s {
 		if x == 0 {
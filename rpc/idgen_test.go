@@ -1,8 +1,15 @@
 package rpc
 
 import (
+	"context"
+	"errors"
+	"net"
 	"sort"
+	"strings"
+	"sync"
 	"testing"
+
+	"capnproto.org/go/capnp/v3"
 )
 
 func TestIDGen(t *testing.T) {
@@ -32,6 +39,104 @@ func TestIDGen(t *testing.T) {
 			t.Errorf("next() #3 = %d; want %d", got, want)
 		}
 	})
+	t.Run("Shrink", func(t *testing.T) {
+		var gen idgen
+		for i := 0; i < 200; i++ {
+			gen.next()
+		}
+		gen.remove(5)
+		for i := uint32(199); i >= 100; i-- {
+			gen.remove(i)
+		}
+		if got, want := gen.len(), 100; got != want {
+			t.Errorf("after removing 100-199, len() = %d; want %d", got, want)
+		}
+		if got, want := gen.next(), uint32(5); got != want {
+			t.Errorf("next() #1 = %d; want %d", got, want)
+		}
+		if got, want := gen.next(), uint32(100); got != want {
+			t.Errorf("next() #2 = %d; want %d", got, want)
+		}
+		for i := uint32(0); i <= 100; i++ {
+			gen.remove(i)
+		}
+		if got := gen.len(); got != 0 {
+			t.Errorf("after removing all IDs, len() = %d; want 0", got)
+		}
+		if gen.free != nil {
+			t.Errorf("after removing all IDs, free = %v; want nil", gen.free)
+		}
+	})
+}
+
+var errChurn = errors.New("churn")
+
+// BenchmarkQuestionChurn makes bursts of concurrent calls and reports
+// the capacity of the questions table afterward, which stays within a
+// small factor of the burst size no matter how many calls are made.
+func BenchmarkQuestionChurn(b *testing.B) {
+	const burst = 64
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer l.Close()
+	p1, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	p2, err := l.Accept()
+	if err != nil {
+		p1.Close()
+		b.Fatal(err)
+	}
+	conn1 := NewConn(NewStreamTransport(p1), &Options{
+		BootstrapClient: capnp.ErrorClient(errChurn),
+	})
+	conn2 := NewConn(NewStreamTransport(p2), nil)
+	defer func() {
+		if err := conn2.Close(); err != nil {
+			b.Error("conn2.Close:", err)
+		}
+		<-conn1.Done()
+		if err := conn1.Close(); err != nil {
+			b.Error("conn1.Close:", err)
+		}
+	}()
+	ctx := context.Background()
+	boot := conn2.Bootstrap(ctx)
+	defer boot.Release()
+	if err := boot.Resolve(ctx); err != nil {
+		b.Fatal("boot.Resolve:", err)
+	}
+
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for i := 0; i < b.N; i++ {
+		wg.Add(burst)
+		for j := 0; j < burst; j++ {
+			go func() {
+				defer wg.Done()
+				ans, release := boot.SendCall(ctx, capnp.Send{
+					Method: capnp.Method{InterfaceID: 0xa7317bd7216570aa, MethodID: 9},
+				})
+				_, err := ans.Struct()
+				release()
+				if err == nil || !strings.Contains(err.Error(), errChurn.Error()) {
+					b.Errorf("call error = %v; want %v", err, errChurn)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+	b.StopTimer()
+	conn2.mu.Lock()
+	n := cap(conn2.questions)
+	conn2.mu.Unlock()
+	b.ReportMetric(float64(n), "questions-cap")
+	if n > 4*burst {
+		b.Errorf("questions table capacity = %d after %d bursts of %d calls; want <= %d", n, b.N, burst, 4*burst)
+	}
 }
 
 func TestUintSet(t *testing.T) {
@@ -42,39 +42,62 @@ type impent struct {
 	// importClient's generation matches the entry's generation before
 	// removing the entry from the table and sending a release message.
 	generation uint64
+
+	// promise is set for an import received as a senderPromise until the
+	// remote vat sends a Resolve message for it.  Fulfilling it
+	// redirects calls on the import's client to the resolution.
+	promise *capnp.ClientPromise
 }
 
 // addImport returns a client that represents the given import,
 // incrementing the number of references to this import from this vat.
 // This is separate from the reference counting that capnp.Client does.
+// If isPromise is true, then the import is a promise: the client is
+// redirected once the remote vat resolves it (see handleResolve).
 //
 // The caller must be holding onto c.mu.
-func (c *Conn) addImport(id importID) *capnp.Client {
+func (c *Conn) addImport(id importID, isPromise bool) *capnp.Client {
 	if ent := c.imports[id]; ent != nil {
 		ent.wireRefs++
 		client, ok := ent.wc.AddRef()
 		if !ok {
 			ent.generation++
-			client = capnp.NewClient(&importClient{
-				c:          c,
-				id:         id,
-				generation: ent.generation,
-			})
+			client, ent.promise = c.newImportClient(id, ent.generation, isPromise)
 			ent.wc = client.WeakRef()
 		}
 		return client
 	}
-	client := capnp.NewClient(&importClient{
-		c:  c,
-		id: id,
-	})
+	client, promise := c.newImportClient(id, 0, isPromise)
 	c.imports[id] = &impent{
 		wc:       client.WeakRef(),
 		wireRefs: 1,
+		promise:  promise,
 	}
 	return client
 }
 
+// newImportClient creates a client for an import.  If isPromise is
+// true, then the client is a promise to be fulfilled by the import's
+// resolution.
+func (c *Conn) newImportClient(id importID, generation uint64, isPromise bool) (*capnp.Client, *capnp.ClientPromise) {
+	ic := &importClient{
+		c:          c,
+		id:         id,
+		generation: generation,
+	}
+	var client *capnp.Client
+	var promise *capnp.ClientPromise
+	if isPromise {
+		client, promise = capnp.NewPromisedClient(ic)
+	} else {
+		client = capnp.NewClient(ic)
+	}
+	if c.gcImports {
+		client.ReleaseWhenUnreachable()
+	}
+	return client, promise
+}
+
 // An importClient implements capnp.Client for a remote capability.
 type importClient struct {
 	c          *Conn
@@ -83,6 +106,12 @@ type importClient struct {
 }
 
 func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	return ic.send(ctx, s, true)
+}
+
+// send sends a call to the import.  If limit is true, the call waits
+// for a slot under Options.MaxInFlightCalls.
+func (ic *importClient) send(ctx context.Context, s capnp.Send, limit bool) (*capnp.Answer, capnp.ReleaseFunc) {
 	// Acquire sender lock.
 	ic.c.mu.Lock()
 	if !ic.c.startTask() {
@@ -90,24 +119,42 @@ func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer,
 		return capnp.ErrorAnswer(s.Method, disconnected("connection closed")), func() {}
 	}
 	defer ic.c.tasks.Done()
+	if limit {
+		if err := ic.c.acquireCallSlot(ctx); err != nil {
+			ic.c.mu.Unlock()
+			return capnp.ErrorAnswer(s.Method, err), func() {}
+		}
+	}
 	ent := ic.c.imports[ic.id]
 	if ent == nil || ic.generation != ent.generation {
+		if limit {
+			ic.c.releaseCallSlot()
+		}
 		ic.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, disconnected("send on closed import")), func() {}
 	}
 	if err := ic.c.tryLockSender(ctx); err != nil {
+		if limit {
+			ic.c.releaseCallSlot()
+		}
 		ic.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, err), func() {}
 	}
 	q := ic.c.newQuestion(s.Method)
+	if limit {
+		q.flags |= callSlot
+	}
+	if releaseResultCapsFromContext(ctx) {
+		q.flags |= dropResultCaps
+	}
 	ic.c.mu.Unlock()
 
 	// Create call message.
 	msg, send, release, err := ic.c.transport.NewMessage(ctx)
 	if err != nil {
 		ic.c.mu.Lock()
-		ic.c.questions[q.id] = nil
-		ic.c.questionID.remove(uint32(q.id))
+		ic.c.clearQuestion(q.id)
+		ic.c.freeQuestionID(q.id)
 		ic.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, errorf("create message: %v", err)), func() {}
 	}
@@ -117,8 +164,8 @@ func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer,
 	err = ic.c.newImportCallMessage(msg, ic.id, q.id, s)
 	if err != nil {
 		ic.c.mu.Lock()
-		ic.c.questions[q.id] = nil
-		ic.c.questionID.remove(uint32(q.id))
+		ic.c.clearQuestion(q.id)
+		ic.c.freeQuestionID(q.id)
 		ic.c.lockSender()
 		ic.c.mu.Unlock()
 		release()
@@ -130,7 +177,7 @@ func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer,
 
 	// Send call.
 	ic.c.mu.Lock()
-	ic.c.lockSender()
+	ic.c.lockSenderPriority(priorityFromContext(ctx))
 	ic.c.mu.Unlock()
 	err = send()
 	release()
@@ -138,8 +185,8 @@ func (ic *importClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer,
 	ic.c.mu.Lock()
 	ic.c.unlockSender()
 	if err != nil {
-		ic.c.questions[q.id] = nil
-		ic.c.questionID.remove(uint32(q.id))
+		ic.c.clearQuestion(q.id)
+		ic.c.freeQuestionID(q.id)
 		ic.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, errorf("send message: %v", err)), func() {}
 	}
@@ -190,7 +237,12 @@ func (c *Conn) newImportCallMessage(msg rpccp.Message, imp importID, qid questio
 		return nil
 	}
 	m := args.Message()
-	if err := s.PlaceArgs(args); err != nil {
+	err = s.PlaceArgs(args)
+	if err == nil {
+		// Catch dangling interface pointers before the call is sent.
+		err = m.ValidateForSend()
+	}
+	if err != nil {
 		for _, c := range m.CapTable {
 			c.Release()
 		}
@@ -198,9 +250,10 @@ func (c *Conn) newImportCallMessage(msg rpccp.Message, imp importID, qid questio
 		return errorf("place arguments: %v", err)
 	}
 	clients, states := extractCapTable(m)
+	intros := c.introduce(clients, states)
 	c.mu.Lock()
 	// TODO(soon): save param refs
-	_, err = c.fillPayloadCapTable(payload, clients, states)
+	_, err = c.fillPayloadCapTable(payload, clients, states, intros)
 	c.mu.Unlock()
 	releaseList(clients).release()
 	if err != nil {
@@ -210,7 +263,7 @@ func (c *Conn) newImportCallMessage(msg rpccp.Message, imp importID, qid questio
 }
 
 func (ic *importClient) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
-	ans, finish := ic.Send(ctx, capnp.Send{
+	ans, finish := ic.send(ctx, capnp.Send{
 		Method:   r.Method,
 		ArgsSize: r.Args.Size(),
 		PlaceArgs: func(s capnp.Struct) error {
@@ -218,7 +271,7 @@ func (ic *importClient) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCa
 			r.ReleaseArgs()
 			return err
 		},
-	})
+	}, false)
 	r.ReleaseArgs()
 	select {
 	case <-ans.Done():
@@ -253,6 +306,33 @@ func (ic *importClient) Brand() capnp.Brand {
 	return capnp.Brand{Value: ic}
 }
 
+// IsLive reports whether calls on client could still be delivered.  It
+// returns false if client is invalid, has resolved to an error (like a
+// client from capnp.ErrorClient or a bootstrap or pipelined capability
+// whose Conn has shut down), or is a capability imported from a Conn that
+// has been shut down.  For all other clients, including local
+// capabilities and unresolved promises, IsLive returns true.
+func IsLive(client *capnp.Client) bool {
+	if !client.IsValid() {
+		return false
+	}
+	state := client.State()
+	switch b := state.Brand.Value.(type) {
+	case *importClient:
+		select {
+		case <-b.c.bgctx.Done():
+			return false
+		default:
+			return true
+		}
+	case nil:
+		// Error clients have no brand and are always resolved.
+		return state.IsPromise
+	default:
+		return true
+	}
+}
+
 func (ic *importClient) Shutdown() {
 	ic.c.mu.Lock()
 	if !ic.c.startTask() {
@@ -123,6 +123,16 @@ func (s PingPong_echoNum_Params) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s PingPong_echoNum_Params) Equal(o PingPong_echoNum_Params) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s PingPong_echoNum_Params) N() int64 {
 	return int64(s.Struct.Uint64(0))
 }
@@ -186,6 +196,16 @@ func (s PingPong_echoNum_Results) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s PingPong_echoNum_Results) Equal(o PingPong_echoNum_Results) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s PingPong_echoNum_Results) N() int64 {
 	return int64(s.Struct.Uint64(0))
 }
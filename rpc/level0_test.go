@@ -1,14 +1,18 @@
 package rpc_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"runtime"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"capnproto.org/go/capnp/v3"
 	"capnproto.org/go/capnp/v3/pogs"
@@ -67,6 +71,9 @@ func TestSendAbort(t *testing.T) {
 		default:
 			t.Error("conn.Done open after Close")
 		}
+		if err := conn.RemoteAbort(); err != nil {
+			t.Errorf("conn.RemoteAbort() = %v; want <nil>", err)
+		}
 		rmsg, release, err := recvMessage(ctx, p2)
 		if err != nil {
 			t.Fatal("recvMessage(ctx, p2):", err)
@@ -97,8 +104,8 @@ func TestSendAbort(t *testing.T) {
 
 // TestRecvAbort writes an abort message to a connection, waits for
 // bootstrap resolution/disconnect (to acknowledge delivery), and then
-// closes the connection, verifying that Close does not return an error.
-// Level 0 requirement.
+// closes the connection, verifying that Close does not return an error
+// and that RemoteAbort still reports the reason.  Level 0 requirement.
 func TestRecvAbort(t *testing.T) {
 	p1, p2 := newPipe(1)
 	defer p2.Close()
@@ -149,6 +156,130 @@ func TestRecvAbort(t *testing.T) {
 	if err := conn.Close(); err != nil {
 		t.Errorf("conn.Close() = %v; want <nil>", err)
 	}
+	if err := conn.RemoteAbort(); err == nil || !strings.Contains(err.Error(), "over it") {
+		t.Errorf("conn.RemoteAbort() = %v; want to contain \"over it\"", err)
+	}
+}
+
+// TestShutdownErrorType verifies that a transport failure is reported
+// as a *rpc.TransportError, while an abort from the remote vat is not.
+func TestShutdownErrorType(t *testing.T) {
+	t.Run("TransportFailure", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		errs := make(chan error, 10)
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: chanErrorReporter(errs),
+		})
+		if err := p2.Close(); err != nil {
+			t.Fatal("p2.Close():", err)
+		}
+		<-conn.Done()
+		var te *rpc.TransportError
+		if err := <-errs; !errors.As(err, &te) {
+			t.Errorf("reported error = %v; want *rpc.TransportError", err)
+		} else if te.Op != "receive" {
+			t.Errorf("TransportError.Op = %q; want \"receive\"", te.Op)
+		}
+		conn.Close()
+	})
+	t.Run("RemoteAbort", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		defer p2.Close()
+		errs := make(chan error, 10)
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: chanErrorReporter(errs),
+		})
+		err := sendMessage(context.Background(), p2, &rpcMessage{
+			Which: rpccp.Message_Which_abort,
+			Abort: &rpcException{
+				Type:   rpccp.Exception_Type_failed,
+				Reason: "over it",
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-conn.Done()
+		var te *rpc.TransportError
+		if err := <-errs; err == nil || errors.As(err, &te) {
+			t.Errorf("reported error = %v; want non-transport error", err)
+		}
+		conn.Close()
+	})
+}
+
+type chanErrorReporter chan error
+
+func (ch chanErrorReporter) ReportError(e error) {
+	select {
+	case ch <- e:
+	default:
+	}
+}
+
+// TestSimultaneousClose closes both ends of a stream connection at the
+// same time, verifying that neither Close reports an error because the
+// peer already hung up.
+func TestSimultaneousClose(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		c1, c2, err := tcpPair()
+		if err != nil {
+			t.Fatal("tcpPair:", err)
+		}
+		conn1 := rpc.NewConn(rpc.NewStreamTransport(hungUpConn{c1}), &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		conn2 := rpc.NewConn(rpc.NewStreamTransport(c2), &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		errs := make(chan error, 2)
+		go func() { errs <- conn1.Close() }()
+		go func() { errs <- conn2.Close() }()
+		for j := 0; j < 2; j++ {
+			if err := <-errs; err != nil {
+				t.Errorf("iteration %d: conn.Close() = %v; want <nil>", i, err)
+			}
+		}
+	}
+}
+
+// hungUpConn is a net.Conn whose Close reports that the connection was
+// already closed, as happens when the peer hangs up first.
+type hungUpConn struct {
+	net.Conn
+}
+
+func (c hungUpConn) Close() error {
+	c.Conn.Close()
+	return net.ErrClosed
+}
+
+// tcpPair returns both ends of a loopback TCP connection.
+func tcpPair() (c1, c2 net.Conn, err error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer l.Close()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			accepted <- nil
+			return
+		}
+		accepted <- c
+	}()
+	c2, err = net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		return nil, nil, err
+	}
+	c1 = <-accepted
+	if c1 == nil {
+		c2.Close()
+		return nil, nil, errors.New("accept failed")
+	}
+	return c1, c2, nil
 }
 
 // TestSendBootstrapError calls Bootstrap, raises an exception, then
@@ -582,7 +713,7 @@ func TestSendBootstrapCallException(t *testing.T) {
 				AnswerID: qid,
 				Which:    rpccp.Return_Which_exception,
 				Exception: &rpcException{
-					Type:   rpccp.Exception_Type_failed,
+					Type:   rpccp.Exception_Type_overloaded,
 					Reason: "everything went wrong",
 				},
 			},
@@ -599,6 +730,20 @@ func TestSendBootstrapCallException(t *testing.T) {
 		if err == nil || !strings.Contains(err.Error(), want) {
 			t.Errorf("ans.Struct() = _, %v; want error to contain %q", err, want)
 		}
+		if wantID := fmt.Sprintf("question %d", qid); err == nil || !strings.Contains(err.Error(), wantID) {
+			t.Errorf("ans.Struct() = _, %v; want error to contain %q", err, wantID)
+		}
+		var exc *rpc.Exception
+		if !errors.As(err, &exc) {
+			t.Errorf("ans.Struct() = _, %v; want error to wrap *rpc.Exception", err)
+		} else {
+			if exc.Type() != rpccp.Exception_Type_overloaded {
+				t.Errorf("exception type = %v; want overloaded", exc.Type())
+			}
+			if exc.Reason() != want {
+				t.Errorf("exception reason = %q; want %q", exc.Reason(), want)
+			}
+		}
 	}
 
 	// 7. Read the finish
@@ -620,6 +765,216 @@ func TestSendBootstrapCallException(t *testing.T) {
 	}
 }
 
+// TestRecvReturnResultsSentElsewhere calls Bootstrap and writes back a
+// return with resultsSentElsewhere, which Conn never asks for.  It
+// checks that the question is rejected instead of being left
+// unresolved and that a finish is still sent.
+func TestRecvReturnResultsSentElsewhere(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+
+	ctx := context.Background()
+
+	// 1. Read bootstrap
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	var qid uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		qid = rmsg.Bootstrap.QuestionID
+	}
+
+	// 2. Write back a resultsSentElsewhere return
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_return,
+		Return: &rpcReturn{
+			AnswerID: qid,
+			Which:    rpccp.Return_Which_resultsSentElsewhere,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Bootstrap client should resolve to an error.
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	ans, releaseCall := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	_, err = ans.Struct()
+	releaseCall()
+	if err == nil || !strings.Contains(err.Error(), "results sent elsewhere") {
+		t.Errorf("call on bootstrap client error = %v; want results sent elsewhere", err)
+	}
+
+	// 4. Read finish
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", rmsg.Which)
+		}
+		if rmsg.Finish.QuestionID != qid {
+			t.Errorf("Received finish for question %d; want %d", rmsg.Finish.QuestionID, qid)
+		}
+	}
+}
+
+// TestIsLive checks that a capability imported from a Conn is live
+// until the remote vat aborts the connection, and that local
+// capabilities are always live.
+func TestIsLive(t *testing.T) {
+	local := newServer(nil, nil)
+	if !rpc.IsLive(local) {
+		t.Error("IsLive(local client) = false; want true")
+	}
+	local.Release()
+	if rpc.IsLive(local) {
+		t.Error("IsLive(released client) = true; want false")
+	}
+	if rpc.IsLive(capnp.ErrorClient(errors.New("broken"))) {
+		t.Error("IsLive(error client) = true; want false")
+	}
+
+	p1, p2 := newPipe(1)
+	defer p2.Close()
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	// 1. Read bootstrap
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	var qid uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		qid = rmsg.Bootstrap.QuestionID
+	}
+
+	// 2. Write back a return
+	{
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		iptr := capnp.NewInterface(msg.Segment(), 0)
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qid,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content: iptr.ToPtr(),
+					CapTable: []rpcCapDescriptor{
+						{
+							Which:        rpccp.CapDescriptor_Which_senderHosted,
+							SenderHosted: bootstrapExportID,
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	if !rpc.IsLive(client) {
+		t.Error("IsLive(bootstrap client) = false before abort; want true")
+	}
+
+	// 3. Abort the connection.
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_abort,
+		Abort: &rpcException{
+			Type:   rpccp.Exception_Type_failed,
+			Reason: "going away",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-conn.Done()
+	if rpc.IsLive(client) {
+		t.Error("IsLive(bootstrap client) = true after abort; want false")
+	}
+	if err := conn.Close(); err != nil {
+		t.Error("conn.Close():", err)
+	}
+}
+
+// TestIsLiveUnresolvedBootstrap closes a connection before the remote
+// vat answers a bootstrap, then checks that IsLive reports that the
+// bootstrap client and later bootstrap clients are not live.
+func TestIsLiveUnresolvedBootstrap(t *testing.T) {
+	p1, p2 := newPipe(1)
+	defer p2.Close()
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+	}
+	if !rpc.IsLive(client) {
+		t.Error("IsLive(unresolved bootstrap client) = false before close; want true")
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Error("conn.Close():", err)
+	}
+	if rpc.IsLive(client) {
+		t.Error("IsLive(bootstrap client) = true after close; want false")
+	}
+	if rpc.IsLive(conn.Bootstrap(ctx)) {
+		t.Error("IsLive(conn.Bootstrap(ctx)) = true after close; want false")
+	}
+}
+
 // TestSendBootstrapPipelineCall calls Bootstrap and makes an RPC on the
 // returned capability without resolving the client.  Level 0 requirement.
 func TestSendBootstrapPipelineCall(t *testing.T) {
@@ -834,85 +1189,2977 @@ func TestRecvBootstrapError(t *testing.T) {
 	}
 }
 
-// TestRecvBootstrapCall sets Options.BootstrapClient on NewConn,
-// bootstraps, waits for a return, then sends a call to the RPC
-// connection.  It checks that the correct messages were sent and that
-// the return values are correct.  Level 0 requirement.
-func TestRecvBootstrapCall(t *testing.T) {
-	srvShutdown := make(chan struct{})
-	srv := newServer(
-		func(ctx context.Context, call *server.Call) error {
-			resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
-			if err != nil {
-				return err
-			}
-			resp.SetUint64(0, 0xdeadbeef|uint64(call.Args().Uint32(0))<<32)
-			return nil
-		},
-		func() {
-			close(srvShutdown)
-		})
-	p1, p2 := newPipe(1)
-	conn := rpc.NewConn(p1, &rpc.Options{
-		BootstrapClient: srv,
-		ErrorReporter:   testErrorReporter{tb: t},
-	})
-	defer func() {
-		finishTest(t, conn, p2)
-		select {
-		case <-srvShutdown:
-		default:
-			t.Error("Bootstrap client still alive after Close returned")
-		}
-	}()
-
+// TestNoBootstrapHandler checks that Options.NoBootstrapHandler is used
+// to answer Bootstrap messages when there is no bootstrap client.
+func TestNoBootstrapHandler(t *testing.T) {
 	ctx := context.Background()
-
+	t.Run("Error", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+			NoBootstrapHandler: func(context.Context) (*capnp.Client, error) {
+				return nil, capnp.Unimplemented("try the other vat")
+			},
+		})
+		defer finishTest(t, conn, p2)
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != 1 {
+			t.Fatalf("received %v message; want return for answer 1", rmsg.Which)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_exception {
+			t.Fatalf("return is %v; want exception", rmsg.Return.Which)
+		}
+		if e := rmsg.Return.Exception; e.Type != rpccp.Exception_Type_unimplemented || !strings.Contains(e.Reason, "try the other vat") {
+			t.Errorf("return.exception = %v %q; want unimplemented \"try the other vat\"", e.Type, e.Reason)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	t.Run("Client", func(t *testing.T) {
+		called := make(chan struct{})
+		fallback := newServer(func(ctx context.Context, call *server.Call) error {
+			close(called)
+			return nil
+		}, nil)
+		p1, p2 := newPipe(1)
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+			NoBootstrapHandler: func(context.Context) (*capnp.Client, error) {
+				return fallback, nil
+			},
+		})
+		defer finishTest(t, conn, p2)
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		importID, err := recvBootstrapReturn(ctx, p2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sendCall(ctx, p2, 2, importID); err != nil {
+			t.Fatal(err)
+		}
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != 2 {
+			t.Fatalf("received %v message; want return for answer 2", rmsg.Which)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_results {
+			t.Errorf("return is %v; want results", rmsg.Return.Which)
+		}
+		select {
+		case <-called:
+		default:
+			t.Error("call on bootstrap capability not delivered to NoBootstrapHandler's client")
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: 2},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// TestBootstrapRouter has one vat bootstrap another with and without an
+// object ID, and checks that Options.BootstrapRouter picks the
+// capability for requests with an object ID.
+func TestBootstrapRouter(t *testing.T) {
+	ctx := context.Background()
+	newIDServer := func(id uint64) *capnp.Client {
+		return newServer(func(ctx context.Context, call *server.Call) error {
+			resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+			if err != nil {
+				return err
+			}
+			resp.SetUint64(0, id)
+			return nil
+		}, nil)
+	}
+	p1, p2 := newPipe(1)
+	srvConn := rpc.NewConn(p2, &rpc.Options{
+		BootstrapClient: newIDServer(1),
+		BootstrapRouter: func(ctx context.Context, objectID capnp.Ptr) (*capnp.Client, error) {
+			if objectID.Text() == "tenant" {
+				return newIDServer(2), nil
+			}
+			return nil, capnp.Unimplemented("unknown tenant " + objectID.Text())
+		},
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer closeConns(t, conn, srvConn)
+
+	callID := func(client *capnp.Client) (uint64, error) {
+		ans, release := client.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+		})
+		defer release()
+		s, err := ans.Struct()
+		if err != nil {
+			return 0, err
+		}
+		return s.Uint64(0), nil
+	}
+	bootstrapObject := func(id string) (*capnp.Client, error) {
+		objectID, err := newTextPtr(id)
+		if err != nil {
+			return nil, err
+		}
+		return conn.BootstrapObject(ctx, objectID), nil
+	}
+
+	boot := conn.Bootstrap(ctx)
+	defer boot.Release()
+	if id, err := callID(boot); err != nil {
+		t.Error("call on bootstrap:", err)
+	} else if id != 1 {
+		t.Errorf("call on bootstrap = %d; want 1", id)
+	}
+	tenant, err := bootstrapObject("tenant")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tenant.Release()
+	if id, err := callID(tenant); err != nil {
+		t.Error("call on tenant:", err)
+	} else if id != 2 {
+		t.Errorf("call on tenant = %d; want 2", id)
+	}
+	unknown, err := bootstrapObject("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unknown.Release()
+	if _, err := callID(unknown); !capnp.IsUnimplemented(err) {
+		t.Errorf("call on unknown tenant error = %v; want unimplemented", err)
+	}
+}
+
+// TestRecvMessageOrdering writes several bootstrap messages without
+// waiting for replies, then checks that the returns arrive in the same
+// order.  Conn handles received messages on a single goroutine, so this
+// ordering is deterministic.
+func TestRecvMessageOrdering(t *testing.T) {
+	p1, p2 := newPipe(3)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	qids := []uint32{7, 3, 5}
+	for _, qid := range qids {
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: qid},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, qid := range qids {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_return {
+			release()
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.AnswerID != qid {
+			t.Errorf("Received return for answer %d; want %d", rmsg.Return.AnswerID, qid)
+		}
+		release()
+	}
+}
+
+// TestSynchronousDispatch writes a call to a slow method followed by a
+// bootstrap message, then checks that the call's return arrives first.
+// The method acknowledges the call before sleeping, so without
+// Options.SynchronousDispatch, the bootstrap would be answered while the
+// method is still running.
+func TestSynchronousDispatch(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		call.Ack()
+		time.Sleep(20 * time.Millisecond)
+		_, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+		return err
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient:     srv,
+		ErrorReporter:       testErrorReporter{tb: t},
+		SynchronousDispatch: true,
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	const bootstrapQID = 1
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const (
+		callQID       = 2
+		bootstrap2QID = 3
+	)
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_call,
+		Call: &rpcCall{
+			QuestionID: callQID,
+			Target: rpcMessageTarget{
+				Which:       rpccp.MessageTarget_Which_importedCap,
+				ImportedCap: bootstrapImportID,
+			},
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+			Params:      rpcPayload{},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrap2QID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, qid := range []uint32{callQID, bootstrap2QID} {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_return {
+			release()
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.AnswerID != qid {
+			t.Errorf("Received return for answer %d; want %d", rmsg.Return.AnswerID, qid)
+		}
+		release()
+	}
+}
+
+// TestSingleSegmentOutbound makes a call with params larger than the
+// first segment of a message and checks that every message the Conn
+// writes has one segment, with and without a transport wrapping the
+// stream transport.
+func TestSingleSegmentOutbound(t *testing.T) {
+	tests := []struct {
+		name string
+		wrap func(rpc.Transport) rpc.Transport
+	}{
+		{"Stream", func(t rpc.Transport) rpc.Transport { return t }},
+		{"Checksummed", func(t rpc.Transport) rpc.Transport { return rpc.NewChecksummedTransport(t, rpc.ChecksumCRC32) }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c1, c2 := net.Pipe()
+			defer c2.Close()
+			conn := rpc.NewConn(test.wrap(rpc.NewStreamTransport(c1)), &rpc.Options{
+				ErrorReporter:         testErrorReporter{tb: t},
+				SingleSegmentOutbound: true,
+			})
+			defer func() {
+				if err := conn.Close(); err != nil {
+					t.Error("conn.Close():", err)
+				}
+			}()
+			// The reader never blocks, so that the Conn can always send.
+			segs := make(chan int64, 16)
+			go func() {
+				dec := capnp.NewDecoder(c2)
+				for {
+					msg, err := dec.Decode()
+					if err != nil {
+						return
+					}
+					select {
+					case segs <- msg.NumSegments():
+					default:
+					}
+				}
+			}()
+			ctx := context.Background()
+
+			client := conn.Bootstrap(ctx)
+			defer client.Release()
+			callCtx, cancel := context.WithCancel(ctx)
+			_, release := client.SendCall(callCtx, capnp.Send{
+				Method: capnp.Method{
+					InterfaceID: interfaceID,
+					MethodID:    methodID,
+				},
+				ArgsSize: capnp.ObjectSize{PointerCount: 1},
+				PlaceArgs: func(s capnp.Struct) error {
+					return s.SetData(0, make([]byte, 64<<10))
+				},
+			})
+			for _, which := range []string{"bootstrap", "call"} {
+				if n := <-segs; n != 1 {
+					t.Errorf("%s message has %d segments; want 1", which, n)
+				}
+			}
+			cancel()
+			release()
+		})
+	}
+}
+
+// TestSingleSegmentOutboundChecksummed makes a large call between two
+// Conns over checksummed transports, with SingleSegmentOutbound set on
+// the caller, and checks that the callee accepts the messages.
+func TestSingleSegmentOutboundChecksummed(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// A net.Pipe could deadlock with both Conns writing at once.
+	c1, c2, err := tcpPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	argsLen := make(chan int, 1)
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		data, err := call.Args().Ptr(0)
+		if err != nil {
+			return err
+		}
+		argsLen <- len(data.Data())
+		return nil
+	}, nil)
+	conn1 := rpc.NewConn(rpc.NewChecksummedTransport(rpc.NewStreamTransport(c1), rpc.ChecksumCRC32), &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(rpc.NewChecksummedTransport(rpc.NewStreamTransport(c2), rpc.ChecksumCRC32), &rpc.Options{
+		ErrorReporter:         testErrorReporter{tb: t},
+		SingleSegmentOutbound: true,
+	})
+
+	client := conn2.Bootstrap(ctx)
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+		ArgsSize: capnp.ObjectSize{PointerCount: 1},
+		PlaceArgs: func(s capnp.Struct) error {
+			return s.SetData(0, make([]byte, 64<<10))
+		},
+	})
+	if _, err := ans.Struct(); err != nil {
+		t.Error("call:", err)
+	}
+	release()
+	client.Release()
+	select {
+	case n := <-argsLen:
+		if n != 64<<10 {
+			t.Errorf("callee received %d bytes of arguments; want %d", n, 64<<10)
+		}
+	default:
+		t.Error("call not delivered")
+	}
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	select {
+	case <-conn1.Done():
+	case <-ctx.Done():
+		t.Fatal("conn1 not shut down after conn2.Close")
+	}
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+}
+
+// TestPoolInboundBuffers makes concurrent calls between two Conns that
+// reuse their inbound buffers and checks that every call sees its own
+// arguments and results.  Unlike race builds, which never reuse released
+// buffers, this catches memory of a received message being read after
+// the Conn released it.
+func TestPoolInboundBuffers(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c1, c2, err := tcpPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The server echoes its arguments.
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		data, err := call.Args().Ptr(0)
+		if err != nil {
+			return err
+		}
+		results, err := call.AllocResults(capnp.ObjectSize{PointerCount: 1})
+		if err != nil {
+			return err
+		}
+		return results.SetData(0, data.Data())
+	}, nil)
+	conn1 := rpc.NewConn(rpc.NewStreamTransport(c1), &rpc.Options{
+		BootstrapClient:    srv,
+		ErrorReporter:      testErrorReporter{tb: t},
+		PoolInboundBuffers: true,
+	})
+	conn2 := rpc.NewConn(rpc.NewStreamTransport(c2), &rpc.Options{
+		ErrorReporter:      testErrorReporter{tb: t},
+		PoolInboundBuffers: true,
+	})
+	client := conn2.Bootstrap(ctx)
+
+	const callers, calls = 8, 25
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func(i int) {
+			for j := 0; j < calls; j++ {
+				want := bytes.Repeat([]byte{byte(i*calls + j)}, 100+i*calls+j)
+				ans, release := client.SendCall(ctx, capnp.Send{
+					Method: capnp.Method{
+						InterfaceID: interfaceID,
+						MethodID:    methodID,
+					},
+					ArgsSize: capnp.ObjectSize{PointerCount: 1},
+					PlaceArgs: func(s capnp.Struct) error {
+						return s.SetData(0, want)
+					},
+				})
+				results, err := ans.Struct()
+				if err != nil {
+					release()
+					errs <- fmt.Errorf("call %d/%d: %v", i, j, err)
+					return
+				}
+				got, err := results.Ptr(0)
+				if err == nil && !bytes.Equal(got.Data(), want) {
+					err = fmt.Errorf("results = %d bytes of %x; want %d bytes of %x", len(got.Data()), got.Data()[:1], len(want), want[:1])
+				}
+				release()
+				if err != nil {
+					errs <- fmt.Errorf("call %d/%d: %v", i, j, err)
+					return
+				}
+			}
+			errs <- nil
+		}(i)
+	}
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	client.Release()
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	select {
+	case <-conn1.Done():
+	case <-ctx.Done():
+		t.Fatal("conn1 not shut down after conn2.Close")
+	}
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+}
+
+// TestReleaseUnreachableImports receives many capabilities in call
+// results, drops every local reference to them without calling Release,
+// and checks that the Conn releases each import after the clients are
+// garbage collected.
+func TestReleaseUnreachableImports(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter:             testErrorReporter{tb: t},
+		ReleaseUnreachableImports: true,
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	var qid uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		qid = rmsg.Bootstrap.QuestionID
+	}
+	{
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		iptr := capnp.NewInterface(msg.Segment(), 0)
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qid,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content: iptr.ToPtr(),
+					CapTable: []rpcCapDescriptor{{
+						Which:        rpccp.CapDescriptor_Which_senderHosted,
+						SenderHosted: bootstrapExportID,
+					}},
+				},
+			},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	if rmsg, release, err := recvMessage(ctx, p2); err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	} else if release(); rmsg.Which != rpccp.Message_Which_finish {
+		t.Fatalf("Received %v message; want finish", rmsg.Which)
+	}
+
+	// Keep the leaked clients reachable until all of the imports have
+	// been made, so that no Release is sent in the middle of a call.
+	const n = 16
+	leaked := make([]*capnp.Client, 0, n)
+	for i := uint32(0); i < n; i++ {
+		c, err := importAndDrop(ctx, client, p2, bootstrapExportID+1+i)
+		if err != nil {
+			t.Fatalf("import #%d: %v", i+1, err)
+		}
+		leaked = append(leaked, c)
+	}
+	runtime.KeepAlive(leaked)
+	runtime.GC()
+
+	released := make(map[uint32]bool)
+	for len(released) < n {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatalf("after %d releases: recvMessage(ctx, p2): %v", len(released), err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_release {
+			t.Fatalf("Received %v message; want release", rmsg.Which)
+		}
+		id := rmsg.Release.ID
+		if id <= bootstrapExportID || id > bootstrapExportID+n || released[id] {
+			t.Fatalf("Received release for import %d; want one of the dropped imports", id)
+		}
+		if rmsg.Release.ReferenceCount != 1 {
+			t.Errorf("Received release of import %d for %d references; want 1", id, rmsg.Release.ReferenceCount)
+		}
+		released[id] = true
+	}
+}
+
+// importAndDrop makes a call on client, answers it with a capability
+// exported as id, and returns a reference to the capability that the
+// caller should drop without releasing it.
+func importAndDrop(ctx context.Context, client *capnp.Client, p2 rpc.Transport, id uint32) (*capnp.Client, error) {
+	ans, finish := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer finish()
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_call {
+		return nil, fmt.Errorf("received %v message; want call", rmsg.Which)
+	}
+	qid := rmsg.Call.QuestionID
+
+	msg, send, release, err := p2.NewMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := capnp.NewStruct(msg.Segment(), capnp.ObjectSize{PointerCount: 1})
+	if err != nil {
+		release()
+		return nil, err
+	}
+	if err := resp.SetPtr(0, capnp.NewInterface(msg.Segment(), 0).ToPtr()); err != nil {
+		release()
+		return nil, err
+	}
+	err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+		Which: rpccp.Message_Which_return,
+		Return: &rpcReturn{
+			AnswerID: qid,
+			Which:    rpccp.Return_Which_results,
+			Results: &rpcPayload{
+				Content: resp.ToPtr(),
+				CapTable: []rpcCapDescriptor{{
+					Which:        rpccp.CapDescriptor_Which_senderHosted,
+					SenderHosted: id,
+				}},
+			},
+		},
+	})
+	if err != nil {
+		release()
+		return nil, err
+	}
+	err = send()
+	release()
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := ans.Struct()
+	if err != nil {
+		return nil, err
+	}
+	p, err := result.Ptr(0)
+	if err != nil {
+		return nil, err
+	}
+	// Leak a reference to the import.
+	c := p.Interface().Client().AddRef()
+	if c == nil {
+		return nil, errors.New("result has no capability")
+	}
+	rmsg, release, err = recvMessage(ctx, p2)
+	if err != nil {
+		return nil, err
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_finish {
+		return nil, fmt.Errorf("received %v message; want finish", rmsg.Which)
+	}
+	return c, nil
+}
+
+// TestReleaseResultCapsDecider makes two calls, one answered with results
+// and one with an exception, and checks that each Finish message carries
+// the releaseResultCaps value chosen by Options.ReleaseResultCapsDecider.
+func TestReleaseResultCapsDecider(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+		ReleaseResultCapsDecider: func(ret rpccp.Return) bool {
+			return ret.Which() == rpccp.Return_Which_exception
+		},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	var qid uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		qid = rmsg.Bootstrap.QuestionID
+	}
+	{
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		iptr := capnp.NewInterface(msg.Segment(), 0)
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qid,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content: iptr.ToPtr(),
+					CapTable: []rpcCapDescriptor{{
+						Which:        rpccp.CapDescriptor_Which_senderHosted,
+						SenderHosted: bootstrapExportID,
+					}},
+				},
+			},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	if rmsg, release, err := recvMessage(ctx, p2); err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	} else if release(); rmsg.Which != rpccp.Message_Which_finish {
+		t.Fatalf("Received %v message; want finish", rmsg.Which)
+	}
+
+	// 2. Make calls and check their finishes.
+	tests := []struct {
+		name    string
+		ret     *rpcReturn
+		release bool
+	}{
+		{
+			name: "results",
+			ret: &rpcReturn{
+				Which:   rpccp.Return_Which_results,
+				Results: &rpcPayload{},
+			},
+			release: false,
+		},
+		{
+			name: "exception",
+			ret: &rpcReturn{
+				Which: rpccp.Return_Which_exception,
+				Exception: &rpcException{
+					Type:   rpccp.Exception_Type_failed,
+					Reason: "broken",
+				},
+			},
+			release: true,
+		},
+	}
+	for _, test := range tests {
+		ans, finish := client.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+		})
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			finish()
+			t.Fatalf("%s: recvMessage(ctx, p2): %v", test.name, err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_call {
+			finish()
+			t.Fatalf("%s: received %v message; want call", test.name, rmsg.Which)
+		}
+		test.ret.AnswerID = rmsg.Call.QuestionID
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_return,
+			Return: test.ret,
+		})
+		if err != nil {
+			finish()
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		<-ans.Done()
+		finish()
+		rmsg, release, err = recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatalf("%s: recvMessage(ctx, p2): %v", test.name, err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("%s: received %v message; want finish", test.name, rmsg.Which)
+		}
+		if rmsg.Finish.ReleaseResultCaps != test.release {
+			t.Errorf("%s: finish.releaseResultCaps = %t; want %t", test.name, rmsg.Finish.ReleaseResultCaps, test.release)
+		}
+	}
+}
+
+// TestWithReleaseResultCaps makes calls with a Context from
+// rpc.WithReleaseResultCaps that are answered with a capability.  The
+// Finish message asks the remote vat to release the capability and the
+// capability is not imported, unless a pipelined call was made on it.
+func TestWithReleaseResultCaps(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: rmsg.Bootstrap.QuestionID,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content: capnp.NewInterface(msg.Segment(), 0).ToPtr(),
+					CapTable: []rpcCapDescriptor{{
+						Which:        rpccp.CapDescriptor_Which_senderHosted,
+						SenderHosted: bootstrapExportID,
+					}},
+				},
+			},
+		})
+		if err == nil {
+			err = send()
+		}
+		release()
+		if err != nil {
+			t.Fatal("send bootstrap return:", err)
+		}
+	}
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	if rmsg, release, err := recvMessage(ctx, p2); err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	} else if release(); rmsg.Which != rpccp.Message_Which_finish {
+		t.Fatalf("Received %v message; want finish", rmsg.Which)
+	}
+
+	// returnCap answers question qid with a struct whose only pointer is
+	// a capability exported as id.
+	returnCap := func(qid, id uint32) error {
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+		resp, err := capnp.NewStruct(msg.Segment(), capnp.ObjectSize{PointerCount: 1})
+		if err != nil {
+			return err
+		}
+		if err := resp.SetPtr(0, capnp.NewInterface(msg.Segment(), 0).ToPtr()); err != nil {
+			return err
+		}
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qid,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content: resp.ToPtr(),
+					CapTable: []rpcCapDescriptor{{
+						Which:        rpccp.CapDescriptor_Which_senderHosted,
+						SenderHosted: id,
+					}},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		return send()
+	}
+	method := capnp.Method{
+		InterfaceID: interfaceID,
+		MethodID:    methodID,
+	}
+	releaseCtx := rpc.WithReleaseResultCaps(ctx)
+
+	t.Run("Released", func(t *testing.T) {
+		ans, finish := client.SendCall(releaseCtx, capnp.Send{Method: method})
+		defer finish()
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_call {
+			t.Fatalf("Received %v message; want call", rmsg.Which)
+		}
+		if err := returnCap(rmsg.Call.QuestionID, 5); err != nil {
+			t.Fatal("return:", err)
+		}
+		result, err := ans.Struct()
+		if err != nil {
+			t.Fatal("ans.Struct():", err)
+		}
+		p, err := result.Ptr(0)
+		if err != nil {
+			t.Fatal("result.Ptr(0):", err)
+		}
+		if c := p.Interface().Client(); c != nil {
+			t.Errorf("result capability = %v; want null", c)
+		}
+		rmsg, release, err = recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", rmsg.Which)
+		}
+		if !rmsg.Finish.ReleaseResultCaps {
+			t.Error("finish.releaseResultCaps = false; want true")
+		}
+		if got := conn.Stats().Imports; got != 1 {
+			t.Errorf("conn has %d imports; want 1 (the bootstrap capability)", got)
+		}
+	})
+	t.Run("Pipelined", func(t *testing.T) {
+		ans, finish := client.SendCall(releaseCtx, capnp.Send{Method: method})
+		finished := false
+		defer func() {
+			if !finished {
+				finish()
+			}
+		}()
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_call {
+			t.Fatalf("Received %v message; want call", rmsg.Which)
+		}
+		qid := rmsg.Call.QuestionID
+		pcap := ans.Future().Field(0, nil).Client()
+		pans, pfinish := pcap.SendCall(ctx, capnp.Send{Method: method})
+		rmsg, release, err = recvMessage(ctx, p2)
+		if err != nil {
+			pfinish()
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_call {
+			pfinish()
+			t.Fatalf("Received %v message; want pipelined call", rmsg.Which)
+		}
+		pqid := rmsg.Call.QuestionID
+		if err := returnCap(qid, 6); err != nil {
+			t.Fatal("return:", err)
+		}
+		result, err := ans.Struct()
+		if err != nil {
+			t.Fatal("ans.Struct():", err)
+		}
+		p, err := result.Ptr(0)
+		if err != nil {
+			t.Fatal("result.Ptr(0):", err)
+		}
+		if p.Interface().Client() == nil {
+			t.Error("result capability is null; want import")
+		}
+		rmsg, release, err = recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", rmsg.Which)
+		}
+		if rmsg.Finish.ReleaseResultCaps {
+			t.Error("finish.releaseResultCaps = true; want false")
+		}
+
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: pqid,
+				Which:    rpccp.Return_Which_results,
+				Results:  &rpcPayload{},
+			},
+		})
+		if err != nil {
+			pfinish()
+			t.Fatal("sendMessage(ctx, p2, return):", err)
+		}
+		if _, err := pans.Struct(); err != nil {
+			t.Error("pipelined call:", err)
+		}
+		pfinish()
+		rmsg, release, err = recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", rmsg.Which)
+		}
+		finish()
+		finished = true
+		if err := recvRelease(ctx, p2, 6); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+// TestSetBootstrap replaces the bootstrap client of a connection and
+// checks that later bootstraps from the remote vat get the new
+// capability, while earlier ones keep the old capability.
+func TestSetBootstrap(t *testing.T) {
+	newIDServer := func(id uint64, shutdown shutdownFunc) *capnp.Client {
+		return newServer(func(ctx context.Context, call *server.Call) error {
+			resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+			if err != nil {
+				return err
+			}
+			resp.SetUint64(0, id)
+			return nil
+		}, shutdown)
+	}
+	callID := func(ctx context.Context, client *capnp.Client) (uint64, error) {
+		ans, release := client.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+		})
+		defer release()
+		s, err := ans.Struct()
+		if err != nil {
+			return 0, err
+		}
+		return s.Uint64(0), nil
+	}
+
+	oldShutdown := make(chan struct{})
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: newIDServer(1, func() { close(oldShutdown) }),
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	oldBoot := conn2.Bootstrap(ctx)
+	if id, err := callID(ctx, oldBoot); err != nil || id != 1 {
+		t.Errorf("call on first bootstrap = %d, %v; want 1, <nil>", id, err)
+	}
+
+	conn1.SetBootstrap(newIDServer(2, nil))
+	newBoot := conn2.Bootstrap(ctx)
+	if id, err := callID(ctx, newBoot); err != nil || id != 2 {
+		t.Errorf("call on second bootstrap = %d, %v; want 2, <nil>", id, err)
+	}
+	if id, err := callID(ctx, oldBoot); err != nil || id != 1 {
+		t.Errorf("call on first bootstrap after SetBootstrap = %d, %v; want 1, <nil>", id, err)
+	}
+
+	oldBoot.Release()
+	newBoot.Release()
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+	select {
+	case <-oldShutdown:
+	default:
+		t.Error("replaced bootstrap client still alive after Close returned")
+	}
+}
+
+// TestBootstrapOutlivesContext cancels the context passed to Bootstrap
+// after the client resolves and checks that the client keeps working.
+func TestBootstrapOutlivesContext(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			return err
+		}
+		resp.SetUint64(0, 42)
+		return nil
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	bootCtx, cancel := context.WithCancel(ctx)
+	client := conn2.Bootstrap(bootCtx)
+	if err := client.Resolve(ctx); err != nil {
+		t.Error("client.Resolve:", err)
+	}
+	cancel()
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if s, err := ans.Struct(); err != nil {
+		t.Error("call after cancelling bootstrap context:", err)
+	} else if got := s.Uint64(0); got != 42 {
+		t.Errorf("call after cancelling bootstrap context returned %d; want 42", got)
+	}
+	release()
+	client.Release()
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+}
+
+// TestCallDeadlineCancelsRemote makes a call with a deadline on a
+// capability hosted by another Conn and checks that the remote call's
+// Context is canceled once the deadline passes.
+func TestCallDeadlineCancelsRemote(t *testing.T) {
+	remoteDone := make(chan error, 1)
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		call.Ack()
+		<-ctx.Done()
+		remoteDone <- ctx.Err()
+		return ctx.Err()
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	client := conn2.Bootstrap(ctx)
+	if err := client.Resolve(ctx); err != nil {
+		t.Error("client.Resolve:", err)
+	}
+	callCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	ans, release := client.SendCall(callCtx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if _, err := ans.Struct(); err == nil || !strings.Contains(err.Error(), context.DeadlineExceeded.Error()) {
+		t.Errorf("call with expired deadline returned error %v; want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case err := <-remoteDone:
+		if err != context.Canceled {
+			t.Errorf("remote call Context error = %v; want %v", err, context.Canceled)
+		}
+	case <-time.After(10 * time.Second):
+		t.Error("remote call not canceled after deadline")
+	}
+	release()
+	cancel()
+	client.Release()
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+}
+
+// TestSendDanglingInterface checks that call arguments and results with
+// an interface pointer that has no cap-table entry are rejected before
+// they are sent.
+func TestSendDanglingInterface(t *testing.T) {
+	calls := make(chan struct{}, 1)
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		calls <- struct{}{}
+		results, err := call.AllocResults(capnp.ObjectSize{PointerCount: 1})
+		if err != nil {
+			return err
+		}
+		return results.SetPtr(0, capnp.NewInterface(results.Segment(), 3).ToPtr())
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+	client := conn2.Bootstrap(ctx)
+	if err := client.Resolve(ctx); err != nil {
+		t.Error("client.Resolve:", err)
+	}
+	method := capnp.Method{
+		InterfaceID: interfaceID,
+		MethodID:    methodID,
+	}
+
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method:   method,
+		ArgsSize: capnp.ObjectSize{PointerCount: 1},
+		PlaceArgs: func(args capnp.Struct) error {
+			return args.SetPtr(0, capnp.NewInterface(args.Segment(), 3).ToPtr())
+		},
+	})
+	if _, err := ans.Struct(); err == nil || !strings.Contains(err.Error(), "capability 3") {
+		t.Errorf("call with dangling interface in arguments returned error %v; want error mentioning capability 3", err)
+	}
+	release()
+	select {
+	case <-calls:
+		t.Error("call with dangling interface in arguments was delivered")
+	default:
+	}
+
+	ans, release = client.SendCall(ctx, capnp.Send{Method: method})
+	if _, err := ans.Struct(); err == nil || !strings.Contains(err.Error(), "capability 3") {
+		t.Errorf("call with dangling interface in results returned error %v; want error mentioning capability 3", err)
+	}
+	release()
+	select {
+	case <-calls:
+	default:
+		t.Error("call not delivered")
+	}
+	client.Release()
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+}
+
+// TestExceptionMapper checks that Options.ExceptionMapper sets the
+// type and reason of exceptions returned to the remote vat.
+func TestExceptionMapper(t *testing.T) {
+	errNotFound := errors.New("row 7 missing")
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		return errNotFound
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+		ExceptionMapper: func(err error) (string, rpccp.Exception_Type) {
+			if errors.Is(err, errNotFound) {
+				return "not found: " + err.Error(), rpccp.Exception_Type_unimplemented
+			}
+			return err.Error(), rpccp.Exception_Type_failed
+		},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	client := conn2.Bootstrap(ctx)
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if _, err := ans.Struct(); err == nil {
+		t.Error("call returned no error")
+	} else {
+		if !capnp.IsUnimplemented(err) {
+			t.Errorf("call error %v is not unimplemented", err)
+		}
+		if !strings.Contains(err.Error(), "not found: ") {
+			t.Errorf("call error = %v; want reason from ExceptionMapper", err)
+		}
+	}
+	release()
+	client.Release()
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+}
+
+// TestBootstrapCancelBeforeResolution cancels the context passed to
+// Bootstrap before the return arrives and checks that the question is
+// not finished early and the client still resolves to the remote
+// bootstrap capability.
+func TestBootstrapCancelBeforeResolution(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancelTest := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTest()
+
+	bootCtx, cancel := context.WithCancel(ctx)
+	client := conn.Bootstrap(bootCtx)
+	var qid uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		qid = rmsg.Bootstrap.QuestionID
+	}
+	cancel()
+	{
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		iptr := capnp.NewInterface(msg.Segment(), 0)
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qid,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content: iptr.ToPtr(),
+					CapTable: []rpcCapDescriptor{
+						{
+							Which:        rpccp.CapDescriptor_Which_senderHosted,
+							SenderHosted: bootstrapExportID,
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+	{
+		if err := client.Resolve(ctx); err != nil {
+			t.Error("client.Resolve:", err)
+		}
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", rmsg.Which)
+		}
+		if rmsg.Finish.ReleaseResultCaps {
+			t.Error("Received finish that releases bootstrap result capabilities")
+		}
+	}
+
+	ans, releaseCall := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_call {
+			t.Fatalf("Received %v message; want call", rmsg.Which)
+		}
+		if rmsg.Call.Target.Which != rpccp.MessageTarget_Which_importedCap {
+			t.Errorf("call.target which = %v; want importedCap", rmsg.Call.Target.Which)
+		} else if rmsg.Call.Target.ImportedCap != bootstrapExportID {
+			t.Errorf("call.target.importedCap = %d; want %d", rmsg.Call.Target.ImportedCap, bootstrapExportID)
+		}
+		qid = rmsg.Call.QuestionID
+	}
+	{
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		resp, err := capnp.NewStruct(msg.Segment(), capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			t.Fatal("capnp.NewStruct:", err)
+		}
+		resp.SetUint64(0, 0xdeadbeef)
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qid,
+				Which:    rpccp.Return_Which_results,
+				Results:  &rpcPayload{Content: resp.ToPtr()},
+			},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+	if resp, err := ans.Struct(); err != nil {
+		t.Error("ans.Struct():", err)
+	} else if resp.Uint64(0) != 0xdeadbeef {
+		t.Errorf("ans.Struct().Uint64(0) = %#x; want 0xdeadbeef", resp.Uint64(0))
+	}
+	releaseCall()
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", rmsg.Which)
+		}
+	}
+	client.Release()
+}
+
+// TestBootstrapCanceledBeforeSend calls Bootstrap with a context that is
+// already done and checks that no bootstrap request is sent and that
+// calls on the client fail.
+func TestBootstrapCanceledBeforeSend(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancelTest := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTest()
+
+	client := conn.Bootstrap(canceledContext(ctx))
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if _, err := ans.Struct(); err == nil {
+		t.Error("call on bootstrap with canceled context succeeded")
+	}
+	release()
+	client.Release()
+
+	// The next message on the wire is the next bootstrap request.
+	client = conn.Bootstrap(ctx)
+	defer client.Release()
+	rmsg, releaseMsg, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	}
+	defer releaseMsg()
+	if rmsg.Which != rpccp.Message_Which_bootstrap {
+		t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+	}
+	if rmsg.Bootstrap.QuestionID != 0 {
+		t.Errorf("bootstrap.questionId = %d; want 0", rmsg.Bootstrap.QuestionID)
+	}
+}
+
+// TestBootstrapTimeout sets Options.BootstrapTimeout and never answers
+// the bootstrap request.  The question should be finished and the
+// client should resolve to an error that names the timeout.
+func TestBootstrapTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapTimeout: timeout,
+		ErrorReporter:    testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancelTest := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTest()
+
+	start := time.Now()
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	var qid uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_bootstrap {
+			t.Fatalf("Received %v message; want bootstrap", rmsg.Which)
+		}
+		qid = rmsg.Bootstrap.QuestionID
+	}
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", rmsg.Which)
+		}
+		if rmsg.Finish.QuestionID != qid {
+			t.Errorf("finish.questionId = %d; want %d (bootstrap)", rmsg.Finish.QuestionID, qid)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < timeout {
+		t.Errorf("bootstrap finished after %v; want >= %v", elapsed, timeout)
+	}
+
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer release()
+	if _, err := ans.Struct(); err == nil {
+		t.Error("call on timed out bootstrap succeeded")
+	} else if !strings.Contains(err.Error(), "no return from remote vat") {
+		t.Errorf("call on timed out bootstrap error = %v; want bootstrap timeout", err)
+	}
+}
+
+// TestSendTimeout checks that a send blocked for longer than
+// Options.SendTimeout fails and shuts down the connection.
+func TestSendTimeout(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	p1, p2 := newPipe(0) // nothing reads from p2, so sends block
+	defer p2.Close()
+	conn := rpc.NewConn(p1, &rpc.Options{
+		SendTimeout:   timeout,
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx, cancelTest := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTest()
+
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	select {
+	case <-conn.Done():
+	case <-ctx.Done():
+		t.Fatal("connection not shut down after send timed out")
+	}
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer release()
+	if _, err := ans.Struct(); err == nil {
+		t.Error("call on bootstrap that could not be sent succeeded")
+	} else if !strings.Contains(err.Error(), "send timed out") {
+		t.Errorf("call on bootstrap that could not be sent error = %v; want send timeout", err)
+	}
+}
+
+// TestCloseWithBlockedSend checks that closing a connection cancels a
+// send that is blocked on the remote vat, even if the send's Context
+// is never canceled.
+func TestCloseWithBlockedSend(t *testing.T) {
+	p1, p2 := newPipe(1)
+	defer p2.Close()
+	bt := &blockingSendTransport{
+		Transport: p1,
+		started:   make(chan struct{}, 2),
+		returned:  make(chan error, 2),
+	}
+	conn := rpc.NewConn(bt, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+		AbortTimeout:  time.Millisecond,
+	})
+	ctx, cancelTest := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelTest()
+
+	// The bootstrap request is sent with a Context that is never
+	// canceled.
+	client := conn.Bootstrap(context.Background())
+	defer client.Release()
+	select {
+	case <-bt.started:
+	case <-ctx.Done():
+		t.Fatal("bootstrap not sent")
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- conn.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Error("conn.Close():", err)
+		}
+	case <-ctx.Done():
+		t.Fatal("conn.Close() blocked on a send")
+	}
+	select {
+	case err := <-bt.returned:
+		if err == nil {
+			t.Error("blocked send succeeded")
+		}
+	case <-ctx.Done():
+		t.Fatal("blocked send did not return after conn.Close()")
+	}
+}
+
+// blockingSendTransport is a transport whose sends block until their
+// Context is canceled, as if the remote vat stopped reading.
+type blockingSendTransport struct {
+	rpc.Transport
+	started  chan struct{} // receives when a send starts blocking
+	returned chan error    // receives the result of each send
+}
+
+func (bt *blockingSendTransport) NewMessage(ctx context.Context) (rpccp.Message, func() error, capnp.ReleaseFunc, error) {
+	msg, _, release, err := bt.Transport.NewMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, nil, err
+	}
+	send := func() error {
+		bt.started <- struct{}{}
+		<-ctx.Done()
+		err := ctx.Err()
+		bt.returned <- err
+		return err
+	}
+	return msg, send, release, nil
+}
+
+// TestConnStats checks the table and message counts reported by
+// Conn.Stats.
+func TestConnStats(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		return nil
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const bootstrapQID = 5
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, bootstrap):", err)
+	}
+	if _, err := recvBootstrapReturn(ctx, p2, bootstrapQID); err != nil {
+		t.Fatal(err)
+	}
+	stats := conn.Stats()
+	if stats.Questions != 0 || stats.Answers != 1 || stats.Exports != 1 || stats.Imports != 0 || stats.Embargoes != 0 {
+		t.Errorf("after bootstrap, conn.Stats() = %+v; want 1 answer and 1 export", stats)
+	}
+	if n := stats.MessagesReceived[rpccp.Message_Which_bootstrap]; n != 1 || len(stats.MessagesReceived) != 1 {
+		t.Errorf("after bootstrap, MessagesReceived = %v; want 1 bootstrap", stats.MessagesReceived)
+	}
+
+	// Closing waits for all sends to finish, so the message counts are
+	// final afterward.
+	finishTest(t, conn, p2)
+	stats = conn.Stats()
+	if stats.Questions != 0 || stats.Answers != 0 || stats.Exports != 0 || stats.Imports != 0 || stats.Embargoes != 0 {
+		t.Errorf("after close, conn.Stats() = %+v; want empty tables", stats)
+	}
+	wantSent := map[rpccp.Message_Which]uint64{
+		rpccp.Message_Which_return: 1,
+		rpccp.Message_Which_abort:  1,
+	}
+	if len(stats.MessagesSent) != len(wantSent) {
+		t.Errorf("after close, MessagesSent = %v; want %v", stats.MessagesSent, wantSent)
+	}
+	for which, want := range wantSent {
+		if n := stats.MessagesSent[which]; n != want {
+			t.Errorf("after close, MessagesSent[%v] = %d; want %d", which, n, want)
+		}
+	}
+}
+
+// TestCloseReason checks the reason reported by Conn.CloseReason for
+// each way that a connection can shut down.
+func TestCloseReason(t *testing.T) {
+	waitDone := func(t *testing.T, conn *rpc.Conn) {
+		select {
+		case <-conn.Done():
+		case <-time.After(10 * time.Second):
+			t.Fatal("connection not shut down")
+		}
+	}
+	ctx := context.Background()
+
+	t.Run("Close", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		if err := conn.CloseReason(); err != nil {
+			t.Errorf("before shutdown, conn.CloseReason() = %v; want <nil>", err)
+		}
+		finishTest(t, conn, p2)
+		if err := conn.CloseReason(); err == nil || !strings.Contains(err.Error(), "connection closed") {
+			t.Errorf("after Close, conn.CloseReason() = %v; want connection closed", err)
+		}
+		err := conn.CloseReason()
+		if !errors.Is(err, rpc.ErrConnClosed) {
+			t.Errorf("after Close, conn.CloseReason() = %v; want rpc.ErrConnClosed", err)
+		}
+		var aerr *rpc.AbortError
+		if !errors.As(err, &aerr) {
+			t.Fatalf("after Close, conn.CloseReason() = %v; want *rpc.AbortError", err)
+		}
+		if aerr.Remote {
+			t.Error("after Close, AbortError.Remote = true; want false")
+		}
+		if aerr.Type != rpccp.Exception_Type_failed || aerr.Reason != "connection closed" {
+			t.Errorf("after Close, AbortError = {Type: %v, Reason: %q}; want {Type: failed, Reason: \"connection closed\"}", aerr.Type, aerr.Reason)
+		}
+	})
+	t.Run("RemoteAbort", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		defer p2.Close()
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_abort,
+			Abort: &rpcException{
+				Type:   rpccp.Exception_Type_overloaded,
+				Reason: "over it",
+			},
+		})
+		if err != nil {
+			conn.Close()
+			t.Fatal(err)
+		}
+		waitDone(t, conn)
+		var aerr *rpc.AbortError
+		if err := conn.CloseReason(); !errors.As(err, &aerr) {
+			t.Errorf("after remote abort, conn.CloseReason() = %v; want *rpc.AbortError", err)
+		} else if !aerr.Remote || aerr.Type != rpccp.Exception_Type_overloaded || aerr.Reason != "over it" {
+			t.Errorf("after remote abort, AbortError = %+v; want {Type: overloaded, Reason: \"over it\", Remote: true}", aerr)
+		}
+		if err := conn.CloseReason(); errors.Is(err, rpc.ErrConnClosed) {
+			t.Errorf("after remote abort, errors.Is(conn.CloseReason(), rpc.ErrConnClosed) = true; want false")
+		}
+		if err := conn.CloseReason(); err == nil || err != conn.RemoteAbort() {
+			t.Errorf("after remote abort, conn.CloseReason() = %v; want %v", err, conn.RemoteAbort())
+		}
+		if err := conn.Close(); err != nil {
+			t.Error("conn.Close():", err)
+		}
+		if err := conn.CloseReason(); err != conn.RemoteAbort() {
+			t.Errorf("after remote abort and Close, conn.CloseReason() = %v; want %v", err, conn.RemoteAbort())
+		}
+	})
+	t.Run("TransportClosed", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		if err := p2.Close(); err != nil {
+			t.Fatal("p2.Close():", err)
+		}
+		waitDone(t, conn)
+		var terr *rpc.TransportError
+		if err := conn.CloseReason(); !errors.As(err, &terr) {
+			t.Errorf("after transport closed, conn.CloseReason() = %v; want *rpc.TransportError", err)
+		}
+	})
+	t.Run("ProtocolError", func(t *testing.T) {
+		p1, p2 := newPipe(1)
+		defer p2.Close()
+		conn := rpc.NewConn(p1, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		for i := 0; i < 2; i++ {
+			err := sendMessage(ctx, p2, &rpcMessage{
+				Which:     rpccp.Message_Which_bootstrap,
+				Bootstrap: &rpcBootstrap{QuestionID: 1},
+			})
+			if err != nil {
+				t.Fatal("sendMessage(ctx, p2, bootstrap):", err)
+			}
+		}
+		var abortReason string
+		for abortReason == "" {
+			rmsg, release, err := recvMessage(ctx, p2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			if rmsg.Which == rpccp.Message_Which_abort {
+				abortReason = rmsg.Abort.Reason
+			}
+			release()
+		}
+		waitDone(t, conn)
+		if err := conn.CloseReason(); err == nil || err.Error() != abortReason {
+			t.Errorf("after protocol error, conn.CloseReason() = %v; want %q (sent in abort)", err, abortReason)
+		}
+		var aerr *rpc.AbortError
+		if err := conn.CloseReason(); !errors.As(err, &aerr) || aerr.Remote || aerr.Reason != abortReason {
+			t.Errorf("after protocol error, conn.CloseReason() = %v; want local *rpc.AbortError with reason %q", err, abortReason)
+		}
+	})
+	t.Run("SendTimeout", func(t *testing.T) {
+		p1, p2 := newPipe(0) // nothing reads from p2, so sends block
+		defer p2.Close()
+		conn := rpc.NewConn(p1, &rpc.Options{
+			SendTimeout:   10 * time.Millisecond,
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		client := conn.Bootstrap(ctx)
+		defer client.Release()
+		waitDone(t, conn)
+		if err := conn.CloseReason(); err == nil || !strings.Contains(err.Error(), "send timed out") {
+			t.Errorf("after send timeout, conn.CloseReason() = %v; want send timeout", err)
+		}
+	})
+}
+
+// TestShutdown starts a call that blocks, then calls Shutdown.  It
+// checks that calls received while draining are rejected, that the
+// blocked call still returns, and that the connection is closed once
+// the call is finished.
+func TestShutdown(t *testing.T) {
+	ctx := context.Background()
+
+	// setup bootstraps conn and sends a call that blocks until unblock
+	// is closed or the call is canceled.  Later calls return
+	// immediately.
+	setup := func(t *testing.T, unblock <-chan struct{}) (conn *rpc.Conn, p2 rpc.Transport, importID uint32) {
+		started := make(chan struct{})
+		var once sync.Once
+		srv := newServer(func(ctx context.Context, call *server.Call) error {
+			first := false
+			once.Do(func() { first = true })
+			if !first {
+				return nil
+			}
+			call.Ack()
+			close(started)
+			select {
+			case <-unblock:
+			case <-ctx.Done():
+			}
+			return nil
+		}, nil)
+		p1, p2 := newPipe(1)
+		conn = rpc.NewConn(p1, &rpc.Options{
+			BootstrapClient: srv,
+			ErrorReporter:   testErrorReporter{tb: t},
+		})
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		importID, err = recvBootstrapReturn(ctx, p2, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: 1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sendCall(ctx, p2, 2, importID); err != nil {
+			t.Fatal(err)
+		}
+		<-started
+		return conn, p2, importID
+	}
+
+	t.Run("Drain", func(t *testing.T) {
+		unblock := make(chan struct{})
+		conn, p2, importID := setup(t, unblock)
+		defer p2.Close()
+		shutdownDone := make(chan error, 1)
+		go func() {
+			shutdownDone <- conn.Shutdown(ctx)
+		}()
+
+		// Shutdown runs concurrently, so calls may be delivered until
+		// it starts draining.
+		for qid := uint32(3); ; qid++ {
+			if err := sendCall(ctx, p2, qid, importID); err != nil {
+				t.Fatal(err)
+			}
+			rmsg, release, err := recvMessage(ctx, p2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != qid {
+				release()
+				t.Fatalf("received %v message; want return for answer %d", rmsg.Which, qid)
+			}
+			rejected := rmsg.Return.Which == rpccp.Return_Which_exception
+			if rejected {
+				if e := rmsg.Return.Exception; e.Type != rpccp.Exception_Type_disconnected || !strings.Contains(e.Reason, "shutting down") {
+					t.Errorf("call during shutdown: exception = %v %q; want disconnected shutting down", e.Type, e.Reason)
+				}
+			}
+			release()
+			err = sendMessage(ctx, p2, &rpcMessage{
+				Which:  rpccp.Message_Which_finish,
+				Finish: &rpcFinish{QuestionID: qid},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rejected {
+				break
+			}
+		}
+		select {
+		case err := <-shutdownDone:
+			t.Fatalf("Shutdown returned %v before outstanding call finished", err)
+		default:
+		}
+
+		close(unblock)
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != 2 {
+			t.Errorf("received %v message; want return for answer 2", rmsg.Which)
+		} else if rmsg.Return.Which != rpccp.Return_Which_results {
+			t.Errorf("return.which = %v; want results", rmsg.Return.Which)
+		}
+		release()
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: 2},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		rmsg, release, err = recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_abort {
+			t.Errorf("received %v message; want abort", rmsg.Which)
+		}
+		release()
+		if err := <-shutdownDone; err != nil {
+			t.Error("conn.Shutdown(ctx):", err)
+		}
+	})
+	t.Run("ContextDone", func(t *testing.T) {
+		conn, p2, _ := setup(t, make(chan struct{}))
+		defer p2.Close()
+		sctx, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := conn.Shutdown(sctx); err != context.Canceled {
+			t.Errorf("conn.Shutdown(canceled context) = %v; want %v", err, context.Canceled)
+		}
+		select {
+		case <-conn.Done():
+		default:
+			t.Error("connection not shut down after Shutdown returned")
+		}
+		if err := conn.Shutdown(ctx); err == nil {
+			t.Error("second conn.Shutdown(ctx) = <nil>; want error")
+		}
+	})
+}
+
+// TestMaxInFlightCalls checks that a call made while
+// Options.MaxInFlightCalls calls are outstanding is not sent until one
+// of them returns, and that it gives up when its Context is done.
+func TestMaxInFlightCalls(t *testing.T) {
+	ctx := context.Background()
+	started := make(chan struct{}, 1)
+	unblock := make(chan struct{})
+	var mu sync.Mutex
+	var delivered int
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		mu.Lock()
+		delivered++
+		first := delivered == 1
+		mu.Unlock()
+		if !first {
+			return nil
+		}
+		call.Ack()
+		started <- struct{}{}
+		select {
+		case <-unblock:
+		case <-ctx.Done():
+		}
+		return nil
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		MaxInFlightCalls: 1,
+		ErrorReporter:    testErrorReporter{tb: t},
+	})
+	defer func() {
+		if err := conn2.Close(); err != nil {
+			t.Error("conn2.Close:", err)
+		}
+		<-conn1.Done()
+		if err := conn1.Close(); err != nil {
+			t.Error("conn1.Close:", err)
+		}
+	}()
+	client := conn2.Bootstrap(ctx)
+	defer client.Release()
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	call := func(ctx context.Context) error {
+		ans, release := client.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+		})
+		defer release()
+		_, err := ans.Struct()
+		return err
+	}
+
+	firstErr := make(chan error, 1)
+	go func() {
+		firstErr <- call(ctx)
+	}()
+	<-started
+
+	// The limit is reached, so this call is never sent.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	err := call(timeoutCtx)
+	cancel()
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("call while limit reached = %v; want %v", err, context.DeadlineExceeded)
+	}
+
+	// This call waits for the first call to return.
+	lastErr := make(chan error, 1)
+	go func() {
+		lastErr <- call(ctx)
+	}()
+	close(unblock)
+	if err := <-firstErr; err != nil {
+		t.Error("first call:", err)
+	}
+	if err := <-lastErr; err != nil {
+		t.Error("last call:", err)
+	}
+	mu.Lock()
+	n := delivered
+	mu.Unlock()
+	if n != 2 {
+		t.Errorf("server received %d calls; want 2", n)
+	}
+}
+
+// sendCall sends a call with no parameters to an imported capability.
+func sendCall(ctx context.Context, p2 rpc.Transport, qid, importID uint32) error {
+	return sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_call,
+		Call: &rpcCall{
+			QuestionID: qid,
+			Target: rpcMessageTarget{
+				Which:       rpccp.MessageTarget_Which_importedCap,
+				ImportedCap: importID,
+			},
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+}
+
+// TestBootstrapWrapper checks that calls on a remote vat's bootstrap
+// capability go through the client returned by Options.BootstrapWrapper.
+func TestBootstrapWrapper(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			return err
+		}
+		resp.SetUint64(0, 42)
+		return nil
+	}, nil)
+	var wrapped int
+	proxy := &countingProxy{}
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		BootstrapWrapper: func(c *capnp.Client) *capnp.Client {
+			wrapped++
+			proxy.c = c
+			return capnp.NewClient(proxy)
+		},
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	client := conn2.Bootstrap(ctx)
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if s, err := ans.Struct(); err != nil {
+		t.Error("call on bootstrap:", err)
+	} else if got := s.Uint64(0); got != 42 {
+		t.Errorf("call on bootstrap returned %d; want 42", got)
+	}
+	release()
+	client.Release()
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+	if wrapped != 1 {
+		t.Errorf("BootstrapWrapper called %d times; want 1", wrapped)
+	}
+	if n := proxy.calls(); n != 1 {
+		t.Errorf("proxy received %d calls; want 1", n)
+	}
+	if !proxy.isShutdown() {
+		t.Error("proxy not shut down after Close")
+	}
+}
+
+// countingProxy is a client hook that forwards to another client and
+// counts the calls it forwards.
+type countingProxy struct {
+	c *capnp.Client
+
+	mu       sync.Mutex
+	n        int
+	shutdown bool
+}
+
+func (cp *countingProxy) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	cp.mu.Lock()
+	cp.n++
+	cp.mu.Unlock()
+	return cp.c.SendCall(ctx, s)
+}
+
+func (cp *countingProxy) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
+	cp.mu.Lock()
+	cp.n++
+	cp.mu.Unlock()
+	return cp.c.RecvCall(ctx, r)
+}
+
+func (cp *countingProxy) Brand() capnp.Brand {
+	return capnp.Brand{}
+}
+
+func (cp *countingProxy) Shutdown() {
+	cp.mu.Lock()
+	cp.shutdown = true
+	cp.mu.Unlock()
+	cp.c.Release()
+}
+
+func (cp *countingProxy) calls() int {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.n
+}
+
+func (cp *countingProxy) isShutdown() bool {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+	return cp.shutdown
+}
+
+// TestCustomClientHook exports a capability implemented directly as a
+// ClientHook, rather than with a server, and calls it both locally and
+// through a Conn.
+func TestCustomClientHook(t *testing.T) {
+	hook := &echoHook{shutdown: make(chan struct{})}
+	echo := capnp.NewClient(hook)
+	send := func(ctx context.Context, c *capnp.Client, n uint64) (uint64, error) {
+		ans, release := c.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+			ArgsSize: capnp.ObjectSize{DataSize: 8},
+			PlaceArgs: func(s capnp.Struct) error {
+				s.SetUint64(0, n)
+				return nil
+			},
+		})
+		defer release()
+		s, err := ans.Struct()
+		if err != nil {
+			return 0, err
+		}
+		return s.Uint64(0), nil
+	}
+	ctx := context.Background()
+	if got, err := send(ctx, echo, 7); err != nil || got != 7 {
+		t.Errorf("local call = %d, %v; want 7, <nil>", got, err)
+	}
+
+	p1, p2 := newPipe(1)
+	conn1 := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: echo,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	conn2 := rpc.NewConn(p2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	client := conn2.Bootstrap(ctx)
+	if got, err := send(ctx, client, 42); err != nil || got != 42 {
+		t.Errorf("remote call = %d, %v; want 42, <nil>", got, err)
+	}
+	client.Release()
+
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close():", err)
+	}
+	<-conn1.Done()
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close():", err)
+	}
+	select {
+	case <-hook.shutdown:
+	default:
+		t.Error("hook not shut down after Close")
+	}
+}
+
+// echoHook is a client hook that returns its call arguments as results.
+type echoHook struct {
+	shutdown chan struct{}
+}
+
+func (h *echoHook) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.ErrorAnswer(s.Method, err), func() {}
+	}
+	args, err := capnp.NewRootStruct(seg, s.ArgsSize)
+	if err != nil {
+		return capnp.ErrorAnswer(s.Method, err), func() {}
+	}
+	if s.PlaceArgs != nil {
+		if err := s.PlaceArgs(args); err != nil {
+			return capnp.ErrorAnswer(s.Method, err), func() {}
+		}
+	}
+	return capnp.ImmediateAnswer(s.Method, args), func() {}
+}
+
+func (h *echoHook) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
+	defer r.ReleaseArgs()
+	res, err := r.Returner.AllocResults(r.Args.Size())
+	if err == nil {
+		err = res.CopyFrom(r.Args)
+	}
+	r.Returner.Return(err)
+	return nil
+}
+
+func (h *echoHook) Brand() capnp.Brand {
+	return capnp.Brand{}
+}
+
+func (h *echoHook) Shutdown() {
+	close(h.shutdown)
+}
+
+// TestRecvBootstrapCall sets Options.BootstrapClient on NewConn,
+// bootstraps, waits for a return, then sends a call to the RPC
+// connection.  It checks that the correct messages were sent and that
+// the return values are correct.  Level 0 requirement.
+func TestRecvBootstrapCall(t *testing.T) {
+	srvShutdown := make(chan struct{})
+	srv := newServer(
+		func(ctx context.Context, call *server.Call) error {
+			resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+			if err != nil {
+				return err
+			}
+			resp.SetUint64(0, 0xdeadbeef|uint64(call.Args().Uint32(0))<<32)
+			return nil
+		},
+		func() {
+			close(srvShutdown)
+		})
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer func() {
+		finishTest(t, conn, p2)
+		select {
+		case <-srvShutdown:
+		default:
+			t.Error("Bootstrap client still alive after Close returned")
+		}
+	}()
+
+	ctx := context.Background()
+
 	// 1. Write bootstrap
 	const bootstrapQID = 54
 	{
 		msg := &rpcMessage{
-			Which:     rpccp.Message_Which_bootstrap,
-			Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+		}
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 2. Read return
+	var bootstrapImportID uint32
+	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Write finish
+	{
+		msg := &rpcMessage{
+			Which: rpccp.Message_Which_finish,
+			Finish: &rpcFinish{
+				QuestionID:        bootstrapQID,
+				ReleaseResultCaps: false,
+			},
+		}
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 4. Write call
+	const callQID = 55
+	{
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		params, err := capnp.NewStruct(msg.Segment(), capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			t.Fatal("capnp.NewStruct:", err)
+		}
+		params.SetUint32(0, 0x2a2b)
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_call,
+			Call: &rpcCall{
+				QuestionID: callQID,
+				Target: rpcMessageTarget{
+					Which:       rpccp.MessageTarget_Which_importedCap,
+					ImportedCap: bootstrapImportID,
+				},
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+				Params: rpcPayload{
+					Content: params.ToPtr(),
+				},
+			},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+
+	// 5. Read return
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.AnswerID != callQID {
+			t.Errorf("Received return for answer %d; want %d", rmsg.Return.AnswerID, callQID)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_results {
+			t.Fatalf("return which = %v; want results", rmsg.Return.Which)
+		}
+		result := rmsg.Return.Results.Content.Struct()
+		if got, want := result.Uint64(0), uint64(0x00002a2bdeadbeef); got != want {
+			t.Errorf("return results content = %#016x; want %#016x", got, want)
+		}
+	}
+
+	// 6. Write finish
+	{
+		msg := &rpcMessage{
+			Which: rpccp.Message_Which_finish,
+			Finish: &rpcFinish{
+				QuestionID:        callQID,
+				ReleaseResultCaps: false,
+			},
+		}
+		if err := sendMessage(ctx, p2, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 7. Write release
+	{
+		msg := &rpcMessage{
+			Which: rpccp.Message_Which_release,
+			Release: &rpcRelease{
+				ID:             bootstrapImportID,
+				ReferenceCount: 1,
+			},
 		}
 		if err := sendMessage(ctx, p2, msg); err != nil {
 			t.Fatal(err)
 		}
 	}
 
-	// 2. Read return
-	var bootstrapImportID uint32
+	// 8. srv should not be released (Conn should be holding on).
+	select {
+	case <-srvShutdown:
+		t.Error("Bootstrap client released after release message")
+	default:
+	}
+}
+
+// TestExportHeldByRemote returns a capability that the server keeps only
+// a weak reference to, then releases it from the remote vat.  It checks
+// that the export was the last strong reference: the capability shuts
+// down, the weak reference can no longer be upgraded, and the server's
+// object becomes collectable.
+func TestExportHeldByRemote(t *testing.T) {
+	type cacheEntry struct{ value uint64 }
+	shutdown := make(chan struct{})
+	collected := make(chan struct{})
+	var weak *capnp.WeakClient
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		entry := &cacheEntry{value: 42}
+		runtime.SetFinalizer(entry, func(*cacheEntry) { close(collected) })
+		c := capnp.NewClient(server.New([]server.Method{{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+			Impl: func(ctx context.Context, call *server.Call) error {
+				resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+				if err != nil {
+					return err
+				}
+				resp.SetUint64(0, entry.value)
+				return nil
+			},
+		}}, nil /* brand */, shutdownFunc(func() { close(shutdown) }), nil /* policy */))
+		weak = c.WeakRef()
+		resp, err := call.AllocResults(capnp.ObjectSize{PointerCount: 1})
+		if err != nil {
+			c.Release()
+			return err
+		}
+		// The message steals the only strong reference.
+		iface := capnp.NewInterface(resp.Segment(), resp.Message().AddCap(c))
+		return resp.SetPtr(0, iface.ToPtr())
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 1. Bootstrap
+	const bootstrapQID = 54
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Call the bootstrap capability to get the cache entry.
+	const callQID = 55
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_call,
+		Call: &rpcCall{
+			QuestionID: callQID,
+			Target: rpcMessageTarget{
+				Which:       rpccp.MessageTarget_Which_importedCap,
+				ImportedCap: bootstrapImportID,
+			},
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entryImportID uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_return {
+			release()
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		if rmsg.Return.Which != rpccp.Return_Which_results {
+			release()
+			t.Fatalf("return which = %v; want results", rmsg.Return.Which)
+		}
+		ctab := rmsg.Return.Results.CapTable
+		if len(ctab) != 1 || ctab[0].Which != rpccp.CapDescriptor_Which_senderHosted {
+			release()
+			t.Fatalf("return capability table = %+v; want one senderHosted capability", ctab)
+		}
+		entryImportID = ctab[0].SenderHosted
+		release()
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: callQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-shutdown:
+		t.Fatal("cache entry shut down while the remote vat still holds a reference")
+	default:
+	}
+
+	// 3. Release the cache entry from the remote vat.
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_release,
+		Release: &rpcRelease{
+			ID:             entryImportID,
+			ReferenceCount: 1,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-shutdown:
+	case <-ctx.Done():
+		t.Fatal("cache entry not shut down after the remote vat released it")
+	}
+	if c, ok := weak.AddRef(); ok {
+		c.Release()
+		t.Error("weak.AddRef() succeeded after the remote vat released the export")
+	}
+	weak = nil
+	runtime.GC()
+	select {
+	case <-collected:
+	case <-ctx.Done():
+		t.Error("cache entry not collected after the remote vat released it")
+	}
+}
+
+// TestRecvReleaseBadCount bootstraps, then releases the bootstrap export
+// with a reference count of zero and with more references than the
+// remote vat holds.  It checks that a zero count is ignored unless
+// Options.StrictReleases is set, and that an excessive count aborts the
+// connection.
+func TestRecvReleaseBadCount(t *testing.T) {
+	tests := []struct {
+		name      string
+		strict    bool
+		count     uint32
+		wantAbort bool
+	}{
+		{name: "Zero", count: 0, wantAbort: false},
+		{name: "ZeroStrict", strict: true, count: 0, wantAbort: true},
+		{name: "TooMany", count: 2, wantAbort: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p1, p2 := newPipe(1)
+			defer p2.Close()
+			conn := rpc.NewConn(p1, &rpc.Options{
+				BootstrapClient: newServer(nil, nil),
+				ErrorReporter:   testErrorReporter{tb: t},
+				StrictReleases:  test.strict,
+			})
+			defer func() {
+				if err := conn.Close(); err != nil {
+					t.Error("conn.Close():", err)
+				}
+			}()
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			// 1. Bootstrap, which exports one reference.
+			const bootstrapQID = 1
+			err := sendMessage(ctx, p2, &rpcMessage{
+				Which:     rpccp.Message_Which_bootstrap,
+				Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			importID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = sendMessage(ctx, p2, &rpcMessage{
+				Which:  rpccp.Message_Which_finish,
+				Finish: &rpcFinish{QuestionID: bootstrapQID},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// 2. Release with a bad count.
+			err = sendMessage(ctx, p2, &rpcMessage{
+				Which: rpccp.Message_Which_release,
+				Release: &rpcRelease{
+					ID:             importID,
+					ReferenceCount: test.count,
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.wantAbort {
+				rmsg, release, err := recvMessage(ctx, p2)
+				if err != nil {
+					t.Fatal("recvMessage(ctx, p2):", err)
+				}
+				release()
+				if rmsg.Which != rpccp.Message_Which_abort {
+					t.Fatalf("Received %v message; want abort", rmsg.Which)
+				}
+				<-conn.Done()
+				return
+			}
+
+			// 3. The connection is still usable: a second bootstrap gets
+			// the same export.
+			const bootstrap2QID = 2
+			err = sendMessage(ctx, p2, &rpcMessage{
+				Which:     rpccp.Message_Which_bootstrap,
+				Bootstrap: &rpcBootstrap{QuestionID: bootstrap2QID},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			importID2, err := recvBootstrapReturn(ctx, p2, bootstrap2QID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if importID2 != importID {
+				t.Errorf("second bootstrap returned export %d; want %d", importID2, importID)
+			}
+		})
+	}
+}
+
+// TestRecvMessageTooLarge sets Options.MaxMessageSize and sends a small
+// bootstrap message followed by one that exceeds the limit.  The first
+// one should be answered and the second should abort the connection.
+func TestRecvMessageTooLarge(t *testing.T) {
+	p1, p2 := newPipe(1)
+	defer p2.Close()
+	conn := rpc.NewConn(p1, &rpc.Options{
+		MaxMessageSize: 1024,
+		ErrorReporter:  testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	const smallQID = 54
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: smallQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_return || rmsg.Return.AnswerID != smallQID {
+			t.Fatalf("Received %v message; want return for question %d", rmsg.Which, smallQID)
+		}
+	}
+
+	// Write a bootstrap message with 2048 bytes of unreferenced data.
+	{
+		msg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		if _, err := capnp.NewData(msg.Segment(), make([]byte, 2048)); err != nil {
+			release()
+			t.Fatal("capnp.NewData:", err)
+		}
+		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+			Which:     rpccp.Message_Which_bootstrap,
+			Bootstrap: &rpcBootstrap{QuestionID: smallQID + 1},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+	}
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	}
+	defer release()
+	if rmsg.Which != rpccp.Message_Which_abort {
+		t.Fatalf("Received %v message; want abort", rmsg.Which)
+	}
+	if !strings.Contains(rmsg.Abort.Reason, "message exceeds configured maximum size") {
+		t.Errorf("abort.reason = %q; want it to mention the maximum message size", rmsg.Abort.Reason)
+	}
+	<-conn.Done()
+	if err := conn.Close(); err != nil {
+		t.Errorf("conn.Close() = %v; want <nil>", err)
+	}
+}
+
+// TestRecvMessageTooLargeStream sets Options.MaxMessageSize on a Conn
+// over a stream transport, then writes only the stream header of a
+// message much larger than the limit.  It checks that the Conn aborts
+// without waiting for the message's segments.
+func TestRecvMessageTooLargeStream(t *testing.T) {
+	c1, c2, err := tcpPair()
+	if err != nil {
+		t.Fatal("tcpPair:", err)
+	}
+	conn := rpc.NewConn(rpc.NewStreamTransport(c1), &rpc.Options{
+		MaxMessageSize: 1024,
+		ErrorReporter:  testErrorReporter{tb: t},
+	})
+	peer := rpc.NewStreamTransport(c2)
+	defer peer.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// One segment of 1<<27 words, which is never written.
+	var hdr [8]byte
+	binary.LittleEndian.PutUint32(hdr[4:], 1<<27)
+	if _, err := c2.Write(hdr[:]); err != nil {
+		t.Fatal("c2.Write:", err)
+	}
+	rmsg, release, err := recvMessage(ctx, peer)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, peer):", err)
+	}
+	defer release()
+	if rmsg.Which != rpccp.Message_Which_abort {
+		t.Fatalf("Received %v message; want abort", rmsg.Which)
+	}
+	if !strings.Contains(rmsg.Abort.Reason, "message exceeds configured maximum size") {
+		t.Errorf("abort.reason = %q; want it to mention the maximum message size", rmsg.Abort.Reason)
+	}
+	select {
+	case <-conn.Done():
+	case <-ctx.Done():
+		t.Fatal("conn not shut down after abort")
+	}
+	if err := conn.Close(); err != nil {
+		t.Errorf("conn.Close() = %v; want <nil>", err)
+	}
+}
+
+// TestRecvCallParamsTooLarge sets Options.MaxParamsSize on NewConn,
+// bootstraps, then sends a call with params larger than the limit.  It
+// checks that the call is answered with an exception without being
+// delivered to the bootstrap capability.
+func TestRecvCallParamsTooLarge(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		t.Error("call delivered despite oversized params")
+		return nil
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+		MaxParamsSize:   64,
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	// 1. Bootstrap
+	const bootstrapQID = 54
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
 	bootstrapImportID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// 3. Write finish
-	{
-		msg := &rpcMessage{
-			Which: rpccp.Message_Which_finish,
-			Finish: &rpcFinish{
-				QuestionID:        bootstrapQID,
-				ReleaseResultCaps: false,
-			},
-		}
-		if err := sendMessage(ctx, p2, msg); err != nil {
-			t.Fatal(err)
-		}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
 
-	// 4. Write call
+	// 2. Write call with a 128-byte data field.
 	const callQID = 55
 	{
 		msg, send, release, err := p2.NewMessage(ctx)
 		if err != nil {
 			t.Fatal("p2.NewMessage():", err)
 		}
-		params, err := capnp.NewStruct(msg.Segment(), capnp.ObjectSize{DataSize: 8})
+		params, err := capnp.NewStruct(msg.Segment(), capnp.ObjectSize{PointerCount: 1})
 		if err != nil {
 			t.Fatal("capnp.NewStruct:", err)
 		}
-		params.SetUint32(0, 0x2a2b)
+		if err := params.SetData(0, make([]byte, 128)); err != nil {
+			t.Fatal("params.SetData:", err)
+		}
 		err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
 			Which: rpccp.Message_Which_call,
 			Call: &rpcCall{
@@ -939,61 +4186,42 @@ func TestRecvBootstrapCall(t *testing.T) {
 		}
 	}
 
-	// 5. Read return
-	{
-		rmsg, release, err := recvMessage(ctx, p2)
-		if err != nil {
-			t.Fatal("recvMessage(ctx, p2):", err)
-		}
-		defer release()
-		if rmsg.Which != rpccp.Message_Which_return {
-			t.Fatalf("Received %v message; want return", rmsg.Which)
-		}
-		if rmsg.Return.AnswerID != callQID {
-			t.Errorf("Received return for answer %d; want %d", rmsg.Return.AnswerID, callQID)
-		}
-		if rmsg.Return.Which != rpccp.Return_Which_results {
-			t.Fatalf("return which = %v; want results", rmsg.Return.Which)
-		}
-		result := rmsg.Return.Results.Content.Struct()
-		if got, want := result.Uint64(0), uint64(0x00002a2bdeadbeef); got != want {
-			t.Errorf("return results content = %#016x; want %#016x", got, want)
-		}
+	// 3. Read return
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
 	}
-
-	// 6. Write finish
-	{
-		msg := &rpcMessage{
-			Which: rpccp.Message_Which_finish,
-			Finish: &rpcFinish{
-				QuestionID:        callQID,
-				ReleaseResultCaps: false,
-			},
-		}
-		if err := sendMessage(ctx, p2, msg); err != nil {
-			t.Fatal(err)
-		}
+	defer release()
+	if rmsg.Which != rpccp.Message_Which_return {
+		t.Fatalf("Received %v message; want return", rmsg.Which)
 	}
-
-	// 7. Write release
-	{
-		msg := &rpcMessage{
-			Which: rpccp.Message_Which_release,
-			Release: &rpcRelease{
-				ID:             bootstrapImportID,
-				ReferenceCount: 1,
-			},
-		}
-		if err := sendMessage(ctx, p2, msg); err != nil {
-			t.Fatal(err)
-		}
+	if rmsg.Return.AnswerID != callQID {
+		t.Errorf("Received return for answer %d; want %d", rmsg.Return.AnswerID, callQID)
+	}
+	if rmsg.Return.Which != rpccp.Return_Which_exception {
+		t.Fatalf("return which = %v; want exception", rmsg.Return.Which)
+	}
+	if !strings.Contains(rmsg.Return.Exception.Reason, "exceeds limit") {
+		t.Errorf("return.exception.reason = %q; want to mention limit", rmsg.Return.Exception.Reason)
 	}
 
-	// 8. srv should not be released (Conn should be holding on).
-	select {
-	case <-srvShutdown:
-		t.Error("Bootstrap client released after release message")
-	default:
+	// 4. Finish and release
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: callQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_release,
+		Release: &rpcRelease{
+			ID:             bootstrapImportID,
+			ReferenceCount: 1,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
 	}
 }
 
@@ -1376,6 +4604,117 @@ func TestCallOnClosedConn(t *testing.T) {
 	}
 }
 
+// TestRecvPipelineCallOnRejectedAnswer checks that a call pipelined on
+// an answer that has not returned yet is rejected once that answer
+// returns an exception.
+func TestRecvPipelineCallOnRejectedAnswer(t *testing.T) {
+	fail := make(chan struct{})
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		call.Ack()
+		select {
+		case <-fail:
+			return errors.New("dependency failed")
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const bootstrapQID = 11
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, bootstrap):", err)
+	}
+	bootstrapID, err := recvBootstrapReturn(ctx, p2, bootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Call the bootstrap capability, then pipeline a call on the
+	// capability in the first call's results.
+	const callQID = 12
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_call,
+		Call: &rpcCall{
+			QuestionID: callQID,
+			Target: rpcMessageTarget{
+				Which:       rpccp.MessageTarget_Which_importedCap,
+				ImportedCap: bootstrapID,
+			},
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, call):", err)
+	}
+	const pipelineQID = 13
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_call,
+		Call: &rpcCall{
+			QuestionID: pipelineQID,
+			Target: rpcMessageTarget{
+				Which: rpccp.MessageTarget_Which_promisedAnswer,
+				PromisedAnswer: &rpcPromisedAnswer{
+					QuestionID: callQID,
+					Transform: []rpcPromisedAnswerOp{{
+						Which:           rpccp.PromisedAnswer_Op_Which_getPointerField,
+						GetPointerField: 0,
+					}},
+				},
+			},
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, pipelined call):", err)
+	}
+
+	close(fail)
+	returned := make(map[uint32]bool)
+	for len(returned) < 2 {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if rmsg.Which != rpccp.Message_Which_return {
+			release()
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+		id := rmsg.Return.AnswerID
+		if id != callQID && id != pipelineQID || returned[id] {
+			t.Errorf("Received return for answer %d; want %d and %d once each", id, callQID, pipelineQID)
+		}
+		returned[id] = true
+		if rmsg.Return.Which != rpccp.Return_Which_exception {
+			t.Errorf("Return for answer %d is %v; want exception", id, rmsg.Return.Which)
+		} else if reason := rmsg.Return.Exception.Reason; !strings.Contains(reason, "dependency failed") {
+			t.Errorf("Return for answer %d has reason %q; want the first call's error", id, reason)
+		}
+		release()
+	}
+	for _, qid := range []uint32{callQID, pipelineQID} {
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which:  rpccp.Message_Which_finish,
+			Finish: &rpcFinish{QuestionID: qid},
+		})
+		if err != nil {
+			t.Fatal("sendMessage(ctx, p2, finish):", err)
+		}
+	}
+}
+
 // TestRecvCancel makes a call, sends a finish before it returns, then
 // checks to see whether the call's Context was canceled and whether the
 // capability the call returned is released.  Level 0 requirement.
@@ -1546,6 +4885,93 @@ func TestRecvCancel(t *testing.T) {
 	}
 }
 
+// TestRecvAbortCancelsCalls starts a call that blocks until its Context
+// is canceled, then writes an abort message to the connection.  It
+// checks that shutting down the connection cancels the call's Context.
+func TestRecvAbortCancelsCalls(t *testing.T) {
+	callStart := make(chan struct{})
+	callCancel := make(chan struct{})
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		call.Ack()
+		close(callStart)
+		<-ctx.Done()
+		close(callCancel)
+		return ctx.Err()
+	}, nil)
+	p1, p2 := newPipe(1)
+	defer p2.Close()
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	ctx := context.Background()
+
+	// 1. Write bootstrap
+	const bootstrapQID = 54
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: bootstrapQID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 2. Write call
+	const callQID = 55
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_call,
+		Call: &rpcCall{
+			QuestionID: callQID,
+			Target: rpcMessageTarget{
+				Which: rpccp.MessageTarget_Which_promisedAnswer,
+				PromisedAnswer: &rpcPromisedAnswer{
+					QuestionID: bootstrapQID,
+				},
+			},
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 3. Read bootstrap return
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		defer release()
+		if rmsg.Which != rpccp.Message_Which_return {
+			t.Fatalf("Received %v message; want return", rmsg.Which)
+		}
+	}
+
+	// 4. Wait for the call to start, then abort.
+	<-callStart
+	select {
+	case <-callCancel:
+		t.Error("call context done before abort written")
+	default:
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_abort,
+		Abort: &rpcException{
+			Type:   rpccp.Exception_Type_failed,
+			Reason: "going away",
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-conn.Done()
+	<-callCancel
+	if err := conn.Close(); err != nil {
+		t.Errorf("conn.Close() = %v; want <nil>", err)
+	}
+}
+
 // TestSendCancel makes a call, cancels the Context, then checks to
 // see whether a finish message was sent.  Level 0 requirement.
 func TestSendCancel(t *testing.T) {
@@ -1,13 +1,18 @@
 package rpc_test
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"capnproto.org/go/capnp/v3"
 	"capnproto.org/go/capnp/v3/pogs"
 	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
 	"capnproto.org/go/capnp/v3/server"
 	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
 )
@@ -483,6 +488,240 @@ func testSendDisembargo(t *testing.T, sendPrimeTo rpccp.Call_sendResultsTo_Which
 	}
 }
 
+// TestSendDisembargoReusesID resolves several embargoes one after
+// another on the same connection and checks that each disembargo reuses
+// the ID freed by the previous one, so that embargo IDs stay bounded by
+// the number of outstanding embargoes.
+func TestSendDisembargoReusesID(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	client := conn.Bootstrap(ctx)
+	defer client.Release()
+	{
+		msg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		if msg.Which != rpccp.Message_Which_bootstrap {
+			release()
+			t.Fatalf("Received %v message; want bootstrap", msg.Which)
+		}
+		bootQID := msg.Bootstrap.QuestionID
+		release()
+		rmsg, send, release, err := p2.NewMessage(ctx)
+		if err != nil {
+			t.Fatal("p2.NewMessage():", err)
+		}
+		err = pogs.Insert(rpccp.Message_TypeID, rmsg.Struct, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: bootQID,
+				Which:    rpccp.Return_Which_results,
+				Results: &rpcPayload{
+					Content: capnp.NewInterface(rmsg.Segment(), 0).ToPtr(),
+					CapTable: []rpcCapDescriptor{
+						{
+							Which:        rpccp.CapDescriptor_Which_senderHosted,
+							SenderHosted: bootstrapExportID,
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			release()
+			t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+		}
+		err = send()
+		release()
+		if err != nil {
+			t.Fatal("send():", err)
+		}
+		msg, release, err = recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if msg.Which != rpccp.Message_Which_finish {
+			t.Fatalf("Received %v message; want finish", msg.Which)
+		}
+	}
+
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		return nil
+	}, nil)
+	defer srv.Release()
+	for i := 0; i < 5; i++ {
+		// Make a call with an export parameter and a pipelined call on
+		// its answer.  Returning the export embargoes the answer.
+		ansA, releaseCallA := client.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+			ArgsSize: capnp.ObjectSize{PointerCount: 1},
+			PlaceArgs: func(s capnp.Struct) error {
+				id := s.Message().AddCap(srv.AddRef())
+				return s.SetPtr(0, capnp.NewInterface(s.Segment(), id).ToPtr())
+			},
+		})
+		var qidA, importID uint32
+		{
+			msg, release, err := recvMessage(ctx, p2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			if msg.Which != rpccp.Message_Which_call {
+				release()
+				t.Fatalf("#%d: received %v message; want call", i, msg.Which)
+			}
+			qidA = msg.Call.QuestionID
+			if len(msg.Call.Params.CapTable) != 1 {
+				release()
+				t.Fatalf("#%d: call.params.capTable has %d entries; want 1", i, len(msg.Call.Params.CapTable))
+			}
+			importID = msg.Call.Params.CapTable[0].SenderHosted
+			release()
+		}
+		ansB, releaseCallB := ansA.PipelineSend(ctx, []capnp.PipelineOp{{Field: 0}}, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+		})
+		var qidB uint32
+		{
+			msg, release, err := recvMessage(ctx, p2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			if msg.Which != rpccp.Message_Which_call {
+				release()
+				t.Fatalf("#%d: received %v message; want call", i, msg.Which)
+			}
+			qidB = msg.Call.QuestionID
+			release()
+		}
+
+		// Return the export for call A.
+		{
+			msg, send, release, err := p2.NewMessage(ctx)
+			if err != nil {
+				t.Fatal("p2.NewMessage():", err)
+			}
+			results, err := capnp.NewStruct(msg.Segment(), capnp.ObjectSize{PointerCount: 1})
+			if err != nil {
+				release()
+				t.Fatal("capnp.NewStruct:", err)
+			}
+			if err := results.SetPtr(0, capnp.NewInterface(msg.Segment(), 0).ToPtr()); err != nil {
+				release()
+				t.Fatal("results.SetPtr:", err)
+			}
+			err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+				Which: rpccp.Message_Which_return,
+				Return: &rpcReturn{
+					AnswerID: qidA,
+					Which:    rpccp.Return_Which_results,
+					Results: &rpcPayload{
+						Content: results.ToPtr(),
+						CapTable: []rpcCapDescriptor{
+							{
+								Which:          rpccp.CapDescriptor_Which_receiverHosted,
+								ReceiverHosted: importID,
+							},
+						},
+					},
+				},
+			})
+			if err != nil {
+				release()
+				t.Fatal("pogs.Insert(p2.NewMessage(), &rpcMessage{...}):", err)
+			}
+			err = send()
+			release()
+			if err != nil {
+				t.Fatal("send():", err)
+			}
+		}
+
+		// Read the disembargo and call A's finish.
+		var embargoID uint32
+		{
+			msg, release, err := recvMessage(ctx, p2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			if msg.Which != rpccp.Message_Which_disembargo {
+				release()
+				t.Fatalf("#%d: received %v message; want disembargo", i, msg.Which)
+			}
+			embargoID = msg.Disembargo.Context.SenderLoopback
+			release()
+			if embargoID != 0 {
+				t.Errorf("#%d: disembargo.context.senderLoopback = %d; want 0 (reused)", i, embargoID)
+			}
+			msg, release, err = recvMessage(ctx, p2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			release()
+			if msg.Which != rpccp.Message_Which_finish || msg.Finish.QuestionID != qidA {
+				t.Fatalf("#%d: received %v message; want finish for question %d", i, msg.Which, qidA)
+			}
+		}
+
+		// Loop the disembargo back and return call B.
+		err := sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_disembargo,
+			Disembargo: &rpcDisembargo{
+				Context: rpcDisembargoContext{
+					Which:            rpccp.Disembargo_context_Which_receiverLoopback,
+					ReceiverLoopback: embargoID,
+				},
+				Target: rpcMessageTarget{
+					Which:       rpccp.MessageTarget_Which_importedCap,
+					ImportedCap: importID,
+				},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = sendMessage(ctx, p2, &rpcMessage{
+			Which: rpccp.Message_Which_return,
+			Return: &rpcReturn{
+				AnswerID: qidB,
+				Which:    rpccp.Return_Which_results,
+				Results:  &rpcPayload{},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ansB.Struct(); err != nil {
+			t.Errorf("#%d: call B: %v", i, err)
+		}
+		{
+			msg, release, err := recvMessage(ctx, p2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, p2):", err)
+			}
+			release()
+			if msg.Which != rpccp.Message_Which_finish || msg.Finish.QuestionID != qidB {
+				t.Fatalf("#%d: received %v message; want finish for question %d", i, msg.Which, qidB)
+			}
+		}
+		releaseCallB()
+		releaseCallA()
+	}
+}
+
 // TestRecvDisembargo exposes a capability that echoes back received
 // capabilities, writes a call to the conn's capability followed by two
 // pipelined calls to the return value, reads the returns and sends a
@@ -770,6 +1009,131 @@ func TestRecvDisembargo(t *testing.T) {
 	}
 }
 
+// TestRecvDisembargoUnimplemented sends a disembargo with a context that
+// Conn does not implement and checks that the disembargo echoed back in
+// the unimplemented message has the same target, including every op in
+// the promised answer's transform.
+func TestRecvDisembargoUnimplemented(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	target := rpcMessageTarget{
+		Which: rpccp.MessageTarget_Which_promisedAnswer,
+		PromisedAnswer: &rpcPromisedAnswer{
+			QuestionID: 42,
+			Transform: []rpcPromisedAnswerOp{
+				{Which: rpccp.PromisedAnswer_Op_Which_getPointerField, GetPointerField: 1},
+				{Which: rpccp.PromisedAnswer_Op_Which_noop},
+				{Which: rpccp.PromisedAnswer_Op_Which_getPointerField, GetPointerField: 3},
+			},
+		},
+	}
+	want, err := canonicalTarget(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_disembargo,
+		Disembargo: &rpcDisembargo{
+			Target:  target,
+			Context: rpcDisembargoContext{Which: rpccp.Disembargo_context_Which_accept},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg, release, err := p2.RecvMessage(ctx)
+	if err != nil {
+		t.Fatal("p2.RecvMessage(ctx):", err)
+	}
+	defer release()
+	if msg.Which() != rpccp.Message_Which_unimplemented {
+		t.Fatalf("Received %v message; want unimplemented", msg.Which())
+	}
+	um, err := msg.Unimplemented()
+	if err != nil {
+		t.Fatal("msg.Unimplemented():", err)
+	}
+	if um.Which() != rpccp.Message_Which_disembargo {
+		t.Fatalf("unimplemented.which = %v; want disembargo", um.Which())
+	}
+	d, err := um.Disembargo()
+	if err != nil {
+		t.Fatal("unimplemented.Disembargo():", err)
+	}
+	dtarget, err := d.Target()
+	if err != nil {
+		t.Fatal("unimplemented.disembargo.Target():", err)
+	}
+	got, err := capnp.Canonicalize(dtarget.Struct)
+	if err != nil {
+		t.Fatal("capnp.Canonicalize(unimplemented.disembargo.target):", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("unimplemented.disembargo.target = %x; want %x", got, want)
+	}
+}
+
+// TestRecvDisembargoProbe sends a sender loopback disembargo that does
+// not target a resolved promise, as a liveness probe would.  The
+// protocol does not allow this, so the connection must abort rather
+// than echo the disembargo.
+func TestRecvDisembargoProbe(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx := context.Background()
+
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_disembargo,
+		Disembargo: &rpcDisembargo{
+			Target: rpcMessageTarget{
+				Which:       rpccp.MessageTarget_Which_importedCap,
+				ImportedCap: 0,
+			},
+			Context: rpcDisembargoContext{
+				Which:          rpccp.Disembargo_context_Which_senderLoopback,
+				SenderLoopback: 7,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_abort {
+		t.Fatalf("Received %v message; want abort", rmsg.Which)
+	}
+	<-conn.Done()
+}
+
+// canonicalTarget returns the canonical encoding of tgt.
+func canonicalTarget(tgt rpcMessageTarget) ([]byte, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return nil, err
+	}
+	s, err := rpccp.NewRootMessageTarget(seg)
+	if err != nil {
+		return nil, err
+	}
+	if err := pogs.Insert(rpccp.MessageTarget_TypeID, s.Struct, &tgt); err != nil {
+		return nil, err
+	}
+	return capnp.Canonicalize(s.Struct)
+}
+
 // TestIssue3 exposes a capability that makes a call to its received
 // capability argument, acks the call, then waits on its return.  In
 // earlier versions of go-capnproto, this would cause a deadlock.
@@ -1046,3 +1410,580 @@ type rpcDisembargoContext struct {
 	ReceiverLoopback uint32
 	Provide          uint32
 }
+
+// TestRecvResolve checks that calls on an imported promise are sent to
+// the promise until the remote vat resolves it, and to the resolution
+// afterward.
+func TestRecvResolve(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const (
+		promiseID  = 7
+		resolvedID = 8
+	)
+	client, err := bootstrapPromise(ctx, conn, p2, promiseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Release()
+	if err := callImport(ctx, client, p2, promiseID); err != nil {
+		t.Fatal("before resolve:", err)
+	}
+
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_resolve,
+		Resolve: &rpcResolve{
+			PromiseID: promiseID,
+			Which:     rpccp.Resolve_Which_cap,
+			Cap: &rpcCapDescriptor{
+				Which:        rpccp.CapDescriptor_Which_senderHosted,
+				SenderHosted: resolvedID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, resolve):", err)
+	}
+	if err := recvRelease(ctx, p2, promiseID); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	if client.State().IsPromise {
+		t.Error("client.State().IsPromise = true after resolve")
+	}
+	if err := callImport(ctx, client, p2, resolvedID); err != nil {
+		t.Fatal("after resolve:", err)
+	}
+}
+
+// TestRecvResolveException checks that calls on an imported promise
+// fail once the remote vat resolves it to an exception.
+func TestRecvResolveException(t *testing.T) {
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const promiseID = 7
+	client, err := bootstrapPromise(ctx, conn, p2, promiseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Release()
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_resolve,
+		Resolve: &rpcResolve{
+			PromiseID: promiseID,
+			Which:     rpccp.Resolve_Which_exception,
+			Exception: &rpcException{Reason: "promise broken"},
+		},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, resolve):", err)
+	}
+	if err := recvRelease(ctx, p2, promiseID); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Resolve(ctx); err != nil {
+		t.Fatal("client.Resolve:", err)
+	}
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer release()
+	if _, err := ans.Struct(); err == nil {
+		t.Error("call on promise resolved to exception succeeded")
+	} else if !strings.Contains(err.Error(), "promise broken") {
+		t.Errorf("call on promise resolved to exception error = %v; want promise broken", err)
+	}
+}
+
+// TestRecvResolveToLocal checks that when an imported promise resolves
+// to a capability exported by this vat, the Conn embargoes the
+// capability until its Disembargo is looped back, and then delivers
+// calls locally.
+func TestRecvResolveToLocal(t *testing.T) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			return err
+		}
+		resp.SetUint64(0, 42)
+		return nil
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient: srv,
+		ErrorReporter:   testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Import this vat's bootstrap capability into the remote vat.
+	const remoteBootstrapQID = 3
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: remoteBootstrapQID},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, bootstrap):", err)
+	}
+	exportID, err := recvBootstrapReturn(ctx, p2, remoteBootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: remoteBootstrapQID},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, finish):", err)
+	}
+
+	const promiseID = 7
+	client, err := bootstrapPromise(ctx, conn, p2, promiseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Release()
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_resolve,
+		Resolve: &rpcResolve{
+			PromiseID: promiseID,
+			Which:     rpccp.Resolve_Which_cap,
+			Cap: &rpcCapDescriptor{
+				Which:          rpccp.CapDescriptor_Which_receiverHosted,
+				ReceiverHosted: exportID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, resolve):", err)
+	}
+	var embargoID uint32
+	{
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_disembargo {
+			t.Fatalf("Received %v message; want disembargo", rmsg.Which)
+		}
+		d := rmsg.Disembargo
+		if d.Target.Which != rpccp.MessageTarget_Which_importedCap || d.Target.ImportedCap != promiseID {
+			t.Errorf("disembargo.target = %+v; want importedCap %d", d.Target, promiseID)
+		}
+		if d.Context.Which != rpccp.Disembargo_context_Which_senderLoopback {
+			t.Fatalf("disembargo.context is %v; want senderLoopback", d.Context.Which)
+		}
+		embargoID = d.Context.SenderLoopback
+	}
+	if err := recvRelease(ctx, p2, promiseID); err != nil {
+		t.Fatal(err)
+	}
+
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_disembargo,
+		Disembargo: &rpcDisembargo{
+			Target: rpcMessageTarget{
+				Which:       rpccp.MessageTarget_Which_importedCap,
+				ImportedCap: exportID,
+			},
+			Context: rpcDisembargoContext{
+				Which:            rpccp.Disembargo_context_Which_receiverLoopback,
+				ReceiverLoopback: embargoID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, disembargo):", err)
+	}
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer release()
+	if s, err := ans.Struct(); err != nil {
+		t.Error("call after disembargo:", err)
+	} else if x := s.Uint64(0); x != 42 {
+		t.Errorf("call after disembargo returned %d; want 42 from local server", x)
+	}
+}
+
+// TestEmbargoTimeout resolves an imported promise to a capability
+// exported by this vat and never loops back the Disembargo.  With
+// Options.EmbargoTimeout, a call made during the embargo is delivered
+// once it expires, or the connection is aborted if
+// Options.AbortOnEmbargoTimeout is set.
+func TestEmbargoTimeout(t *testing.T) {
+	t.Run("Lift", func(t *testing.T) {
+		testEmbargoTimeout(t, false)
+	})
+	t.Run("Abort", func(t *testing.T) {
+		testEmbargoTimeout(t, true)
+	})
+}
+
+func testEmbargoTimeout(t *testing.T, abort bool) {
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+		if err != nil {
+			return err
+		}
+		resp.SetUint64(0, 42)
+		return nil
+	}, nil)
+	p1, p2 := newPipe(1)
+	conn := rpc.NewConn(p1, &rpc.Options{
+		BootstrapClient:       srv,
+		EmbargoTimeout:        100 * time.Millisecond,
+		AbortOnEmbargoTimeout: abort,
+		ErrorReporter:         testErrorReporter{tb: t},
+	})
+	defer finishTest(t, conn, p2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	const remoteBootstrapQID = 3
+	err := sendMessage(ctx, p2, &rpcMessage{
+		Which:     rpccp.Message_Which_bootstrap,
+		Bootstrap: &rpcBootstrap{QuestionID: remoteBootstrapQID},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, bootstrap):", err)
+	}
+	exportID, err := recvBootstrapReturn(ctx, p2, remoteBootstrapQID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which:  rpccp.Message_Which_finish,
+		Finish: &rpcFinish{QuestionID: remoteBootstrapQID},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, finish):", err)
+	}
+
+	const promiseID = 7
+	client, err := bootstrapPromise(ctx, conn, p2, promiseID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Release()
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_resolve,
+		Resolve: &rpcResolve{
+			PromiseID: promiseID,
+			Which:     rpccp.Resolve_Which_cap,
+			Cap: &rpcCapDescriptor{
+				Which:          rpccp.CapDescriptor_Which_receiverHosted,
+				ReceiverHosted: exportID,
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal("sendMessage(ctx, p2, resolve):", err)
+	}
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		t.Fatal("recvMessage(ctx, p2):", err)
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_disembargo {
+		t.Fatalf("Received %v message; want disembargo", rmsg.Which)
+	}
+	if err := recvRelease(ctx, p2, promiseID); err != nil {
+		t.Fatal(err)
+	}
+
+	if abort {
+		rmsg, release, err := recvMessage(ctx, p2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, p2):", err)
+		}
+		release()
+		if rmsg.Which != rpccp.Message_Which_abort {
+			t.Fatalf("Received %v message; want abort", rmsg.Which)
+		}
+		if !strings.Contains(rmsg.Abort.Reason, "embargo") {
+			t.Errorf("abort reason = %q; want it to mention the embargo", rmsg.Abort.Reason)
+		}
+		select {
+		case <-conn.Done():
+		case <-ctx.Done():
+			t.Fatal("conn not shut down after abort")
+		}
+		return
+	}
+	ans, finish := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer finish()
+	if s, err := ans.Struct(); err != nil {
+		t.Error("call after embargo timeout:", err)
+	} else if x := s.Uint64(0); x != 42 {
+		t.Errorf("call after embargo timeout returned %d; want 42 from local server", x)
+	}
+}
+
+// TestPipelinedCallOrder checks that calls made on a promised answer
+// from one goroutine are delivered in the order they were made while
+// the answer returns.  Vat A passes a capability it hosts to vat B,
+// which returns it, so the calls that A makes on the result are first
+// pipelined through B, then embargoed, and finally delivered locally.
+// Each call carries its sequence number as an argument.  Several
+// goroutines make calls at the same time to vary the timing.
+func TestPipelinedCallOrder(t *testing.T) {
+	const (
+		goroutines = 4
+		rounds     = 50
+		calls      = 20
+	)
+	ctx := context.Background()
+	// Each vat's receive goroutine sends messages, so the pipe must hold
+	// every message that can be in flight, or both vats could block on
+	// sending.
+	pAB, pBA := newPipe(goroutines * calls * 4)
+	connB := rpc.NewConn(pBA, &rpc.Options{
+		BootstrapClient: newServer(func(ctx context.Context, call *server.Call) error {
+			ptr, err := call.Args().Ptr(0)
+			if err != nil {
+				return err
+			}
+			results, err := call.AllocResults(capnp.ObjectSize{PointerCount: 1})
+			if err != nil {
+				return err
+			}
+			id := results.Message().AddCap(ptr.Interface().Client().AddRef())
+			return results.SetPtr(0, capnp.NewInterface(results.Segment(), id).ToPtr())
+		}, nil),
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	connA := rpc.NewConn(pAB, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer closeConns(t, connA, connB)
+	boot := connA.Bootstrap(ctx)
+	defer boot.Release()
+
+	round := func() error {
+		rec := new(callRecorder)
+		recClient := testcp.PingPong_ServerToClient(rec, nil).Client
+		defer recClient.Release()
+		ans, release := boot.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+			ArgsSize: capnp.ObjectSize{PointerCount: 1},
+			PlaceArgs: func(s capnp.Struct) error {
+				id := s.Message().AddCap(recClient.AddRef())
+				return s.SetPtr(0, capnp.NewInterface(s.Segment(), id).ToPtr())
+			},
+		})
+		defer release()
+		pp := testcp.PingPong{Client: ans.Field(0, nil).Client()}
+		futures := make([]testcp.PingPong_echoNum_Results_Future, 0, calls)
+		for i := 0; i < calls; i++ {
+			n := int64(i)
+			f, release := pp.EchoNum(ctx, func(args testcp.PingPong_echoNum_Params) error {
+				args.SetN(n)
+				return nil
+			})
+			defer release()
+			futures = append(futures, f)
+		}
+		for i, f := range futures {
+			if _, err := f.Struct(); err != nil {
+				return fmt.Errorf("call %d: %v", i, err)
+			}
+		}
+		got := rec.received()
+		for i, n := range got {
+			if n != int64(i) {
+				return fmt.Errorf("calls delivered in order %v; want 0 to %d", got, calls-1)
+			}
+		}
+		return nil
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				if err := round(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// callRecorder is a PingPong server that records the numbers it is
+// called with.
+type callRecorder struct {
+	mu sync.Mutex
+	ns []int64
+}
+
+func (cr *callRecorder) EchoNum(ctx context.Context, call testcp.PingPong_echoNum) error {
+	n := call.Args().N()
+	cr.mu.Lock()
+	cr.ns = append(cr.ns, n)
+	cr.mu.Unlock()
+	out, err := call.AllocResults()
+	if err != nil {
+		return err
+	}
+	out.SetN(n)
+	return nil
+}
+
+func (cr *callRecorder) received() []int64 {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return append([]int64(nil), cr.ns...)
+}
+
+// bootstrapPromise returns conn's bootstrap capability from the remote
+// vat at p2, which returns it as a promise with the given import ID.
+func bootstrapPromise(ctx context.Context, conn *rpc.Conn, p2 rpc.Transport, promiseID uint32) (*capnp.Client, error) {
+	client := conn.Bootstrap(ctx)
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		client.Release()
+		return nil, fmt.Errorf("bootstrap promise: %v", err)
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_bootstrap {
+		client.Release()
+		return nil, fmt.Errorf("bootstrap promise: received %v message; want bootstrap", rmsg.Which)
+	}
+	qid := rmsg.Bootstrap.QuestionID
+	msg, send, release, err := p2.NewMessage(ctx)
+	if err != nil {
+		client.Release()
+		return nil, fmt.Errorf("bootstrap promise: %v", err)
+	}
+	err = pogs.Insert(rpccp.Message_TypeID, msg.Struct, &rpcMessage{
+		Which: rpccp.Message_Which_return,
+		Return: &rpcReturn{
+			AnswerID: qid,
+			Which:    rpccp.Return_Which_results,
+			Results: &rpcPayload{
+				Content: capnp.NewInterface(msg.Segment(), 0).ToPtr(),
+				CapTable: []rpcCapDescriptor{{
+					Which:         rpccp.CapDescriptor_Which_senderPromise,
+					SenderPromise: promiseID,
+				}},
+			},
+		},
+	})
+	if err == nil {
+		err = send()
+	}
+	release()
+	if err != nil {
+		client.Release()
+		return nil, fmt.Errorf("bootstrap promise: send return: %v", err)
+	}
+	rmsg, release, err = recvMessage(ctx, p2)
+	if err != nil {
+		client.Release()
+		return nil, fmt.Errorf("bootstrap promise: %v", err)
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_finish {
+		client.Release()
+		return nil, fmt.Errorf("bootstrap promise: received %v message; want finish", rmsg.Which)
+	}
+	return client, nil
+}
+
+// callImport makes a call on client, checks that it is sent to the
+// import with the given ID on p2, and returns empty results for it.
+func callImport(ctx context.Context, client *capnp.Client, p2 rpc.Transport, id uint32) error {
+	ans, releaseAns := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	defer releaseAns()
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		return fmt.Errorf("call import %d: %v", id, err)
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_call {
+		return fmt.Errorf("call import %d: received %v message; want call", id, rmsg.Which)
+	}
+	if tgt := rmsg.Call.Target; tgt.Which != rpccp.MessageTarget_Which_importedCap || tgt.ImportedCap != id {
+		return fmt.Errorf("call import %d: call.target = %+v", id, tgt)
+	}
+	err = sendMessage(ctx, p2, &rpcMessage{
+		Which: rpccp.Message_Which_return,
+		Return: &rpcReturn{
+			AnswerID: rmsg.Call.QuestionID,
+			Which:    rpccp.Return_Which_results,
+			Results:  &rpcPayload{},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("call import %d: send return: %v", id, err)
+	}
+	if _, err := ans.Struct(); err != nil {
+		return fmt.Errorf("call import %d: %v", id, err)
+	}
+	rmsg, release, err = recvMessage(ctx, p2)
+	if err != nil {
+		return fmt.Errorf("call import %d: %v", id, err)
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_finish {
+		return fmt.Errorf("call import %d: received %v message; want finish", id, rmsg.Which)
+	}
+	return nil
+}
+
+// recvRelease receives a message from p2 and checks that it releases
+// the import with the given ID.
+func recvRelease(ctx context.Context, p2 rpc.Transport, id uint32) error {
+	rmsg, release, err := recvMessage(ctx, p2)
+	if err != nil {
+		return fmt.Errorf("receive release: %v", err)
+	}
+	release()
+	if rmsg.Which != rpccp.Message_Which_release {
+		return fmt.Errorf("received %v message; want release", rmsg.Which)
+	}
+	if rmsg.Release.ID != id {
+		return fmt.Errorf("release.id = %d; want %d", rmsg.Release.ID, id)
+	}
+	return nil
+}
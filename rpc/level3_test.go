@@ -0,0 +1,243 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	testcp "capnproto.org/go/capnp/v3/rpc/internal/testcapnp"
+	"capnproto.org/go/capnp/v3/server"
+)
+
+// TestThirdPartyHandoff has vat A pass a capability hosted by vat B to
+// vat C.  With a ThirdPartyNetwork on every Conn, C picks up the
+// capability from B directly, so it keeps working after A's Conns are
+// closed.  Without one on C, C calls B through A.
+func TestThirdPartyHandoff(t *testing.T) {
+	t.Run("Direct", func(t *testing.T) {
+		testThirdPartyHandoff(t, true)
+	})
+	t.Run("Vine", func(t *testing.T) {
+		testThirdPartyHandoff(t, false)
+	})
+}
+
+func testThirdPartyHandoff(t *testing.T, recipientNetwork bool) {
+	ctx := context.Background()
+	network := &testNetwork{t: t}
+
+	// Vat B hosts the capability.
+	pAB, pBA := newPipe(1)
+	connBA := rpc.NewConn(pBA, &rpc.Options{
+		BootstrapClient:   testcp.PingPong_ServerToClient(pingPongServer{}, nil).Client,
+		ThirdPartyNetwork: network,
+		ErrorReporter:     testErrorReporter{tb: t},
+	})
+	network.connBA = connBA
+	connAB := rpc.NewConn(pAB, &rpc.Options{
+		ThirdPartyNetwork: network,
+		ErrorReporter:     testErrorReporter{tb: t},
+	})
+	defer closeConns(t, connAB, connBA)
+	network.connAB = connAB
+	fromB := connAB.Bootstrap(ctx)
+	defer fromB.Release()
+	if err := fromB.Resolve(ctx); err != nil {
+		t.Fatal("fromB.Resolve:", err)
+	}
+
+	// Vat A passes B's capability to vat C in the results of a call.
+	pCA, pAC := newPipe(1)
+	connAC := rpc.NewConn(pAC, &rpc.Options{
+		BootstrapClient: newServer(func(ctx context.Context, call *server.Call) error {
+			results, err := call.AllocResults(capnp.ObjectSize{PointerCount: 1})
+			if err != nil {
+				return err
+			}
+			id := results.Message().AddCap(fromB.AddRef())
+			return results.SetPtr(0, capnp.NewInterface(results.Segment(), id).ToPtr())
+		}, nil),
+		ThirdPartyNetwork: network,
+		ErrorReporter:     testErrorReporter{tb: t},
+	})
+	network.connAC = connAC
+	opts := &rpc.Options{ErrorReporter: testErrorReporter{tb: t}}
+	if recipientNetwork {
+		opts.ThirdPartyNetwork = network
+	}
+	connCA := rpc.NewConn(pCA, opts)
+	defer closeConns(t, connCA, connAC)
+
+	fromA := connCA.Bootstrap(ctx)
+	defer fromA.Release()
+	ans, release := fromA.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+		ArgsSize: capnp.ObjectSize{},
+	})
+	results, err := ans.Struct()
+	if err != nil {
+		release()
+		t.Fatal("call on A:", err)
+	}
+	ptr, err := results.Ptr(0)
+	if err != nil {
+		release()
+		t.Fatal("read results:", err)
+	}
+	pp := testcp.PingPong{Client: ptr.Interface().Client().AddRef()}
+	release()
+	defer pp.Client.Release()
+
+	echo := func(n int64) error {
+		ans, release := pp.EchoNum(ctx, func(args testcp.PingPong_echoNum_Params) error {
+			args.SetN(n)
+			return nil
+		})
+		defer release()
+		result, err := ans.Struct()
+		if err != nil {
+			return err
+		}
+		if result.N() != n {
+			return errors.New("echoed wrong number")
+		}
+		return nil
+	}
+	if err := echo(42); err != nil {
+		t.Fatal("echoNum:", err)
+	}
+	if got := network.introductions(); got != 1 {
+		t.Errorf("network introduced %d times; want 1", got)
+	}
+	connCB := network.recipientConn()
+	if !recipientNetwork {
+		if connCB != nil {
+			t.Error("recipient without ThirdPartyNetwork connected to provider")
+		}
+		return
+	}
+	if connCB == nil {
+		t.Fatal("recipient did not connect to provider")
+	}
+	defer closeConns(t, connCB, network.providerConn())
+
+	// Nothing goes through A anymore.
+	if err := connCA.Close(); err != nil {
+		t.Error("connCA.Close:", err)
+	}
+	<-connAC.Done()
+	if err := echo(43); err != nil {
+		t.Error("echoNum after closing the connection to A:", err)
+	}
+}
+
+// closeConns closes conn and then peer once conn's remote vat has
+// noticed.  A Conn that is already closed is skipped.
+func closeConns(t *testing.T, conn, peer *rpc.Conn) {
+	select {
+	case <-conn.Done():
+	default:
+		if err := conn.Close(); err != nil {
+			t.Error("conn.Close:", err)
+		}
+	}
+	<-peer.Done()
+	if err := peer.Close(); err != nil {
+		t.Error("peer.Close:", err)
+	}
+}
+
+// testNetwork is a ThirdPartyNetwork for vats A, B, and C, where A
+// introduces C to B.  All of the IDs are the same text.
+type testNetwork struct {
+	t                      *testing.T
+	connAB, connBA, connAC *rpc.Conn
+
+	mu     sync.Mutex
+	intros int
+	connCB *rpc.Conn
+	connBC *rpc.Conn
+}
+
+func (n *testNetwork) Introduce(provider, recipient *rpc.Conn) (recipientID, capID capnp.Ptr, err error) {
+	if provider != n.connAB || recipient != n.connAC {
+		n.t.Error("Introduce called with unexpected Conns")
+	}
+	n.mu.Lock()
+	n.intros++
+	n.mu.Unlock()
+	id, err := newTextPtr("C")
+	return id, id, err
+}
+
+func (n *testNetwork) Connect(ctx context.Context, conn *rpc.Conn, capID capnp.Ptr) (*rpc.Conn, capnp.Ptr, error) {
+	if capID.Text() != "C" {
+		n.t.Errorf("Connect capID = %q; want \"C\"", capID.Text())
+	}
+	pCB, pBC := newPipe(1)
+	connBC := rpc.NewConn(pBC, &rpc.Options{
+		ThirdPartyNetwork: n,
+		ErrorReporter:     testErrorReporter{tb: n.t},
+	})
+	connCB := rpc.NewConn(pCB, &rpc.Options{
+		ThirdPartyNetwork: n,
+		ErrorReporter:     testErrorReporter{tb: n.t},
+	})
+	n.mu.Lock()
+	n.connCB, n.connBC = connCB, connBC
+	n.mu.Unlock()
+	id, err := newTextPtr("C")
+	return connCB, id, err
+}
+
+func (n *testNetwork) ProvideKey(conn *rpc.Conn, recipientID capnp.Ptr) (string, error) {
+	if conn != n.connBA {
+		n.t.Error("ProvideKey called with unexpected Conn")
+	}
+	return recipientID.Text(), nil
+}
+
+func (n *testNetwork) AcceptKey(conn *rpc.Conn, provisionID capnp.Ptr) (*rpc.Conn, string, error) {
+	if conn != n.providerConn() {
+		n.t.Error("AcceptKey called with unexpected Conn")
+	}
+	return n.connBA, provisionID.Text(), nil
+}
+
+func (n *testNetwork) introductions() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.intros
+}
+
+// recipientConn returns C's Conn to B, or nil if C did not connect.
+func (n *testNetwork) recipientConn() *rpc.Conn {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.connCB
+}
+
+// providerConn returns B's Conn to C, or nil if C did not connect.
+func (n *testNetwork) providerConn() *rpc.Conn {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.connBC
+}
+
+func newTextPtr(s string) (capnp.Ptr, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	text, err := capnp.NewText(seg, s)
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	return text.ToPtr(), nil
+}
@@ -0,0 +1,120 @@
+package rpc
+
+import (
+	"testing"
+
+	"capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestParamsSize(t *testing.T) {
+	msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := capnp.NewRootStruct(seg, capnp.ObjectSize{DataSize: 8, PointerCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := capnp.NewCompositeList(seg, capnp.ObjectSize{DataSize: 8, PointerCount: 1}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < list.Len(); i++ {
+		if err := list.Struct(i).SetText(0, "hi"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := args.SetPtr(0, list.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	bits, err := capnp.NewBitList(seg, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := args.SetPtr(1, bits.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	if err := args.SetPtr(2, capnp.NewInterface(seg, 0).ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+
+	// args (32) + elements (2*16) + texts (2*3) + bits (2)
+	const want = 32 + 2*16 + 2*3 + 2
+	// The limit is enough for one traversal, so later calls fail if the
+	// traversal consumes the read limit.
+	msg.ResetReadLimit(200)
+	for i := 0; i < 5; i++ {
+		got, err := paramsSize(args)
+		if err != nil {
+			t.Fatalf("paramsSize #%d: %v", i+1, err)
+		}
+		if got != want {
+			t.Errorf("paramsSize #%d = %d; want %d", i+1, got, want)
+		}
+	}
+	if got, err := paramsSize(capnp.Struct{}); got != 0 || err != nil {
+		t.Errorf("paramsSize(capnp.Struct{}) = %d, %v; want 0, <nil>", got, err)
+	}
+}
+
+func TestEstimateWireSize(t *testing.T) {
+	msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rmsg, err := rpccp.NewRootMessage(seg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	call, err := rmsg.NewCall()
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := call.NewParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := capnp.NewStruct(seg, capnp.ObjectSize{PointerCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := payload.SetContent(args.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without capabilities, there is no capability table.
+	got, err := EstimateWireSize(msg)
+	if err != nil {
+		t.Fatal("EstimateWireSize:", err)
+	}
+	want, err := msg.TotalSize()
+	if err != nil {
+		t.Fatal("TotalSize:", err)
+	}
+	if got != want {
+		t.Errorf("EstimateWireSize(msg without caps) = %d; want %d", got, want)
+	}
+
+	for i := uint16(0); i < 2; i++ {
+		id := msg.AddCap(capnp.ErrorClient(errorf("dummy")))
+		if err := args.SetPtr(i, capnp.NewInterface(seg, id).ToPtr()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer releaseList(msg.CapTable).release()
+	got, err = EstimateWireSize(msg)
+	if err != nil {
+		t.Fatal("EstimateWireSize:", err)
+	}
+	if _, err := payload.NewCapTable(2); err != nil {
+		t.Fatal(err)
+	}
+	want, err = msg.TotalSize()
+	if err != nil {
+		t.Fatal("TotalSize:", err)
+	}
+	if got != want {
+		t.Errorf("EstimateWireSize(msg with 2 caps) = %d; want %d (size after filling in cap table)", got, want)
+	}
+}
@@ -0,0 +1,63 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+)
+
+// PeerInfo describes the remote end of a transport, for use in access
+// control decisions.  See Options.BaseContext.
+type PeerInfo struct {
+	// RemoteAddr is the network address of the remote vat, or nil if
+	// unknown.
+	RemoteAddr net.Addr
+
+	// TLS is the state of the TLS connection to the remote vat, or nil
+	// if the transport does not use TLS.  Once the handshake is done,
+	// TLS.PeerCertificates holds the certificates the remote vat
+	// authenticated with.
+	TLS *tls.ConnectionState
+}
+
+// NetConnPeerInfo returns the PeerInfo of a network connection.  If c
+// is a *tls.Conn, then the TLS handshake is run if it has not happened
+// yet, and an error from it is returned.
+func NetConnPeerInfo(c net.Conn) (*PeerInfo, error) {
+	info := &PeerInfo{RemoteAddr: c.RemoteAddr()}
+	if tc, ok := c.(*tls.Conn); ok {
+		if err := tc.Handshake(); err != nil {
+			return nil, err
+		}
+		state := tc.ConnectionState()
+		info.TLS = &state
+	}
+	return info, nil
+}
+
+type peerInfoKey struct{}
+
+// WithPeerInfo returns a copy of ctx that carries info.  The key is
+// unexported, so PeerInfoFromContext is the only way to read it.
+func WithPeerInfo(ctx context.Context, info *PeerInfo) context.Context {
+	return context.WithValue(ctx, peerInfoKey{}, info)
+}
+
+// PeerInfoFromContext returns the PeerInfo attached to ctx by
+// WithPeerInfo, or nil if there is none.  Inside a Conn, this is the
+// PeerInfo set by Options.BaseContext.
+func PeerInfoFromContext(ctx context.Context) *PeerInfo {
+	info, _ := ctx.Value(peerInfoKey{}).(*PeerInfo)
+	return info
+}
+
+// valuesContext is a Context with the values of another Context, but
+// without its deadline or cancelation.
+type valuesContext struct {
+	context.Context
+}
+
+func (valuesContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (valuesContext) Done() <-chan struct{}       { return nil }
+func (valuesContext) Err() error                  { return nil }
@@ -0,0 +1,106 @@
+package rpc_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/server"
+)
+
+// TestBaseContext checks that the values of Options.BaseContext reach
+// NoBootstrapHandler and the calls delivered to local capabilities.
+func TestBaseContext(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	info := &rpc.PeerInfo{RemoteAddr: &net.TCPAddr{IP: net.IPv4(192, 0, 2, 1), Port: 1234}}
+	t1, t2 := rpc.NewPipe()
+
+	// Canceling the base Context must not affect the connection.
+	baseCtx, cancelBase := context.WithCancel(rpc.WithPeerInfo(context.Background(), info))
+	cancelBase()
+
+	var (
+		gotTransport rpc.Transport
+		bootInfo     *rpc.PeerInfo
+		callInfo     = make(chan *rpc.PeerInfo, 1)
+	)
+	srv := newServer(func(ctx context.Context, call *server.Call) error {
+		callInfo <- rpc.PeerInfoFromContext(ctx)
+		return nil
+	}, nil)
+	conn1 := rpc.NewConn(t1, &rpc.Options{
+		BaseContext: func(t rpc.Transport) context.Context {
+			gotTransport = t
+			return baseCtx
+		},
+		NoBootstrapHandler: func(ctx context.Context) (*capnp.Client, error) {
+			bootInfo = rpc.PeerInfoFromContext(ctx)
+			return srv.AddRef(), nil
+		},
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	defer srv.Release()
+	if gotTransport != t1 {
+		t.Errorf("BaseContext called with %v; want the transport passed to NewConn", gotTransport)
+	}
+	conn2 := rpc.NewConn(t2, &rpc.Options{
+		ErrorReporter: testErrorReporter{tb: t},
+	})
+	client := conn2.Bootstrap(ctx)
+	ans, release := client.SendCall(ctx, capnp.Send{
+		Method: capnp.Method{
+			InterfaceID: interfaceID,
+			MethodID:    methodID,
+		},
+	})
+	if _, err := ans.Struct(); err != nil {
+		t.Error("call:", err)
+	}
+	release()
+	client.Release()
+	if bootInfo != info {
+		t.Errorf("PeerInfoFromContext in NoBootstrapHandler = %v; want %v", bootInfo, info)
+	}
+	select {
+	case got := <-callInfo:
+		if got != info {
+			t.Errorf("PeerInfoFromContext in call = %v; want %v", got, info)
+		}
+	default:
+		t.Error("call not delivered")
+	}
+	if err := conn2.Close(); err != nil {
+		t.Error("conn2.Close:", err)
+	}
+	select {
+	case <-conn1.Done():
+	case <-ctx.Done():
+		t.Fatal("conn1 not shut down after conn2.Close")
+	}
+	if err := conn1.Close(); err != nil {
+		t.Error("conn1.Close:", err)
+	}
+}
+
+func TestNetConnPeerInfo(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	info, err := rpc.NetConnPeerInfo(c1)
+	if err != nil {
+		t.Fatal("NetConnPeerInfo:", err)
+	}
+	if info.RemoteAddr != c1.RemoteAddr() || info.TLS != nil {
+		t.Errorf("NetConnPeerInfo(c1) = %+v; want remote address %v without TLS", info, c1.RemoteAddr())
+	}
+	if got := rpc.PeerInfoFromContext(rpc.WithPeerInfo(context.Background(), info)); got != info {
+		t.Errorf("PeerInfoFromContext(WithPeerInfo(ctx, info)) = %v; want %v", got, info)
+	}
+	if got := rpc.PeerInfoFromContext(context.Background()); got != nil {
+		t.Errorf("PeerInfoFromContext(context.Background()) = %+v; want nil", got)
+	}
+}
@@ -0,0 +1,205 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	capnp "capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/errors"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// PipeOptions specifies faults for a pipe created by NewPipeWithOptions.
+// A nil *PipeOptions is equivalent to a zero PipeOptions.
+type PipeOptions struct {
+	// Latency delays the delivery of every message by the given
+	// duration.  Sends do not block, and messages are still received in
+	// the order they were sent.
+	Latency time.Duration
+
+	// Drop is called with each message before it is sent.  If it
+	// returns true, the message is silently discarded and send returns
+	// nil, as if the message was lost in transit.
+	Drop func(rpccp.Message) bool
+}
+
+// NewPipe returns two in-memory transports that are connected to each
+// other, suitable for creating a Conn on both ends.  Sends never block:
+// each message is copied and queued for the other end.  After either
+// transport is closed, sends on both transports fail, and RecvMessage
+// returns io.EOF once the messages already queued have been received.
+func NewPipe() (a, b Transport) {
+	return NewPipeWithOptions(nil)
+}
+
+// NewPipeWithOptions is like NewPipe, but injects the faults given in
+// opts into messages sent in either direction.
+func NewPipeWithOptions(opts *PipeOptions) (a, b Transport) {
+	if opts == nil {
+		opts = new(PipeOptions)
+	}
+	q1, q2 := newPipeQueue(), newPipeQueue()
+	return &pipeTransport{r: q1, w: q2, opts: *opts},
+		&pipeTransport{r: q2, w: q1, opts: *opts}
+}
+
+// pipeTransport is one end of a pipe created by NewPipe.
+type pipeTransport struct {
+	r, w   *pipeQueue
+	opts   PipeOptions
+	shape  messageShape
+	closed bool
+}
+
+func (p *pipeTransport) shapeMessages(shape messageShape) bool {
+	p.shape.add(shape)
+	return true
+}
+
+func (p *pipeTransport) NewMessage(ctx context.Context) (_ rpccp.Message, send func() error, release capnp.ReleaseFunc, _ error) {
+	arena := capnp.MultiSegment(nil)
+	if p.shape.singleSegment {
+		arena = capnp.SingleSegment(nil)
+	}
+	msg, seg, err := capnp.NewMessage(arena)
+	if err != nil {
+		return rpccp.Message{}, nil, nil, errors.New(errors.Failed, "rpc pipe", "new message: "+err.Error())
+	}
+	rmsg, err := p.shape.newRoot(seg)
+	if err != nil {
+		return rpccp.Message{}, nil, nil, errors.New(errors.Failed, "rpc pipe", "new message: "+err.Error())
+	}
+	send = func() error {
+		if err := ctx.Err(); err != nil {
+			return errors.New(errors.Failed, "rpc pipe", "send: "+err.Error())
+		}
+		if p.opts.Drop != nil && p.opts.Drop(rmsg) {
+			return nil
+		}
+		// Copy the message, so that the receiver can hold onto it after
+		// the sender has released it.
+		data, err := msg.Marshal()
+		if err != nil {
+			return errors.New(errors.Failed, "rpc pipe", "send: "+err.Error())
+		}
+		return p.w.push(data, time.Now().Add(p.opts.Latency))
+	}
+	return rmsg, send, func() { msg.Reset(nil) }, nil
+}
+
+func (p *pipeTransport) RecvMessage(ctx context.Context) (rpccp.Message, capnp.ReleaseFunc, error) {
+	data, err := p.r.pop(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, err
+	}
+	msg, err := capnp.Unmarshal(data)
+	if err != nil {
+		return rpccp.Message{}, nil, errors.New(errors.Failed, "rpc pipe", "receive: "+err.Error())
+	}
+	rmsg, err := rpccp.ReadRootMessage(msg)
+	if err != nil {
+		return rpccp.Message{}, nil, errors.New(errors.Failed, "rpc pipe", "receive: "+err.Error())
+	}
+	return rmsg, func() { msg.Reset(nil) }, nil
+}
+
+// Close closes both directions of the pipe.  The other end receives the
+// messages already sent to it, then io.EOF.
+func (p *pipeTransport) Close() error {
+	if p.closed {
+		return errors.New(errors.Disconnected, "rpc pipe", "already closed")
+	}
+	p.closed = true
+	p.w.close()
+	p.r.close()
+	return nil
+}
+
+// pipeQueue is an unbounded queue of serialized messages going in one
+// direction of a pipe.
+type pipeQueue struct {
+	// ready has a value when the queue has changed since the last pop
+	// looked at it.
+	ready chan struct{}
+
+	mu     sync.Mutex
+	msgs   []pipeMessage
+	closed bool
+}
+
+type pipeMessage struct {
+	data      []byte
+	deliverAt time.Time
+}
+
+func newPipeQueue() *pipeQueue {
+	return &pipeQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *pipeQueue) push(data []byte, deliverAt time.Time) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return errors.New(errors.Disconnected, "rpc pipe", "send on closed pipe")
+	}
+	q.msgs = append(q.msgs, pipeMessage{data, deliverAt})
+	q.mu.Unlock()
+	q.notify()
+	return nil
+}
+
+// pop removes the first message from the queue, waiting until one has
+// been delivered.  It returns io.EOF if the queue is closed and empty.
+func (q *pipeQueue) pop(ctx context.Context) ([]byte, error) {
+	var err error
+	for {
+		q.mu.Lock()
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if len(q.msgs) > 0 {
+			m := q.msgs[0]
+			d := time.Until(m.deliverAt)
+			if d <= 0 {
+				q.msgs[0] = pipeMessage{}
+				q.msgs = q.msgs[1:]
+				q.mu.Unlock()
+				return m.data, nil
+			}
+			timer = time.NewTimer(d)
+			wait = timer.C
+		} else if q.closed {
+			q.mu.Unlock()
+			return nil, io.EOF
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-q.ready:
+		case <-ctx.Done():
+			err = errors.New(errors.Failed, "rpc pipe", "receive: "+ctx.Err().Error())
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (q *pipeQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *pipeQueue) notify() {
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
@@ -170,7 +170,10 @@ func (p *pipe) Close() error {
 		}
 		panic("Close called before releasing all messages.  Unreleased: " + string(callers))
 	}
-	close(p.w)
+	if p.w != nil {
+		// p.w is cleared when a send notices the other end hung up.
+		close(p.w)
+	}
 	close(p.rc)
 	for {
 		select {
@@ -0,0 +1,160 @@
+package rpc
+
+import (
+	"context"
+	"sync"
+
+	"capnproto.org/go/capnp/v3"
+)
+
+// A DialFunc opens a new transport to a remote vat.
+type DialFunc func(ctx context.Context) (Transport, error)
+
+// A ConnPool is a fixed-size set of connections to the same remote vat.
+// Bootstrap spreads bootstrap capabilities across the connections, so
+// that calls made on them can proceed in parallel.  A connection that
+// has shut down is replaced with a newly dialed one the next time it is
+// picked.  It is safe to use from multiple goroutines.
+type ConnPool struct {
+	dial DialFunc
+	opts *Options
+
+	mu     sync.Mutex
+	conns  []*Conn
+	next   int // index of the next connection to pick
+	stats  PoolStats
+	closed bool
+}
+
+// PoolStats is a snapshot of a ConnPool's activity.
+type PoolStats struct {
+	// Conns is the number of connections in the pool.
+	Conns int
+	// Bootstraps is the number of calls to ConnPool.Bootstrap.
+	Bootstraps uint64
+	// Redials is the number of connections that were replaced after
+	// they shut down.
+	Redials uint64
+	// DialErrors is the number of failed attempts to replace a
+	// connection.
+	DialErrors uint64
+}
+
+// NewConnPool dials n connections with dial and creates a pool of them.
+// Each connection is created with opts, which must not set
+// BootstrapClient: each Conn would take ownership of the same client.
+// If any dial fails, then the connections opened so far are closed and
+// the error is returned.
+func NewConnPool(ctx context.Context, n int, dial DialFunc, opts *Options) (*ConnPool, error) {
+	if n <= 0 {
+		return nil, errorf("new conn pool: size %d is not positive", n)
+	}
+	if opts != nil && opts.BootstrapClient != nil {
+		return nil, fail("new conn pool: options must not set BootstrapClient")
+	}
+	pool := &ConnPool{
+		dial:  dial,
+		opts:  opts,
+		conns: make([]*Conn, 0, n),
+	}
+	for i := 0; i < n; i++ {
+		t, err := dial(ctx)
+		if err != nil {
+			pool.Close()
+			return nil, annotate(err).errorf("new conn pool: dial")
+		}
+		pool.conns = append(pool.conns, NewConn(t, opts))
+	}
+	pool.stats.Conns = n
+	return pool, nil
+}
+
+// Bootstrap returns the remote vat's bootstrap interface on the next
+// connection of the pool, in round-robin order.  If that connection has
+// shut down, Bootstrap dials a replacement first.  The caller is
+// responsible for releasing the returned client.
+func (pool *ConnPool) Bootstrap(ctx context.Context) *capnp.Client {
+	conn, err := pool.pick(ctx)
+	if err != nil {
+		return capnp.ErrorClient(err)
+	}
+	return conn.Bootstrap(ctx)
+}
+
+// pick returns the next live connection, replacing it if needed.
+func (pool *ConnPool) pick(ctx context.Context) (*Conn, error) {
+	pool.mu.Lock()
+	if pool.closed {
+		pool.mu.Unlock()
+		return nil, disconnected("conn pool closed")
+	}
+	pool.stats.Bootstraps++
+	i := pool.next
+	pool.next = (pool.next + 1) % len(pool.conns)
+	dead := pool.conns[i]
+	select {
+	case <-dead.Done():
+	default:
+		pool.mu.Unlock()
+		return dead, nil
+	}
+	pool.mu.Unlock()
+
+	// Dial without holding the lock, so that other connections can
+	// still be used.
+	t, err := pool.dial(ctx)
+	pool.mu.Lock()
+	if err != nil {
+		pool.stats.DialErrors++
+		pool.mu.Unlock()
+		return nil, annotate(err).errorf("conn pool: redial")
+	}
+	if pool.closed {
+		pool.mu.Unlock()
+		t.Close()
+		return nil, disconnected("conn pool closed")
+	}
+	if pool.conns[i] != dead {
+		// Another goroutine replaced the connection first.
+		conn := pool.conns[i]
+		pool.mu.Unlock()
+		t.Close()
+		return conn, nil
+	}
+	conn := NewConn(t, pool.opts)
+	pool.conns[i] = conn
+	pool.stats.Redials++
+	pool.mu.Unlock()
+	// The dead connection has shut down, but its resources are only
+	// released by Close.
+	dead.Close()
+	return conn, nil
+}
+
+// Stats returns a snapshot of the pool's activity.
+func (pool *ConnPool) Stats() PoolStats {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return pool.stats
+}
+
+// Close closes every connection in the pool and returns the first error
+// encountered.  Clients returned by Bootstrap stop working.
+func (pool *ConnPool) Close() error {
+	pool.mu.Lock()
+	if pool.closed {
+		pool.mu.Unlock()
+		return disconnected("conn pool already closed")
+	}
+	pool.closed = true
+	conns := pool.conns
+	pool.mu.Unlock()
+
+	var firstErr error
+	for _, conn := range conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
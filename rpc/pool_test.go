@@ -0,0 +1,134 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/server"
+)
+
+func TestConnPool(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	const (
+		interfaceID = 0xa7317bd7216570aa
+		methodID    = 9
+	)
+	method := capnp.Method{InterfaceID: interfaceID, MethodID: methodID}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	// Each dial connects to a new server whose bootstrap capability
+	// returns the order in which it was dialed.
+	var (
+		mu      sync.Mutex
+		servers []*Conn
+	)
+	dial := func(ctx context.Context) (Transport, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		id := uint64(len(servers) + 1)
+		var d net.Dialer
+		c1, err := d.DialContext(ctx, "tcp", l.Addr().String())
+		if err != nil {
+			return nil, err
+		}
+		c2, err := l.Accept()
+		if err != nil {
+			c1.Close()
+			return nil, err
+		}
+		srv := capnp.NewClient(server.New([]server.Method{{
+			Method: method,
+			Impl: func(ctx context.Context, call *server.Call) error {
+				resp, err := call.AllocResults(capnp.ObjectSize{DataSize: 8})
+				if err != nil {
+					return err
+				}
+				resp.SetUint64(0, id)
+				return nil
+			},
+		}}, nil, nil, nil))
+		servers = append(servers, NewConn(NewStreamTransport(c2), &Options{BootstrapClient: srv}))
+		return NewStreamTransport(c1), nil
+	}
+	serverID := func(client *capnp.Client) (uint64, error) {
+		defer client.Release()
+		ans, release := client.SendCall(ctx, capnp.Send{Method: method})
+		defer release()
+		s, err := ans.Struct()
+		if err != nil {
+			return 0, err
+		}
+		return s.Uint64(0), nil
+	}
+
+	pool, err := NewConnPool(ctx, 2, dial, nil)
+	if err != nil {
+		t.Fatal("NewConnPool:", err)
+	}
+	for i, want := range []uint64{1, 2, 1, 2} {
+		if id, err := serverID(pool.Bootstrap(ctx)); err != nil || id != want {
+			t.Errorf("bootstrap #%d served by %d, %v; want %d, <nil>", i+1, id, err, want)
+		}
+	}
+
+	// Kill the first member.  The next bootstrap picks it and redials.
+	mu.Lock()
+	dead := servers[0]
+	mu.Unlock()
+	if err := dead.Close(); err != nil {
+		t.Fatal("server.Close():", err)
+	}
+	<-pool.conns[0].Done()
+	for i, want := range []uint64{3, 2} {
+		if id, err := serverID(pool.Bootstrap(ctx)); err != nil || id != want {
+			t.Errorf("bootstrap #%d after member died served by %d, %v; want %d, <nil>", i+1, id, err, want)
+		}
+	}
+	want := PoolStats{Conns: 2, Bootstraps: 6, Redials: 1}
+	if stats := pool.Stats(); stats != want {
+		t.Errorf("pool.Stats() = %+v; want %+v", stats, want)
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Error("pool.Close():", err)
+	}
+	if err := pool.Close(); err == nil {
+		t.Error("second pool.Close() = <nil>; want error")
+	}
+	if _, err := serverID(pool.Bootstrap(ctx)); err == nil {
+		t.Error("call on bootstrap from closed pool succeeded")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, srv := range servers[1:] {
+		<-srv.Done()
+		if err := srv.Close(); err != nil {
+			t.Error("server.Close():", err)
+		}
+	}
+}
+
+func TestNewConnPoolInvalid(t *testing.T) {
+	dial := func(ctx context.Context) (Transport, error) {
+		t.Error("dial called")
+		return nil, nil
+	}
+	client := capnp.ErrorClient(fail("unused"))
+	defer client.Release()
+	if _, err := NewConnPool(context.Background(), 1, dial, &Options{BootstrapClient: client}); err == nil {
+		t.Error("NewConnPool with BootstrapClient = <nil>; want error")
+	}
+	if _, err := NewConnPool(context.Background(), 0, dial, nil); err == nil {
+		t.Error("NewConnPool of size 0 = <nil>; want error")
+	}
+}
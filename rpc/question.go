@@ -24,6 +24,12 @@ type question struct {
 	flags         questionFlags
 	finishMsgSend chan struct{}        // closed after attempting to send the Finish message
 	called        [][]capnp.PipelineOp // paths to called clients
+
+	// result and resultErr are the parsed Return message.  They are set
+	// along with the returned flag.  result is cleared before the Return
+	// message is released, so it must only be read while holding c.mu.
+	result    capnp.Ptr
+	resultErr error
 }
 
 // questionFlags is a bitmask of which events have occurred in a question's
@@ -40,6 +46,19 @@ const (
 	// successfully.  It is only valid to query after finishMsgSend is
 	// closed.
 	finishSent
+
+	// callSlot is set if the question holds one of the Conn's slots for
+	// Options.MaxInFlightCalls.  The slot is released when the question
+	// is removed from the table.
+	callSlot
+
+	// dropResultCaps is set if the call was made with a Context from
+	// WithReleaseResultCaps.
+	dropResultCaps
+
+	// returned is set once the Return message has been parsed, before
+	// the question's promise is resolved.
+	returned
 )
 
 // newQuestion adds a new question to c's table.  The caller must be
@@ -68,6 +87,10 @@ func (q *question) handleCancel(ctx context.Context) {
 	select {
 	case <-ctx.Done():
 		rejectErr = ctx.Err()
+		if rejectErr == context.DeadlineExceeded && q.bootstrapPromise != nil {
+			// Only Options.BootstrapTimeout sets a bootstrap deadline.
+			rejectErr = errorf("bootstrap: no return from remote vat within %v", q.c.bootstrapTimeout)
+		}
 	case <-q.c.bgctx.Done():
 		rejectErr = disconnected("connection closed")
 	case <-q.p.Answer().Done():
@@ -111,13 +134,49 @@ func (q *question) handleCancel(ctx context.Context) {
 }
 
 func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineOp, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
-	// Acquire sender lock.
+	return q.pipelineSend(ctx, transform, s, true)
+}
+
+// pipelineSend sends a call to the question's promised answer.  If
+// limit is true, the call waits for a slot under
+// Options.MaxInFlightCalls.
+func (q *question) pipelineSend(ctx context.Context, transform []capnp.PipelineOp, s capnp.Send, limit bool) (*capnp.Answer, capnp.ReleaseFunc) {
 	q.c.mu.Lock()
+	if q.flags&returned != 0 {
+		// The Return arrived while the promise was being resolved, so
+		// the results were embargoed without knowing about this call.
+		// Sent to the remote vat, it could be overtaken by calls made
+		// on the results once the promise resolves, so it is delivered
+		// to the results instead.
+		// The results are only read while holding c.mu, since the Return
+		// message may be released once it is unlocked.
+		var client *capnp.Client
+		err := q.resultErr
+		if err == nil {
+			var ptr capnp.Ptr
+			ptr, err = capnp.Transform(q.result, transform)
+			client = ptr.Interface().Client().AddRef()
+		}
+		q.c.mu.Unlock()
+		if err != nil {
+			return capnp.ErrorAnswer(s.Method, err), func() {}
+		}
+		defer client.Release()
+		return client.SendCall(ctx, s)
+	}
+
+	// Acquire sender lock.
 	if !q.c.startTask() {
 		q.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, disconnected("connection closed")), func() {}
 	}
 	defer q.c.tasks.Done()
+	if limit {
+		if err := q.c.acquireCallSlot(ctx); err != nil {
+			q.c.mu.Unlock()
+			return capnp.ErrorAnswer(s.Method, err), func() {}
+		}
+	}
 	// Mark this transform as having been used for a call ASAP.
 	// q's Return could be received while q2 is being sent.
 	// Don't bother cleaning it up if the call fails because:
@@ -126,18 +185,27 @@ func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineO
 	// c) the worst that happens is we trade bandwidth for code simplicity.
 	q.mark(transform)
 	if err := q.c.tryLockSender(ctx); err != nil {
+		if limit {
+			q.c.releaseCallSlot()
+		}
 		q.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, err), func() {}
 	}
 	q2 := q.c.newQuestion(s.Method)
+	if limit {
+		q2.flags |= callSlot
+	}
+	if releaseResultCapsFromContext(ctx) {
+		q2.flags |= dropResultCaps
+	}
 	q.c.mu.Unlock()
 
 	// Create call message.
 	msg, send, release, err := q.c.transport.NewMessage(ctx)
 	if err != nil {
 		q.c.mu.Lock()
-		q.c.questions[q2.id] = nil
-		q.c.questionID.remove(uint32(q2.id))
+		q.c.clearQuestion(q2.id)
+		q.c.freeQuestionID(q2.id)
 		q.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, errorf("create message: %v", err)), func() {}
 	}
@@ -147,8 +215,8 @@ func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineO
 	err = q.c.newPipelineCallMessage(msg, q.id, transform, q2.id, s)
 	if err != nil {
 		q.c.mu.Lock()
-		q.c.questions[q2.id] = nil
-		q.c.questionID.remove(uint32(q2.id))
+		q.c.clearQuestion(q2.id)
+		q.c.freeQuestionID(q2.id)
 		q.c.lockSender()
 		q.c.mu.Unlock()
 		release()
@@ -160,7 +228,7 @@ func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineO
 
 	// Send call.
 	q.c.mu.Lock()
-	q.c.lockSender()
+	q.c.lockSenderPriority(priorityFromContext(ctx))
 	q.c.mu.Unlock()
 	err = send()
 	release()
@@ -168,8 +236,8 @@ func (q *question) PipelineSend(ctx context.Context, transform []capnp.PipelineO
 	q.c.mu.Lock()
 	q.c.unlockSender()
 	if err != nil {
-		q.c.questions[q2.id] = nil
-		q.c.questionID.remove(uint32(q2.id))
+		q.c.clearQuestion(q2.id)
+		q.c.freeQuestionID(q2.id)
 		q.c.mu.Unlock()
 		return capnp.ErrorAnswer(s.Method, errorf("send message: %v", err)), func() {}
 	}
@@ -233,7 +301,12 @@ func (c *Conn) newPipelineCallMessage(msg rpccp.Message, tgt questionID, transfo
 		return nil
 	}
 	m := args.Message()
-	if err := s.PlaceArgs(args); err != nil {
+	err = s.PlaceArgs(args)
+	if err == nil {
+		// Catch dangling interface pointers before the call is sent.
+		err = m.ValidateForSend()
+	}
+	if err != nil {
 		for _, c := range m.CapTable {
 			c.Release()
 		}
@@ -241,9 +314,10 @@ func (c *Conn) newPipelineCallMessage(msg rpccp.Message, tgt questionID, transfo
 		return errorf("place arguments: %v", err)
 	}
 	clients, states := extractCapTable(m)
+	intros := c.introduce(clients, states)
 	c.mu.Lock()
 	// TODO(soon): save param refs
-	_, err = c.fillPayloadCapTable(payload, clients, states)
+	_, err = c.fillPayloadCapTable(payload, clients, states, intros)
 	c.mu.Unlock()
 	releaseList(clients).release()
 	if err != nil {
@@ -253,7 +327,7 @@ func (c *Conn) newPipelineCallMessage(msg rpccp.Message, tgt questionID, transfo
 }
 
 func (q *question) PipelineRecv(ctx context.Context, transform []capnp.PipelineOp, r capnp.Recv) capnp.PipelineCaller {
-	ans, finish := q.PipelineSend(ctx, transform, capnp.Send{
+	ans, finish := q.pipelineSend(ctx, transform, capnp.Send{
 		Method:   r.Method,
 		ArgsSize: r.Args.Size(),
 		PlaceArgs: func(s capnp.Struct) error {
@@ -261,7 +335,7 @@ func (q *question) PipelineRecv(ctx context.Context, transform []capnp.PipelineO
 			r.ReleaseArgs()
 			return err
 		},
-	})
+	}, false)
 	r.ReleaseArgs()
 	select {
 	case <-ans.Done():
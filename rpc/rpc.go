@@ -1,9 +1,27 @@
 // Package rpc implements the Cap'n Proto RPC protocol.
+//
+// A Conn does not send keepalive messages.  To check that a remote vat
+// is alive, make a call on a capability that it hosts and time the
+// return.  Disembargo messages cannot be used as a cheaper probe: the
+// protocol only allows a senderLoopback disembargo that targets a
+// promise the receiver resolved to one of the sender's own
+// capabilities, and a receiver aborts the connection on any other, as
+// Conn does.
+//
+// Call messages have no field for a deadline, so the deadline of the
+// Context passed to a call is not sent to the remote vat.  Instead, if
+// the Context is done before the call returns, the Conn sends a Finish
+// message for the call.  A Conn that receives the Finish cancels the
+// Context it passed to the method's implementation, so a server stops
+// computing an answer shortly after the caller's deadline passes.
 package rpc // import "capnproto.org/go/capnp/v3/rpc"
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"io"
+	"net"
 	"sync"
 	"time"
 
@@ -35,12 +53,13 @@ indeterminate time or are provided by the application.  This reduces
 contention, but more importantly, prevents deadlocks.  An application-
 provided operation can (and commonly will) call back into the Conn.
 
-Conn protects the outbound stream with a signal channel, Conn.sendCond,
-referred to as the sender lock.  The sender lock is required to create,
+Conn protects the outbound stream with a flag, Conn.sending, referred
+to as the sender lock.  The sender lock is required to create,
 send, or release outbound transport messages.  While a goroutine may
 hold onto both Conn.mu and the sender lock, the goroutine must not hold
 onto Conn.mu while performing any transport operations, for reasons
-mentioned above.
+mentioned above.  Goroutines waiting on the sender lock acquire it in
+order of the priority set with WithPriority.
 
 The receive goroutine, being the only goroutine that receives messages
 from the transport, can receive from the transport without additional
@@ -66,10 +85,59 @@ is a common source of errors and/or inefficiencies.
 // A Conn is a connection to another Cap'n Proto vat.
 // It is safe to use from multiple goroutines.
 type Conn struct {
-	bootstrap    *capnp.Client
 	reporter     ErrorReporter
 	abortTimeout time.Duration
 
+	// maxMessageSize is set by Options.MaxMessageSize.
+	maxMessageSize uint64
+
+	// maxParamsSize is the largest params payload accepted on an
+	// incoming call.  Zero means no limit.
+	maxParamsSize uint64
+
+	// syncDispatch is set by Options.SynchronousDispatch.
+	syncDispatch bool
+
+	// gcImports is set by Options.ReleaseUnreachableImports.
+	gcImports bool
+
+	// releaseResultCaps is set by Options.ReleaseResultCapsDecider.
+	releaseResultCaps func(rpccp.Return) bool
+
+	// strictReleases is set by Options.StrictReleases.
+	strictReleases bool
+
+	// bootstrapWrapper is set by Options.BootstrapWrapper.
+	bootstrapWrapper func(*capnp.Client) *capnp.Client
+
+	// bootstrapTimeout is set by Options.BootstrapTimeout.
+	bootstrapTimeout time.Duration
+
+	// noBootstrap is set by Options.NoBootstrapHandler.
+	noBootstrap func(context.Context) (*capnp.Client, error)
+
+	// bootstrapRouter is set by Options.BootstrapRouter.
+	bootstrapRouter func(context.Context, capnp.Ptr) (*capnp.Client, error)
+
+	// exceptionMapper is set by Options.ExceptionMapper.
+	exceptionMapper func(error) (string, rpccp.Exception_Type)
+
+	// network is set by Options.ThirdPartyNetwork.
+	network ThirdPartyNetwork
+
+	// maxInFlightCalls is set by Options.MaxInFlightCalls.
+	maxInFlightCalls int
+
+	// embargoTimeout is set by Options.EmbargoTimeout.
+	embargoTimeout time.Duration
+
+	// abortOnEmbargoTimeout is set by Options.AbortOnEmbargoTimeout.
+	abortOnEmbargoTimeout bool
+
+	// sentCounts and recvCounts count messages for Stats.  They are
+	// allocated separately to keep their counters 64-bit aligned.
+	sentCounts, recvCounts *messageCounts
+
 	// bgctx is a Context that is canceled when shutdown starts.
 	bgctx context.Context
 
@@ -83,16 +151,50 @@ type Conn struct {
 	// call RecvMessage.
 	transport Transport
 
+	// sends is the outermost layer of transport.  shutdown uses it to
+	// cancel messages that are still being sent.
+	sends *cancelTransport
+
 	// mu protects all the following fields in the Conn.
 	mu sync.Mutex
 
 	closed bool          // set when Close() is called, used to distinguish user Closing multiple times
 	shut   chan struct{} // closed when shutdown() returns
 
-	// sendCond is non-nil if an operation involving sender is in
-	// progress, and the channel is closed when the operation is finished.
+	// remoteAbort is the error received in an abort message from the
+	// remote vat, if any.
+	remoteAbort error
+
+	// closeReason is the cause of shutdown.  It is set when shutdown
+	// starts.
+	closeReason error
+
+	// drained is non-nil once Shutdown has been called.  While it is
+	// non-nil, incoming bootstrap and call messages are rejected.  It
+	// is closed once no questions or answers are outstanding.
+	drained chan struct{}
+
+	// bootstrap is the client returned for Bootstrap messages.
+	bootstrap *capnp.Client
+
+	// sending is true if an operation involving sender is in progress.
 	// See the above comment for a longer explanation.
-	sendCond chan struct{}
+	sending bool
+
+	// sendWaiters is the list of goroutines waiting to acquire the
+	// sender lock, ordered by descending priority.  The lock is handed
+	// directly to the first waiter when it is released.
+	sendWaiters []*senderWaiter
+
+	// onSenderWait is called with c.mu held whenever a goroutine starts
+	// waiting on the sender lock.  It is used by tests.
+	onSenderWait func()
+
+	// inFlightCalls is the number of questions holding a call slot.
+	// callWaiters are the goroutines waiting for a call slot, in the
+	// order they started waiting.  See acquireCallSlot.
+	inFlightCalls int
+	callWaiters   []chan struct{}
 
 	// Tables
 	questions  []*question
@@ -103,6 +205,13 @@ type Conn struct {
 	imports    map[importID]*impent
 	embargoes  []*embargo
 	embargoID  idgen
+
+	// provisions are the capabilities that the remote vat asked this vat
+	// to provide to third vats, by the key from
+	// ThirdPartyNetwork.ProvideKey.  provided is closed when a provision
+	// is added if any Accept is waiting for one.
+	provisions map[string]*provision
+	provided   chan struct{}
 }
 
 // Options specifies optional parameters for creating a Conn.
@@ -114,13 +223,191 @@ type Options struct {
 	BootstrapClient *capnp.Client
 
 	// ErrorReporter will be called upon when errors occur while the Conn
-	// is receiving messages from the remote vat.
+	// is receiving messages from the remote vat.  The Conn never writes
+	// to the standard logger, so ErrorReporter is the place to route
+	// these errors to an application's logging.  If nil, they are
+	// discarded.
 	ErrorReporter ErrorReporter
 
 	// AbortTimeout specifies how long to block on sending an abort message
 	// before closing the transport.  If zero, then a reasonably short
 	// timeout is used.
 	AbortTimeout time.Duration
+
+	// SendTimeout bounds how long the Conn blocks writing any single
+	// message to the transport, including the abort message sent on
+	// Close.  A send that exceeds it fails and shuts down the
+	// connection, since the remote vat is presumably no longer reading.
+	// The timeout only applies from the time the message is sent, not
+	// while it is being built.  If zero, then there is no timeout.
+	SendTimeout time.Duration
+
+	// MaxParamsSize is the maximum number of bytes that the params of an
+	// incoming call may occupy.  Calls with larger params are answered
+	// with an exception without being delivered.  If zero, then there is
+	// no limit other than the transport's.
+	MaxParamsSize uint64
+
+	// MaxMessageSize is the maximum number of bytes that an incoming
+	// message of any kind may occupy, counting all of its segments.  It
+	// is checked as soon as a message is received, before the message
+	// is processed, and the stream transports reject a larger message
+	// from its stream header, before reading the rest of it.  A larger
+	// message aborts the connection.  If zero, then there is no limit
+	// other than the transport's.
+	MaxMessageSize uint64
+
+	// SynchronousDispatch makes the Conn wait for each incoming call to
+	// return before it receives the next message, so calls are
+	// delivered and answered in the order they were received.  This is
+	// intended for tests that need deterministic message ordering.  A
+	// method that waits on the results of a call made over the same Conn
+	// will deadlock.
+	SynchronousDispatch bool
+
+	// SingleSegmentOutbound makes the Conn build every message it sends
+	// in a single segment, so that the remote vat never receives far
+	// pointers.  This is intended for peers that only handle
+	// single-segment messages.  The transport must build the messages
+	// this way: the transports created by this package do, and NewConn
+	// panics if the transport can't.
+	SingleSegmentOutbound bool
+
+	// PoolInboundBuffers makes the stream transports read received
+	// messages into pooled buffers, which are reused for later messages
+	// as soon as the Conn is done with each one.  This saves an
+	// allocation per message, but anything that holds onto memory of a
+	// received message past its lifetime, like call arguments after the
+	// call returns, then silently reads another message's data.  In race
+	// builds, released buffers are overwritten instead of reused.
+	// Transports that don't read messages from a byte stream ignore it.
+	PoolInboundBuffers bool
+
+	// ReleaseUnreachableImports makes the Conn send a Release message
+	// for an imported capability once every client referring to it has
+	// been garbage collected, even if the application never called
+	// Release on them.  This bounds the import table of a long-lived
+	// connection that receives many transient capabilities.
+	ReleaseUnreachableImports bool
+
+	// ReleaseResultCapsDecider is called with each Return message that
+	// the Conn receives for a question that it has not canceled.  Its
+	// result is sent as the releaseResultCaps field of the question's
+	// Finish message.  If nil, releaseResultCaps is always false, since
+	// the Conn imports every capability in the results.  Returning true
+	// asks the remote vat to release those capabilities, so it should
+	// only be done by a proxy that accounts for the references itself.
+	// The function must return quickly, must not use the Conn, and must
+	// not retain the Return message.
+	ReleaseResultCapsDecider func(ret rpccp.Return) bool
+
+	// StrictReleases makes the Conn abort if the remote vat sends a
+	// Release message with a reference count of zero, which is likely a
+	// bug in the remote vat.  Otherwise, such messages are ignored.  A
+	// Release of more references than the remote vat holds always
+	// aborts the connection.
+	StrictReleases bool
+
+	// BootstrapWrapper is called with a new reference to the bootstrap
+	// client each time the remote vat sends a Bootstrap message.  The
+	// client it returns is sent to the remote vat instead, so it can be
+	// used to wrap the bootstrap capability in a proxy, e.g. to add
+	// logging.  BootstrapWrapper takes ownership of the reference it is
+	// passed and the Conn takes ownership of the returned client.  It is
+	// not called if there is no bootstrap client, and it must not use the
+	// Conn.
+	BootstrapWrapper func(*capnp.Client) *capnp.Client
+
+	// NoBootstrapHandler is called when the remote vat sends a
+	// Bootstrap message and there is no bootstrap client.  The Conn
+	// takes ownership of the returned client and sends it to the remote
+	// vat, such as a capability that forwards to another vat.  If it
+	// returns an error instead, the error is sent to the remote vat as
+	// an exception, so returning capnp.Unimplemented makes the Conn
+	// answer with an unimplemented exception.  If it is nil or returns
+	// neither, the Conn answers with a failed exception.  Like
+	// BootstrapWrapper, it is called on the Conn's receive goroutine, so
+	// it should return quickly and must not use the Conn.
+	NoBootstrapHandler func(ctx context.Context) (*capnp.Client, error)
+
+	// BootstrapRouter, if not nil, chooses the capability returned for
+	// a Bootstrap message that carries an object ID, as sent by
+	// Conn.BootstrapObject in the message's deprecatedObjectId field.
+	// The Conn takes ownership of the returned client, which is passed
+	// through BootstrapWrapper like the bootstrap client.  If it returns
+	// an error, or neither a client nor an error, the remote vat
+	// receives an exception.  Bootstrap messages without an object ID
+	// get the bootstrap client as usual.  Like NoBootstrapHandler, it is
+	// called on the Conn's receive goroutine, so it should return
+	// quickly and must not use the Conn.  objectID is only valid until
+	// BootstrapRouter returns.
+	BootstrapRouter func(ctx context.Context, objectID capnp.Ptr) (*capnp.Client, error)
+
+	// ExceptionMapper, if not nil, converts errors into the reason and
+	// type of the exceptions sent to the remote vat, both in returns and
+	// in abort messages.  It can be used to map an application's errors
+	// to exception types, which the remote vat can check with functions
+	// like capnp.IsUnimplemented.  By default, the reason is
+	// err.Error() and the type is failed unless err was created with a
+	// function like capnp.Unimplemented or capnp.Disconnected.  It may
+	// be called with the Conn's lock held, so it must not use the Conn.
+	ExceptionMapper func(err error) (reason string, typ rpccp.Exception_Type)
+
+	// CaptureTo, if not nil, receives a copy of every message that the
+	// Conn sends or receives, for offline analysis.  NewCaptureReader
+	// reads the messages back.  Writes are serialized, but happen on
+	// the Conn's goroutines, so w should be fast, e.g. buffered.  Write
+	// errors are sent to ErrorReporter and do not affect the Conn.
+	CaptureTo io.Writer
+
+	// BootstrapTimeout, if positive, is how long a bootstrap request
+	// made with Conn.Bootstrap may wait for the remote vat's return.
+	// If the return does not arrive in time, the question is finished
+	// and the client resolves to an error, independent of the context
+	// passed to Bootstrap.
+	BootstrapTimeout time.Duration
+
+	// ThirdPartyNetwork, if not nil, lets the Conn take part in
+	// three-party handoffs with other Conns that use the same network,
+	// so that a capability passed along by the remote vat can be called
+	// on the vat that hosts it directly.  If nil, the Conn answers
+	// Provide and Accept messages as unimplemented and sends calls on
+	// capabilities hosted by a third vat through the remote vat.
+	ThirdPartyNetwork ThirdPartyNetwork
+
+	// MaxInFlightCalls, if positive, is the maximum number of calls that
+	// the local vat may have outstanding on the Conn.  A call is
+	// outstanding until its Return message is received.  Once the limit
+	// is reached, new calls wait, in the order they were made, until an
+	// outstanding call returns or the call's Context is done.  Calls
+	// that the Conn forwards on behalf of the remote vat or another Conn
+	// and bootstrap requests are not limited, so that the receive
+	// goroutine never waits on the remote vat.
+	MaxInFlightCalls int
+
+	// EmbargoTimeout, if positive, is how long the Conn holds calls on
+	// an embargoed capability while waiting for the remote vat to loop
+	// back its Disembargo message.  Once it expires, the embargo is
+	// lifted and the error is sent to ErrorReporter, so a remote vat
+	// that never answers cannot block the calls forever, although they
+	// may then be delivered out of order.  If zero, then the Conn waits
+	// until the connection is closed.
+	EmbargoTimeout time.Duration
+
+	// AbortOnEmbargoTimeout makes the Conn abort the connection when
+	// EmbargoTimeout expires, treating the missing disembargo as a
+	// protocol violation by the remote vat.
+	AbortOnEmbargoTimeout bool
+
+	// BaseContext, if not nil, is called once by NewConn with the
+	// transport it was given.  The values of the returned Context are
+	// visible to every Context the Conn creates for the remote vat's
+	// requests: those passed to NoBootstrapHandler and BootstrapRouter,
+	// and those of the calls delivered to local capabilities.  Only
+	// its values are used; its deadline and cancelation are ignored.
+	// Use WithPeerInfo to attach the transport's remote address and
+	// TLS state for access control.
+	BaseContext func(Transport) context.Context
 }
 
 // A type that implements ErrorReporter can receive errors from a Conn.
@@ -137,7 +424,16 @@ type ErrorReporter interface {
 // Once a connection is created, it will immediately start receiving
 // requests from the transport.
 func NewConn(t Transport, opts *Options) *Conn {
-	bgctx, bgcancel := context.WithCancel(context.Background())
+	base := context.Background()
+	if opts != nil && opts.BaseContext != nil {
+		if ctx := opts.BaseContext(t); ctx != nil {
+			base = valuesContext{ctx}
+		}
+	}
+	bgctx, bgcancel := context.WithCancel(base)
+	// Canceling the receive loop's Context starts shutdown.
+	recvctx, recvcancel := context.WithCancel(bgctx)
+	var sendTimeouts *timeoutTransport
 	c := &Conn{
 		transport: t,
 		shut:      make(chan struct{}),
@@ -150,13 +446,72 @@ func NewConn(t Transport, opts *Options) *Conn {
 		c.bootstrap = opts.BootstrapClient
 		c.reporter = opts.ErrorReporter
 		c.abortTimeout = opts.AbortTimeout
+		c.maxParamsSize = opts.MaxParamsSize
+		c.maxMessageSize = opts.MaxMessageSize
+		c.syncDispatch = opts.SynchronousDispatch
+		c.gcImports = opts.ReleaseUnreachableImports
+		c.releaseResultCaps = opts.ReleaseResultCapsDecider
+		c.strictReleases = opts.StrictReleases
+		c.bootstrapWrapper = opts.BootstrapWrapper
+		c.bootstrapTimeout = opts.BootstrapTimeout
+		c.noBootstrap = opts.NoBootstrapHandler
+		c.bootstrapRouter = opts.BootstrapRouter
+		c.exceptionMapper = opts.ExceptionMapper
+		c.network = opts.ThirdPartyNetwork
+		c.maxInFlightCalls = opts.MaxInFlightCalls
+		c.embargoTimeout = opts.EmbargoTimeout
+		c.abortOnEmbargoTimeout = opts.AbortOnEmbargoTimeout
+		if opts.SingleSegmentOutbound {
+			st, ok := t.(shapedTransport)
+			if !ok || !st.shapeMessages(messageShape{singleSegment: true}) {
+				panic("rpc: SingleSegmentOutbound is not supported by the transport")
+			}
+		}
+		if lt, ok := t.(limitedTransport); ok && opts.MaxMessageSize > 0 {
+			lt.limitInbound(opts.MaxMessageSize)
+		}
+		if opts.PoolInboundBuffers {
+			if pt, ok := t.(poolingTransport); ok {
+				pt.poolInbound()
+			}
+		}
+		if opts.SendTimeout > 0 {
+			sendTimeouts = &timeoutTransport{
+				Transport: c.transport,
+				timeout:   opts.SendTimeout,
+				onTimeout: recvcancel,
+			}
+			c.transport = sendTimeouts
+		}
+		if opts.CaptureTo != nil {
+			c.transport = &captureTransport{
+				Transport: c.transport,
+				reporter:  c.reporter,
+				w:         opts.CaptureTo,
+			}
+		}
+	}
+	c.sentCounts = new(messageCounts)
+	c.recvCounts = new(messageCounts)
+	c.transport = statsTransport{
+		Transport: c.transport,
+		sent:      c.sentCounts,
+		received:  c.recvCounts,
 	}
+	c.sends = &cancelTransport{Transport: c.transport}
+	c.transport = c.sends
 	if c.abortTimeout == 0 {
 		c.abortTimeout = 100 * time.Millisecond
 	}
 	c.tasks.Add(1)
 	go func() {
-		abortErr := c.receive(c.bgctx)
+		abortErr := c.receive(recvctx)
+		recvcancel()
+		if sendTimeouts != nil {
+			if err := sendTimeouts.timeoutErr(); err != nil {
+				abortErr = err
+			}
+		}
 		c.tasks.Done()
 
 		c.mu.Lock()
@@ -178,71 +533,215 @@ func NewConn(t Transport, opts *Options) *Conn {
 
 // Bootstrap returns the remote vat's bootstrap interface.  This creates
 // a new client that the caller is responsible for releasing.
+//
+// Bootstrap returns immediately: the bootstrap request is sent in the
+// background, and calls made on the client wait until it has been sent.
+// ctx is only used to send the bootstrap request.  If sending fails,
+// for example because ctx is done first, then the client resolves to an
+// error client.  The returned client is tied to the lifetime of the
+// connection, so cancelling ctx after the request has been sent does
+// not affect it, even if the bootstrap has not resolved yet.  Releasing
+// the client before it resolves cancels the request.
 func (c *Conn) Bootstrap(ctx context.Context) *capnp.Client {
+	client, _ := c.startBootstrap(ctx, capnp.Ptr{})
+	return client
+}
+
+// BootstrapObject is like Bootstrap, but asks for the capability
+// identified by objectID, which the remote vat chooses with
+// Options.BootstrapRouter.  objectID is sent in the deprecatedObjectId
+// field of the Bootstrap message.  It is copied before BootstrapObject
+// returns.
+func (c *Conn) BootstrapObject(ctx context.Context, objectID capnp.Ptr) *capnp.Client {
+	objectID, err := clonePtr(objectID)
+	if err != nil {
+		return capnp.ErrorClient(annotate(err).errorf("bootstrap: copy object ID"))
+	}
+	client, _ := c.startBootstrap(ctx, objectID)
+	return client
+}
+
+// startBootstrap is Bootstrap, but it also returns the client's hook,
+// or nil if the connection has shut down.  If objectID is valid, it is
+// sent with the request.
+func (c *Conn) startBootstrap(ctx context.Context, objectID capnp.Ptr) (*capnp.Client, *bootstrapClient) {
+	select {
+	case <-c.bgctx.Done():
+		return capnp.ErrorClient(disconnected("connection closed")), nil
+	default:
+	}
+	bootCtx, cancel := context.WithCancel(context.Background())
+	hook := &bootstrapClient{
+		ready:  make(chan struct{}),
+		cancel: cancel,
+	}
+	bc, cp := capnp.NewPromisedClient(hook)
+	go c.sendBootstrap(ctx, bootCtx, objectID, hook, cp)
+	return bc, hook
+}
+
+// sendBootstrap sends the bootstrap request for a client returned by
+// Bootstrap and waits for the request to be canceled or finished.
+func (c *Conn) sendBootstrap(ctx, bootCtx context.Context, objectID capnp.Ptr, hook *bootstrapClient, cp *capnp.ClientPromise) {
 	c.mu.Lock()
 	if !c.startTask() {
 		c.mu.Unlock()
-		return capnp.ErrorClient(disconnected("connection closed"))
+		failBootstrap(hook, cp, disconnected("connection closed"))
+		return
 	}
 	defer c.tasks.Done()
+	err := bootCtx.Err() // client released before the request was sent
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		c.mu.Unlock()
+		failBootstrap(hook, cp, annotate(err).errorf("bootstrap"))
+		return
+	}
 	q := c.newQuestion(capnp.Method{})
-	bootCtx, cancel := context.WithCancel(ctx)
-	bc, cp := capnp.NewPromisedClient(bootstrapClient{
-		c:      q.p.Answer().Client().AddRef(),
-		cancel: cancel,
-	})
 	q.bootstrapPromise = cp // safe to write because we're still holding c.mu
+	ansClient := q.p.Answer().Client().AddRef()
 
-	err := c.sendMessage(ctx, func(msg rpccp.Message) error {
+	err = c.sendMessage(ctx, func(msg rpccp.Message) error {
 		boot, err := msg.NewBootstrap()
 		if err != nil {
 			return err
 		}
 		boot.SetQuestionId(uint32(q.id))
+		if objectID.IsValid() {
+			return boot.SetDeprecatedObjectId(objectID)
+		}
 		return nil
 	})
 	if err != nil {
-		c.questions[q.id] = nil
-		c.questionID.remove(uint32(q.id))
+		c.clearQuestion(q.id)
+		c.freeQuestionID(q.id)
 		c.mu.Unlock()
-		return capnp.ErrorClient(annotate(err).errorf("bootstrap"))
+		ansClient.Release()
+		failBootstrap(hook, cp, annotate(err).errorf("bootstrap"))
+		return
 	}
-	c.tasks.Add(1)
-	go func() {
-		defer c.tasks.Done()
-		q.handleCancel(bootCtx)
-	}()
 	c.mu.Unlock()
-	return bc
+	hook.ans = q.p.Answer()
+	hook.resolve(ansClient)
+	if c.bootstrapTimeout > 0 {
+		var cancel context.CancelFunc
+		bootCtx, cancel = context.WithTimeout(bootCtx, c.bootstrapTimeout)
+		defer cancel()
+	}
+	q.handleCancel(bootCtx)
 }
 
+// failBootstrap resolves a client returned by Bootstrap to an error
+// client when its bootstrap request could not be sent.
+func failBootstrap(hook *bootstrapClient, cp *capnp.ClientPromise, err error) {
+	hook.err = err
+	ec := capnp.ErrorClient(err)
+	hook.resolve(ec.AddRef())
+	cp.Fulfill(ec)
+	ec.Release()
+}
+
+// bootstrapClient is the hook for a client returned by Bootstrap until
+// the bootstrap returns.  Calls are held until the bootstrap request has
+// been sent, so that they follow it on the wire.
 type bootstrapClient struct {
-	c      *capnp.Client
+	ready  chan struct{} // closed by resolve
 	cancel context.CancelFunc
+
+	// ans is the answer to the bootstrap request, or err is why it
+	// could not be sent.  Both are written before ready is closed.
+	ans *capnp.Answer
+	err error
+
+	mu       sync.Mutex
+	c        *capnp.Client
+	shutdown bool
 }
 
-func (bc bootstrapClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
-	return bc.c.SendCall(ctx, s)
+// resolve sets the client that calls are forwarded to, stealing the
+// reference.  It must be called exactly once.
+func (bc *bootstrapClient) resolve(c *capnp.Client) {
+	bc.mu.Lock()
+	if bc.shutdown {
+		bc.mu.Unlock()
+		c.Release()
+	} else {
+		bc.c = c
+		bc.mu.Unlock()
+	}
+	close(bc.ready)
 }
 
-func (bc bootstrapClient) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
-	return bc.c.RecvCall(ctx, r)
+// client waits for resolve to be called and returns the client that
+// calls are forwarded to.
+func (bc *bootstrapClient) client(ctx context.Context) (*capnp.Client, error) {
+	select {
+	case <-bc.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	if bc.shutdown {
+		return nil, disconnected("bootstrap client released")
+	}
+	return bc.c, nil
 }
 
-func (bc bootstrapClient) Brand() capnp.Brand {
-	return bc.c.State().Brand
+func (bc *bootstrapClient) Send(ctx context.Context, s capnp.Send) (*capnp.Answer, capnp.ReleaseFunc) {
+	c, err := bc.client(ctx)
+	if err != nil {
+		return capnp.ErrorAnswer(s.Method, err), func() {}
+	}
+	return c.SendCall(ctx, s)
 }
 
-func (bc bootstrapClient) Shutdown() {
+func (bc *bootstrapClient) Recv(ctx context.Context, r capnp.Recv) capnp.PipelineCaller {
+	c, err := bc.client(ctx)
+	if err != nil {
+		r.Reject(err)
+		return nil
+	}
+	return c.RecvCall(ctx, r)
+}
+
+func (bc *bootstrapClient) Brand() capnp.Brand {
+	select {
+	case <-bc.ready:
+	default:
+		return capnp.Brand{}
+	}
+	bc.mu.Lock()
+	c := bc.c
+	bc.mu.Unlock()
+	if c == nil {
+		return capnp.Brand{}
+	}
+	return c.State().Brand
+}
+
+func (bc *bootstrapClient) Shutdown() {
 	bc.cancel()
-	bc.c.Release()
+	bc.mu.Lock()
+	bc.shutdown = true
+	c := bc.c
+	bc.c = nil
+	bc.mu.Unlock()
+	c.Release()
 }
 
 // Close sends an abort to the remote vat and closes the underlying
-// transport.
+// transport.  An error from closing the transport is returned.  If the
+// Conn had already shut down on its own, for example because the remote
+// vat aborted, Close returns nil and any error from closing the transport
+// was passed to Options.ErrorReporter instead.  Releasing the bootstrap
+// and exported clients cannot fail, so nothing else is reported.
 func (c *Conn) Close() error {
 	c.mu.Lock()
 	if c.closed {
+		c.mu.Unlock()
 		return fail("close on closed connection")
 	}
 	c.closed = true
@@ -253,16 +752,176 @@ func (c *Conn) Close() error {
 		return nil
 	default:
 		// shutdown unlocks c.mu.
-		return c.shutdown(errors.New(errors.Failed, "", "connection closed"))
+		return c.shutdown(ErrConnClosed)
+	}
+}
+
+// Shutdown gracefully closes the connection.  Incoming bootstrap and
+// call messages are rejected with an exception, and Shutdown waits for
+// outstanding questions and answers, including calls made by this vat,
+// to finish before calling Close.  If ctx is done before then, the
+// connection is closed anyway and ctx.Err() is returned.
+func (c *Conn) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return fail("shutdown on closed connection")
+	}
+	if c.drained == nil {
+		c.drained = make(chan struct{})
+		c.checkDrained()
+	}
+	drained := c.drained
+	c.mu.Unlock()
+
+	var err error
+	select {
+	case <-drained:
+	case <-c.bgctx.Done():
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if cerr := c.Close(); err == nil {
+		err = cerr
 	}
+	return err
+}
+
+// checkDrained closes c.drained if Shutdown has been called and no
+// questions or answers are outstanding.  The caller must be holding
+// onto c.mu.
+func (c *Conn) checkDrained() {
+	if c.drained == nil || len(c.answers) > 0 {
+		return
+	}
+	select {
+	case <-c.drained:
+		return
+	default:
+	}
+	for _, q := range c.questions {
+		if q != nil {
+			return
+		}
+	}
+	close(c.drained)
+}
+
+// clearQuestion removes the question with the given ID from the
+// questions table.  The caller must be holding onto c.mu.
+func (c *Conn) clearQuestion(id questionID) {
+	if q := c.questions[id]; q != nil && q.flags&callSlot != 0 {
+		c.releaseCallSlot()
+	}
+	c.questions[id] = nil
+	c.checkDrained()
+}
+
+// freeQuestionID frees the ID of a question that has been cleared for
+// reuse, shrinking the questions table if it held the largest ID in use.
+// The caller must be holding onto c.mu.
+func (c *Conn) freeQuestionID(id questionID) {
+	c.questionID.remove(uint32(id))
+	n := c.questionID.len()
+	if n >= len(c.questions) {
+		return
+	}
+	c.questions = c.questions[:n]
+	if n <= cap(c.questions)/4 {
+		c.questions = append([]*question(nil), c.questions...)
+	}
+}
+
+// SetBootstrap replaces the capability returned to the remote vat for
+// subsequent Bootstrap messages, like changing Options.BootstrapClient
+// after NewConn.  SetBootstrap "steals" the reference to client and
+// releases the previous bootstrap client.  Capabilities already
+// returned to the remote vat are not affected.  Passing nil makes the
+// Conn reject future bootstrap requests.  If the connection has been
+// shut down, client is released immediately.
+func (c *Conn) SetBootstrap(client *capnp.Client) {
+	c.mu.Lock()
+	select {
+	case <-c.bgctx.Done():
+		c.mu.Unlock()
+		client.Release()
+		return
+	default:
+	}
+	old := c.bootstrap
+	c.bootstrap = client
+	c.mu.Unlock()
+	old.Release()
 }
 
 // Done returns a channel that is closed after the connection is
-// shut down.
+// shut down, for use in a select statement.  Every call returns the
+// same channel, so Done is safe to call any number of times from any
+// goroutine.  Once the channel is closed, CloseReason reports why the
+// connection was shut down.
 func (c *Conn) Done() <-chan struct{} {
 	return c.shut
 }
 
+// RemoteAbort returns the reason given by the remote vat in an abort
+// message, or nil if the remote vat has not sent one.  The reason is
+// kept after the connection is shut down, so it can be used to tell
+// whether the remote vat aborted before a local call to Close.
+func (c *Conn) RemoteAbort() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.remoteAbort
+}
+
+// CloseReason returns the reason that the connection was shut down, or
+// nil if it has not started shutting down.  Only the first cause is
+// kept: if the remote vat aborted, it is the error that RemoteAbort
+// returns; if Close was called, it is an error saying that the
+// connection was closed.  Failures of the transport are reported as a
+// *TransportError, and other causes, like a send that exceeded
+// Options.SendTimeout or a protocol violation by the remote vat, as the
+// error that was sent to the remote vat in an abort message.
+//
+// The error wraps an *AbortError, so errors.As can recover the
+// exception in the abort message and which side sent it.  After Close,
+// errors.Is(err, ErrConnClosed) reports true.
+func (c *Conn) CloseReason() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closeReason
+}
+
+// ErrConnClosed is the cause of shutdown after a call to Conn.Close.
+// It is sent to the remote vat in an abort message.
+var ErrConnClosed = errors.New(errors.Failed, "", "connection closed")
+
+// An AbortError describes the abort message that shut down a Conn.
+type AbortError struct {
+	// Type and Reason are the exception in the abort message.
+	Type   rpccp.Exception_Type
+	Reason string
+
+	// Remote is true if the remote vat sent the abort message and false
+	// if the Conn sent it to the remote vat.
+	Remote bool
+
+	// Err is the local cause of an abort sent by the Conn, like
+	// ErrConnClosed.  It is nil if Remote is true.
+	Err error
+}
+
+func (e *AbortError) Error() string {
+	if e.Remote {
+		return "rpc: remote abort: " + e.Reason
+	}
+	return e.Reason
+}
+
+// Unwrap returns the local cause of the abort.
+func (e *AbortError) Unwrap() error {
+	return e.Err
+}
+
 // shutdown tears down the connection and transport, optionally sending
 // an abort message before closing.  The caller must be holding onto
 // c.mu, although it will be released while shutting down, and c.bgctx
@@ -270,8 +929,30 @@ func (c *Conn) Done() <-chan struct{} {
 func (c *Conn) shutdown(abortErr error) error {
 	defer close(c.shut)
 
-	// Cancel all work.
+	var (
+		reason string
+		typ    rpccp.Exception_Type
+	)
+	if abortErr != nil {
+		reason, typ = c.exception(abortErr)
+		c.closeReason = errors.Wrap(errors.Type(typ), "", reason, &AbortError{
+			Type:   typ,
+			Reason: reason,
+			Err:    abortErr,
+		})
+	}
+	if c.closeReason == nil {
+		c.closeReason = c.remoteAbort
+	}
+	if c.closeReason == nil {
+		c.closeReason = disconnected("remote vat aborted")
+	}
+
+	// Cancel all work, including sends that are blocked on the remote
+	// vat, since the tasks waiting on them would otherwise hold up
+	// shutdown indefinitely.
 	c.bgcancel()
+	c.sends.cancelAll()
 	for _, a := range c.answers {
 		if a != nil && a.cancel != nil {
 			a.cancel()
@@ -292,12 +973,16 @@ func (c *Conn) shutdown(abortErr error) error {
 	c.questions = nil
 	c.answers = nil
 	c.embargoes = nil
+	boot := c.bootstrap
+	c.bootstrap = nil
 	c.mu.Unlock()
 
-	c.bootstrap.Release()
-	c.bootstrap = nil
+	boot.Release()
 	for _, e := range exports {
 		if e != nil {
+			for _, cancel := range e.provides {
+				cancel()
+			}
 			e.client.Release()
 		}
 	}
@@ -318,7 +1003,8 @@ func (c *Conn) shutdown(abortErr error) error {
 	// Send abort message (ignoring error).
 	if abortErr != nil {
 		abortCtx, cancel := context.WithTimeout(context.Background(), c.abortTimeout)
-		msg, send, release, err := c.transport.NewMessage(abortCtx)
+		// Outgoing messages on c.sends are canceled by now, so bypass it.
+		msg, send, release, err := c.sends.Transport.NewMessage(abortCtx)
 		if err != nil {
 			cancel()
 			goto closeTransport
@@ -329,25 +1015,31 @@ func (c *Conn) shutdown(abortErr error) error {
 			cancel()
 			goto closeTransport
 		}
-		abort.SetType(rpccp.Exception_Type(errors.TypeOf(abortErr)))
-		if err := abort.SetReason(abortErr.Error()); err != nil {
+		abort.SetType(typ)
+		if err := abort.SetReason(reason); err != nil {
 			release()
 			cancel()
 			goto closeTransport
 		}
+		// A failed send is expected if the remote vat is closing the
+		// connection at the same time, so the error is ignored.
 		send()
 		release()
 		cancel()
 	}
 closeTransport:
-	if err := c.transport.Close(); err != nil {
+	if err := c.transport.Close(); err != nil && !isClosedError(err) {
 		return errorf("close transport: %v", err)
 	}
 	return nil
 }
 
 // receive receives and dispatches messages coming from c.transport.  receive
-// runs in a background goroutine.
+// runs in a background goroutine.  Messages are handled one at a time
+// in the order they were received, so any message a handler sends
+// synchronously (like a bootstrap return) is sent before the next
+// message is read.  Incoming calls are delivered in order, but return
+// asynchronously unless Options.SynchronousDispatch is set.
 //
 // After receive returns, the connection is shut down.  If receive
 // returns a non-nil error, it is sent to the remove vat as an abort.
@@ -355,7 +1047,22 @@ func (c *Conn) receive(ctx context.Context) error {
 	for {
 		recv, releaseRecv, err := c.transport.RecvMessage(ctx)
 		if err != nil {
-			return err
+			if c.maxMessageSize > 0 && goerrors.Is(err, capnp.ErrMessageTooLarge) {
+				return errorf("receive: message exceeds configured maximum size (%d bytes)", c.maxMessageSize)
+			}
+			return &TransportError{Op: "receive", Err: err}
+		}
+		// Transports that can't limit the size of the messages they read
+		// are checked here.
+		if c.maxMessageSize > 0 {
+			sz, err := messageSize(recv.Message())
+			if err == nil && sz > c.maxMessageSize {
+				err = errorf("message exceeds configured maximum size (%d > %d bytes)", sz, c.maxMessageSize)
+			}
+			if err != nil {
+				releaseRecv()
+				return annotate(err).errorf("receive")
+			}
 		}
 		switch recv.Which() {
 		case rpccp.Message_Which_unimplemented:
@@ -375,7 +1082,15 @@ func (c *Conn) receive(ctx context.Context) error {
 			}
 			ty := exc.Type()
 			releaseRecv()
-			c.report(errors.New(errors.Type(ty), "rpc", "remote abort: "+reason))
+			err = errors.Wrap(errors.Type(ty), "rpc", "remote abort: "+reason, &AbortError{
+				Type:   ty,
+				Reason: reason,
+				Remote: true,
+			})
+			c.mu.Lock()
+			c.remoteAbort = err
+			c.mu.Unlock()
+			c.report(err)
 			return nil
 		case rpccp.Message_Which_bootstrap:
 			bootstrap, err := recv.Bootstrap()
@@ -385,8 +1100,18 @@ func (c *Conn) receive(ctx context.Context) error {
 				continue
 			}
 			qid := answerID(bootstrap.QuestionId())
+			var objectID capnp.Ptr
+			if c.bootstrapRouter != nil && bootstrap.HasDeprecatedObjectId() {
+				objectID, err = bootstrap.DeprecatedObjectId()
+				if err != nil {
+					releaseRecv()
+					c.reportf("read bootstrap: %v", err)
+					continue
+				}
+			}
+			err = c.handleBootstrap(ctx, qid, objectID)
 			releaseRecv()
-			if err := c.handleBootstrap(ctx, qid); err != nil {
+			if err != nil {
 				return err
 			}
 		case rpccp.Message_Which_call:
@@ -435,6 +1160,18 @@ func (c *Conn) receive(ctx context.Context) error {
 			if err := c.handleRelease(ctx, id, count); err != nil {
 				return err
 			}
+		case rpccp.Message_Which_resolve:
+			res, err := recv.Resolve()
+			if err != nil {
+				releaseRecv()
+				c.reportf("read resolve: %v", err)
+				continue
+			}
+			err = c.handleResolve(ctx, res)
+			releaseRecv()
+			if err != nil {
+				return err
+			}
 		case rpccp.Message_Which_disembargo:
 			d, err := recv.Disembargo()
 			if err != nil {
@@ -447,6 +1184,46 @@ func (c *Conn) receive(ctx context.Context) error {
 			if err != nil {
 				return err
 			}
+		case rpccp.Message_Which_provide:
+			if c.network == nil {
+				err := c.handleUnknownMessage(ctx, recv)
+				releaseRecv()
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			p, err := recv.Provide()
+			if err != nil {
+				releaseRecv()
+				c.reportf("read provide: %v", err)
+				continue
+			}
+			err = c.handleProvide(ctx, p)
+			releaseRecv()
+			if err != nil {
+				return err
+			}
+		case rpccp.Message_Which_accept:
+			if c.network == nil {
+				err := c.handleUnknownMessage(ctx, recv)
+				releaseRecv()
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			a, err := recv.Accept()
+			if err != nil {
+				releaseRecv()
+				c.reportf("read accept: %v", err)
+				continue
+			}
+			err = c.handleAccept(ctx, a)
+			releaseRecv()
+			if err != nil {
+				return err
+			}
 		default:
 			err := c.handleUnknownMessage(ctx, recv)
 			releaseRecv()
@@ -457,7 +1234,7 @@ func (c *Conn) receive(ctx context.Context) error {
 	}
 }
 
-func (c *Conn) handleBootstrap(ctx context.Context, id answerID) error {
+func (c *Conn) handleBootstrap(ctx context.Context, id answerID, objectID capnp.Ptr) error {
 	c.mu.Lock()
 	if c.answers[id] != nil {
 		c.mu.Unlock()
@@ -481,7 +1258,30 @@ func (c *Conn) handleBootstrap(ctx context.Context, id answerID) error {
 	}
 	ret.SetAnswerId(uint32(id))
 	ret.SetReleaseParamCaps(false)
-	bootState := c.bootstrap.State()
+	var boot *capnp.Client
+	var noBootErr error
+	if objectID.IsValid() {
+		boot, noBootErr = c.bootstrapRouter(ctx, objectID)
+	} else {
+		c.mu.Lock()
+		boot = c.bootstrap.AddRef()
+		c.mu.Unlock()
+	}
+	if noBootErr != nil {
+		boot.Release()
+		boot = nil
+	}
+	if c.bootstrapWrapper != nil && boot.IsValid() {
+		boot = c.bootstrapWrapper(boot)
+	}
+	if !boot.IsValid() && !objectID.IsValid() && c.noBootstrap != nil {
+		boot, noBootErr = c.noBootstrap(ctx)
+		if noBootErr != nil {
+			boot.Release()
+			boot = nil
+		}
+	}
+	bootState := boot.State()
 	c.mu.Lock()
 	ans := &answer{
 		c:          c,
@@ -491,28 +1291,40 @@ func (c *Conn) handleBootstrap(ctx context.Context, id answerID) error {
 		releaseMsg: release,
 	}
 	c.answers[id] = ans
-	if !c.bootstrap.IsValid() {
-		rl := ans.sendException(errors.New(errors.Failed, "", "vat does not expose a public/bootstrap interface"))
+	if c.drained != nil {
+		rl := ans.sendException(disconnected("incoming bootstrap: connection shutting down"))
+		c.unlockSender()
+		c.mu.Unlock()
+		rl.release()
+		boot.Release()
+		return nil
+	}
+	if !boot.IsValid() {
+		if noBootErr == nil {
+			noBootErr = errors.New(errors.Failed, "", "vat does not expose a public/bootstrap interface")
+		}
+		rl := ans.sendException(noBootErr)
 		c.unlockSender()
 		c.mu.Unlock()
 		rl.release()
 		return nil
 	}
-	if err := ans.setBootstrap(c.bootstrap.AddRef()); err != nil {
+	if err := ans.setBootstrap(boot); err != nil {
 		rl := ans.sendException(err)
 		c.unlockSender()
 		c.mu.Unlock()
 		rl.release()
 		return nil
 	}
-	rl, err := ans.sendReturn([]capnp.ClientState{bootState})
+	rl, err := ans.sendReturn([]capnp.ClientState{bootState}, nil)
 	c.unlockSender()
 	c.mu.Unlock()
 	rl.release()
 	if err != nil {
 		// Answer cannot possibly encounter a Finish, since we still
-		// haven't returned to receive().
-		panic(err)
+		// haven't returned to receive(), but if releasing exports
+		// fails anyway, abort the connection rather than crash.
+		return annotate(err).errorf("incoming bootstrap")
 	}
 	return nil
 }
@@ -583,8 +1395,34 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 		releaseMsg: releaseRet,
 	}
 	c.answers[id] = ans
+	if c.drained != nil {
+		// Remote vat should not be sending new calls.  Don't report.
+		rl := ans.sendException(disconnected("incoming call: connection shutting down"))
+		c.unlockSender()
+		c.mu.Unlock()
+		rl.release()
+		clearCapTable(call.Message())
+		releaseCall()
+		return nil
+	}
+	if parseErr == nil && c.maxParamsSize > 0 {
+		sz, err := paramsSize(p.args)
+		if err == nil && sz > c.maxParamsSize {
+			err = errorf("params size %d exceeds limit of %d bytes", sz, c.maxParamsSize)
+		}
+		if err != nil {
+			// The remote vat's fault.  Don't report.
+			rl := ans.sendException(annotate(err).errorf("incoming call"))
+			c.unlockSender()
+			c.mu.Unlock()
+			rl.release()
+			clearCapTable(call.Message())
+			releaseCall()
+			return nil
+		}
+	}
 	if parseErr != nil {
-		parseErr = annotate(err).errorf("incoming call")
+		parseErr = annotate(parseErr).errorf("incoming call")
 		rl := ans.sendException(parseErr)
 		c.unlockSender()
 		c.mu.Unlock()
@@ -622,6 +1460,9 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 		c.tasks.Add(1) // will be finished by answer.Return
 		var callCtx context.Context
 		callCtx, ans.cancel = context.WithCancel(c.bgctx)
+		if c.syncDispatch {
+			ans.returned = make(chan struct{})
+		}
 		c.unlockSender()
 		c.mu.Unlock()
 		pcall := ent.client.RecvCall(callCtx, capnp.Recv{
@@ -634,6 +1475,7 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 		// the only one that uses answer.pcall, it's fine that there's a
 		// time gap for this being set.
 		ans.setPipelineCaller(pcall)
+		c.waitReturned(ans)
 		return nil
 	case rpccp.MessageTarget_Which_promisedAnswer:
 		tgtAns := c.answers[p.target.promisedAnswer]
@@ -701,6 +1543,9 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 			c.tasks.Add(1) // will be finished by answer.Return
 			var callCtx context.Context
 			callCtx, ans.cancel = context.WithCancel(c.bgctx)
+			if c.syncDispatch {
+				ans.returned = make(chan struct{})
+			}
 			c.unlockSender()
 			c.mu.Unlock()
 			pcall := tgt.RecvCall(callCtx, capnp.Recv{
@@ -710,6 +1555,7 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 				Returner:    ans,
 			})
 			ans.setPipelineCaller(pcall)
+			c.waitReturned(ans)
 		} else {
 			// Results not ready, use pipeline caller.
 			tgtAns.pcalls.Add(1) // will be finished by answer.Return
@@ -717,6 +1563,9 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 			callCtx, ans.cancel = context.WithCancel(c.bgctx)
 			tgt := tgtAns.pcall
 			c.tasks.Add(1) // will be finished by answer.Return
+			if c.syncDispatch {
+				ans.returned = make(chan struct{})
+			}
 			c.mu.Unlock()
 			pcall := tgt.PipelineRecv(callCtx, p.target.transform, capnp.Recv{
 				Args:        p.args,
@@ -726,6 +1575,7 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 			})
 			tgtAns.pcalls.Done()
 			ans.setPipelineCaller(pcall)
+			c.waitReturned(ans)
 		}
 		return nil
 	default:
@@ -733,6 +1583,19 @@ func (c *Conn) handleCall(ctx context.Context, call rpccp.Call, releaseCall capn
 	}
 }
 
+// waitReturned waits for ans to return if c was created with
+// Options.SynchronousDispatch, or until c starts shutting down.  The
+// caller must not be holding onto c.mu.
+func (c *Conn) waitReturned(ans *answer) {
+	if ans.returned == nil {
+		return
+	}
+	select {
+	case <-ans.returned:
+	case <-c.bgctx.Done():
+	}
+}
+
 type parsedCall struct {
 	target parsedMessageTarget
 	method capnp.Method
@@ -746,6 +1609,119 @@ type parsedMessageTarget struct {
 	transform      []capnp.PipelineOp
 }
 
+// messageSize returns the number of bytes in msg's segments.
+func messageSize(msg *capnp.Message) (uint64, error) {
+	var sz uint64
+	n := msg.NumSegments()
+	for i := int64(0); i < n; i++ {
+		seg, err := msg.Segment(capnp.SegmentID(i))
+		if err != nil {
+			return 0, err
+		}
+		sz += uint64(seg.Len())
+	}
+	return sz, nil
+}
+
+// EstimateWireSize returns the number of bytes that an outbound
+// message is expected to occupy on the wire once the Conn fills in its
+// payload's capability table from msg.CapTable, as it does right
+// before sending a call or return.  It is msg.TotalSize plus a
+// CapDescriptor list with one element per capability.  Descriptors for
+// promised answers or third-party capabilities need a few more words,
+// and the list may start a new segment, so the estimate is a lower
+// bound.  msg's capability table must not have been filled in yet.
+func EstimateWireSize(msg *capnp.Message) (uint64, error) {
+	sz, err := msg.TotalSize()
+	if err != nil {
+		return 0, err
+	}
+	if n := uint64(len(msg.CapTable)); n > 0 {
+		// A composite list tag followed by descriptors of one data word
+		// and one pointer each.
+		sz += 8 + n*16
+	}
+	return sz, nil
+}
+
+// paramsSize returns the number of bytes occupied by args and the
+// objects reachable from it, not counting list tags.  The traversal
+// does not count toward the message's read limit.
+func paramsSize(args capnp.Struct) (uint64, error) {
+	sz, charged, err := objectSize(args.ToPtr())
+	if m := args.Message(); m != nil {
+		m.Unread(charged)
+	}
+	return sz, err
+}
+
+// objectSize returns the size of the object tree rooted at p along with
+// the amount of read limit consumed while traversing it.  It only uses
+// the exported capnp API, so it derives list element sizes from
+// List.Struct, which is zero-sized for lists of bits.
+func objectSize(p capnp.Ptr) (sz uint64, charged capnp.Size, err error) {
+	var ptrs []capnp.Ptr
+	if s := p.Struct(); s.IsValid() {
+		ssz := s.Size()
+		sz = uint64(ssz.DataSize) + 8*uint64(ssz.PointerCount)
+		for i := uint16(0); i < ssz.PointerCount; i++ {
+			q, err := s.Ptr(i)
+			if err != nil {
+				return sz, charged, err
+			}
+			ptrs = append(ptrs, q)
+		}
+	} else if l := p.List(); l.IsValid() && l.Len() > 0 {
+		n := uint64(l.Len())
+		esz := l.Struct(0).Size()
+		e := uint64(esz.DataSize) + 8*uint64(esz.PointerCount)
+		if e == 0 && !l.Struct(0).IsValid() {
+			// List of bits.
+			sz = (n + 7) / 8
+		} else {
+			sz = n * e
+		}
+		for i := 0; i < l.Len(); i++ {
+			s := l.Struct(i)
+			for j := uint16(0); j < esz.PointerCount; j++ {
+				q, err := s.Ptr(j)
+				if err != nil {
+					return sz, charged, err
+				}
+				ptrs = append(ptrs, q)
+			}
+		}
+	}
+	for _, q := range ptrs {
+		charged += readSize(q)
+		n, c, err := objectSize(q)
+		sz += n
+		charged += c
+		if err != nil {
+			return sz, charged, err
+		}
+	}
+	return sz, charged, nil
+}
+
+// readSize returns the amount of read limit that reading p consumed.
+func readSize(p capnp.Ptr) capnp.Size {
+	if s := p.Struct(); s.IsValid() {
+		ssz := s.Size()
+		return ssz.DataSize + 8*capnp.Size(ssz.PointerCount)
+	}
+	l := p.List()
+	if !l.IsValid() || l.Len() == 0 {
+		return 0
+	}
+	esz := l.Struct(0).Size()
+	e := esz.DataSize + 8*capnp.Size(esz.PointerCount)
+	if e == 0 {
+		e = 8
+	}
+	return e * capnp.Size(l.Len())
+}
+
 func (c *Conn) parseCall(p *parsedCall, call rpccp.Call) error {
 	p.method = capnp.Method{
 		InterfaceID: call.InterfaceId(),
@@ -823,7 +1799,7 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 	// will always remove the question from the table, because it's the
 	// only time the remote vat will use it.
 	q := c.questions[qid]
-	c.questions[qid] = nil
+	c.clearQuestion(qid)
 	if q == nil {
 		c.mu.Unlock()
 		releaseRet()
@@ -838,7 +1814,7 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 		select {
 		case <-q.finishMsgSend:
 			if q.flags&finishSent != 0 {
-				c.questionID.remove(uint32(qid))
+				c.freeQuestionID(qid)
 			}
 			c.mu.Unlock()
 			releaseRet()
@@ -848,16 +1824,28 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 			<-q.finishMsgSend
 			c.mu.Lock()
 			if q.flags&finishSent != 0 {
-				c.questionID.remove(uint32(qid))
+				c.freeQuestionID(qid)
 			}
 			c.mu.Unlock()
 		}
 		return nil
 	}
-	pr := c.parseReturn(ret, q.called) // fills in CapTable
+	// Decide before ret is released below.  Capabilities in the results
+	// of a call made with WithReleaseResultCaps are not imported, unless
+	// pipelined calls were made on them.
+	dropCaps := q.flags&dropResultCaps != 0 && len(q.called) == 0
+	releaseResultCaps := dropCaps || c.releaseResultCaps != nil && c.releaseResultCaps(ret)
+	pr := c.parseReturn(ret, q.called, !dropCaps) // fills in CapTable
 	if pr.parseFailed {
 		c.report(annotate(pr.err).errorf("incoming return"))
 	}
+	if pr.err != nil {
+		// Include the question ID so that errors can be matched with
+		// messages on the wire.
+		pr.err = annotate(pr.err).errorf("question %d", qid)
+	}
+	q.flags |= returned
+	q.result, q.resultErr = pr.result, pr.err
 	switch {
 	case q.bootstrapPromise != nil && pr.err == nil:
 		q.release = func() {}
@@ -865,6 +1853,9 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 		q.p.Fulfill(pr.result)
 		q.bootstrapPromise.Fulfill(q.p.Answer().Client())
 		q.p.ReleaseClients()
+		c.mu.Lock()
+		q.result = capnp.Ptr{} // points into ret, which is released now
+		c.mu.Unlock()
 		clearCapTable(pr.result.Message())
 		releaseRet()
 		c.mu.Lock()
@@ -889,6 +1880,9 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 	default:
 		m := ret.Message()
 		q.release = func() {
+			c.mu.Lock()
+			q.result = capnp.Ptr{} // points into ret
+			c.mu.Unlock()
 			clearCapTable(m)
 			releaseRet()
 		}
@@ -905,24 +1899,28 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 	c.mu.Unlock()
 
 	// Send disembargoes.  Failing to send one of these just never lifts
-	// the embargo on our side, but doesn't cause a leak.
+	// the embargo on our side.  Its ID is freed, since the remote vat
+	// will never loop it back.
 	//
 	// TODO(soon): make embargo resolve to error client.
 	for i := range pr.disembargoes {
 		msg, send, release, err := c.transport.NewMessage(ctx)
 		if err != nil {
 			c.report(errorf("incoming return: send disembargo: create message: %v", err))
+			c.dropEmbargo(pr.disembargoes[i].id)
 			continue
 		}
 		if err := pr.disembargoes[i].buildDisembargo(msg); err != nil {
 			release()
 			c.report(annotate(err).errorf("incoming return"))
+			c.dropEmbargo(pr.disembargoes[i].id)
 			continue
 		}
 		err = send()
 		release()
 		if err != nil {
 			c.report(errorf("incoming return: send disembargo: %v", err))
+			c.dropEmbargo(pr.disembargoes[i].id)
 			continue
 		}
 	}
@@ -949,7 +1947,7 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 			return nil
 		}
 		fin.SetQuestionId(uint32(qid))
-		fin.SetReleaseResultCaps(false)
+		fin.SetReleaseResultCaps(releaseResultCaps)
 		err = send()
 		release()
 		if err != nil {
@@ -965,19 +1963,28 @@ func (c *Conn) handleReturn(ctx context.Context, ret rpccp.Return, releaseRet ca
 	c.mu.Lock()
 	c.unlockSender()
 	q.flags |= finishSent
-	c.questionID.remove(uint32(qid))
+	c.freeQuestionID(qid)
 	close(q.finishMsgSend)
 	c.mu.Unlock()
 	return nil
 }
 
-func (c *Conn) parseReturn(ret rpccp.Return, called [][]capnp.PipelineOp) parsedReturn {
+func (c *Conn) parseReturn(ret rpccp.Return, called [][]capnp.PipelineOp, importCaps bool) parsedReturn {
 	switch ret.Which() {
 	case rpccp.Return_Which_results:
 		r, err := ret.Results()
 		if err != nil {
 			return parsedReturn{err: errorf("parse return: %v", err), parseFailed: true}
 		}
+		if !importCaps {
+			// The capabilities are left out of the cap table, so they
+			// read as null.
+			content, err := r.Content()
+			if err != nil {
+				return parsedReturn{err: errorf("parse return: %v", err), parseFailed: true}
+			}
+			return parsedReturn{result: content}
+		}
 		content, locals, err := c.recvPayload(r)
 		if err != nil {
 			return parsedReturn{err: errorf("parse return: %v", err), parseFailed: true}
@@ -1018,7 +2025,12 @@ func (c *Conn) parseReturn(ret rpccp.Return, called [][]capnp.PipelineOp) parsed
 		if err != nil {
 			return parsedReturn{err: errorf("parse return: %v", err), parseFailed: true}
 		}
-		return parsedReturn{err: errors.New(errors.Type(exc.Type()), "", reason)}
+		return parsedReturn{err: newException(exc.Type(), reason)}
+	case rpccp.Return_Which_resultsSentElsewhere:
+		// Conn always asks for results to be sent to the caller, so the
+		// remote vat should never send this.  Reject the question rather
+		// than leaving it without an answer.
+		return parsedReturn{err: errorf("parse return: results sent elsewhere, but call did not use sendResultsTo"), parseFailed: true}
 	default:
 		w := ret.Which()
 		return parsedReturn{err: errorf("parse return: unhandled type %v", w), parseFailed: true, unimplemented: true}
@@ -1033,6 +2045,36 @@ type parsedReturn struct {
 	unimplemented bool
 }
 
+// An Exception is an error reported by the remote vat in a Return or
+// Resolve message.  Errors from calls to the remote vat wrap an
+// *Exception, so callers can use errors.As to inspect it, for example
+// to retry calls that failed with an overloaded exception.
+type Exception struct {
+	typ    rpccp.Exception_Type
+	reason string
+}
+
+// newException returns an error for an exception received from the
+// remote vat.  The error has the exception's type and unwraps to an
+// *Exception.
+func newException(typ rpccp.Exception_Type, reason string) error {
+	return errors.Wrap(errors.Type(typ), "", reason, &Exception{typ: typ, reason: reason})
+}
+
+// Type returns the exception's type.
+func (e *Exception) Type() rpccp.Exception_Type {
+	return e.typ
+}
+
+// Reason returns the human-readable reason sent by the remote vat.
+func (e *Exception) Reason() string {
+	return e.reason
+}
+
+func (e *Exception) Error() string {
+	return e.reason
+}
+
 func (c *Conn) handleFinish(ctx context.Context, id answerID, releaseResultCaps bool) error {
 	c.mu.Lock()
 	ans := c.answers[id]
@@ -1084,20 +2126,13 @@ func (c *Conn) recvCap(d rpccp.CapDescriptor) (_ *capnp.Client, local bool, _ er
 		return nil, false, nil
 	case rpccp.CapDescriptor_Which_senderHosted:
 		id := importID(d.SenderHosted())
-		return c.addImport(id), false, nil
+		return c.addImport(id, false), false, nil
 	case rpccp.CapDescriptor_Which_senderPromise:
-		// We do the same thing as senderHosted, above. @kentonv suggested this on
-		// issue #2; this lets messages be delivered properly, although it's a bit
-		// of a hack, and as Kenton describes, it has some disadvantages:
-		//
-		// > * Apps sometimes want to wait for promise resolution, and to find out if
-		// >   it resolved to an exception. You won't be able to provide that API. But,
-		// >   usually, it isn't needed.
-		// > * If the promise resolves to a capability hosted on the receiver,
-		// >   messages sent to it will uselessly round-trip over the network
-		// >   rather than being delivered locally.
+		// Calls are sent to the promise until the remote vat resolves it,
+		// after which they go directly to the resolution.  Callers can
+		// wait for this with capnp.Client.Resolve.  See handleResolve.
 		id := importID(d.SenderPromise())
-		return c.addImport(id), false, nil
+		return c.addImport(id, true), false, nil
 	case rpccp.CapDescriptor_Which_receiverHosted:
 		id := exportID(d.ReceiverHosted())
 		ent := c.findExport(id)
@@ -1105,6 +2140,22 @@ func (c *Conn) recvCap(d rpccp.CapDescriptor) (_ *capnp.Client, local bool, _ er
 			return nil, false, errorf("receive capability: invalid export %d", id)
 		}
 		return ent.client.AddRef(), true, nil
+	case rpccp.CapDescriptor_Which_thirdPartyHosted:
+		// A vat that doesn't take part in handoffs uses the vine, which
+		// is hosted by the sender.
+		tp, err := d.ThirdPartyHosted()
+		if err != nil {
+			return nil, false, errorf("receive capability: %v", err)
+		}
+		vine := c.addImport(importID(tp.VineId()), false)
+		if c.network == nil {
+			return vine, false, nil
+		}
+		capID, err := tp.Id()
+		if err != nil {
+			return vine, false, nil
+		}
+		return c.recvThirdPartyCap(capID, vine), false, nil
 	default:
 		return capnp.ErrorClient(errorf("unknown CapDescriptor type %v", d.Which())), false, nil
 	}
@@ -1164,6 +2215,98 @@ func (c *Conn) handleRelease(ctx context.Context, id exportID, count uint32) err
 	return nil
 }
 
+// handleResolve redirects an imported promise to the capability or
+// exception that the remote vat resolved it to.  If the promise resolved
+// to a capability hosted by this vat, new calls are embargoed until the
+// calls already sent to the promise have been reflected back.
+func (c *Conn) handleResolve(ctx context.Context, res rpccp.Resolve) error {
+	id := importID(res.PromiseId())
+	c.mu.Lock()
+	var client *capnp.Client
+	local := false
+	switch res.Which() {
+	case rpccp.Resolve_Which_cap:
+		desc, err := res.Cap()
+		if err != nil {
+			c.mu.Unlock()
+			return errorf("incoming resolve: read cap: %v", err)
+		}
+		client, local, err = c.recvCap(desc)
+		if err != nil {
+			c.mu.Unlock()
+			return annotate(err).errorf("incoming resolve")
+		}
+	case rpccp.Resolve_Which_exception:
+		exc, err := res.Exception()
+		if err != nil {
+			c.mu.Unlock()
+			return errorf("incoming resolve: read exception: %v", err)
+		}
+		reason, err := exc.Reason()
+		if err != nil {
+			c.mu.Unlock()
+			return errorf("incoming resolve: read exception: %v", err)
+		}
+		client = capnp.ErrorClient(newException(exc.Type(), reason))
+	default:
+		c.mu.Unlock()
+		return errorf("incoming resolve: unknown type %v", res.Which())
+	}
+	ent := c.imports[id]
+	if ent == nil {
+		// The promise was released before the remote vat resolved it.
+		c.mu.Unlock()
+		client.Release()
+		return nil
+	}
+	if ent.promise == nil {
+		c.mu.Unlock()
+		client.Release()
+		return errorf("incoming resolve: import %d is not an unresolved promise", id)
+	}
+	promise := ent.promise
+	ent.promise = nil
+	if !local {
+		c.mu.Unlock()
+		promise.Fulfill(client)
+		client.Release()
+		return nil
+	}
+
+	eid, embargoed := c.embargo(client)
+	err := c.sendMessage(ctx, func(msg rpccp.Message) error {
+		d, err := msg.NewDisembargo()
+		if err != nil {
+			return err
+		}
+		tgt, err := d.NewTarget()
+		if err != nil {
+			return err
+		}
+		tgt.SetImportedCap(uint32(id))
+		d.Context().SetSenderLoopback(uint32(eid))
+		return nil
+	})
+	var e *embargo
+	if err != nil {
+		// The remote vat will never loop the embargo back, so lift it
+		// now rather than leaving calls blocked.
+		if e = c.findEmbargo(eid); e != nil {
+			c.clearEmbargo(eid)
+		}
+	}
+	c.mu.Unlock()
+	if err != nil {
+		c.report(annotate(err).errorf("incoming resolve: send disembargo"))
+	}
+	if e != nil {
+		e.lift()
+	}
+	promise.Fulfill(embargoed)
+	embargoed.Release()
+	return nil
+}
+
 func (c *Conn) handleDisembargo(ctx context.Context, d rpccp.Disembargo) error {
 	dtarget, err := d.Target()
 	if err != nil {
@@ -1184,8 +2327,7 @@ func (c *Conn) handleDisembargo(ctx context.Context, d rpccp.Disembargo) error {
 			return errorf("incoming disembargo: received sender loopback for unknown ID %d", id)
 		}
 		// TODO(soon): verify target matches the right import.
-		c.embargoes[id] = nil
-		c.embargoID.remove(uint32(id))
+		c.clearEmbargo(id)
 		c.mu.Unlock()
 		e.lift()
 	case rpccp.Disembargo_context_Which_senderLoopback:
@@ -1262,6 +2404,8 @@ func (c *Conn) handleDisembargo(ctx context.Context, d rpccp.Disembargo) error {
 			if err != nil {
 				return err
 			}
+			// SetDisembargo deep-copies d, so the echoed target
+			// (including its transform) matches the one received.
 			if err := mm.SetDisembargo(d); err != nil {
 				return err
 			}
@@ -1329,60 +2473,209 @@ func (c *Conn) sendMessage(ctx context.Context, f func(msg rpccp.Message) error)
 	c.mu.Lock()
 	c.unlockSender()
 	if err != nil {
-		return errorf("send message: %v", err)
+		return &TransportError{Op: "send", Err: err}
 	}
 	return nil
 }
 
 // tryLockSender attempts to acquire the sender lock, returning an error
 // if either the Context is Done or c starts shutdown before the lock
-// can be acquired.  The caller must be holding c.mu.
+// can be acquired.  If other goroutines are waiting on the lock, the
+// priority from ctx (see WithPriority) determines the order in which
+// they acquire it.  The caller must be holding c.mu.
 func (c *Conn) tryLockSender(ctx context.Context) error {
-	for {
+	select {
+	case <-c.bgctx.Done():
+		return disconnected("connection closed")
+	default:
+	}
+	if !c.sending {
+		c.sending = true
+		return nil
+	}
+	w := c.waitSender(priorityFromContext(ctx))
+	c.mu.Unlock()
+	var err error
+	select {
+	case <-w.ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-c.bgctx.Done():
+		err = disconnected("connection closed")
+	}
+	c.mu.Lock()
+	if err == nil {
 		select {
 		case <-c.bgctx.Done():
-			return disconnected("connection closed")
+			err = disconnected("connection closed")
 		default:
+			return nil
 		}
-		s := c.sendCond
-		if s == nil {
-			break
-		}
-		c.mu.Unlock()
-		select {
-		case <-s:
-		case <-ctx.Done():
-			c.mu.Lock()
-			return ctx.Err()
-		case <-c.bgctx.Done():
-			c.mu.Lock()
-			return disconnected("connection closed")
-		}
-		c.mu.Lock()
 	}
-	c.sendCond = make(chan struct{})
-	return nil
+	if !c.removeSenderWaiter(w) {
+		// The lock was handed to w before it noticed the error.
+		c.unlockSender()
+	}
+	return err
 }
 
 // lockSender acquires the sender lock, ignoring shutdown or any
 // cancelation signal.  The caller must be holding c.mu.
 func (c *Conn) lockSender() {
-	for {
-		s := c.sendCond
-		if s == nil {
-			break
-		}
-		c.mu.Unlock()
-		<-s
-		c.mu.Lock()
+	c.lockSenderPriority(0)
+}
+
+// lockSenderPriority is like lockSender, but waits with the given
+// priority if the lock is held.  The caller must be holding c.mu.
+func (c *Conn) lockSenderPriority(pri int) {
+	if !c.sending {
+		c.sending = true
+		return
 	}
-	c.sendCond = make(chan struct{})
+	w := c.waitSender(pri)
+	c.mu.Unlock()
+	<-w.ready
+	c.mu.Lock()
 }
 
-// unlockSender releases the sender lock.  The caller must be holding c.mu.
+// unlockSender releases the sender lock, handing it to the waiter with
+// the highest priority, if any.  The caller must be holding c.mu.
 func (c *Conn) unlockSender() {
-	close(c.sendCond)
-	c.sendCond = nil
+	if len(c.sendWaiters) == 0 {
+		c.sending = false
+		return
+	}
+	w := c.sendWaiters[0]
+	n := copy(c.sendWaiters, c.sendWaiters[1:])
+	c.sendWaiters[n] = nil
+	c.sendWaiters = c.sendWaiters[:n]
+	close(w.ready)
+}
+
+// acquireCallSlot takes a slot for a new call, waiting until fewer than
+// Options.MaxInFlightCalls calls are outstanding.  Waiters acquire slots
+// in the order they started waiting.  It returns an error if either
+// the Context is Done or c starts shutdown first.  The caller must be
+// holding c.mu.
+func (c *Conn) acquireCallSlot(ctx context.Context) error {
+	if c.maxInFlightCalls <= 0 || (c.inFlightCalls < c.maxInFlightCalls && len(c.callWaiters) == 0) {
+		c.inFlightCalls++
+		return nil
+	}
+	ready := make(chan struct{})
+	c.callWaiters = append(c.callWaiters, ready)
+	c.mu.Unlock()
+	var err error
+	select {
+	case <-ready:
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-c.bgctx.Done():
+		err = disconnected("connection closed")
+	}
+	c.mu.Lock()
+	if err == nil {
+		return nil
+	}
+	for i, w := range c.callWaiters {
+		if w == ready {
+			n := copy(c.callWaiters[i:], c.callWaiters[i+1:])
+			c.callWaiters[i+n] = nil
+			c.callWaiters = c.callWaiters[:i+n]
+			return err
+		}
+	}
+	// The slot was handed to this waiter before it noticed the error.
+	c.releaseCallSlot()
+	return err
+}
+
+// releaseCallSlot releases a slot taken by acquireCallSlot, handing it
+// to the first waiter, if any.  The caller must be holding c.mu.
+func (c *Conn) releaseCallSlot() {
+	if len(c.callWaiters) == 0 {
+		c.inFlightCalls--
+		return
+	}
+	ready := c.callWaiters[0]
+	n := copy(c.callWaiters, c.callWaiters[1:])
+	c.callWaiters[n] = nil
+	c.callWaiters = c.callWaiters[:n]
+	close(ready)
+}
+
+// A senderWaiter is a goroutine waiting to acquire the sender lock.
+type senderWaiter struct {
+	pri   int
+	ready chan struct{} // closed when the lock is handed to the waiter
+}
+
+// waitSender adds a waiter to c.sendWaiters after any waiters with the
+// same or higher priority.  The caller must be holding c.mu.
+func (c *Conn) waitSender(pri int) *senderWaiter {
+	w := &senderWaiter{pri: pri, ready: make(chan struct{})}
+	i := len(c.sendWaiters)
+	for i > 0 && c.sendWaiters[i-1].pri < pri {
+		i--
+	}
+	c.sendWaiters = append(c.sendWaiters, nil)
+	copy(c.sendWaiters[i+1:], c.sendWaiters[i:])
+	c.sendWaiters[i] = w
+	if c.onSenderWait != nil {
+		c.onSenderWait()
+	}
+	return w
+}
+
+// removeSenderWaiter removes w from c.sendWaiters, reporting false if
+// the lock was already handed to w.  The caller must be holding c.mu.
+func (c *Conn) removeSenderWaiter(w *senderWaiter) bool {
+	for i, w2 := range c.sendWaiters {
+		if w2 == w {
+			n := copy(c.sendWaiters[i:], c.sendWaiters[i+1:])
+			c.sendWaiters[i+n] = nil
+			c.sendWaiters = c.sendWaiters[:i+n]
+			return true
+		}
+	}
+	return false
+}
+
+type priorityContextKey struct{}
+
+// WithPriority returns a copy of ctx that carries a send priority.
+// When several calls made on the same Conn are waiting to be sent, calls
+// with a higher priority are sent first; calls with the same priority
+// are sent in the order they started waiting.  The default priority is
+// zero.  Priority does not affect messages that are already being sent.
+func WithPriority(ctx context.Context, pri int) context.Context {
+	return context.WithValue(ctx, priorityContextKey{}, pri)
+}
+
+// priorityFromContext returns the priority set by WithPriority, or zero.
+func priorityFromContext(ctx context.Context) int {
+	pri, _ := ctx.Value(priorityContextKey{}).(int)
+	return pri
+}
+
+type releaseResultCapsContextKey struct{}
+
+// WithReleaseResultCaps returns a copy of ctx that marks calls made with
+// it as not needing the capabilities in their results.  The Conn asks
+// the remote vat to release those capabilities as soon as the call
+// returns, by setting releaseResultCaps in the Finish message, and the
+// capabilities read as null in the results.  If pipelined calls were
+// made on the results before the call returned, then the capabilities
+// are kept as usual.
+func WithReleaseResultCaps(ctx context.Context) context.Context {
+	return context.WithValue(ctx, releaseResultCapsContextKey{}, true)
+}
+
+// releaseResultCapsFromContext reports whether ctx was returned by
+// WithReleaseResultCaps.
+func releaseResultCapsFromContext(ctx context.Context) bool {
+	release, _ := ctx.Value(releaseResultCapsContextKey{}).(bool)
+	return release
 }
 
 // report sends an error to c's reporter.  The caller does not have to
@@ -1402,11 +2695,27 @@ func (c *Conn) reportf(format string, args ...interface{}) {
 	c.reporter.ReportError(errorf(format, args...))
 }
 
+// isClosedError reports whether err indicates that the underlying
+// stream was already closed, usually because the remote vat hung up
+// first.
+func isClosedError(err error) bool {
+	return goerrors.Is(err, io.ErrClosedPipe) || goerrors.Is(err, net.ErrClosed)
+}
+
 func clearCapTable(msg *capnp.Message) {
 	releaseList(msg.CapTable).release()
 	msg.CapTable = nil
 }
 
+// exception returns the reason and type of the exception that reports
+// err to the remote vat.
+func (c *Conn) exception(err error) (string, rpccp.Exception_Type) {
+	if c.exceptionMapper != nil {
+		return c.exceptionMapper(err)
+	}
+	return err.Error(), rpccp.Exception_Type(errors.TypeOf(err))
+}
+
 func fail(msg string) error {
 	return errors.New(errors.Failed, "rpc", msg)
 }
@@ -0,0 +1,111 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+func TestSendPriority(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c1, c2 := net.Pipe()
+	defer c2.Close()
+	conn := NewConn(NewStreamTransport(c1), nil)
+	defer conn.Close()
+	waiting := make(chan struct{}, 4)
+	conn.mu.Lock()
+	conn.onSenderWait = func() { waiting <- struct{}{} }
+	conn.mu.Unlock()
+	waitSender := func() {
+		t.Helper()
+		select {
+		case <-waiting:
+		case <-ctx.Done():
+			t.Fatal("call never waited on sender lock")
+		}
+	}
+
+	// Read calls sent by conn in order.
+	calls := make(chan uint16, 4)
+	go func() {
+		defer close(calls)
+		dec := capnp.NewDecoder(c2)
+		for {
+			msg, err := dec.Decode()
+			if err != nil {
+				return
+			}
+			rmsg, err := rpccp.ReadRootMessage(msg)
+			if err != nil {
+				return
+			}
+			if rmsg.Which() == rpccp.Message_Which_call {
+				call, _ := rmsg.Call()
+				calls <- call.MethodId()
+			}
+		}
+	}()
+
+	boot := conn.Bootstrap(ctx)
+	defer boot.Release()
+
+	// Start a low-priority call and a high-priority call, and stop each
+	// one in PlaceArgs.
+	const (
+		lowMethod  = 1
+		highMethod = 2
+	)
+	placing := make(chan struct{}, 2)
+	lowGate, highGate := make(chan struct{}), make(chan struct{})
+	startCall := func(ctx context.Context, methodID uint16, gate <-chan struct{}) {
+		go func() {
+			_, release := boot.SendCall(ctx, capnp.Send{
+				Method: capnp.Method{InterfaceID: 0xa7317bd7216570aa, MethodID: methodID},
+				PlaceArgs: func(capnp.Struct) error {
+					placing <- struct{}{}
+					<-gate
+					return nil
+				},
+			})
+			release()
+		}()
+	}
+	startCall(WithPriority(ctx, -1), lowMethod, lowGate)
+	startCall(WithPriority(ctx, 1), highMethod, highGate)
+	<-placing
+	<-placing
+
+	// Hold the sender lock while both calls wait to send, with the
+	// low-priority call waiting first.
+	conn.mu.Lock()
+	conn.lockSender()
+	conn.mu.Unlock()
+	close(lowGate)
+	waitSender()
+	close(highGate)
+	waitSender()
+	conn.mu.Lock()
+	conn.unlockSender()
+	conn.mu.Unlock()
+
+	var got []uint16
+	for len(got) < 2 {
+		select {
+		case id, ok := <-calls:
+			if !ok {
+				t.Fatalf("connection closed after receiving calls %v", got)
+			}
+			got = append(got, id)
+		case <-ctx.Done():
+			t.Fatalf("calls received = %v; want 2 calls", got)
+		}
+	}
+	if got[0] != highMethod || got[1] != lowMethod {
+		t.Errorf("calls sent in order %v; want [%d %d]", got, highMethod, lowMethod)
+	}
+}
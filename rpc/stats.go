@@ -0,0 +1,116 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+
+	capnp "capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// ConnStats is a snapshot of a Conn's tables and traffic.
+type ConnStats struct {
+	// Questions is the number of calls made by the Conn that are
+	// waiting for a return or have not been finished.
+	Questions int
+	// Answers is the number of calls from the remote vat that have not
+	// been finished.
+	Answers int
+	// Exports is the number of capabilities exported to the remote vat.
+	Exports int
+	// Imports is the number of capabilities imported from the remote
+	// vat.
+	Imports int
+	// Embargoes is the number of embargoes waiting to be lifted.
+	Embargoes int
+
+	// MessagesSent is the number of messages the Conn has sent, by
+	// type.  Types that have not been sent are omitted.
+	MessagesSent map[rpccp.Message_Which]uint64
+	// MessagesReceived is the number of messages the Conn has
+	// received, by type.  Types that have not been received are omitted.
+	MessagesReceived map[rpccp.Message_Which]uint64
+}
+
+// Stats returns a snapshot of c's tables and traffic.  After c is shut
+// down, the table counts are zero, but the message counts are kept.
+func (c *Conn) Stats() ConnStats {
+	c.mu.Lock()
+	s := ConnStats{
+		Answers: len(c.answers),
+		Imports: len(c.imports),
+	}
+	for _, q := range c.questions {
+		if q != nil {
+			s.Questions++
+		}
+	}
+	for _, e := range c.exports {
+		if e != nil {
+			s.Exports++
+		}
+	}
+	for _, e := range c.embargoes {
+		if e != nil {
+			s.Embargoes++
+		}
+	}
+	c.mu.Unlock()
+	s.MessagesSent = c.sentCounts.snapshot()
+	s.MessagesReceived = c.recvCounts.snapshot()
+	return s
+}
+
+// messageCounts counts messages by type.  It is safe to use from
+// multiple goroutines.
+type messageCounts struct {
+	n [rpccp.Message_Which_disembargo + 1]uint64
+}
+
+func (mc *messageCounts) add(which rpccp.Message_Which) {
+	if int(which) < len(mc.n) {
+		atomic.AddUint64(&mc.n[which], 1)
+	}
+}
+
+func (mc *messageCounts) snapshot() map[rpccp.Message_Which]uint64 {
+	m := make(map[rpccp.Message_Which]uint64)
+	for i := range mc.n {
+		if n := atomic.LoadUint64(&mc.n[i]); n > 0 {
+			m[rpccp.Message_Which(i)] = n
+		}
+	}
+	return m
+}
+
+// statsTransport is a transport that counts the messages that are sent
+// and received through it.  See Conn.Stats.
+type statsTransport struct {
+	Transport
+	sent, received *messageCounts
+}
+
+func (st statsTransport) NewMessage(ctx context.Context) (rpccp.Message, func() error, capnp.ReleaseFunc, error) {
+	msg, send, release, err := st.Transport.NewMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, nil, err
+	}
+	countedSend := func() error {
+		which := msg.Which()
+		if err := send(); err != nil {
+			return err
+		}
+		st.sent.add(which)
+		return nil
+	}
+	return msg, countedSend, release, nil
+}
+
+func (st statsTransport) RecvMessage(ctx context.Context) (rpccp.Message, capnp.ReleaseFunc, error) {
+	msg, release, err := st.Transport.RecvMessage(ctx)
+	if err != nil {
+		return rpccp.Message{}, nil, err
+	}
+	st.received.add(msg.Which())
+	return msg, release, nil
+}
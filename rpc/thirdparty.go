@@ -0,0 +1,532 @@
+package rpc
+
+import (
+	"context"
+
+	"capnproto.org/go/capnp/v3"
+	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
+)
+
+// A ThirdPartyNetwork lets Conns hand off capabilities between vats,
+// which is level 3 of the Cap'n Proto RPC protocol.  When a vat sends
+// a capability that it imported from another vat, the receiver can
+// then connect to the capability's host directly instead of sending
+// every call through the sender.
+//
+// The protocol leaves the RecipientId, ProvisionId, and ThirdPartyCapId
+// structures to the network, so they are passed as capnp.Ptr.  Returned
+// pointers are copied into outgoing messages and may belong to any
+// message.  Pointers passed to the methods are only valid until the
+// method returns.
+//
+// A handoff only happens if the Conns to both the recipient and the
+// capability's host have the same ThirdPartyNetwork.  The methods are
+// not called while holding onto any Conn's locks, but ProvideKey and
+// AcceptKey are called on the Conn's receive goroutine, so they should
+// return quickly.  Join messages and embargoed Accept messages are
+// answered as unimplemented.
+type ThirdPartyNetwork interface {
+	// Introduce is called when the local vat sends recipient's remote
+	// vat a capability imported from provider's remote vat.  It returns
+	// the RecipientId sent to the provider in a Provide message and the
+	// ThirdPartyCapId sent to the recipient.  If Introduce returns an
+	// error, the capability is sent as a capability hosted by the local
+	// vat.
+	Introduce(provider, recipient *Conn) (recipientID, capID capnp.Ptr, err error)
+
+	// Connect is called when conn's remote vat sends a capability
+	// hosted by a third vat.  It returns a Conn to the third vat and the
+	// ProvisionId to send it in an Accept message.  If Connect returns
+	// an error, calls on the capability are sent to conn's remote vat,
+	// which proxies them.
+	Connect(ctx context.Context, conn *Conn, capID capnp.Ptr) (provider *Conn, provisionID capnp.Ptr, err error)
+
+	// ProvideKey is called when conn's remote vat sends a Provide
+	// message.  It returns a key for the provision that AcceptKey
+	// returns for the recipient's Accept message.
+	ProvideKey(conn *Conn, recipientID capnp.Ptr) (string, error)
+
+	// AcceptKey is called when conn's remote vat sends an Accept
+	// message.  It returns the Conn on which the matching Provide
+	// message is received, along with the provision's key.
+	AcceptKey(conn *Conn, provisionID capnp.Ptr) (introducer *Conn, key string, err error)
+}
+
+// An introduction is a handoff started by introduce.
+type introduction struct {
+	// capID is the ThirdPartyCapId to send to the recipient.
+	capID capnp.Ptr
+
+	// cancel finishes the Provide question sent to the provider.
+	cancel context.CancelFunc
+}
+
+// introduce starts a handoff to c's remote vat for each of clients
+// that is imported on another Conn.  The returned slice is indexed
+// like clients, with a zero value for clients that are not handed off,
+// or is nil if no clients are handed off.
+//
+// The caller must not be holding onto any locks, since this function
+// calls application code (ThirdPartyNetwork.Introduce).
+func (c *Conn) introduce(clients []*capnp.Client, states []capnp.ClientState) []introduction {
+	if c.network == nil {
+		return nil
+	}
+	var intros []introduction
+	for i := range clients {
+		ic, ok := states[i].Brand.Value.(*importClient)
+		if !ok || ic.c == c || ic.c.network == nil {
+			continue
+		}
+		recipientID, capID, err := c.network.Introduce(ic.c, c)
+		if err != nil {
+			continue
+		}
+		cancel, err := ic.c.sendProvide(ic, recipientID)
+		if err != nil {
+			continue
+		}
+		if intros == nil {
+			intros = make([]introduction, len(clients))
+		}
+		intros[i] = introduction{capID: capID, cancel: cancel}
+	}
+	return intros
+}
+
+// sendProvide sends a Provide message asking the remote vat to hold
+// onto ic's capability for recipientID.  The returned function
+// finishes the Provide question.
+//
+// The caller must not be holding onto c.mu.
+func (c *Conn) sendProvide(ic *importClient, recipientID capnp.Ptr) (context.CancelFunc, error) {
+	c.mu.Lock()
+	if ent := c.imports[ic.id]; ent == nil || ent.generation != ic.generation || ent.promise != nil {
+		c.mu.Unlock()
+		return nil, errorf("provide: import %d is not a settled capability", ic.id)
+	}
+	if !c.startTask() {
+		c.mu.Unlock()
+		return nil, disconnected("connection closed")
+	}
+	q := c.newQuestion(capnp.Method{})
+	err := c.sendMessage(c.bgctx, func(msg rpccp.Message) error {
+		p, err := msg.NewProvide()
+		if err != nil {
+			return err
+		}
+		p.SetQuestionId(uint32(q.id))
+		tgt, err := p.NewTarget()
+		if err != nil {
+			return err
+		}
+		tgt.SetImportedCap(uint32(ic.id))
+		return p.SetRecipient(recipientID)
+	})
+	if err != nil {
+		c.clearQuestion(q.id)
+		c.freeQuestionID(q.id)
+		c.mu.Unlock()
+		c.tasks.Done()
+		return nil, annotate(err).errorf("send provide")
+	}
+	c.mu.Unlock()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer c.tasks.Done()
+		q.handleCancel(ctx)
+		<-q.p.Answer().Done()
+		q.p.ReleaseClients()
+		q.release()
+	}()
+	return cancel, nil
+}
+
+// sendThirdPartyCap rewrites d, which sendCap wrote for export id, as
+// a capability hosted by a third party, with the export as the vine.
+// The export finishes the introduction's Provide question once it is
+// released.
+//
+// The caller must be holding onto c.mu.
+func (c *Conn) sendThirdPartyCap(d rpccp.CapDescriptor, id exportID, intro introduction) {
+	tp, err := d.NewThirdPartyHosted()
+	if err == nil {
+		err = tp.SetId(intro.capID)
+	}
+	if err != nil {
+		// Calls will go through the vine instead.
+		intro.cancel()
+		d.SetSenderHosted(uint32(id))
+		return
+	}
+	tp.SetVineId(uint32(id))
+	ent := c.exports[id]
+	ent.provides = append(ent.provides, intro.cancel)
+}
+
+// recvThirdPartyCap returns a client for a capability hosted by a
+// third vat, stealing the reference to vine.  Calls on the client are
+// held until the capability has been accepted from the third vat, or
+// sent to vine if that fails.
+//
+// The caller must be holding onto c.mu.
+func (c *Conn) recvThirdPartyCap(capID capnp.Ptr, vine *capnp.Client) *capnp.Client {
+	capID, err := clonePtr(capID)
+	if err != nil {
+		return vine
+	}
+	if !c.startTask() {
+		return vine
+	}
+	ctx, cancel := context.WithCancel(c.bgctx)
+	// Like a bootstrap client, the hook holds calls until it is resolved,
+	// so that they are delivered in order.
+	hook := &bootstrapClient{
+		ready:  make(chan struct{}),
+		cancel: cancel,
+	}
+	client, cp := capnp.NewPromisedClient(hook)
+	go func() {
+		defer c.tasks.Done()
+		target, err := c.acceptThirdParty(ctx, capID)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.report(annotate(err).errorf("third-party handoff"))
+			}
+			target = vine.AddRef()
+		}
+		vine.Release()
+		hook.resolve(target.AddRef())
+		cp.Fulfill(target)
+		target.Release()
+	}()
+	return client
+}
+
+// acceptThirdParty connects to the vat identified by capID and picks up
+// the capability from it.
+func (c *Conn) acceptThirdParty(ctx context.Context, capID capnp.Ptr) (*capnp.Client, error) {
+	provider, provisionID, err := c.network.Connect(ctx, c, capID)
+	if err != nil {
+		return nil, err
+	}
+	return provider.accept(ctx, provisionID)
+}
+
+// accept sends an Accept message for provisionID and waits for its
+// return.
+//
+// The caller must not be holding onto c.mu.
+func (c *Conn) accept(ctx context.Context, provisionID capnp.Ptr) (*capnp.Client, error) {
+	c.mu.Lock()
+	if !c.startTask() {
+		c.mu.Unlock()
+		return nil, disconnected("connection closed")
+	}
+	defer c.tasks.Done()
+	q := c.newQuestion(capnp.Method{})
+	err := c.sendMessage(ctx, func(msg rpccp.Message) error {
+		a, err := msg.NewAccept()
+		if err != nil {
+			return err
+		}
+		a.SetQuestionId(uint32(q.id))
+		return a.SetProvision(provisionID)
+	})
+	if err != nil {
+		c.clearQuestion(q.id)
+		c.freeQuestionID(q.id)
+		c.mu.Unlock()
+		return nil, annotate(err).errorf("send accept")
+	}
+	c.mu.Unlock()
+	q.handleCancel(ctx)
+	ans := q.p.Answer()
+	<-ans.Done()
+	defer q.release()
+	defer q.p.ReleaseClients()
+	if _, err := ans.Struct(); err != nil {
+		return nil, annotate(err).errorf("accept")
+	}
+	return ans.Client().AddRef(), nil
+}
+
+// A provision is a capability that the remote vat asked this vat to
+// provide to a third vat with a Provide message.
+type provision struct {
+	client   *capnp.Client
+	accepted chan struct{} // closed by takeProvision
+}
+
+func (c *Conn) handleProvide(ctx context.Context, p rpccp.Provide) error {
+	id := answerID(p.QuestionId())
+	var tgt parsedMessageTarget
+	var key string
+	parseErr := c.parseProvide(&tgt, &key, p)
+
+	c.mu.Lock()
+	if c.answers[id] != nil {
+		c.mu.Unlock()
+		return errorf("incoming provide: answer ID %d reused", id)
+	}
+	if err := c.tryLockSender(ctx); err != nil {
+		// Shutting down.  Don't report.
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+	ret, send, release, err := c.newReturn(ctx)
+	if err != nil {
+		err = annotate(err).errorf("incoming provide")
+		c.mu.Lock()
+		c.answers[id] = errorAnswer(c, id, err)
+		c.unlockSender()
+		c.mu.Unlock()
+		c.report(err)
+		return nil
+	}
+	ret.SetAnswerId(uint32(id))
+	ret.SetReleaseParamCaps(false)
+	c.mu.Lock()
+	ans := &answer{
+		c:          c,
+		id:         id,
+		ret:        ret,
+		sendMsg:    send,
+		releaseMsg: release,
+	}
+	c.answers[id] = ans
+	if c.drained != nil {
+		rl := ans.sendException(disconnected("incoming provide: connection shutting down"))
+		c.unlockSender()
+		c.mu.Unlock()
+		rl.release()
+		return nil
+	}
+	if parseErr != nil {
+		// Not reporting, as this is the introducer's fault.
+		rl := ans.sendException(annotate(parseErr).errorf("incoming provide"))
+		c.unlockSender()
+		c.mu.Unlock()
+		rl.release()
+		return nil
+	}
+	ent := c.findExport(tgt.importedCap)
+	if ent == nil {
+		ans.ret = rpccp.Return{}
+		ans.sendMsg = nil
+		ans.releaseMsg = nil
+		c.mu.Unlock()
+		release()
+		c.mu.Lock()
+		c.unlockSender()
+		c.mu.Unlock()
+		return errorf("incoming provide: unknown export ID %d", tgt.importedCap)
+	}
+	if c.provisions[key] != nil {
+		rl := ans.sendException(errorf("incoming provide: recipient already has a pending provision"))
+		c.unlockSender()
+		c.mu.Unlock()
+		rl.release()
+		return nil
+	}
+	pv := &provision{
+		client:   ent.client.AddRef(),
+		accepted: make(chan struct{}),
+	}
+	if c.provisions == nil {
+		c.provisions = make(map[string]*provision)
+	}
+	c.provisions[key] = pv
+	if c.provided != nil {
+		close(c.provided)
+		c.provided = nil
+	}
+	c.tasks.Add(1) // will be finished by answer.Return
+	var provideCtx context.Context
+	provideCtx, ans.cancel = context.WithCancel(c.bgctx)
+	c.unlockSender()
+	c.mu.Unlock()
+	go c.waitProvision(provideCtx, key, pv, ans)
+	return nil
+}
+
+// parseProvide reads the target of a Provide message and the key of
+// its recipient.
+func (c *Conn) parseProvide(tgt *parsedMessageTarget, key *string, p rpccp.Provide) error {
+	t, err := p.Target()
+	if err != nil {
+		return errorf("read target: %v", err)
+	}
+	if err := parseMessageTarget(tgt, t); err != nil {
+		return err
+	}
+	if tgt.which != rpccp.MessageTarget_Which_importedCap {
+		return unimplementedf("provide of a promised answer")
+	}
+	recipient, err := p.Recipient()
+	if err != nil {
+		return errorf("read recipient: %v", err)
+	}
+	*key, err = c.network.ProvideKey(c, recipient)
+	return err
+}
+
+// waitProvision returns the Provide question once the provision has
+// been accepted, or with an error if ctx is done first.
+func (c *Conn) waitProvision(ctx context.Context, key string, pv *provision, ans *answer) {
+	select {
+	case <-pv.accepted:
+	case <-ctx.Done():
+	}
+	var err error
+	c.mu.Lock()
+	if c.provisions[key] == pv {
+		delete(c.provisions, key)
+		err = ctx.Err()
+	}
+	c.mu.Unlock()
+	pv.client.Release()
+	ans.Return(err)
+}
+
+// takeProvision waits for a provision with the given key to be
+// received on c and removes it, returning a new reference to its
+// capability.
+//
+// The caller must not be holding onto c.mu.
+func (c *Conn) takeProvision(ctx context.Context, key string) (*capnp.Client, error) {
+	for {
+		c.mu.Lock()
+		if pv := c.provisions[key]; pv != nil {
+			delete(c.provisions, key)
+			close(pv.accepted)
+			client := pv.client.AddRef()
+			c.mu.Unlock()
+			return client, nil
+		}
+		if c.provided == nil {
+			c.provided = make(chan struct{})
+		}
+		provided := c.provided
+		c.mu.Unlock()
+		select {
+		case <-provided:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.bgctx.Done():
+			return nil, disconnected("connection to introducer closed")
+		}
+	}
+}
+
+func (c *Conn) handleAccept(ctx context.Context, a rpccp.Accept) error {
+	id := answerID(a.QuestionId())
+	introducer, key, parseErr := c.parseAccept(a)
+
+	c.mu.Lock()
+	if c.answers[id] != nil {
+		c.mu.Unlock()
+		return errorf("incoming accept: answer ID %d reused", id)
+	}
+	if err := c.tryLockSender(ctx); err != nil {
+		// Shutting down.  Don't report.
+		c.mu.Unlock()
+		return nil
+	}
+	c.mu.Unlock()
+	ret, send, release, err := c.newReturn(ctx)
+	if err != nil {
+		err = annotate(err).errorf("incoming accept")
+		c.mu.Lock()
+		c.answers[id] = errorAnswer(c, id, err)
+		c.unlockSender()
+		c.mu.Unlock()
+		c.report(err)
+		return nil
+	}
+	ret.SetAnswerId(uint32(id))
+	ret.SetReleaseParamCaps(false)
+	c.mu.Lock()
+	ans := &answer{
+		c:          c,
+		id:         id,
+		ret:        ret,
+		sendMsg:    send,
+		releaseMsg: release,
+	}
+	c.answers[id] = ans
+	if c.drained != nil {
+		rl := ans.sendException(disconnected("incoming accept: connection shutting down"))
+		c.unlockSender()
+		c.mu.Unlock()
+		rl.release()
+		return nil
+	}
+	if parseErr != nil {
+		// Not reporting, as this is the recipient's fault.
+		rl := ans.sendException(annotate(parseErr).errorf("incoming accept"))
+		c.unlockSender()
+		c.mu.Unlock()
+		rl.release()
+		return nil
+	}
+	c.tasks.Add(1) // will be finished by answer.Return
+	var acceptCtx context.Context
+	acceptCtx, ans.cancel = context.WithCancel(c.bgctx)
+	c.unlockSender()
+	c.mu.Unlock()
+	go c.returnProvision(acceptCtx, ans, introducer, key)
+	return nil
+}
+
+// parseAccept finds the Conn and key of an Accept message's provision.
+func (c *Conn) parseAccept(a rpccp.Accept) (*Conn, string, error) {
+	if a.Embargo() {
+		return nil, "", unimplementedf("embargoed accept")
+	}
+	provision, err := a.Provision()
+	if err != nil {
+		return nil, "", errorf("read provision: %v", err)
+	}
+	return c.network.AcceptKey(c, provision)
+}
+
+// returnProvision returns an Accept question with the capability from
+// the matching provision on introducer.
+func (c *Conn) returnProvision(ctx context.Context, ans *answer, introducer *Conn, key string) {
+	client, err := introducer.takeProvision(ctx, key)
+	if err != nil {
+		ans.Return(err)
+		return
+	}
+	ans.results, err = ans.ret.NewResults()
+	if err != nil {
+		client.Release()
+		ans.Return(errorf("alloc accept results: %v", err))
+		return
+	}
+	iface := capnp.NewInterface(ans.results.Segment(), ans.results.Message().AddCap(client))
+	if err := ans.results.SetContent(iface.ToPtr()); err != nil {
+		ans.Return(errorf("alloc accept results: %v", err))
+		return
+	}
+	ans.Return(nil)
+}
+
+// clonePtr copies p into a new message, so that the copy stays valid
+// after p's message is released.
+func clonePtr(p capnp.Ptr) (capnp.Ptr, error) {
+	_, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	holder, err := capnp.NewRootStruct(seg, capnp.ObjectSize{PointerCount: 1})
+	if err != nil {
+		return capnp.Ptr{}, err
+	}
+	if err := holder.SetPtr(0, p); err != nil {
+		return capnp.Ptr{}, err
+	}
+	return holder.Ptr(0)
+}
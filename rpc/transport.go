@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"io"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -52,6 +53,27 @@ type Transport interface {
 	Close() error
 }
 
+// A TransportError is an error from the Conn's Transport while sending
+// or receiving a message, as opposed to an error reported by the remote
+// vat (like an abort).  A TransportError usually means that the
+// underlying connection was lost, so it may be worth reconnecting.
+type TransportError struct {
+	// Op is the operation that failed: "send" or "receive".
+	Op string
+
+	// Err is the error returned by the Transport.
+	Err error
+}
+
+func (e *TransportError) Error() string {
+	return "rpc: " + e.Op + " message: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the Transport.
+func (e *TransportError) Unwrap() error {
+	return e.Err
+}
+
 // A Codec is responsible for encoding and decoding messages from
 // a single logical stream.
 type Codec interface {
@@ -66,6 +88,7 @@ type Codec interface {
 // byte transfer mechanism.
 type transport struct {
 	c      Codec
+	shape  messageShape
 	closed bool
 	err    errorValue
 }
@@ -103,13 +126,18 @@ func (s *transport) NewMessage(ctx context.Context) (_ rpccp.Message, send func(
 		return rpccp.Message{}, nil, nil, err
 	}
 
-	// TODO(soon): reuse memory
-	msg, seg, err := capnp.NewMessage(capnp.MultiSegment(nil))
+	// Outbound messages are released once sent, so their buffers are
+	// reused.
+	arena := capnp.NewPooledArena()
+	msg, seg, err := capnp.NewMessage(arena)
 	if err != nil {
+		arena.Release()
 		return rpccp.Message{}, nil, nil, errors.New(errors.Failed, "rpc stream transport", "new message: "+err.Error())
 	}
-	rmsg, err := rpccp.NewRootMessage(seg)
+	rmsg, err := s.shape.newRoot(seg)
 	if err != nil {
+		msg.Reset(nil)
+		arena.Release()
 		return rpccp.Message{}, nil, nil, errors.New(errors.Failed, "rpc stream transport", "new message: "+err.Error())
 	}
 
@@ -136,7 +164,24 @@ func (s *transport) NewMessage(ctx context.Context) (_ rpccp.Message, send func(
 		return err
 	}
 
-	return rmsg, send, func() { msg.Reset(nil) }, nil
+	released := false
+	return rmsg, send, func() {
+		// The arena must only go back to the pool once, even if release
+		// is called again.
+		if released {
+			return
+		}
+		released = true
+		msg.Reset(nil)
+		arena.Release()
+	}, nil
+}
+
+// shapeMessages adds shape to the messages that s builds.  Messages
+// are always built in a PooledArena, which has a single segment.
+func (s *transport) shapeMessages(shape messageShape) bool {
+	s.shape.add(shape)
+	return true
 }
 
 // SetPartialWriteTimeout sets the timeout for completing the
@@ -151,6 +196,15 @@ func (s *transport) SetPartialWriteTimeout(d time.Duration) {
 	s.c.SetPartialWriteTimeout(d)
 }
 
+// poolInbound makes s read received messages into pooled buffers if
+// its codec decodes from a byte stream.  The buffers are reused once the
+// messages are released.  See Options.PoolInboundBuffers.
+func (s *transport) poolInbound() {
+	if sc, ok := s.c.(*streamCodec); ok {
+		sc.dec.PoolBuffers()
+	}
+}
+
 // RecvMessage reads the next message from the underlying reader.
 //
 // It is safe to call RecvMessage concurrently with NewMessage.
@@ -161,13 +215,23 @@ func (s *transport) RecvMessage(ctx context.Context) (rpccp.Message, capnp.Relea
 
 	msg, err := s.c.Decode(ctx)
 	if err != nil {
-		return rpccp.Message{}, nil, errors.New(errors.Failed, "rpc stream transport", "receive: "+err.Error())
+		return rpccp.Message{}, nil, errors.Wrap(errors.Failed, "rpc stream transport", "receive: "+err.Error(), err)
 	}
 	rmsg, err := rpccp.ReadRootMessage(msg)
 	if err != nil {
 		return rpccp.Message{}, nil, errors.New(errors.Failed, "rpc stream transport", "receive: "+err.Error())
 	}
-	return rmsg, func() { msg.Reset(nil) }, nil
+	return rmsg, func() { msg.Release() }, nil
+}
+
+// limitInbound makes s reject received messages larger than max bytes
+// of segments from their stream header, if its codec decodes from a byte
+// stream.  The header itself may take up to maxStreamHeaderSize more.
+// See Options.MaxMessageSize.
+func (s *transport) limitInbound(max uint64) {
+	if sc, ok := s.c.(*streamCodec); ok {
+		sc.dec.MaxMessageSize = max + maxStreamHeaderSize
+	}
 }
 
 // Close closes the underlying ReadWriteCloser.  It is not safe to call
@@ -178,12 +242,213 @@ func (s *transport) Close() error {
 	}
 	s.closed = true
 	err := s.c.Close()
-	if err != nil {
+	if err != nil && !isClosedError(err) {
 		return errors.New(errors.Failed, "rpc stream transport", "close: "+err.Error())
 	}
 	return nil
 }
 
+// A messageShape describes how a transport builds outbound messages.
+// Wrappers in this package that need messages built a certain way ask
+// the underlying transport for it with shapeMessages, instead of
+// rebuilding the messages it creates: the underlying transport chose the
+// message's arena and may need it back on release.
+type messageShape struct {
+	// singleSegment builds every message in a single segment.  See
+	// Options.SingleSegmentOutbound.
+	singleSegment bool
+
+	// rootSize is the minimum size of the root struct, which is
+	// allocated right after the root pointer, before anything else is
+	// written to the message.  Fields past those of rpccp.Message are
+	// ignored by the remote vat.  See NewChecksummedTransport.
+	rootSize capnp.ObjectSize
+}
+
+// add adds the requirements of other to shape.
+func (shape *messageShape) add(other messageShape) {
+	shape.singleSegment = shape.singleSegment || other.singleSegment
+	if other.rootSize.DataSize > shape.rootSize.DataSize {
+		shape.rootSize.DataSize = other.rootSize.DataSize
+	}
+	if other.rootSize.PointerCount > shape.rootSize.PointerCount {
+		shape.rootSize.PointerCount = other.rootSize.PointerCount
+	}
+}
+
+// newRoot allocates the root struct of an outbound message in seg,
+// which must be the first segment of an empty message.
+func (shape messageShape) newRoot(seg *capnp.Segment) (rpccp.Message, error) {
+	sz := capnp.ObjectSize{DataSize: 8, PointerCount: 1} // rpccp.Message
+	if shape.rootSize.DataSize > sz.DataSize {
+		sz.DataSize = shape.rootSize.DataSize
+	}
+	if shape.rootSize.PointerCount > sz.PointerCount {
+		sz.PointerCount = shape.rootSize.PointerCount
+	}
+	st, err := capnp.NewRootStruct(seg, sz)
+	return rpccp.Message{Struct: st}, err
+}
+
+// A shapedTransport is a Transport whose outbound messages can be
+// shaped.  shapeMessages adds the requirements in shape to the messages
+// that NewMessage builds from then on, and reports false if the
+// transport cannot meet them.  It must not be called concurrently with
+// NewMessage.
+type shapedTransport interface {
+	Transport
+	shapeMessages(shape messageShape) bool
+}
+
+// A poolingTransport is a Transport that can read received messages
+// into pooled buffers.  poolInbound must be called before RecvMessage.
+// See Options.PoolInboundBuffers.
+type poolingTransport interface {
+	Transport
+	poolInbound()
+}
+
+// A limitedTransport is a Transport that can reject received messages
+// by size before reading them.  limitInbound must be called before
+// RecvMessage.  See Options.MaxMessageSize.
+type limitedTransport interface {
+	Transport
+	limitInbound(max uint64)
+}
+
+// maxStreamHeaderSize is the size of the largest stream header that a
+// capnp.Decoder accepts: the segment count and 513 segment sizes,
+// padded to a word.
+const maxStreamHeaderSize = (4 + 513*4 + 7) &^ 7
+
+// timeoutTransport is a transport that fails any send that takes longer
+// than timeout.  See Options.SendTimeout.
+type timeoutTransport struct {
+	Transport
+	timeout time.Duration
+
+	// onTimeout is called after the first send that times out.
+	onTimeout func()
+
+	mu  sync.Mutex
+	err error // error from the first send that timed out
+}
+
+func (tt *timeoutTransport) NewMessage(ctx context.Context) (rpccp.Message, func() error, capnp.ReleaseFunc, error) {
+	// The message may be held for a while before it is sent, so the
+	// timer only starts in send.
+	ctx, cancel := context.WithCancel(ctx)
+	msg, send, release, err := tt.Transport.NewMessage(ctx)
+	if err != nil {
+		cancel()
+		return rpccp.Message{}, nil, nil, err
+	}
+	timedSend := func() error {
+		timer := time.AfterFunc(tt.timeout, cancel)
+		err := send()
+		if timer.Stop() || err == nil {
+			return err
+		}
+		err = errorf("send timed out after %v: %v", tt.timeout, err)
+		tt.mu.Lock()
+		first := tt.err == nil
+		if first {
+			tt.err = err
+		}
+		tt.mu.Unlock()
+		if first {
+			tt.onTimeout()
+		}
+		return err
+	}
+	return msg, timedSend, func() {
+		release()
+		cancel()
+	}, nil
+}
+
+// timeoutErr returns the error from the first send that timed out, or
+// nil if no send has timed out.
+func (tt *timeoutTransport) timeoutErr() error {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	return tt.err
+}
+
+// cancelTransport is a transport whose outgoing messages can all be
+// canceled at once.  Once cancelAll is called, the contexts of messages
+// that have not been released are canceled, their sends fail, and
+// NewMessage returns an error.
+type cancelTransport struct {
+	Transport
+
+	mu       sync.Mutex
+	canceled bool
+	nextID   uint64
+	cancels  map[uint64]context.CancelFunc
+}
+
+func (ct *cancelTransport) NewMessage(ctx context.Context) (rpccp.Message, func() error, capnp.ReleaseFunc, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	ct.mu.Lock()
+	if ct.canceled {
+		ct.mu.Unlock()
+		cancel()
+		return rpccp.Message{}, nil, nil, disconnected("connection closed")
+	}
+	id := ct.nextID
+	ct.nextID++
+	if ct.cancels == nil {
+		ct.cancels = make(map[uint64]context.CancelFunc)
+	}
+	ct.cancels[id] = cancel
+	ct.mu.Unlock()
+
+	msg, send, release, err := ct.Transport.NewMessage(ctx)
+	if err != nil {
+		ct.forget(id)
+		cancel()
+		return rpccp.Message{}, nil, nil, err
+	}
+	cancelableSend := func() error {
+		err := send()
+		if err != nil && ctx.Err() != nil && ct.isCanceled() {
+			return disconnected("connection closed")
+		}
+		return err
+	}
+	return msg, cancelableSend, func() {
+		release()
+		ct.forget(id)
+		cancel()
+	}, nil
+}
+
+// cancelAll cancels all outstanding messages and causes subsequent
+// calls to NewMessage to fail.
+func (ct *cancelTransport) cancelAll() {
+	ct.mu.Lock()
+	ct.canceled = true
+	cancels := ct.cancels
+	ct.cancels = nil
+	ct.mu.Unlock()
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+func (ct *cancelTransport) isCanceled() bool {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.canceled
+}
+
+func (ct *cancelTransport) forget(id uint64) {
+	ct.mu.Lock()
+	delete(ct.cancels, id)
+	ct.mu.Unlock()
+}
+
 type streamCodec struct {
 	r   *ctxReader
 	dec *capnp.Decoder
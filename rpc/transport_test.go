@@ -10,6 +10,7 @@ import (
 
 	capnp "capnproto.org/go/capnp/v3"
 	"capnproto.org/go/capnp/v3/rpc"
+	"capnproto.org/go/capnp/v3/server"
 	rpccp "capnproto.org/go/capnp/v3/std/capnp/rpc"
 )
 
@@ -244,3 +245,205 @@ func testTCPStreamTransport(t *testing.T, newTransport func(io.ReadWriteCloser)
 		})
 	})
 }
+
+func TestPipe(t *testing.T) {
+	t.Run("Transport", func(t *testing.T) {
+		testTransport(t, func() (t1, t2 rpc.Transport, err error) {
+			t1, t2 = rpc.NewPipe()
+			return t1, t2, nil
+		})
+	})
+	t.Run("CloseEOF", func(t *testing.T) {
+		ctx := context.Background()
+		t1, t2 := rpc.NewPipe()
+		if err := sendMessage(ctx, t1, &rpcMessage{Which: rpccp.Message_Which_bootstrap, Bootstrap: &rpcBootstrap{QuestionID: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := t1.Close(); err != nil {
+			t.Fatal("t1.Close:", err)
+		}
+		if err := sendMessage(ctx, t2, &rpcMessage{Which: rpccp.Message_Which_bootstrap, Bootstrap: &rpcBootstrap{QuestionID: 2}}); err == nil {
+			t.Error("send on t2 after t1.Close succeeded")
+		}
+		// Messages queued before Close are still delivered.
+		msg, release, err := recvMessage(ctx, t2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, t2):", err)
+		}
+		if msg.Which != rpccp.Message_Which_bootstrap || msg.Bootstrap.QuestionID != 1 {
+			t.Errorf("recvMessage(ctx, t2) = %+v; want bootstrap with question ID 1", msg)
+		}
+		release()
+		if _, _, err := t2.RecvMessage(ctx); err != io.EOF {
+			t.Errorf("t2.RecvMessage after t1.Close error = %v; want io.EOF", err)
+		}
+		if err := t2.Close(); err != nil {
+			t.Error("t2.Close:", err)
+		}
+		if err := t2.Close(); err == nil {
+			t.Error("second t2.Close succeeded")
+		}
+	})
+	t.Run("Latency", func(t *testing.T) {
+		const latency = 20 * time.Millisecond
+		ctx := context.Background()
+		t1, t2 := rpc.NewPipeWithOptions(&rpc.PipeOptions{Latency: latency})
+		defer t2.Close()
+		defer t1.Close()
+		start := time.Now()
+		for i := uint32(1); i <= 2; i++ {
+			if err := sendMessage(ctx, t1, &rpcMessage{Which: rpccp.Message_Which_bootstrap, Bootstrap: &rpcBootstrap{QuestionID: i}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for i := uint32(1); i <= 2; i++ {
+			msg, release, err := recvMessage(ctx, t2)
+			if err != nil {
+				t.Fatal("recvMessage(ctx, t2):", err)
+			}
+			if msg.Which != rpccp.Message_Which_bootstrap || msg.Bootstrap.QuestionID != i {
+				t.Errorf("message #%d = %+v; want bootstrap with question ID %d", i, msg, i)
+			}
+			release()
+		}
+		if d := time.Since(start); d < latency {
+			t.Errorf("messages received after %v; want at least %v", d, latency)
+		}
+	})
+	t.Run("Drop", func(t *testing.T) {
+		ctx := context.Background()
+		t1, t2 := rpc.NewPipeWithOptions(&rpc.PipeOptions{
+			Drop: func(msg rpccp.Message) bool {
+				return msg.Which() == rpccp.Message_Which_bootstrap
+			},
+		})
+		defer t2.Close()
+		defer t1.Close()
+		if err := sendMessage(ctx, t1, &rpcMessage{Which: rpccp.Message_Which_bootstrap, Bootstrap: &rpcBootstrap{QuestionID: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := sendMessage(ctx, t1, &rpcMessage{Which: rpccp.Message_Which_finish, Finish: &rpcFinish{QuestionID: 1}}); err != nil {
+			t.Fatal(err)
+		}
+		msg, release, err := recvMessage(ctx, t2)
+		if err != nil {
+			t.Fatal("recvMessage(ctx, t2):", err)
+		}
+		if msg.Which != rpccp.Message_Which_finish {
+			t.Errorf("recvMessage(ctx, t2).Which = %v; want finish (bootstrap dropped)", msg.Which)
+		}
+		release()
+	})
+	t.Run("Conn", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		t1, t2 := rpc.NewPipe()
+		srv := newServer(func(ctx context.Context, call *server.Call) error {
+			return nil
+		}, nil)
+		conn1 := rpc.NewConn(t1, &rpc.Options{
+			BootstrapClient: srv,
+			ErrorReporter:   testErrorReporter{tb: t},
+		})
+		conn2 := rpc.NewConn(t2, &rpc.Options{
+			ErrorReporter: testErrorReporter{tb: t},
+		})
+		client := conn2.Bootstrap(ctx)
+		ans, release := client.SendCall(ctx, capnp.Send{
+			Method: capnp.Method{
+				InterfaceID: interfaceID,
+				MethodID:    methodID,
+			},
+		})
+		if _, err := ans.Struct(); err != nil {
+			t.Error("call over pipe:", err)
+		}
+		release()
+		client.Release()
+		if err := conn2.Close(); err != nil {
+			t.Error("conn2.Close:", err)
+		}
+		select {
+		case <-conn1.Done():
+		case <-ctx.Done():
+			t.Fatal("conn1 not shut down after conn2.Close")
+		}
+		if err := conn1.Close(); err != nil {
+			t.Error("conn1.Close:", err)
+		}
+	})
+}
+
+// TestPackedStreamTransportWireFormat checks that messages sent by a
+// packed stream transport can be read with a plain packed decoder, and
+// that the transport reads messages written by a packed encoder.
+func TestPackedStreamTransportWireFormat(t *testing.T) {
+	ctx := context.Background()
+	c1, c2 := net.Pipe()
+	tr := rpc.NewPackedStreamTransport(c1)
+	defer func() {
+		if err := tr.Close(); err != nil {
+			t.Error("tr.Close:", err)
+		}
+		if err := c2.Close(); err != nil {
+			t.Error("c2.Close:", err)
+		}
+	}()
+
+	msg, send, release, err := tr.NewMessage(ctx)
+	if err != nil {
+		t.Fatal("tr.NewMessage:", err)
+	}
+	call, err := msg.NewCall()
+	if err != nil {
+		release()
+		t.Fatal("NewCall:", err)
+	}
+	call.SetQuestionId(123)
+	call.SetInterfaceId(456)
+	call.SetMethodId(7)
+	sendErr := make(chan error, 1)
+	go func() {
+		sendErr <- send()
+	}()
+	raw, err := capnp.NewPackedDecoder(c2).Decode()
+	if err != nil {
+		t.Fatal("decode packed message:", err)
+	}
+	release()
+	if err := <-sendErr; err != nil {
+		t.Fatal("send:", err)
+	}
+	rmsg, err := rpccp.ReadRootMessage(raw)
+	if err != nil {
+		t.Fatal("ReadRootMessage:", err)
+	}
+	if rmsg.Which() != rpccp.Message_Which_call {
+		t.Fatalf("decoded message is a %v; want call", rmsg.Which())
+	}
+	if rcall, err := rmsg.Call(); err != nil {
+		t.Error("decoded message call:", err)
+	} else if rcall.QuestionId() != 123 || rcall.InterfaceId() != 456 || rcall.MethodId() != 7 {
+		t.Errorf("decoded call = (question %d, %#x.%d); want (question 123, %#x.7)", rcall.QuestionId(), rcall.InterfaceId(), rcall.MethodId(), 456)
+	}
+
+	// Write the message back with a packed encoder.
+	go func() {
+		sendErr <- capnp.NewPackedEncoder(c2).Encode(raw)
+	}()
+	got, release, err := tr.RecvMessage(ctx)
+	if err != nil {
+		t.Fatal("tr.RecvMessage:", err)
+	}
+	defer release()
+	if err := <-sendErr; err != nil {
+		t.Fatal("encode packed message:", err)
+	}
+	if got.Which() != rpccp.Message_Which_call {
+		t.Errorf("received message is a %v; want call", got.Which())
+	} else if rcall, err := got.Call(); err != nil {
+		t.Error("received message call:", err)
+	} else if rcall.QuestionId() != 123 {
+		t.Errorf("received call question ID = %d; want 123", rcall.QuestionId())
+	}
+}
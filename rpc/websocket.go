@@ -0,0 +1,126 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/internal/errors"
+)
+
+// wsBinaryMessage is the WebSocket message type for binary data, from
+// the frame opcodes in RFC 6455.
+const wsBinaryMessage = 2
+
+// A WebSocketConn is a WebSocket connection that reads and writes whole
+// messages.  Message types are the frame opcodes defined by RFC 6455:
+// 1 for text and 2 for binary.  *websocket.Conn from
+// github.com/gorilla/websocket implements WebSocketConn.
+type WebSocketConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	Close() error
+}
+
+// NewWebSocketTransport creates a new transport that sends each Cap'n
+// Proto message as a single binary WebSocket message on conn.  Receiving
+// a text message, or a binary message that does not hold exactly one
+// Cap'n Proto message, is an error.  Closing the transport will close
+// conn, and a close frame from the remote peer makes RecvMessage return
+// an error, which shuts down a Conn using the transport.
+//
+// Context cancellation and deadlines are handled by setting a deadline
+// in the past with conn's SetReadDeadline or SetWriteDeadline.  Many
+// WebSocket implementations, including gorilla/websocket, fail every
+// later read or write after one times out, even once the deadline is
+// cleared.  Canceling the Context of a RecvMessage or send therefore
+// breaks the transport for good: all later calls fail, and the
+// transport should be closed.  A Conn only cancels its receive when it
+// is shutting down, but it sends a message with the Context of the call
+// that caused it, so canceling a call while its message is being written
+// can break the transport under the Conn.
+func NewWebSocketTransport(conn WebSocketConn) Transport {
+	return NewTransport(&wsCodec{conn: conn})
+}
+
+type wsCodec struct {
+	conn WebSocketConn
+}
+
+func (c *wsCodec) Encode(ctx context.Context, m *capnp.Message) error {
+	data, err := m.Marshal()
+	if err != nil {
+		return err
+	}
+	stop := interruptOnDone(ctx, c.conn.SetWriteDeadline)
+	err = c.conn.WriteMessage(wsBinaryMessage, data)
+	stop()
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func (c *wsCodec) Decode(ctx context.Context) (*capnp.Message, error) {
+	stop := interruptOnDone(ctx, c.conn.SetReadDeadline)
+	typ, data, err := c.conn.ReadMessage()
+	stop()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if typ != wsBinaryMessage {
+		return nil, errors.New(errors.Failed, "rpc websocket transport", "received non-binary message")
+	}
+	r := bytes.NewReader(data)
+	msg, err := capnp.NewDecoder(r).Decode()
+	if err != nil {
+		return nil, errors.Wrap(errors.Failed, "rpc websocket transport", "decode: "+err.Error(), err)
+	}
+	if r.Len() > 0 {
+		return nil, errors.New(errors.Failed, "rpc websocket transport", "message has data after the Cap'n Proto message")
+	}
+	return msg, nil
+}
+
+// SetPartialWriteTimeout does nothing: WebSocket messages are written
+// whole.
+func (c *wsCodec) SetPartialWriteTimeout(time.Duration) {}
+
+func (c *wsCodec) Close() error {
+	return c.conn.Close()
+}
+
+// interruptOnDone calls setDeadline with the current time if ctx is
+// done before the returned function is called.  The returned function
+// waits for interruptOnDone's goroutine to exit and clears the deadline
+// if it was set.  Clearing the deadline does not undo a timeout that
+// already happened: a gorilla/websocket connection remembers the error
+// and returns it from every later read or write.
+func interruptOnDone(ctx context.Context, setDeadline func(time.Time) error) (stop func()) {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	interrupted := make(chan bool, 1)
+	go func() {
+		select {
+		case <-ctx.Done():
+			setDeadline(time.Now())
+			interrupted <- true
+		case <-done:
+			interrupted <- false
+		}
+	}()
+	return func() {
+		close(done)
+		if <-interrupted {
+			setDeadline(time.Time{})
+		}
+	}
+}
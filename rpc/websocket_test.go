@@ -0,0 +1,159 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	capnp "capnproto.org/go/capnp/v3"
+	"capnproto.org/go/capnp/v3/rpc"
+)
+
+func TestWebSocketTransport(t *testing.T) {
+	testTransport(t, func() (t1, t2 rpc.Transport, err error) {
+		c1, c2 := newWebSocketPipe()
+		return rpc.NewWebSocketTransport(c1), rpc.NewWebSocketTransport(c2), nil
+	})
+
+	ctx := context.Background()
+	t.Run("TextMessage", func(t *testing.T) {
+		c1, c2 := newWebSocketPipe()
+		tr := rpc.NewWebSocketTransport(c1)
+		defer tr.Close()
+		if err := c2.WriteMessage(1, []byte("hello")); err != nil {
+			t.Fatal("c2.WriteMessage:", err)
+		}
+		if _, _, err := tr.RecvMessage(ctx); err == nil {
+			t.Error("RecvMessage after text message returned nil error")
+		}
+	})
+	t.Run("TrailingData", func(t *testing.T) {
+		c1, c2 := newWebSocketPipe()
+		tr := rpc.NewWebSocketTransport(c1)
+		defer tr.Close()
+		msg, _, err := capnp.NewMessage(capnp.SingleSegment(nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := msg.Marshal()
+		if err != nil {
+			t.Fatal("msg.Marshal:", err)
+		}
+		if err := c2.WriteMessage(2, append(data, data...)); err != nil {
+			t.Fatal("c2.WriteMessage:", err)
+		}
+		if _, _, err := tr.RecvMessage(ctx); err == nil {
+			t.Error("RecvMessage after two Cap'n Proto messages in one WebSocket message returned nil error")
+		}
+	})
+	t.Run("PeerClose", func(t *testing.T) {
+		c1, c2 := newWebSocketPipe()
+		tr := rpc.NewWebSocketTransport(c1)
+		defer tr.Close()
+		if err := c2.Close(); err != nil {
+			t.Fatal("c2.Close:", err)
+		}
+		if _, _, err := tr.RecvMessage(ctx); err == nil {
+			t.Error("RecvMessage after peer closed returned nil error")
+		}
+	})
+}
+
+// newWebSocketPipe returns two connected in-memory rpc.WebSocketConns.
+func newWebSocketPipe() (c1, c2 *webSocketPipeConn) {
+	ch1 := make(chan webSocketMessage, 1)
+	ch2 := make(chan webSocketMessage, 1)
+	close1 := make(chan struct{})
+	close2 := make(chan struct{})
+	c1 = &webSocketPipeConn{r: ch1, w: ch2, closed: close1, peerClosed: close2, wake: make(chan struct{}, 1)}
+	c2 = &webSocketPipeConn{r: ch2, w: ch1, closed: close2, peerClosed: close1, wake: make(chan struct{}, 1)}
+	return c1, c2
+}
+
+type webSocketMessage struct {
+	typ  int
+	data []byte
+}
+
+type webSocketPipeConn struct {
+	r          <-chan webSocketMessage
+	w          chan<- webSocketMessage
+	closed     chan struct{}
+	peerClosed <-chan struct{}
+
+	wake      chan struct{} // signaled when the read deadline changes
+	closeOnce sync.Once
+
+	mu           sync.Mutex
+	readDeadline time.Time
+}
+
+var errWebSocketTimeout = errors.New("websocket pipe: i/o timeout")
+
+func (c *webSocketPipeConn) ReadMessage() (int, []byte, error) {
+	for {
+		c.mu.Lock()
+		d := c.readDeadline
+		c.mu.Unlock()
+		if typ, data, ok, err := c.read(d); ok {
+			return typ, data, err
+		}
+	}
+}
+
+// read waits for a message until the deadline d.  ok is false if the
+// read deadline changed while waiting.
+func (c *webSocketPipeConn) read(d time.Time) (typ int, data []byte, ok bool, err error) {
+	var timeout <-chan time.Time
+	if !d.IsZero() {
+		timer := time.NewTimer(time.Until(d))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case m := <-c.r:
+		return m.typ, m.data, true, nil
+	case <-c.closed:
+		return 0, nil, true, errors.New("websocket pipe: closed")
+	case <-c.peerClosed:
+		return 0, nil, true, errors.New("websocket pipe: close frame received")
+	case <-timeout:
+		return 0, nil, true, errWebSocketTimeout
+	case <-c.wake:
+		return 0, nil, false, nil
+	}
+}
+
+func (c *webSocketPipeConn) WriteMessage(typ int, data []byte) error {
+	select {
+	case c.w <- webSocketMessage{typ, append([]byte(nil), data...)}:
+		return nil
+	case <-c.closed:
+		return errors.New("websocket pipe: closed")
+	case <-c.peerClosed:
+		return errors.New("websocket pipe: peer closed")
+	}
+}
+
+func (c *webSocketPipeConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// SetWriteDeadline is a no-op: the tests never block on writes.
+func (c *webSocketPipeConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *webSocketPipeConn) Close() error {
+	c.closeOnce.Do(func() { close(c.closed) })
+	return nil
+}
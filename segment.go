@@ -26,9 +26,20 @@ func (s *Segment) ID() SegmentID {
 	return s.id
 }
 
-// Data returns the raw byte slice for the segment.
+// Data returns the used portion of the segment's raw bytes.  Multi-byte
+// values in the slice are little-endian, regardless of the host's byte
+// order.  Together with Len, it can be used to write a custom framing
+// of a message.  The returned slice's capacity is capped at its length,
+// so appending to it never overwrites memory that the segment may
+// allocate later.
 func (s *Segment) Data() []byte {
-	return s.data
+	return s.data[:len(s.data):len(s.data)]
+}
+
+// Len returns the number of bytes used in the segment.  This is the
+// length of Data, not the capacity of the segment's buffer.
+func (s *Segment) Len() int64 {
+	return int64(len(s.data))
 }
 
 func (s *Segment) inBounds(addr address) bool {
@@ -121,7 +132,7 @@ func (s *Segment) readPtr(paddr address, depthLimit uint) (ptr Ptr, err error) {
 		return Ptr{}, nil
 	}
 	if depthLimit == 0 {
-		return Ptr{}, newError("read pointer: depth limit reached")
+		return Ptr{}, errDepthLimitReached
 	}
 	switch val.pointerType() {
 	case structPointer:
@@ -285,6 +296,14 @@ func (s *Segment) resolveFarPointer(paddr address) (dst *Segment, base address,
 	}
 }
 
+// A copyKey identifies an object that writePtr copied by its location
+// and by the pointer that referred to it, with a zero offset.
+type copyKey struct {
+	seg *Segment
+	off address
+	raw rawPointer
+}
+
 func (s *Segment) writePtr(off address, src Ptr, forceCopy bool) error {
 	if !src.IsValid() {
 		s.writeRawPointer(off, 0)
@@ -305,64 +324,80 @@ func (s *Segment) writePtr(off address, src Ptr, forceCopy bool) error {
 			return nil
 		}
 		if forceCopy || src.seg.msg != s.msg || st.flags&isListMember != 0 {
-			newSeg, newAddr, err := alloc(s, st.size.totalSize())
-			if err != nil {
-				return annotate(err).errorf("write pointer: copy")
-			}
-			dst := Struct{
-				seg:        newSeg,
-				off:        newAddr,
-				size:       st.size,
-				depthLimit: maxDepth,
-				// clear flags
-			}
-			if err := copyStruct(dst, st); err != nil {
-				return annotate(err).errorf("write pointer")
+			key := copyKey{src.seg, st.off, rawStructPointer(0, st.size)}
+			cp, ok := s.msg.copies[key]
+			if !ok || st.flags&isListMember != 0 {
+				newSeg, newAddr, err := alloc(s, st.size.totalSize())
+				if err != nil {
+					return annotate(err).errorf("write pointer: copy")
+				}
+				dst := Struct{
+					seg:        newSeg,
+					off:        newAddr,
+					size:       st.size,
+					depthLimit: maxDepth,
+					// clear flags
+				}
+				if s.msg.copies != nil && st.flags&isListMember == 0 {
+					s.msg.copies[key] = dst.ToPtr()
+				}
+				if err := copyStruct(dst, st); err != nil {
+					return annotate(err).errorf("write pointer")
+				}
+				cp = dst.ToPtr()
 			}
-			st = dst
-			src = dst.ToPtr()
+			st = cp.Struct()
+			src = cp
 		}
 		srcAddr = st.off
 		srcRaw = rawStructPointer(0, st.size)
 	case listPtrType:
 		l := src.List()
 		if forceCopy || src.seg.msg != s.msg {
-			sz := l.allocSize()
-			newSeg, newAddr, err := alloc(s, sz)
-			if err != nil {
-				return annotate(err).errorf("write pointer: copy")
-			}
-			dst := List{
-				seg:        newSeg,
-				off:        newAddr,
-				length:     l.length,
-				size:       l.size,
-				flags:      l.flags,
-				depthLimit: maxDepth,
-			}
-			if dst.flags&isCompositeList != 0 {
-				// Copy tag word
-				newSeg.writeRawPointer(newAddr, l.seg.readRawPointer(l.off-address(wordSize)))
-				var ok bool
-				dst.off, ok = dst.off.addSize(wordSize)
-				if !ok {
-					return newError("write pointer: copy composite list: content address overflow")
-				}
-				sz -= wordSize
-			}
-			if dst.flags&isBitList != 0 || dst.size.PointerCount == 0 {
-				end, _ := l.off.addSize(sz) // list was already validated
-				copy(newSeg.data[dst.off:], l.seg.data[l.off:end])
+			key := copyKey{src.seg, l.off, l.raw()}
+			if cp, ok := s.msg.copies[key]; ok {
+				l = cp.List()
 			} else {
-				for i := 0; i < l.Len(); i++ {
-					err := copyStruct(dst.Struct(i), l.Struct(i))
-					if err != nil {
-						return annotate(err).errorf("write pointer: copy list element %d", i)
+				sz := l.allocSize()
+				newSeg, newAddr, err := alloc(s, sz)
+				if err != nil {
+					return annotate(err).errorf("write pointer: copy")
+				}
+				dst := List{
+					seg:        newSeg,
+					off:        newAddr,
+					length:     l.length,
+					size:       l.size,
+					flags:      l.flags,
+					depthLimit: maxDepth,
+				}
+				if dst.flags&isCompositeList != 0 {
+					// Copy tag word
+					newSeg.writeRawPointer(newAddr, l.seg.readRawPointer(l.off-address(wordSize)))
+					var ok bool
+					dst.off, ok = dst.off.addSize(wordSize)
+					if !ok {
+						return newError("write pointer: copy composite list: content address overflow")
+					}
+					sz -= wordSize
+				}
+				if s.msg.copies != nil {
+					s.msg.copies[key] = dst.ToPtr()
+				}
+				if dst.flags&isBitList != 0 || dst.size.PointerCount == 0 {
+					end, _ := l.off.addSize(sz) // list was already validated
+					copy(newSeg.data[dst.off:], l.seg.data[l.off:end])
+				} else {
+					for i := 0; i < l.Len(); i++ {
+						err := copyStruct(dst.Struct(i), l.Struct(i))
+						if err != nil {
+							return annotate(err).errorf("write pointer: copy list element %d", i)
+						}
 					}
 				}
+				l = dst
 			}
-			l = dst
-			src = dst.ToPtr()
+			src = l.ToPtr()
 		}
 		srcAddr = l.off
 		if l.flags&isCompositeList != 0 {
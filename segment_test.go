@@ -7,6 +7,35 @@ import (
 	"testing"
 )
 
+func TestSegmentDataLen(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(make([]byte, 0, 1024)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{DataSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	root.SetUint64(0, 0x0102030405060708)
+
+	// Root pointer + one data word.
+	const want = 16
+	if n := seg.Len(); n != want {
+		t.Errorf("seg.Len() = %d; want %d", n, want)
+	}
+	data := seg.Data()
+	if len(data) != want || cap(data) != want {
+		t.Errorf("len, cap of seg.Data() = %d, %d; want %d, %d", len(data), cap(data), want, want)
+	}
+	wantData := []byte{
+		0, 0, 0, 0, 1, 0, 0, 0,
+		8, 7, 6, 5, 4, 3, 2, 1,
+	}
+	if !bytes.Equal(data, wantData) {
+		t.Errorf("seg.Data() = %v; want %v", data, wantData)
+	}
+}
+
 func TestSegmentInBounds(t *testing.T) {
 	tests := []struct {
 		n    int
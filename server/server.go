@@ -78,6 +78,10 @@ func (c *Call) Ack() {
 }
 
 // Shutdowner is the interface that wraps the Shutdown method.
+// Shutdown has no way to return an error, since it is called when the
+// last reference to a client is released and no caller is waiting on
+// it.  Implementations whose cleanup can fail, like rolling back a
+// transaction, must report the failure themselves.
 type Shutdowner interface {
 	Shutdown()
 }
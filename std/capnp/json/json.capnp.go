@@ -68,6 +68,58 @@ func (s JsonValue) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s JsonValue) Equal(o JsonValue) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch JsonValue_Which(s.Struct.Uint16(0)) {
+	case JsonValue_Which_boolean:
+		if s.Struct.Bit(16) != o.Struct.Bit(16) {
+			return false, nil
+		}
+	case JsonValue_Which_number:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case JsonValue_Which_string_:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case JsonValue_Which_array:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case JsonValue_Which_object:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case JsonValue_Which_call:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s JsonValue) Which() JsonValue_Which {
 	return JsonValue_Which(s.Struct.Uint16(0))
 }
@@ -108,6 +160,17 @@ func (s JsonValue) String_() (string, error) {
 	return p.Text(), err
 }
 
+// MustString_ is like String_, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue) MustString_() string {
+	v, err := s.String_()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue) HasString_() bool {
 	if s.Struct.Uint16(0) != 3 {
 		return false
@@ -133,6 +196,17 @@ func (s JsonValue) Array() (JsonValue_List, error) {
 	return JsonValue_List{List: p.List()}, err
 }
 
+// MustArray is like Array, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue) MustArray() JsonValue_List {
+	v, err := s.Array()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue) HasArray() bool {
 	if s.Struct.Uint16(0) != 4 {
 		return false
@@ -165,6 +239,17 @@ func (s JsonValue) Object() (JsonValue_Field_List, error) {
 	return JsonValue_Field_List{List: p.List()}, err
 }
 
+// MustObject is like Object, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue) MustObject() JsonValue_Field_List {
+	v, err := s.Object()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue) HasObject() bool {
 	if s.Struct.Uint16(0) != 5 {
 		return false
@@ -197,6 +282,17 @@ func (s JsonValue) Call() (JsonValue_Call, error) {
 	return JsonValue_Call{Struct: p.Struct()}, err
 }
 
+// MustCall is like Call, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue) MustCall() JsonValue_Call {
+	v, err := s.Call()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue) HasCall() bool {
 	if s.Struct.Uint16(0) != 6 {
 		return false
@@ -276,11 +372,43 @@ func (s JsonValue_Field) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s JsonValue_Field) Equal(o JsonValue_Field) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s JsonValue_Field) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue_Field) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue_Field) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -299,6 +427,17 @@ func (s JsonValue_Field) Value() (JsonValue, error) {
 	return JsonValue{Struct: p.Struct()}, err
 }
 
+// MustValue is like Value, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue_Field) MustValue() JsonValue {
+	v, err := s.Value()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue_Field) HasValue() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -375,11 +514,43 @@ func (s JsonValue_Call) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s JsonValue_Call) Equal(o JsonValue_Call) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s JsonValue_Call) Function() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustFunction is like Function, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue_Call) MustFunction() string {
+	v, err := s.Function()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue_Call) HasFunction() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -398,6 +569,17 @@ func (s JsonValue_Call) Params() (JsonValue_List, error) {
 	return JsonValue_List{List: p.List()}, err
 }
 
+// MustParams is like Params, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JsonValue_Call) MustParams() JsonValue_List {
+	v, err := s.Params()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JsonValue_Call) HasParams() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -125,10 +125,35 @@ func (s Persistent_SaveParams) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Persistent_SaveParams) Equal(o Persistent_SaveParams) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Persistent_SaveParams) SealFor() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustSealFor is like SealFor, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Persistent_SaveParams) MustSealFor() capnp.Ptr {
+	v, err := s.SealFor()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Persistent_SaveParams) HasSealFor() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -196,10 +221,35 @@ func (s Persistent_SaveResults) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Persistent_SaveResults) Equal(o Persistent_SaveResults) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Persistent_SaveResults) SturdyRef() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustSturdyRef is like SturdyRef, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Persistent_SaveResults) MustSturdyRef() capnp.Ptr {
+	v, err := s.SturdyRef()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Persistent_SaveResults) HasSturdyRef() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -402,6 +452,27 @@ func (s RealmGateway_import_Params) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s RealmGateway_import_Params) Equal(o RealmGateway_import_Params) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s RealmGateway_import_Params) Cap() Persistent {
 	p, _ := s.Struct.Ptr(0)
 	return Persistent{Client: p.Interface().Client()}
@@ -425,6 +496,17 @@ func (s RealmGateway_import_Params) Params() (Persistent_SaveParams, error) {
 	return Persistent_SaveParams{Struct: p.Struct()}, err
 }
 
+// MustParams is like Params, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s RealmGateway_import_Params) MustParams() Persistent_SaveParams {
+	v, err := s.Params()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s RealmGateway_import_Params) HasParams() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -507,6 +589,27 @@ func (s RealmGateway_export_Params) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s RealmGateway_export_Params) Equal(o RealmGateway_export_Params) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s RealmGateway_export_Params) Cap() Persistent {
 	p, _ := s.Struct.Ptr(0)
 	return Persistent{Client: p.Interface().Client()}
@@ -530,6 +633,17 @@ func (s RealmGateway_export_Params) Params() (Persistent_SaveParams, error) {
 	return Persistent_SaveParams{Struct: p.Struct()}, err
 }
 
+// MustParams is like Params, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s RealmGateway_export_Params) MustParams() Persistent_SaveParams {
+	v, err := s.Params()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s RealmGateway_export_Params) HasParams() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -88,6 +88,130 @@ func (s Message) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Message) Equal(o Message) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Message_Which(s.Struct.Uint16(0)) {
+	case Message_Which_unimplemented:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_abort:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_bootstrap:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_call:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_return:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_finish:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_resolve:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_release:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_disembargo:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_obsoleteSave:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_obsoleteDelete:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_provide:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_accept:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Message_Which_join:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Message) Which() Message_Which {
 	return Message_Which(s.Struct.Uint16(0))
 }
@@ -99,6 +223,17 @@ func (s Message) Unimplemented() (Message, error) {
 	return Message{Struct: p.Struct()}, err
 }
 
+// MustUnimplemented is like Unimplemented, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustUnimplemented() Message {
+	v, err := s.Unimplemented()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasUnimplemented() bool {
 	if s.Struct.Uint16(0) != 0 {
 		return false
@@ -131,6 +266,17 @@ func (s Message) Abort() (Exception, error) {
 	return Exception{Struct: p.Struct()}, err
 }
 
+// MustAbort is like Abort, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustAbort() Exception {
+	v, err := s.Abort()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasAbort() bool {
 	if s.Struct.Uint16(0) != 1 {
 		return false
@@ -163,6 +309,17 @@ func (s Message) Bootstrap() (Bootstrap, error) {
 	return Bootstrap{Struct: p.Struct()}, err
 }
 
+// MustBootstrap is like Bootstrap, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustBootstrap() Bootstrap {
+	v, err := s.Bootstrap()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasBootstrap() bool {
 	if s.Struct.Uint16(0) != 8 {
 		return false
@@ -195,6 +352,17 @@ func (s Message) Call() (Call, error) {
 	return Call{Struct: p.Struct()}, err
 }
 
+// MustCall is like Call, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustCall() Call {
+	v, err := s.Call()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasCall() bool {
 	if s.Struct.Uint16(0) != 2 {
 		return false
@@ -227,6 +395,17 @@ func (s Message) Return() (Return, error) {
 	return Return{Struct: p.Struct()}, err
 }
 
+// MustReturn is like Return, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustReturn() Return {
+	v, err := s.Return()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasReturn() bool {
 	if s.Struct.Uint16(0) != 3 {
 		return false
@@ -259,6 +438,17 @@ func (s Message) Finish() (Finish, error) {
 	return Finish{Struct: p.Struct()}, err
 }
 
+// MustFinish is like Finish, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustFinish() Finish {
+	v, err := s.Finish()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasFinish() bool {
 	if s.Struct.Uint16(0) != 4 {
 		return false
@@ -291,6 +481,17 @@ func (s Message) Resolve() (Resolve, error) {
 	return Resolve{Struct: p.Struct()}, err
 }
 
+// MustResolve is like Resolve, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustResolve() Resolve {
+	v, err := s.Resolve()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasResolve() bool {
 	if s.Struct.Uint16(0) != 5 {
 		return false
@@ -323,6 +524,17 @@ func (s Message) Release() (Release, error) {
 	return Release{Struct: p.Struct()}, err
 }
 
+// MustRelease is like Release, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustRelease() Release {
+	v, err := s.Release()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasRelease() bool {
 	if s.Struct.Uint16(0) != 6 {
 		return false
@@ -355,6 +567,17 @@ func (s Message) Disembargo() (Disembargo, error) {
 	return Disembargo{Struct: p.Struct()}, err
 }
 
+// MustDisembargo is like Disembargo, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustDisembargo() Disembargo {
+	v, err := s.Disembargo()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasDisembargo() bool {
 	if s.Struct.Uint16(0) != 13 {
 		return false
@@ -386,6 +609,17 @@ func (s Message) ObsoleteSave() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustObsoleteSave is like ObsoleteSave, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustObsoleteSave() capnp.Ptr {
+	v, err := s.ObsoleteSave()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasObsoleteSave() bool {
 	if s.Struct.Uint16(0) != 7 {
 		return false
@@ -405,6 +639,17 @@ func (s Message) ObsoleteDelete() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustObsoleteDelete is like ObsoleteDelete, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustObsoleteDelete() capnp.Ptr {
+	v, err := s.ObsoleteDelete()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasObsoleteDelete() bool {
 	if s.Struct.Uint16(0) != 9 {
 		return false
@@ -425,6 +670,17 @@ func (s Message) Provide() (Provide, error) {
 	return Provide{Struct: p.Struct()}, err
 }
 
+// MustProvide is like Provide, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustProvide() Provide {
+	v, err := s.Provide()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasProvide() bool {
 	if s.Struct.Uint16(0) != 10 {
 		return false
@@ -457,6 +713,17 @@ func (s Message) Accept() (Accept, error) {
 	return Accept{Struct: p.Struct()}, err
 }
 
+// MustAccept is like Accept, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustAccept() Accept {
+	v, err := s.Accept()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasAccept() bool {
 	if s.Struct.Uint16(0) != 11 {
 		return false
@@ -489,6 +756,17 @@ func (s Message) Join() (Join, error) {
 	return Join{Struct: p.Struct()}, err
 }
 
+// MustJoin is like Join, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Message) MustJoin() Join {
+	v, err := s.Join()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Message) HasJoin() bool {
 	if s.Struct.Uint16(0) != 12 {
 		return false
@@ -620,6 +898,23 @@ func (s Bootstrap) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Bootstrap) Equal(o Bootstrap) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Bootstrap) QuestionId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -632,6 +927,17 @@ func (s Bootstrap) DeprecatedObjectId() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustDeprecatedObjectId is like DeprecatedObjectId, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Bootstrap) MustDeprecatedObjectId() capnp.Ptr {
+	v, err := s.DeprecatedObjectId()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Bootstrap) HasDeprecatedObjectId() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -717,6 +1023,52 @@ func (s Call) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Call) Equal(o Call) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+		return false, nil
+	}
+	if s.Struct.Uint16(4) != o.Struct.Uint16(4) {
+		return false, nil
+	}
+	if s.Struct.Bit(128) != o.Struct.Bit(128) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(6) != o.Struct.Uint16(6) {
+		return false, nil
+	}
+	switch Call_sendResultsTo_Which(s.Struct.Uint16(6)) {
+	case Call_sendResultsTo_Which_thirdParty:
+		if p1, err := s.Struct.Ptr(2); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(2); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Call) QuestionId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -730,6 +1082,17 @@ func (s Call) Target() (MessageTarget, error) {
 	return MessageTarget{Struct: p.Struct()}, err
 }
 
+// MustTarget is like Target, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Call) MustTarget() MessageTarget {
+	v, err := s.Target()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Call) HasTarget() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -778,6 +1141,17 @@ func (s Call) Params() (Payload, error) {
 	return Payload{Struct: p.Struct()}, err
 }
 
+// MustParams is like Params, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Call) MustParams() Payload {
+	v, err := s.Params()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Call) HasParams() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -819,6 +1193,17 @@ func (s Call_sendResultsTo) ThirdParty() (capnp.Ptr, error) {
 	return s.Struct.Ptr(2)
 }
 
+// MustThirdParty is like ThirdParty, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Call_sendResultsTo) MustThirdParty() capnp.Ptr {
+	v, err := s.ThirdParty()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Call_sendResultsTo) HasThirdParty() bool {
 	if s.Struct.Uint16(6) != 2 {
 		return false
@@ -936,6 +1321,52 @@ func (s Return) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Return) Equal(o Return) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if s.Struct.Bit(32) != o.Struct.Bit(32) {
+		return false, nil
+	}
+	if s.Struct.Uint16(6) != o.Struct.Uint16(6) {
+		return false, nil
+	}
+	switch Return_Which(s.Struct.Uint16(6)) {
+	case Return_Which_results:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Return_Which_exception:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Return_Which_takeFromOtherQuestion:
+		if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+			return false, nil
+		}
+	case Return_Which_acceptFromThirdParty:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Return) Which() Return_Which {
 	return Return_Which(s.Struct.Uint16(6))
 }
@@ -963,6 +1394,17 @@ func (s Return) Results() (Payload, error) {
 	return Payload{Struct: p.Struct()}, err
 }
 
+// MustResults is like Results, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Return) MustResults() Payload {
+	v, err := s.Results()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Return) HasResults() bool {
 	if s.Struct.Uint16(6) != 0 {
 		return false
@@ -995,6 +1437,17 @@ func (s Return) Exception() (Exception, error) {
 	return Exception{Struct: p.Struct()}, err
 }
 
+// MustException is like Exception, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Return) MustException() Exception {
+	v, err := s.Exception()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Return) HasException() bool {
 	if s.Struct.Uint16(6) != 1 {
 		return false
@@ -1048,6 +1501,17 @@ func (s Return) AcceptFromThirdParty() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustAcceptFromThirdParty is like AcceptFromThirdParty, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Return) MustAcceptFromThirdParty() capnp.Ptr {
+	v, err := s.AcceptFromThirdParty()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Return) HasAcceptFromThirdParty() bool {
 	if s.Struct.Uint16(6) != 5 {
 		return false
@@ -1123,6 +1587,19 @@ func (s Finish) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Finish) Equal(o Finish) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if s.Struct.Bit(32) != o.Struct.Bit(32) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Finish) QuestionId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -1208,6 +1685,37 @@ func (s Resolve) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Resolve) Equal(o Resolve) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if s.Struct.Uint16(4) != o.Struct.Uint16(4) {
+		return false, nil
+	}
+	switch Resolve_Which(s.Struct.Uint16(4)) {
+	case Resolve_Which_cap:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Resolve_Which_exception:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Resolve) Which() Resolve_Which {
 	return Resolve_Which(s.Struct.Uint16(4))
 }
@@ -1227,6 +1735,17 @@ func (s Resolve) Cap() (CapDescriptor, error) {
 	return CapDescriptor{Struct: p.Struct()}, err
 }
 
+// MustCap is like Cap, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Resolve) MustCap() CapDescriptor {
+	v, err := s.Cap()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Resolve) HasCap() bool {
 	if s.Struct.Uint16(4) != 0 {
 		return false
@@ -1259,6 +1778,17 @@ func (s Resolve) Exception() (Exception, error) {
 	return Exception{Struct: p.Struct()}, err
 }
 
+// MustException is like Exception, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Resolve) MustException() Exception {
+	v, err := s.Exception()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Resolve) HasException() bool {
 	if s.Struct.Uint16(4) != 1 {
 		return false
@@ -1342,6 +1872,19 @@ func (s Release) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Release) Equal(o Release) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Release) Id() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -1434,11 +1977,53 @@ func (s Disembargo) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Disembargo) Equal(o Disembargo) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(4) != o.Struct.Uint16(4) {
+		return false, nil
+	}
+	switch Disembargo_context_Which(s.Struct.Uint16(4)) {
+	case Disembargo_context_Which_senderLoopback:
+		if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+			return false, nil
+		}
+	case Disembargo_context_Which_receiverLoopback:
+		if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+			return false, nil
+		}
+	case Disembargo_context_Which_provide:
+		if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (s Disembargo) Target() (MessageTarget, error) {
 	p, err := s.Struct.Ptr(0)
 	return MessageTarget{Struct: p.Struct()}, err
 }
 
+// MustTarget is like Target, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Disembargo) MustTarget() MessageTarget {
+	v, err := s.Target()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Disembargo) HasTarget() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1571,6 +2156,30 @@ func (s Provide) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Provide) Equal(o Provide) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Provide) QuestionId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -1584,6 +2193,17 @@ func (s Provide) Target() (MessageTarget, error) {
 	return MessageTarget{Struct: p.Struct()}, err
 }
 
+// MustTarget is like Target, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Provide) MustTarget() MessageTarget {
+	v, err := s.Target()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Provide) HasTarget() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1607,6 +2227,17 @@ func (s Provide) Recipient() (capnp.Ptr, error) {
 	return s.Struct.Ptr(1)
 }
 
+// MustRecipient is like Recipient, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Provide) MustRecipient() capnp.Ptr {
+	v, err := s.Recipient()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Provide) HasRecipient() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -1674,6 +2305,26 @@ func (s Accept) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Accept) Equal(o Accept) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Bit(32) != o.Struct.Bit(32) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Accept) QuestionId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -1686,6 +2337,17 @@ func (s Accept) Provision() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustProvision is like Provision, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Accept) MustProvision() capnp.Ptr {
+	v, err := s.Provision()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Accept) HasProvision() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1757,6 +2419,30 @@ func (s Join) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Join) Equal(o Join) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Join) QuestionId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -1770,6 +2456,17 @@ func (s Join) Target() (MessageTarget, error) {
 	return MessageTarget{Struct: p.Struct()}, err
 }
 
+// MustTarget is like Target, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Join) MustTarget() MessageTarget {
+	v, err := s.Target()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Join) HasTarget() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1793,6 +2490,17 @@ func (s Join) KeyPart() (capnp.Ptr, error) {
 	return s.Struct.Ptr(1)
 }
 
+// MustKeyPart is like KeyPart, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Join) MustKeyPart() capnp.Ptr {
+	v, err := s.KeyPart()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Join) HasKeyPart() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -1878,6 +2586,30 @@ func (s MessageTarget) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s MessageTarget) Equal(o MessageTarget) (bool, error) {
+	if s.Struct.Uint16(4) != o.Struct.Uint16(4) {
+		return false, nil
+	}
+	switch MessageTarget_Which(s.Struct.Uint16(4)) {
+	case MessageTarget_Which_importedCap:
+		if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+			return false, nil
+		}
+	case MessageTarget_Which_promisedAnswer:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s MessageTarget) Which() MessageTarget_Which {
 	return MessageTarget_Which(s.Struct.Uint16(4))
 }
@@ -1901,6 +2633,17 @@ func (s MessageTarget) PromisedAnswer() (PromisedAnswer, error) {
 	return PromisedAnswer{Struct: p.Struct()}, err
 }
 
+// MustPromisedAnswer is like PromisedAnswer, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s MessageTarget) MustPromisedAnswer() PromisedAnswer {
+	v, err := s.PromisedAnswer()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s MessageTarget) HasPromisedAnswer() bool {
 	if s.Struct.Uint16(4) != 1 {
 		return false
@@ -1980,10 +2723,42 @@ func (s Payload) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Payload) Equal(o Payload) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Payload) Content() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustContent is like Content, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Payload) MustContent() capnp.Ptr {
+	v, err := s.Content()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Payload) HasContent() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1997,6 +2772,17 @@ func (s Payload) CapTable() (CapDescriptor_List, error) {
 	return CapDescriptor_List{List: p.List()}, err
 }
 
+// MustCapTable is like CapTable, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Payload) MustCapTable() CapDescriptor_List {
+	v, err := s.CapTable()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Payload) HasCapTable() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -2101,6 +2887,46 @@ func (s CapDescriptor) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CapDescriptor) Equal(o CapDescriptor) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch CapDescriptor_Which(s.Struct.Uint16(0)) {
+	case CapDescriptor_Which_senderHosted:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case CapDescriptor_Which_senderPromise:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case CapDescriptor_Which_receiverHosted:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case CapDescriptor_Which_receiverAnswer:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case CapDescriptor_Which_thirdPartyHosted:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s CapDescriptor) Which() CapDescriptor_Which {
 	return CapDescriptor_Which(s.Struct.Uint16(0))
 }
@@ -2153,6 +2979,17 @@ func (s CapDescriptor) ReceiverAnswer() (PromisedAnswer, error) {
 	return PromisedAnswer{Struct: p.Struct()}, err
 }
 
+// MustReceiverAnswer is like ReceiverAnswer, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CapDescriptor) MustReceiverAnswer() PromisedAnswer {
+	v, err := s.ReceiverAnswer()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CapDescriptor) HasReceiverAnswer() bool {
 	if s.Struct.Uint16(0) != 4 {
 		return false
@@ -2185,6 +3022,17 @@ func (s CapDescriptor) ThirdPartyHosted() (ThirdPartyCapDescriptor, error) {
 	return ThirdPartyCapDescriptor{Struct: p.Struct()}, err
 }
 
+// MustThirdPartyHosted is like ThirdPartyHosted, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CapDescriptor) MustThirdPartyHosted() ThirdPartyCapDescriptor {
+	v, err := s.ThirdPartyHosted()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CapDescriptor) HasThirdPartyHosted() bool {
 	if s.Struct.Uint16(0) != 5 {
 		return false
@@ -2268,6 +3116,23 @@ func (s PromisedAnswer) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s PromisedAnswer) Equal(o PromisedAnswer) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s PromisedAnswer) QuestionId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -2281,6 +3146,17 @@ func (s PromisedAnswer) Transform() (PromisedAnswer_Op_List, error) {
 	return PromisedAnswer_Op_List{List: p.List()}, err
 }
 
+// MustTransform is like Transform, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s PromisedAnswer) MustTransform() PromisedAnswer_Op_List {
+	v, err := s.Transform()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s PromisedAnswer) HasTransform() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2369,6 +3245,22 @@ func (s PromisedAnswer_Op) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s PromisedAnswer_Op) Equal(o PromisedAnswer_Op) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch PromisedAnswer_Op_Which(s.Struct.Uint16(0)) {
+	case PromisedAnswer_Op_Which_getPointerField:
+		if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (s PromisedAnswer_Op) Which() PromisedAnswer_Op_Which {
 	return PromisedAnswer_Op_Which(s.Struct.Uint16(0))
 }
@@ -2444,10 +3336,38 @@ func (s ThirdPartyCapDescriptor) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s ThirdPartyCapDescriptor) Equal(o ThirdPartyCapDescriptor) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s ThirdPartyCapDescriptor) Id() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustId is like Id, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s ThirdPartyCapDescriptor) MustId() capnp.Ptr {
+	v, err := s.Id()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s ThirdPartyCapDescriptor) HasId() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2523,11 +3443,45 @@ func (s Exception) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Exception) Equal(o Exception) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(4) != o.Struct.Uint16(4) {
+		return false, nil
+	}
+	if s.Struct.Bit(0) != o.Struct.Bit(0) {
+		return false, nil
+	}
+	if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Exception) Reason() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustReason is like Reason, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Exception) MustReason() string {
+	v, err := s.Reason()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Exception) HasReason() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -88,6 +88,16 @@ func (s VatId) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s VatId) Equal(o VatId) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s VatId) Side() Side {
 	return Side(s.Struct.Uint16(0))
 }
@@ -147,6 +157,16 @@ func (s ProvisionId) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s ProvisionId) Equal(o ProvisionId) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s ProvisionId) JoinId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -206,6 +226,13 @@ func (s RecipientId) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s RecipientId) Equal(o RecipientId) (bool, error) {
+	return true, nil
+}
+
 // RecipientId_List is a list of RecipientId.
 type RecipientId_List struct{ capnp.List }
 
@@ -257,6 +284,13 @@ func (s ThirdPartyCapId) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s ThirdPartyCapId) Equal(o ThirdPartyCapId) (bool, error) {
+	return true, nil
+}
+
 // ThirdPartyCapId_List is a list of ThirdPartyCapId.
 type ThirdPartyCapId_List struct{ capnp.List }
 
@@ -310,6 +344,22 @@ func (s JoinKeyPart) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s JoinKeyPart) Equal(o JoinKeyPart) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if s.Struct.Uint16(4) != o.Struct.Uint16(4) {
+		return false, nil
+	}
+	if s.Struct.Uint16(6) != o.Struct.Uint16(6) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s JoinKeyPart) JoinId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -385,6 +435,26 @@ func (s JoinResult) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s JoinResult) Equal(o JoinResult) (bool, error) {
+	if s.Struct.Uint32(0) != o.Struct.Uint32(0) {
+		return false, nil
+	}
+	if s.Struct.Bit(32) != o.Struct.Bit(32) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s JoinResult) JoinId() uint32 {
 	return s.Struct.Uint32(0)
 }
@@ -405,6 +475,17 @@ func (s JoinResult) Cap() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustCap is like Cap, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s JoinResult) MustCap() capnp.Ptr {
+	v, err := s.Cap()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s JoinResult) HasCap() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -75,6 +75,166 @@ func (s Node) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Node) Equal(o Node) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint32(8) != o.Struct.Uint32(8) {
+		return false, nil
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(5); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(5); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Bit(288) != o.Struct.Bit(288) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(12) != o.Struct.Uint16(12) {
+		return false, nil
+	}
+	switch Node_Which(s.Struct.Uint16(12)) {
+	case Node_Which_structNode:
+		if s.Struct.Uint16(14) != o.Struct.Uint16(14) {
+			return false, nil
+		}
+		if s.Struct.Uint16(24) != o.Struct.Uint16(24) {
+			return false, nil
+		}
+		if s.Struct.Uint16(26) != o.Struct.Uint16(26) {
+			return false, nil
+		}
+		if s.Struct.Bit(224) != o.Struct.Bit(224) {
+			return false, nil
+		}
+		if s.Struct.Uint16(30) != o.Struct.Uint16(30) {
+			return false, nil
+		}
+		if s.Struct.Uint32(32) != o.Struct.Uint32(32) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_enum:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_interface:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if p1, err := s.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_const:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if p1, err := s.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(4); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Node_Which_annotation:
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if s.Struct.Bit(112) != o.Struct.Bit(112) {
+			return false, nil
+		}
+		if s.Struct.Bit(113) != o.Struct.Bit(113) {
+			return false, nil
+		}
+		if s.Struct.Bit(114) != o.Struct.Bit(114) {
+			return false, nil
+		}
+		if s.Struct.Bit(115) != o.Struct.Bit(115) {
+			return false, nil
+		}
+		if s.Struct.Bit(116) != o.Struct.Bit(116) {
+			return false, nil
+		}
+		if s.Struct.Bit(117) != o.Struct.Bit(117) {
+			return false, nil
+		}
+		if s.Struct.Bit(118) != o.Struct.Bit(118) {
+			return false, nil
+		}
+		if s.Struct.Bit(119) != o.Struct.Bit(119) {
+			return false, nil
+		}
+		if s.Struct.Bit(120) != o.Struct.Bit(120) {
+			return false, nil
+		}
+		if s.Struct.Bit(121) != o.Struct.Bit(121) {
+			return false, nil
+		}
+		if s.Struct.Bit(122) != o.Struct.Bit(122) {
+			return false, nil
+		}
+		if s.Struct.Bit(123) != o.Struct.Bit(123) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (s Node) Which() Node_Which {
 	return Node_Which(s.Struct.Uint16(12))
 }
@@ -91,6 +251,17 @@ func (s Node) DisplayName() (string, error) {
 	return p.Text(), err
 }
 
+// MustDisplayName is like DisplayName, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustDisplayName() string {
+	v, err := s.DisplayName()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasDisplayName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -125,6 +296,17 @@ func (s Node) Parameters() (Node_Parameter_List, error) {
 	return Node_Parameter_List{List: p.List()}, err
 }
 
+// MustParameters is like Parameters, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustParameters() Node_Parameter_List {
+	v, err := s.Parameters()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasParameters() bool {
 	return s.Struct.HasPtr(5)
 }
@@ -157,6 +339,17 @@ func (s Node) NestedNodes() (Node_NestedNode_List, error) {
 	return Node_NestedNode_List{List: p.List()}, err
 }
 
+// MustNestedNodes is like NestedNodes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustNestedNodes() Node_NestedNode_List {
+	v, err := s.NestedNodes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasNestedNodes() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -181,6 +374,17 @@ func (s Node) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node) HasAnnotations() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -264,6 +468,17 @@ func (s Node_structNode) Fields() (Field_List, error) {
 	return Field_List{List: p.List()}, err
 }
 
+// MustFields is like Fields, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_structNode) MustFields() Field_List {
+	v, err := s.Fields()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_structNode) HasFields() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -294,6 +509,17 @@ func (s Node_enum) Enumerants() (Enumerant_List, error) {
 	return Enumerant_List{List: p.List()}, err
 }
 
+// MustEnumerants is like Enumerants, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_enum) MustEnumerants() Enumerant_List {
+	v, err := s.Enumerants()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_enum) HasEnumerants() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -324,6 +550,17 @@ func (s Node_interface) Methods() (Method_List, error) {
 	return Method_List{List: p.List()}, err
 }
 
+// MustMethods is like Methods, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_interface) MustMethods() Method_List {
+	v, err := s.Methods()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_interface) HasMethods() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -348,6 +585,17 @@ func (s Node_interface) Superclasses() (Superclass_List, error) {
 	return Superclass_List{List: p.List()}, err
 }
 
+// MustSuperclasses is like Superclasses, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_interface) MustSuperclasses() Superclass_List {
+	v, err := s.Superclasses()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_interface) HasSuperclasses() bool {
 	return s.Struct.HasPtr(4)
 }
@@ -378,6 +626,17 @@ func (s Node_const) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_const) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_const) HasType() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -402,6 +661,17 @@ func (s Node_const) Value() (Value, error) {
 	return Value{Struct: p.Struct()}, err
 }
 
+// MustValue is like Value, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_const) MustValue() Value {
+	v, err := s.Value()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_const) HasValue() bool {
 	return s.Struct.HasPtr(4)
 }
@@ -432,6 +702,17 @@ func (s Node_annotation) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_annotation) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_annotation) HasType() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -660,11 +941,36 @@ func (s Node_Parameter) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Node_Parameter) Equal(o Node_Parameter) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Node_Parameter) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_Parameter) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_Parameter) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -729,11 +1035,39 @@ func (s Node_NestedNode) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Node_NestedNode) Equal(o Node_NestedNode) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	return true, nil
+}
+
 func (s Node_NestedNode) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Node_NestedNode) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Node_NestedNode) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -848,6 +1182,72 @@ func (s Field) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Field) Equal(o Field) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+		return false, nil
+	}
+	if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+		return false, nil
+	}
+	switch Field_ordinal_Which(s.Struct.Uint16(10)) {
+	case Field_ordinal_Which_explicit:
+		if s.Struct.Uint16(12) != o.Struct.Uint16(12) {
+			return false, nil
+		}
+	}
+	if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+		return false, nil
+	}
+	switch Field_Which(s.Struct.Uint16(8)) {
+	case Field_Which_slot:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(2); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(2); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if p1, err := s.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(3); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+		if s.Struct.Bit(128) != o.Struct.Bit(128) {
+			return false, nil
+		}
+	case Field_Which_group:
+		if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
 func (s Field) Which() Field_Which {
 	return Field_Which(s.Struct.Uint16(8))
 }
@@ -856,6 +1256,17 @@ func (s Field) Name() (string, error) {
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -882,6 +1293,17 @@ func (s Field) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field) HasAnnotations() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -928,6 +1350,17 @@ func (s Field_slot) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field_slot) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field_slot) HasType() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -952,6 +1385,17 @@ func (s Field_slot) DefaultValue() (Value, error) {
 	return Value{Struct: p.Struct()}, err
 }
 
+// MustDefaultValue is like DefaultValue, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Field_slot) MustDefaultValue() Value {
+	v, err := s.DefaultValue()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Field_slot) HasDefaultValue() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -1104,11 +1548,46 @@ func (s Enumerant) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Enumerant) Equal(o Enumerant) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Enumerant) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Enumerant) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Enumerant) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1135,6 +1614,17 @@ func (s Enumerant) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Enumerant) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Enumerant) HasAnnotations() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -1205,6 +1695,23 @@ func (s Superclass) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Superclass) Equal(o Superclass) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Superclass) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -1218,6 +1725,17 @@ func (s Superclass) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Superclass) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Superclass) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1292,11 +1810,73 @@ func (s Method) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Method) Equal(o Method) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(4); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(4); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(2); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(3); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(3); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Method) Name() (string, error) {
 	p, err := s.Struct.Ptr(0)
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1323,6 +1903,17 @@ func (s Method) ImplicitParameters() (Node_Parameter_List, error) {
 	return Node_Parameter_List{List: p.List()}, err
 }
 
+// MustImplicitParameters is like ImplicitParameters, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustImplicitParameters() Node_Parameter_List {
+	v, err := s.ImplicitParameters()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasImplicitParameters() bool {
 	return s.Struct.HasPtr(4)
 }
@@ -1355,6 +1946,17 @@ func (s Method) ParamBrand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustParamBrand is like ParamBrand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustParamBrand() Brand {
+	v, err := s.ParamBrand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasParamBrand() bool {
 	return s.Struct.HasPtr(2)
 }
@@ -1387,6 +1989,17 @@ func (s Method) ResultBrand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustResultBrand is like ResultBrand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustResultBrand() Brand {
+	v, err := s.ResultBrand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasResultBrand() bool {
 	return s.Struct.HasPtr(3)
 }
@@ -1411,6 +2024,17 @@ func (s Method) Annotations() (Annotation_List, error) {
 	return Annotation_List{List: p.List()}, err
 }
 
+// MustAnnotations is like Annotations, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Method) MustAnnotations() Annotation_List {
+	v, err := s.Annotations()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Method) HasAnnotations() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -1613,6 +2237,80 @@ func (s Type) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Type) Equal(o Type) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Type_Which(s.Struct.Uint16(0)) {
+	case Type_Which_list:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_enum:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_structType:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_interface:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Type_Which_anyPointer:
+		if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+			return false, nil
+		}
+		switch Type_anyPointer_Which(s.Struct.Uint16(8)) {
+		case Type_anyPointer_Which_unconstrained:
+			if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+				return false, nil
+			}
+		case Type_anyPointer_Which_parameter:
+			if s.Struct.Uint64(16) != o.Struct.Uint64(16) {
+				return false, nil
+			}
+			if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+				return false, nil
+			}
+		case Type_anyPointer_Which_implicitMethodParameter:
+			if s.Struct.Uint16(10) != o.Struct.Uint16(10) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
 func (s Type) Which() Type_Which {
 	return Type_Which(s.Struct.Uint16(0))
 }
@@ -1697,6 +2395,17 @@ func (s Type_list) ElementType() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustElementType is like ElementType, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_list) MustElementType() Type {
+	v, err := s.ElementType()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_list) HasElementType() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1735,6 +2444,17 @@ func (s Type_enum) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_enum) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_enum) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1773,6 +2493,17 @@ func (s Type_structType) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_structType) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_structType) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -1811,6 +2542,17 @@ func (s Type_interface) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Type_interface) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Type_interface) HasBrand() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2061,11 +2803,36 @@ func (s Brand) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Brand) Equal(o Brand) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Brand) Scopes() (Brand_Scope_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return Brand_Scope_List{List: p.List()}, err
 }
 
+// MustScopes is like Scopes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Brand) MustScopes() Brand_Scope_List {
+	v, err := s.Scopes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Brand) HasScopes() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2154,6 +2921,29 @@ func (s Brand_Scope) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Brand_Scope) Equal(o Brand_Scope) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if s.Struct.Uint16(8) != o.Struct.Uint16(8) {
+		return false, nil
+	}
+	switch Brand_Scope_Which(s.Struct.Uint16(8)) {
+	case Brand_Scope_Which_bind:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Brand_Scope) Which() Brand_Scope_Which {
 	return Brand_Scope_Which(s.Struct.Uint16(8))
 }
@@ -2173,6 +2963,17 @@ func (s Brand_Scope) Bind() (Brand_Binding_List, error) {
 	return Brand_Binding_List{List: p.List()}, err
 }
 
+// MustBind is like Bind, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Brand_Scope) MustBind() Brand_Binding_List {
+	v, err := s.Bind()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Brand_Scope) HasBind() bool {
 	if s.Struct.Uint16(8) != 0 {
 		return false
@@ -2271,6 +3072,26 @@ func (s Brand_Binding) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Brand_Binding) Equal(o Brand_Binding) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Brand_Binding_Which(s.Struct.Uint16(0)) {
+	case Brand_Binding_Which_type:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Brand_Binding) Which() Brand_Binding_Which {
 	return Brand_Binding_Which(s.Struct.Uint16(0))
 }
@@ -2287,6 +3108,17 @@ func (s Brand_Binding) Type() (Type, error) {
 	return Type{Struct: p.Struct()}, err
 }
 
+// MustType is like Type, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Brand_Binding) MustType() Type {
+	v, err := s.Type()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Brand_Binding) HasType() bool {
 	if s.Struct.Uint16(0) != 1 {
 		return false
@@ -2435,6 +3267,106 @@ func (s Value) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Value) Equal(o Value) (bool, error) {
+	if s.Struct.Uint16(0) != o.Struct.Uint16(0) {
+		return false, nil
+	}
+	switch Value_Which(s.Struct.Uint16(0)) {
+	case Value_Which_bool:
+		if s.Struct.Bit(16) != o.Struct.Bit(16) {
+			return false, nil
+		}
+	case Value_Which_int8:
+		if s.Struct.Uint8(2) != o.Struct.Uint8(2) {
+			return false, nil
+		}
+	case Value_Which_int16:
+		if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+			return false, nil
+		}
+	case Value_Which_int32:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case Value_Which_int64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Value_Which_uint8:
+		if s.Struct.Uint8(2) != o.Struct.Uint8(2) {
+			return false, nil
+		}
+	case Value_Which_uint16:
+		if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+			return false, nil
+		}
+	case Value_Which_uint32:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case Value_Which_uint64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Value_Which_float32:
+		if s.Struct.Uint32(4) != o.Struct.Uint32(4) {
+			return false, nil
+		}
+	case Value_Which_float64:
+		if s.Struct.Uint64(8) != o.Struct.Uint64(8) {
+			return false, nil
+		}
+	case Value_Which_text:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_data:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_list:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_enum:
+		if s.Struct.Uint16(2) != o.Struct.Uint16(2) {
+			return false, nil
+		}
+	case Value_Which_structValue:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	case Value_Which_anyPointer:
+		if p1, err := s.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if p2, err := o.Struct.Ptr(0); err != nil {
+			return false, err
+		} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
 func (s Value) Which() Value_Which {
 	return Value_Which(s.Struct.Uint16(0))
 }
@@ -2583,6 +3515,17 @@ func (s Value) Text() (string, error) {
 	return p.Text(), err
 }
 
+// MustText is like Text, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustText() string {
+	v, err := s.Text()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasText() bool {
 	if s.Struct.Uint16(0) != 12 {
 		return false
@@ -2608,6 +3551,17 @@ func (s Value) Data() ([]byte, error) {
 	return []byte(p.Data()), err
 }
 
+// MustData is like Data, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustData() []byte {
+	v, err := s.Data()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasData() bool {
 	if s.Struct.Uint16(0) != 13 {
 		return false
@@ -2627,6 +3581,17 @@ func (s Value) List() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustList is like List, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustList() capnp.Ptr {
+	v, err := s.List()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasList() bool {
 	if s.Struct.Uint16(0) != 14 {
 		return false
@@ -2658,6 +3623,17 @@ func (s Value) StructValue() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustStructValue is like StructValue, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustStructValue() capnp.Ptr {
+	v, err := s.StructValue()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasStructValue() bool {
 	if s.Struct.Uint16(0) != 16 {
 		return false
@@ -2682,6 +3658,17 @@ func (s Value) AnyPointer() (capnp.Ptr, error) {
 	return s.Struct.Ptr(0)
 }
 
+// MustAnyPointer is like AnyPointer, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Value) MustAnyPointer() capnp.Ptr {
+	v, err := s.AnyPointer()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Value) HasAnyPointer() bool {
 	if s.Struct.Uint16(0) != 18 {
 		return false
@@ -2757,6 +3744,30 @@ func (s Annotation) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s Annotation) Equal(o Annotation) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s Annotation) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -2770,6 +3781,17 @@ func (s Annotation) Brand() (Brand, error) {
 	return Brand{Struct: p.Struct()}, err
 }
 
+// MustBrand is like Brand, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Annotation) MustBrand() Brand {
+	v, err := s.Brand()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Annotation) HasBrand() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -2794,6 +3816,17 @@ func (s Annotation) Value() (Value, error) {
 	return Value{Struct: p.Struct()}, err
 }
 
+// MustValue is like Value, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s Annotation) MustValue() Value {
+	v, err := s.Value()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s Annotation) HasValue() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2957,11 +3990,43 @@ func (s CodeGeneratorRequest) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CodeGeneratorRequest) Equal(o CodeGeneratorRequest) (bool, error) {
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s CodeGeneratorRequest) Nodes() (Node_List, error) {
 	p, err := s.Struct.Ptr(0)
 	return Node_List{List: p.List()}, err
 }
 
+// MustNodes is like Nodes, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest) MustNodes() Node_List {
+	v, err := s.Nodes()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest) HasNodes() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -2986,6 +4051,17 @@ func (s CodeGeneratorRequest) RequestedFiles() (CodeGeneratorRequest_RequestedFi
 	return CodeGeneratorRequest_RequestedFile_List{List: p.List()}, err
 }
 
+// MustRequestedFiles is like RequestedFiles, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest) MustRequestedFiles() CodeGeneratorRequest_RequestedFile_List {
+	v, err := s.RequestedFiles()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest) HasRequestedFiles() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -3060,6 +4136,30 @@ func (s CodeGeneratorRequest_RequestedFile) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CodeGeneratorRequest_RequestedFile) Equal(o CodeGeneratorRequest_RequestedFile) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	if p1, err := s.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(1); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s CodeGeneratorRequest_RequestedFile) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -3073,6 +4173,17 @@ func (s CodeGeneratorRequest_RequestedFile) Filename() (string, error) {
 	return p.Text(), err
 }
 
+// MustFilename is like Filename, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest_RequestedFile) MustFilename() string {
+	v, err := s.Filename()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest_RequestedFile) HasFilename() bool {
 	return s.Struct.HasPtr(0)
 }
@@ -3091,6 +4202,17 @@ func (s CodeGeneratorRequest_RequestedFile) Imports() (CodeGeneratorRequest_Requ
 	return CodeGeneratorRequest_RequestedFile_Import_List{List: p.List()}, err
 }
 
+// MustImports is like Imports, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest_RequestedFile) MustImports() CodeGeneratorRequest_RequestedFile_Import_List {
+	v, err := s.Imports()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest_RequestedFile) HasImports() bool {
 	return s.Struct.HasPtr(1)
 }
@@ -3165,6 +4287,23 @@ func (s CodeGeneratorRequest_RequestedFile_Import) String() string {
 	return str
 }
 
+// Equal reports whether s and o hold the same field values.  Only the
+// active member of each union is compared.  Fields are compared in their
+// encoded form, so default values do not need to be applied.
+func (s CodeGeneratorRequest_RequestedFile_Import) Equal(o CodeGeneratorRequest_RequestedFile_Import) (bool, error) {
+	if s.Struct.Uint64(0) != o.Struct.Uint64(0) {
+		return false, nil
+	}
+	if p1, err := s.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if p2, err := o.Struct.Ptr(0); err != nil {
+		return false, err
+	} else if eq, err := capnp.Equal(p1, p2); !eq || err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (s CodeGeneratorRequest_RequestedFile_Import) Id() uint64 {
 	return s.Struct.Uint64(0)
 }
@@ -3178,6 +4317,17 @@ func (s CodeGeneratorRequest_RequestedFile_Import) Name() (string, error) {
 	return p.Text(), err
 }
 
+// MustName is like Name, but panics if the
+// field cannot be read.  It is intended for messages that the caller
+// constructed and trusts.
+func (s CodeGeneratorRequest_RequestedFile_Import) MustName() string {
+	v, err := s.Name()
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 func (s CodeGeneratorRequest_RequestedFile_Import) HasName() bool {
 	return s.Struct.HasPtr(0)
 }
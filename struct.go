@@ -1,5 +1,7 @@
 package capnp
 
+import "encoding/binary"
+
 // Struct is a pointer to a struct.
 type Struct struct {
 	seg        *Segment
@@ -87,6 +89,58 @@ func (p Struct) CopyFrom(other Struct) error {
 	return nil
 }
 
+// AsRootOf returns a read-only message whose root is p.  AsRootOf
+// allocates and copies the first segment of p's message, which for a
+// single-segment message is the whole message: the root pointer must be
+// the first word of that segment, and far pointers refer to segments by
+// number, so it can be neither shared nor renumbered.  Every other
+// segment and the capability table are shared with p's message without
+// copying.  The returned message is only valid as long as p's message
+// is not modified or reset, and it must not be reset itself, since that
+// would release the shared capabilities.
+func (p Struct) AsRootOf() (*Message, error) {
+	if p.seg == nil {
+		return nil, newError("struct as root: invalid struct")
+	}
+	msg := p.seg.msg
+	n := msg.NumSegments()
+	segs := make([][]byte, n)
+	for i := range segs {
+		seg, err := msg.Segment(SegmentID(i))
+		if err != nil {
+			return nil, annotate(err).errorf("struct as root")
+		}
+		segs[i] = seg.data[:len(seg.data):len(seg.data)]
+	}
+
+	// Copy the first segment, appending a landing pad if p is in another
+	// segment.
+	padAddr := address(len(segs[0]))
+	first := make([]byte, len(segs[0]), len(segs[0])+int(wordSize)*2)
+	copy(first, segs[0])
+	var root rawPointer
+	switch {
+	case p.size.isZero():
+		root = rawStructPointer(-1, ObjectSize{})
+	case p.seg.id == 0:
+		root = rawStructPointer(nearPointerOffset(0, p.off), p.size)
+	default:
+		first = first[:len(first)+int(wordSize)*2]
+		binary.LittleEndian.PutUint64(first[padAddr:], uint64(rawFarPointer(p.seg.id, p.off)))
+		binary.LittleEndian.PutUint64(first[padAddr+address(wordSize):], uint64(rawStructPointer(0, p.size)))
+		root = rawDoubleFarPointer(0, padAddr)
+	}
+	binary.LittleEndian.PutUint64(first, uint64(root))
+	segs[0] = first[:len(first):len(first)]
+
+	return &Message{
+		Arena:         roMultiSegment(segs),
+		CapTable:      msg.CapTable,
+		TraverseLimit: msg.TraverseLimit,
+		DepthLimit:    msg.DepthLimit,
+	}, nil
+}
+
 // readSize returns the struct's size for the purposes of read limit
 // accounting.
 func (p Struct) readSize() Size {
@@ -121,6 +175,26 @@ func (p Struct) SetPtr(i uint16, src Ptr) error {
 	return p.seg.writePtr(p.pointerAddress(i), src, false)
 }
 
+// SharePtr sets the i'th pointer in the struct to refer to the same
+// object as src without copying it.  Unlike SetPtr, SharePtr returns an
+// error instead of making a copy when src is in a different message or
+// is an element of a struct list, since neither can be referenced
+// directly.
+func (p Struct) SharePtr(i uint16, src Ptr) error {
+	if p.seg == nil || i >= p.size.PointerCount {
+		panic("capnp: set field outside struct boundaries")
+	}
+	if src.IsValid() {
+		if src.seg.msg != p.seg.msg {
+			return newError("share pointer: source is in a different message")
+		}
+		if src.flags.ptrType() == structPtrType && src.flags.structFlags()&isListMember != 0 {
+			return newError("share pointer: source is a list element")
+		}
+	}
+	return p.seg.writePtr(p.pointerAddress(i), src, false)
+}
+
 // SetText sets the i'th pointer to a newly allocated text or null if v is empty.
 func (p Struct) SetText(i uint16, v string) error {
 	if v == "" {
@@ -162,6 +236,23 @@ func (p Struct) SetData(i uint16, v []byte) error {
 	return p.SetPtr(i, d.List.ToPtr())
 }
 
+// DataInto copies the data that the i'th pointer refers to into dst and
+// returns the number of bytes copied.  A null pointer, or a pointer that
+// does not refer to data, copies nothing.  If dst is too small to hold
+// the data, DataInto copies nothing and returns the required size along
+// with an error, so that the caller can grow its buffer and retry.
+func (p Struct) DataInto(i uint16, dst []byte) (int, error) {
+	ptr, err := p.Ptr(i)
+	if err != nil {
+		return 0, annotate(err).errorf("data into")
+	}
+	b := ptr.Data()
+	if len(b) > len(dst) {
+		return len(b), errorf("data into: field needs %d bytes, buffer has %d", len(b), len(dst))
+	}
+	return copy(dst, b), nil
+}
+
 func (p Struct) pointerAddress(i uint16) address {
 	// Struct already had bounds check
 	ptrStart, _ := p.off.addSize(p.size.DataSize)
@@ -175,6 +266,11 @@ func (p Struct) bitInData(bit BitOffset) bool {
 }
 
 // Bit returns the bit that is n bits from the start of the struct.
+//
+// Bit and the other data section accessors read and write the raw
+// encoded value.  A field with a non-zero default in its schema is
+// stored XORed with the default; the accessors ending in Default, like
+// BitDefault and Uint32Default, apply the XOR.
 func (p Struct) Bit(n BitOffset) bool {
 	if !p.bitInData(n) {
 		return false
@@ -277,6 +373,176 @@ func (p Struct) SetUint64(off DataOffset, v uint64) {
 	p.seg.writeUint64(addr, v)
 }
 
+// Int8 returns a signed 8-bit integer from the struct's data section.
+func (p Struct) Int8(off DataOffset) int8 {
+	return int8(p.Uint8(off))
+}
+
+// Int16 returns a signed 16-bit integer from the struct's data section.
+func (p Struct) Int16(off DataOffset) int16 {
+	return int16(p.Uint16(off))
+}
+
+// Int32 returns a signed 32-bit integer from the struct's data section.
+func (p Struct) Int32(off DataOffset) int32 {
+	return int32(p.Uint32(off))
+}
+
+// Int64 returns a signed 64-bit integer from the struct's data section.
+func (p Struct) Int64(off DataOffset) int64 {
+	return int64(p.Uint64(off))
+}
+
+// SetInt8 sets the signed 8-bit integer that is off bytes from the start of the struct to v.
+func (p Struct) SetInt8(off DataOffset, v int8) {
+	p.SetUint8(off, uint8(v))
+}
+
+// SetInt16 sets the signed 16-bit integer that is off bytes from the start of the struct to v.
+func (p Struct) SetInt16(off DataOffset, v int16) {
+	p.SetUint16(off, uint16(v))
+}
+
+// SetInt32 sets the signed 32-bit integer that is off bytes from the start of the struct to v.
+func (p Struct) SetInt32(off DataOffset, v int32) {
+	p.SetUint32(off, uint32(v))
+}
+
+// SetInt64 sets the signed 64-bit integer that is off bytes from the start of the struct to v.
+func (p Struct) SetInt64(off DataOffset, v int64) {
+	p.SetUint64(off, uint64(v))
+}
+
+// BitDefault returns the bit that is n bits from the start of the
+// struct, for a field whose schema default is def.
+func (p Struct) BitDefault(n BitOffset, def bool) bool {
+	return p.Bit(n) != def
+}
+
+// SetBitDefault sets the bit that is n bits from the start of the
+// struct to v, for a field whose schema default is def.
+func (p Struct) SetBitDefault(n BitOffset, v, def bool) {
+	p.SetBit(n, v != def)
+}
+
+// Uint8Default returns an 8-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Uint8Default(off DataOffset, def uint8) uint8 {
+	return p.Uint8(off) ^ def
+}
+
+// Uint16Default returns a 16-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Uint16Default(off DataOffset, def uint16) uint16 {
+	return p.Uint16(off) ^ def
+}
+
+// Uint32Default returns a 32-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Uint32Default(off DataOffset, def uint32) uint32 {
+	return p.Uint32(off) ^ def
+}
+
+// Uint64Default returns a 64-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Uint64Default(off DataOffset, def uint64) uint64 {
+	return p.Uint64(off) ^ def
+}
+
+// Int8Default returns a signed 8-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Int8Default(off DataOffset, def int8) int8 {
+	return p.Int8(off) ^ def
+}
+
+// Int16Default returns a signed 16-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Int16Default(off DataOffset, def int16) int16 {
+	return p.Int16(off) ^ def
+}
+
+// Int32Default returns a signed 32-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Int32Default(off DataOffset, def int32) int32 {
+	return p.Int32(off) ^ def
+}
+
+// Int64Default returns a signed 64-bit integer from the struct's data
+// section, for a field whose schema default is def.
+func (p Struct) Int64Default(off DataOffset, def int64) int64 {
+	return p.Int64(off) ^ def
+}
+
+// SetUint8Default sets the 8-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetUint8Default(off DataOffset, v, def uint8) {
+	p.SetUint8(off, v^def)
+}
+
+// SetUint16Default sets the 16-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetUint16Default(off DataOffset, v, def uint16) {
+	p.SetUint16(off, v^def)
+}
+
+// SetUint32Default sets the 32-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetUint32Default(off DataOffset, v, def uint32) {
+	p.SetUint32(off, v^def)
+}
+
+// SetUint64Default sets the 64-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetUint64Default(off DataOffset, v, def uint64) {
+	p.SetUint64(off, v^def)
+}
+
+// SetInt8Default sets the signed 8-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetInt8Default(off DataOffset, v, def int8) {
+	p.SetInt8(off, v^def)
+}
+
+// SetInt16Default sets the signed 16-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetInt16Default(off DataOffset, v, def int16) {
+	p.SetInt16(off, v^def)
+}
+
+// SetInt32Default sets the signed 32-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetInt32Default(off DataOffset, v, def int32) {
+	p.SetInt32(off, v^def)
+}
+
+// SetInt64Default sets the signed 64-bit integer that is off bytes from
+// the start of the struct to v, for a field whose schema default is def.
+func (p Struct) SetInt64Default(off DataOffset, v, def int64) {
+	p.SetInt64(off, v^def)
+}
+
+// An AnyStruct is a struct of unknown type, such as an element of a
+// List(AnyPointer) or List(AnyStruct).  It gives generic code access
+// to the struct's raw sections: Size and Ptr are provided by the
+// embedded Struct.
+type AnyStruct struct{ Struct }
+
+// NewAnyStruct converts p to an AnyStruct.  If p does not hold a Struct
+// pointer, the zero value is returned.
+func NewAnyStruct(p Ptr) AnyStruct {
+	return AnyStruct{p.Struct()}
+}
+
+// Data returns the struct's data section.  The returned slice refers
+// directly to the segment's data.  Data returns nil for an invalid or
+// zero-sized struct.
+func (s AnyStruct) Data() []byte {
+	if s.seg == nil || s.size.DataSize == 0 {
+		return nil
+	}
+	return s.seg.slice(s.off, s.size.DataSize)
+}
+
 // structFlags is a bitmask of flags for a pointer.
 type structFlags uint8
 
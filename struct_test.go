@@ -0,0 +1,543 @@
+package capnp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAnyStruct(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := NewPointerList(seg, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	small, err := NewStruct(seg, ObjectSize{DataSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	small.SetUint64(0, 0x0807060504030201)
+	if err := list.Set(0, small.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	big, err := NewStruct(seg, ObjectSize{DataSize: 16, PointerCount: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	big.SetUint8(8, 0xff)
+	if err := big.SetText(0, "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Set(1, big.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+	empty, err := NewStruct(seg, ObjectSize{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := list.Set(2, empty.ToPtr()); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		size ObjectSize
+		data []byte
+		text string
+	}{
+		{ObjectSize{DataSize: 8}, []byte{1, 2, 3, 4, 5, 6, 7, 8}, ""},
+		{ObjectSize{DataSize: 16, PointerCount: 1}, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0, 0, 0, 0, 0, 0, 0}, "hi"},
+		{ObjectSize{}, nil, ""},
+	}
+	for i, test := range tests {
+		p, err := list.At(i)
+		if err != nil {
+			t.Errorf("list.At(%d): %v", i, err)
+			continue
+		}
+		s := NewAnyStruct(p)
+		if !s.IsValid() {
+			t.Errorf("NewAnyStruct(list.At(%d)) is invalid", i)
+			continue
+		}
+		if s.Size() != test.size {
+			t.Errorf("NewAnyStruct(list.At(%d)).Size() = %v; want %v", i, s.Size(), test.size)
+		}
+		if data := s.Data(); !bytes.Equal(data, test.data) {
+			t.Errorf("NewAnyStruct(list.At(%d)).Data() = % 02x; want % 02x", i, data, test.data)
+		}
+		if test.size.PointerCount == 0 {
+			continue
+		}
+		ptr, err := s.Ptr(0)
+		if err != nil {
+			t.Errorf("NewAnyStruct(list.At(%d)).Ptr(0): %v", i, err)
+		} else if txt := ptr.Text(); txt != test.text {
+			t.Errorf("NewAnyStruct(list.At(%d)).Ptr(0).Text() = %q; want %q", i, txt, test.text)
+		}
+	}
+
+	if s := NewAnyStruct(Ptr{}); s.IsValid() || s.Data() != nil {
+		t.Errorf("NewAnyStruct(Ptr{}) = %#v; want invalid with nil data", s)
+	}
+}
+
+func TestSharePtr(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := NewRootStruct(seg, ObjectSize{PointerCount: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := NewStruct(seg, ObjectSize{DataSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub.SetUint64(0, 42)
+	used := len(seg.Data())
+	if err := root.SharePtr(0, sub.ToPtr()); err != nil {
+		t.Fatal("SharePtr(0, sub):", err)
+	}
+	if err := root.SharePtr(1, sub.ToPtr()); err != nil {
+		t.Fatal("SharePtr(1, sub):", err)
+	}
+	if n := len(seg.Data()); n != used {
+		t.Errorf("segment grew from %d to %d bytes; want no copy", used, n)
+	}
+	p0, err := root.Ptr(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1, err := root.Ptr(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !SamePtr(p0, p1) || !SamePtr(p0, sub.ToPtr()) {
+		t.Error("shared fields do not refer to the same struct")
+	}
+	sub.SetUint64(0, 84)
+	if got := p1.Struct().Uint64(0); got != 84 {
+		t.Errorf("field 1 after modifying shared struct = %d; want 84", got)
+	}
+
+	_, seg2, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewStruct(seg2, ObjectSize{DataSize: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SharePtr(0, other.ToPtr()); err == nil {
+		t.Error("SharePtr with struct from another message succeeded; want error")
+	}
+	list, err := NewCompositeList(seg, ObjectSize{DataSize: 8}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := root.SharePtr(0, list.Struct(0).ToPtr()); err == nil {
+		t.Error("SharePtr with list element succeeded; want error")
+	}
+	if p, _ := root.Ptr(0); !SamePtr(p, sub.ToPtr()) {
+		t.Error("failed SharePtr modified the field")
+	}
+}
+
+func TestStructDataLittleEndian(t *testing.T) {
+	msg, err := Unmarshal([]byte{
+		0, 0, 0, 0, 3, 0, 0, 0,
+		0, 0, 0, 0, 2, 0, 0, 0,
+		0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	})
+	if err != nil {
+		t.Fatal("Unmarshal:", err)
+	}
+	p, err := msg.Root()
+	if err != nil {
+		t.Fatal("Root:", err)
+	}
+	s := p.Struct()
+	if v := s.Uint16(0); v != 0x0201 {
+		t.Errorf("Uint16(0) = %#x; want 0x0201", v)
+	}
+	if v := s.Uint32(4); v != 0x08070605 {
+		t.Errorf("Uint32(4) = %#x; want 0x08070605", v)
+	}
+	if v := s.Uint64(0); v != 0x0807060504030201 {
+		t.Errorf("Uint64(0) = %#x; want 0x0807060504030201", v)
+	}
+
+	s.SetUint16(8, 0x0a0b)
+	s.SetUint32(12, 0x0c0d0e0f)
+	want := []byte{0x0b, 0x0a, 0, 0, 0x0f, 0x0e, 0x0d, 0x0c}
+	if data := s.Segment().Data()[16:24]; !bytes.Equal(data, want) {
+		t.Errorf("after SetUint16(8, 0x0a0b) and SetUint32(12, 0x0c0d0e0f), data = % x; want % x", data, want)
+	}
+}
+
+func TestStructScalarAccessors(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewRootStruct(seg, ObjectSize{DataSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each width is written at the first and last offsets it fits at.
+	tests := []struct {
+		width DataOffset
+		set   func(off DataOffset)
+		check func(off DataOffset) bool
+	}{
+		{
+			width: 1,
+			set:   func(off DataOffset) { s.SetInt8(off, -128) },
+			check: func(off DataOffset) bool { return s.Int8(off) == -128 && s.Uint8(off) == 0x80 },
+		},
+		{
+			width: 2,
+			set:   func(off DataOffset) { s.SetInt16(off, -2) },
+			check: func(off DataOffset) bool { return s.Int16(off) == -2 && s.Uint16(off) == 0xfffe },
+		},
+		{
+			width: 4,
+			set:   func(off DataOffset) { s.SetInt32(off, -1<<31) },
+			check: func(off DataOffset) bool { return s.Int32(off) == -1<<31 && s.Uint32(off) == 0x80000000 },
+		},
+		{
+			width: 8,
+			set:   func(off DataOffset) { s.SetInt64(off, -3) },
+			check: func(off DataOffset) bool { return s.Int64(off) == -3 && s.Uint64(off) == 0xfffffffffffffffd },
+		},
+	}
+	for _, test := range tests {
+		for _, off := range []DataOffset{0, 16 - test.width} {
+			for i := DataOffset(0); i < 16; i++ {
+				s.SetUint8(i, 0)
+			}
+			test.set(off)
+			if !test.check(off) {
+				t.Errorf("%d-byte value at offset %d does not read back", test.width, off)
+			}
+			// Bytes outside the value are untouched.
+			for i := DataOffset(0); i < 16; i++ {
+				if (i < off || i >= off+test.width) && s.Uint8(i) != 0 {
+					t.Errorf("setting %d-byte value at offset %d changed byte %d", test.width, off, i)
+				}
+			}
+		}
+	}
+
+	for _, n := range []BitOffset{0, 127} {
+		s.SetBit(n, true)
+		if !s.Bit(n) {
+			t.Errorf("Bit(%d) = false after SetBit(%d, true)", n, n)
+		}
+		s.SetBit(n, false)
+		if s.Bit(n) {
+			t.Errorf("Bit(%d) = true after SetBit(%d, false)", n, n)
+		}
+	}
+
+	// Reads past the end of the data section return zero, and writes panic.
+	if v := s.Int8(16); v != 0 {
+		t.Errorf("Int8(16) = %d; want 0", v)
+	}
+	if v := s.Int16(15); v != 0 {
+		t.Errorf("Int16(15) = %d; want 0", v)
+	}
+	if v := s.Int32(13); v != 0 {
+		t.Errorf("Int32(13) = %d; want 0", v)
+	}
+	if v := s.Int64(9); v != 0 {
+		t.Errorf("Int64(9) = %d; want 0", v)
+	}
+	if s.Bit(128) {
+		t.Error("Bit(128) = true; want false")
+	}
+	for name, set := range map[string]func(){
+		"SetInt8(16, 1)":    func() { s.SetInt8(16, 1) },
+		"SetInt16(15, 1)":   func() { s.SetInt16(15, 1) },
+		"SetInt32(13, 1)":   func() { s.SetInt32(13, 1) },
+		"SetInt64(9, 1)":    func() { s.SetInt64(9, 1) },
+		"SetBit(128, true)": func() { s.SetBit(128, true) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			set()
+		}()
+	}
+}
+
+func TestStructDefaultAccessors(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewRootStruct(seg, ObjectSize{DataSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each width is written at the first and last offsets it fits at,
+	// with a non-zero default.  The raw value is stored XORed with it.
+	tests := []struct {
+		width DataOffset
+		set   func(off DataOffset)
+		check func(off DataOffset) bool
+		unset func(off DataOffset) // sets the default value
+	}{
+		{
+			width: 1,
+			set:   func(off DataOffset) { s.SetUint8Default(off, 0x0f, 0xf1) },
+			check: func(off DataOffset) bool {
+				return s.Uint8Default(off, 0xf1) == 0x0f && s.Int8Default(off, -15) == 0x0f && s.Uint8(off) == 0xfe
+			},
+			unset: func(off DataOffset) { s.SetInt8Default(off, -15, -15) },
+		},
+		{
+			width: 2,
+			set:   func(off DataOffset) { s.SetInt16Default(off, -2, 0x1234) },
+			check: func(off DataOffset) bool {
+				return s.Int16Default(off, 0x1234) == -2 && s.Uint16Default(off, 0x1234) == 0xfffe && s.Uint16(off) == 0xedca
+			},
+			unset: func(off DataOffset) { s.SetUint16Default(off, 0x1234, 0x1234) },
+		},
+		{
+			width: 4,
+			set:   func(off DataOffset) { s.SetUint32Default(off, 7, 0xdeadbeef) },
+			check: func(off DataOffset) bool {
+				return s.Uint32Default(off, 0xdeadbeef) == 7 && s.Int32Default(off, -1) == 0x21524117 && s.Uint32(off) == 0xdeadbee8
+			},
+			unset: func(off DataOffset) { s.SetInt32Default(off, -1, -1) },
+		},
+		{
+			width: 8,
+			set:   func(off DataOffset) { s.SetInt64Default(off, -1<<63, 1) },
+			check: func(off DataOffset) bool {
+				return s.Int64Default(off, 1) == -1<<63 && s.Uint64Default(off, 1) == 1<<63 && s.Uint64(off) == 1<<63|1
+			},
+			unset: func(off DataOffset) { s.SetUint64Default(off, 1, 1) },
+		},
+	}
+	for _, test := range tests {
+		for _, off := range []DataOffset{0, 16 - test.width} {
+			for i := DataOffset(0); i < 16; i++ {
+				s.SetUint8(i, 0)
+			}
+			test.set(off)
+			if !test.check(off) {
+				t.Errorf("%d-byte value at offset %d does not read back with its default", test.width, off)
+			}
+			for i := DataOffset(0); i < 16; i++ {
+				if (i < off || i >= off+test.width) && s.Uint8(i) != 0 {
+					t.Errorf("setting %d-byte value at offset %d changed byte %d", test.width, off, i)
+				}
+			}
+			// Setting the default stores zero.
+			test.unset(off)
+			for i := DataOffset(0); i < 16; i++ {
+				if s.Uint8(i) != 0 {
+					t.Errorf("setting %d-byte default at offset %d left byte %d set", test.width, off, i)
+				}
+			}
+		}
+	}
+
+	for _, n := range []BitOffset{0, 127} {
+		s.SetBitDefault(n, false, true)
+		if s.BitDefault(n, true) || !s.Bit(n) {
+			t.Errorf("after SetBitDefault(%d, false, true), BitDefault = %t and Bit = %t; want false and true", n, s.BitDefault(n, true), s.Bit(n))
+		}
+		s.SetBitDefault(n, true, true)
+		if !s.BitDefault(n, true) || s.Bit(n) {
+			t.Errorf("after SetBitDefault(%d, true, true), BitDefault = %t and Bit = %t; want true and false", n, s.BitDefault(n, true), s.Bit(n))
+		}
+	}
+
+	// Reads past the end of the data section return the default.
+	if v := s.Uint8Default(16, 0xf1); v != 0xf1 {
+		t.Errorf("Uint8Default(16, 0xf1) = %#x; want 0xf1", v)
+	}
+	if v := s.Int16Default(15, -2); v != -2 {
+		t.Errorf("Int16Default(15, -2) = %d; want -2", v)
+	}
+	if v := s.Uint32Default(13, 0xdeadbeef); v != 0xdeadbeef {
+		t.Errorf("Uint32Default(13, 0xdeadbeef) = %#x; want 0xdeadbeef", v)
+	}
+	if v := s.Int64Default(9, -3); v != -3 {
+		t.Errorf("Int64Default(9, -3) = %d; want -3", v)
+	}
+	if !s.BitDefault(128, true) {
+		t.Error("BitDefault(128, true) = false; want true")
+	}
+	for name, set := range map[string]func(){
+		"SetUint8Default(16, 1, 2)":       func() { s.SetUint8Default(16, 1, 2) },
+		"SetInt16Default(15, 1, 2)":       func() { s.SetInt16Default(15, 1, 2) },
+		"SetUint32Default(13, 1, 2)":      func() { s.SetUint32Default(13, 1, 2) },
+		"SetInt64Default(9, 1, 2)":        func() { s.SetInt64Default(9, 1, 2) },
+		"SetBitDefault(128, false, true)": func() { s.SetBitDefault(128, false, true) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", name)
+				}
+			}()
+			set()
+		}()
+	}
+}
+
+func TestStructDataInto(t *testing.T) {
+	_, seg, err := NewMessage(SingleSegment(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewRootStruct(seg, ObjectSize{PointerCount: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetData(0, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetData(1, []byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same buffer is reused for each field.
+	buf := make([]byte, 8)
+	tests := []struct {
+		i    uint16
+		want string
+	}{
+		{0, "hello"},
+		{1, "bye"},
+		{2, ""},
+	}
+	for _, test := range tests {
+		n, err := s.DataInto(test.i, buf)
+		if err != nil {
+			t.Errorf("DataInto(%d, buf): %v", test.i, err)
+			continue
+		}
+		if got := string(buf[:n]); got != test.want {
+			t.Errorf("DataInto(%d, buf) copied %q; want %q", test.i, got, test.want)
+		}
+	}
+
+	small := []byte("abc")
+	n, err := s.DataInto(0, small)
+	if err == nil {
+		t.Error("DataInto(0, 3-byte buffer) = _, <nil>; want error")
+	}
+	if n != 5 {
+		t.Errorf("DataInto(0, 3-byte buffer) = %d, _; want required size 5", n)
+	}
+	if string(small) != "abc" {
+		t.Errorf("DataInto(0, 3-byte buffer) changed buffer to %q", small)
+	}
+}
+
+func TestStructAsRootOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		arena    Arena
+		otherSeg bool // whether the sub-struct is outside the first segment
+	}{
+		{"SingleSegment", SingleSegment(nil), false},
+		{"MultiSegment", MultiSegment([][]byte{make([]byte, 0, 16)}), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, seg, err := NewMessage(test.arena)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, err := NewRootStruct(seg, ObjectSize{PointerCount: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sub, err := NewStruct(seg, ObjectSize{DataSize: 8, PointerCount: 1})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sub.SetUint64(0, 0xdeadbeef)
+			if err := sub.SetText(0, "hello"); err != nil {
+				t.Fatal(err)
+			}
+			if err := root.SetPtr(0, sub.ToPtr()); err != nil {
+				t.Fatal(err)
+			}
+			if otherSeg := sub.Segment().ID() != 0; otherSeg != test.otherSeg {
+				t.Fatalf("sub-struct in segment %d; test expects otherSeg = %t", sub.Segment().ID(), test.otherSeg)
+			}
+
+			view, err := sub.AsRootOf()
+			if err != nil {
+				t.Fatal("AsRootOf:", err)
+			}
+			data, err := view.Marshal()
+			if err != nil {
+				t.Fatal("view.Marshal:", err)
+			}
+			msg, err := Unmarshal(data)
+			if err != nil {
+				t.Fatal("Unmarshal:", err)
+			}
+			p, err := msg.Root()
+			if err != nil {
+				t.Fatal("Root:", err)
+			}
+			s := p.Struct()
+			if got := s.Uint64(0); got != 0xdeadbeef {
+				t.Errorf("root.Uint64(0) = %#x; want 0xdeadbeef", got)
+			}
+			text, err := s.Ptr(0)
+			if err != nil {
+				t.Fatal("root.Ptr(0):", err)
+			}
+			if got := text.Text(); got != "hello" {
+				t.Errorf("root.Ptr(0).Text() = %q; want \"hello\"", got)
+			}
+
+			// The original message keeps its root.
+			p, err = root.Message().Root()
+			if err != nil {
+				t.Fatal("original Root:", err)
+			}
+			if !SamePtr(p, root.ToPtr()) {
+				t.Error("original message's root changed")
+			}
+			vseg, err := view.Segment(0)
+			if err != nil {
+				t.Fatal("view.Segment(0):", err)
+			}
+			if _, err := NewStruct(vseg, ObjectSize{DataSize: 8}); err == nil {
+				t.Error("NewStruct in view = <nil>; want read-only error")
+			}
+			// Only the first segment is copied.
+			for id := SegmentID(1); int64(id) < root.Message().NumSegments(); id++ {
+				orig, err := root.Message().Segment(id)
+				if err != nil {
+					t.Fatalf("original Segment(%d): %v", id, err)
+				}
+				vs, err := view.Segment(id)
+				if err != nil {
+					t.Fatalf("view.Segment(%d): %v", id, err)
+				}
+				if &vs.Data()[0] != &orig.Data()[0] {
+					t.Errorf("view segment %d is a copy; want it shared with the original message", id)
+				}
+			}
+		})
+	}
+}